@@ -41,6 +41,9 @@ typedef	struct	Library	Library;
 
 typedef	struct	Pcln	Pcln;
 typedef	struct	Pcdata	Pcdata;
+typedef	struct	Inlcall	Inlcall;
+typedef	struct	Inlpos	Inlpos;
+typedef	struct	Imppos	Imppos;
 typedef	struct	Pciter	Pciter;
 
 // prevent incompatible type signatures between liblink and 8l on Plan 9
@@ -325,6 +328,48 @@ struct Pcln
 
 	LSym *lastfile;
 	int lastindex;
+
+	// inlining tree, see pctoinline
+	int32 *inlcall;	// global index (into ctxt->inlcall) of each node
+	int32 *inlparent;	// index of parent node, or -1
+	int ninl;
+	int minl;
+};
+
+// Inlining.
+// The compiler records each call it inlines as an Inlcall.
+// The instructions of an inlined body carry virtual line numbers,
+// LineInl and up, indexing ctxt->inlpos.  Each Inlpos pairs the
+// real line number of a position within the inlined function with
+// the call that the position was inlined by, so that the chain of
+// call sites leading to the position can be recovered.
+// The line number of an Inlcall is itself virtual when the call
+// appears in the body of another inlined call.
+// The bodies of functions imported from other packages carry
+// line numbers LineImp and up, indexing ctxt->imppos, which hold
+// the file and line recorded for the position by the exporting package.
+enum
+{
+	LineImp = 1<<29,
+	LineInl = 1<<30,
+};
+
+struct	Inlcall
+{
+	int32	lineno;	// line number of the call
+	LSym*	func;	// function called
+};
+
+struct	Inlpos
+{
+	int32	lineno;	// real line number in the inlined function
+	int32	call;	// index into ctxt->inlcall
+};
+
+struct	Imppos
+{
+	char*	file;	// file name, as recorded in the exporting package
+	int32	line;	// line number in file
 };
 
 // Pcdata iterator.
@@ -438,6 +483,17 @@ struct	Link
 	int32	histdepth;
 	int32	nhistfile;
 	LSym*	filesyms;
+
+	// inlined calls, for compilers
+	Inlcall*	inlcall;
+	int32	ninlcall;
+	int32	minlcall;
+	Inlpos*	inlpos;
+	int32	ninlpos;
+	int32	minlpos;
+	Imppos*	imppos;
+	int32	nimppos;
+	int32	mimppos;
 };
 
 enum {
@@ -593,6 +649,10 @@ void	listinit8(void);
 void	listinit9(void);
 
 // obj.c
+int32	linkinlcall(Link *ctxt, int32 lineno, LSym *func);
+int32	linkinlpos(Link *ctxt, int32 lineno, int32 call);
+int32	linkimppos(Link *ctxt, char *file, int32 line);
+int32	linkrealline(Link *ctxt, int32 lineno, int32 *call);
 int	linklinefmt(Link *ctxt, Fmt *fp);
 void	linklinehist(Link *ctxt, int lineno, char *f, int offset);
 Plist*	linknewplist(Link *ctxt);
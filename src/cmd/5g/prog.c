@@ -149,3 +149,20 @@ proginfo(ProgInfo *info, Prog *p)
 	if(((p->scond & C_SCOND) != C_SCOND_NONE) && (info->flags & RightWrite))
 		info->flags |= RightRead;
 }
+
+// Asynchronous preemption is not supported, so there are no register maps.
+
+int
+regmapinit(Regmap *rm)
+{
+	USED(rm);
+	return 0;
+}
+
+int
+regmapprog(Prog *p, Regmap *rm)
+{
+	USED(p);
+	USED(rm);
+	return 0;
+}
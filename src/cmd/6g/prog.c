@@ -316,3 +316,190 @@ proginfo(ProgInfo *info, Prog *p)
 	if(p->to.index != D_NONE)
 		info->regindex |= RtoB(p->to.index);
 }
+
+// Register maps for asynchronous preemption.
+//
+// regmapprog applies the effect of p to rm, which tracks the registers
+// that may hold pointers and those that may hold addresses in the
+// outgoing arguments, and reports whether p reads or writes the
+// outgoing arguments.  It is driven by the data flow analysis in
+// ../gc/plive.c, which joins states by union.  A register is assumed
+// to hold a pointer unless the instruction that wrote it shows that
+// it holds a scalar, so the maps err only toward retaining garbage.
+
+int
+regmapinit(Regmap *rm)
+{
+	// With 32-bit pointers in 64-bit registers,
+	// a 32-bit move would not show a scalar.
+	if(widthptr != 8)
+		return 0;
+
+	// DX holds the closure context on entry.
+	rm->ptr = DX;
+	rm->argp = 0;
+	return 1;
+}
+
+// Reports whether a is memory addressed through the stack pointer or a
+// register holding an address in the outgoing arguments.  The compiler
+// addresses locals and incoming arguments by name, never that way.
+static int
+isargaddr(Addr *a, Regmap *rm)
+{
+	if(a->type < D_INDIR)
+		return 0;
+	if(a->type == D_INDIR+D_SP)
+		return 1;
+	return ((RtoB(a->type-D_INDIR) | RtoB(a->index)) & rm->argp) != 0;
+}
+
+// Reports whether the value read from a may be a pointer.
+static int
+mayptr(Addr *a, Regmap *rm)
+{
+	if(RtoB(a->type))
+		return (RtoB(a->type) & rm->ptr) != 0;
+	switch(a->type) {
+	case D_ADDR:
+		return 1;
+	case D_EXTERN:
+	case D_STATIC:
+	case D_AUTO:
+	case D_PARAM:
+		break;
+	default:
+		// A constant or non-integer register is not a pointer.
+		// Memory addressed through a register may be, whatever
+		// its etype says: sgen copies structs through int64 temporaries.
+		return a->type >= D_INDIR;
+	}
+	switch(a->etype) {
+	case TINT8:
+	case TUINT8:
+	case TINT16:
+	case TUINT16:
+	case TINT32:
+	case TUINT32:
+	case TINT64:
+	case TUINT64:
+	case TINT:
+	case TUINT:
+	case TUINTPTR:
+	case TFLOAT32:
+	case TFLOAT64:
+	case TCOMPLEX64:
+	case TCOMPLEX128:
+	case TBOOL:
+		return 0;
+	}
+	return 1;
+}
+
+int
+regmapprog(Prog *p, Regmap *rm)
+{
+	ProgInfo info;
+	uint32 b, from;
+	int flags, ptr, argp;
+
+	proginfo(&info, p);
+	if((info.flags & Pseudo) || p->as == ANOP)
+		return 0;
+
+	flags = 0;
+	switch(p->as) {
+	case ACALL:
+		// All registers are dead after a call.
+		rm->ptr = 0;
+		rm->argp = 0;
+		return 0;
+
+	case APUSHQ:
+		return RegmapArgWrite;
+
+	case APOPQ:
+		flags |= RegmapArgRead;
+		break;
+
+	case ADUFFCOPY:
+		// Copies through CX.
+		rm->ptr |= CX;
+		rm->argp &= ~CX;
+		// fall through
+	case AMOVSB:
+	case AMOVSW:
+	case AMOVSL:
+	case AMOVSQ:
+		if(rm->argp & SI)
+			flags |= RegmapArgRead;
+		// fall through
+	case ADUFFZERO:
+	case ASTOSB:
+	case ASTOSW:
+	case ASTOSL:
+	case ASTOSQ:
+		if(rm->argp & DI)
+			flags |= RegmapArgWrite;
+		return flags;
+	}
+
+	if(isargaddr(&p->from, rm)) {
+		if(info.flags & LeftRead)
+			flags |= RegmapArgRead;
+		if(info.flags & LeftWrite)
+			flags |= RegmapArgWrite;
+	}
+	if(isargaddr(&p->to, rm)) {
+		if(info.flags & RightRead)
+			flags |= RegmapArgRead;
+		if(info.flags & RightWrite)
+			flags |= RegmapArgWrite;
+	}
+
+	from = RtoB(p->from.type);
+	b = RtoB(p->to.type);
+	if(b != 0 && (info.flags & RightWrite)) {
+		if(p->as == ALEAQ) {
+			ptr = 1;
+			argp = isargaddr(&p->from, rm);
+		} else if((info.flags & (SizeB | SizeW | SizeL | SizeF | SizeD | Conv)) != 0) {
+			// Narrower writes zero or leave the upper bits.
+			ptr = 0;
+			argp = 0;
+		} else if(info.flags & Move) {
+			ptr = mayptr(&p->from, rm);
+			argp = (from & rm->argp) != 0;
+		} else if((info.flags & (LeftRead | RightRead)) == 0) {
+			ptr = 1;
+			argp = 0;
+		} else {
+			ptr = 0;
+			argp = 0;
+			if(info.flags & RightRead) {
+				ptr = (b & rm->ptr) != 0;
+				argp = (b & rm->argp) != 0;
+			}
+			if(info.flags & LeftRead) {
+				ptr |= mayptr(&p->from, rm);
+				argp |= (from & rm->argp) != 0;
+			}
+		}
+		if(from != 0 && (info.flags & LeftWrite)) {
+			// Exchange.
+			if(ptr)
+				rm->ptr |= from;
+			if(argp)
+				rm->argp |= from;
+		}
+		rm->ptr &= ~b;
+		rm->argp &= ~b;
+		if(ptr)
+			rm->ptr |= b;
+		if(argp)
+			rm->argp |= b;
+	}
+	rm->ptr &= ~RtoB(D_SP);
+	rm->argp &= ~RtoB(D_SP);
+	return flags;
+}
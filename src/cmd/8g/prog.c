@@ -347,3 +347,20 @@ proginfo(ProgInfo *info, Prog *p)
 	if(p->to.index != D_NONE)
 		info->regindex |= RtoB(p->to.index);
 }
+
+// Asynchronous preemption is not supported, so there are no register maps.
+
+int
+regmapinit(Regmap *rm)
+{
+	USED(rm);
+	return 0;
+}
+
+int
+regmapprog(Prog *p, Regmap *rm)
+{
+	USED(p);
+	USED(rm);
+	return 0;
+}
@@ -305,3 +305,20 @@ variant2as(int as, int flags)
 	initvariants();
 	return varianttable[as][flags];
 }
+
+// Asynchronous preemption is not supported, so there are no register maps.
+
+int
+regmapinit(Regmap *rm)
+{
+	USED(rm);
+	return 0;
+}
+
+int
+regmapprog(Prog *p, Regmap *rm)
+{
+	USED(p);
+	USED(rm);
+	return 0;
+}
@@ -99,6 +99,24 @@ widstruct(Type *errtype, Type *t, vlong o, int flag)
 	return o;
 }
 
+/*
+ * sync/atomic's align64 is an empty struct that makes
+ * the struct containing it 64-bit aligned, so that its
+ * 64-bit fields can be accessed atomically on 32-bit systems.
+ */
+static int
+isalign64(Type *t)
+{
+	Sym *s;
+
+	s = t->sym;
+	if(s == S || strcmp(s->name, "align64") != 0)
+		return 0;
+	if(s->pkg == localpkg)
+		return myimportpath != nil && strcmp(myimportpath, "sync/atomic") == 0;
+	return strcmp(s->pkg->path->s, "sync/atomic") == 0;
+}
+
 void
 dowidth(Type *t)
 {
@@ -272,6 +290,8 @@ dowidth(Type *t)
 		if(t->funarg)
 			fatal("dowidth fn struct %T", t);
 		w = widstruct(t, t, 0, 1);
+		if(isalign64(t))
+			t->align = 8;
 		break;
 
 	case TFUNC:
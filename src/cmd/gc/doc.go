@@ -50,6 +50,9 @@ Flags:
 		show entire file path when printing line numbers in errors
 	-I dir1 -I dir2
 		add dir1 and dir2 to the list of paths to check for imported packages
+	-importmap source=actual
+		satisfy imports of source with the package whose import path is actual;
+		the go command uses this for packages found in vendor directories
 	-N
 		disable optimizations
 	-nolocalimports
@@ -195,6 +195,7 @@ static void escflows(EscState*, Node *dst, Node *src);
 static void escflood(EscState*, Node *dst);
 static void escwalk(EscState*, int level, Node *dst, Node *src);
 static void esctag(EscState*, Node *func);
+static void escoveraligned(Node *n);
 
 struct EscState {
 	// Fake node that all
@@ -299,6 +300,12 @@ analyze(NodeList *all, int recursive)
 	for(l = e->dsts; l; l=l->next)
 		escflood(e, l->n);
 
+	// the stack is only register-aligned, so values that need
+	// more alignment (see sync/atomic's align64) go to the heap
+	// even when they do not escape.
+	for(l=e->noesc; l; l=l->next)
+		escoveraligned(l->n);
+
 	// for all top level functions, tag the typenodes corresponding to the param nodes
 	for(l=all; l; l=l->next)
 		if(l->n->op == ODCLFUNC)
@@ -314,6 +321,44 @@ analyze(NodeList *all, int recursive)
 }
 
 
+static void
+escoveraligned(Node *n)
+{
+	Type *t;
+	Node *v;
+
+	if(n->esc != EscNone)
+		return;
+	switch(n->op) {
+	default:
+		return;
+	case ONEW:
+		t = n->type->type;
+		break;
+	case OPTRLIT:
+		t = n->left->type;
+		break;
+	case OADDR:
+		// only the address of a variable that may be on the stack.
+		for(v=n->left; v->op == ODOT || (v->op == OINDEX && isfixedarray(v->left->type)); v=v->left)
+			;
+		if(v->op != ONAME || (v->class&~PHEAP) == PEXTERN || (v->class&PHEAP))
+			return;
+		t = n->left->type;
+		break;
+	}
+	if(t == T)
+		return;
+	dowidth(t);
+	if(t->align <= widthreg)
+		return;
+	n->esc = EscHeap;
+	if(n->op != ONEW)
+		addrescapes(n->left);
+	if(debug['m'])
+		warnl(n->lineno, "%hN escapes to heap: overaligned", n);
+}
+
 static void
 escfunc(EscState *e, Node *func)
 {
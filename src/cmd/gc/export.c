@@ -247,7 +247,7 @@ dumpexportvar(Sym *s)
 			if(debug['l'] < 2)
 				typecheckinl(n);
 			// NOTE: The space after %#S here is necessary for ld's export data parser.
-			Bprint(bout, "\tfunc %#S %#hT { %#H }\n", s, t, n->inl);
+			Bprint(bout, "\tfunc %#S %#hT { %#lH }\n", s, t, n->inl);
 			reexportdeplist(n->inl);
 		} else
 			Bprint(bout, "\tfunc %#S %#hT\n", s, t);
@@ -309,7 +309,7 @@ dumpexporttype(Type *t)
 			// currently that can leave unresolved ONONAMEs in import-dot-ed packages in the wrong package
 			if(debug['l'] < 2)
 				typecheckinl(f->type->nname);
-			Bprint(bout, "\tfunc (%#T) %#hhS %#hT { %#H }\n", getthisx(f->type)->type, f->sym, f->type, f->type->nname->inl);
+			Bprint(bout, "\tfunc (%#T) %#hhS %#hT { %#lH }\n", getthisx(f->type)->type, f->sym, f->type, f->type->nname->inl);
 			reexportdeplist(f->type->nname->inl);
 		} else
 			Bprint(bout, "\tfunc (%#T) %#hhS %#hT\n", getthisx(f->type)->type, f->sym, f->type);
@@ -40,6 +40,7 @@
 //	%H NodeList*	NodeLists
 //		Flags: those of %N
 //			','  separate items with ',' instead of ';'
+//			'l' (only in Export mode) precede statements with their positions
 //
 //	%Z Strlit*	String literals
 //
@@ -72,6 +73,7 @@ static int fmtpkgpfx;	// %uT stickyness
 //	%#N   %#T	export format
 //	%#lT		type definition instead of name
 //	%#hT		omit"func" and receiver in function signature
+//	%#lH		inlinable function body, with statement positions
 //
 //	%lN		"foo (type Bar)" for error messages
 //
@@ -1622,13 +1624,33 @@ Nconv(Fmt *fp)
 	return r;
 }
 
+static int exportpos;	// set while formatting with %#lH
+
+// stmtpos writes a comment recording the file and line of statement n,
+// so that a package importing the inlinable body n is part of
+// can attribute the body to its own source (see getimportpos in lex.c).
+static int
+stmtpos(Fmt *fp, Node *n)
+{
+	LSym *file;
+	int32 line;
+
+	if(n->lineno == 0)
+		return 0;
+	linkgetline(ctxt, n->lineno, &file, &line);
+	if(file == nil || line <= 0 || strstr(file->name, "*/") != nil)
+		return 0;
+	return fmtprint(fp, "/*line %s:%d*/ ", file->name, line);
+}
+
 // Fmt '%H': NodeList.
-// Flags: all those of %N plus ',': separate with comma's instead of semicolons.
+// Flags: all those of %N plus ',': separate with comma's instead of semicolons,
+// and 'l': in export mode, precede statements with their positions.
 static int
 Hconv(Fmt *fp)
 {
 	NodeList *l;
-	int r, sm;
+	int r, sm, sp;
 	unsigned long sf;
 	char *sep;
 
@@ -1639,6 +1661,9 @@ Hconv(Fmt *fp)
 
 	sf = fp->flags;
 	sm = setfmode(&fp->flags);
+	sp = exportpos;
+	if(fmtmode == FExp && (fp->flags & FmtLong))
+		exportpos = 1;
 	r = 0;
 	sep = "; ";
 	if(fmtmode == FDbg)
@@ -1647,11 +1672,14 @@ Hconv(Fmt *fp)
 		sep = ", ";
 
 	for(;l; l=l->next) {
+		if(exportpos && !(fp->flags & FmtComma))
+			r += stmtpos(fp, l->n);
 		r += fmtprint(fp, "%N", l->n);
 		if(l->next)
 			r += fmtstrcpy(fp, sep);
 	}
 
+	exportpos = sp;
 	fp->flags = sf;
 	fmtmode = sm;
 	return r;
@@ -813,9 +813,10 @@ cgen_slice(Node *n, Node *res)
 	// without making any calls, so without doing anything that
 	// might cause preemption or garbage collection.
 	// this makes the whole slice update atomic as far as the
-	// garbage collector can see.
+	// garbage collector can see, except when it preempts asynchronously,
+	// so base has a pointer type to keep the array live until then.
 	
-	base = temp(types[TUINTPTR]);
+	base = temp(types[tptr]);
 	tmplen = temp(types[TINT]);
 	if(n->op != OSLICESTR)
 		tmpcap = temp(types[TINT]);
@@ -866,8 +867,10 @@ cgen_slice(Node *n, Node *res)
 		typecheck(&cmp, Erv);
 		bgen(cmp, 1, -1, p2);
 
+		// pointer arithmetic, which typecheck would reject.
 		add = nod(OADD, base, offs);
-		typecheck(&add, Erv);
+		add->type = base->type;
+		add->typecheck = 1;
 		cgen(add, base);
 
 		patch(p2, pc);
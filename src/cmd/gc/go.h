@@ -788,6 +788,14 @@ struct Idir
 	char*	dir;
 };
 
+typedef	struct	Importmap	Importmap;
+struct Importmap
+{
+	Importmap*	link;
+	char*	from;	// import path as written in source
+	char*	to;	// actual import path of the package
+};
+
 /*
  * argument passing to/from
  * smagic and umagic
@@ -809,6 +817,23 @@ struct	Magic
 	int	ua;	// output - adder
 };
 
+/*
+ * register state tracked by the back end
+ * for asynchronous preemption (see plive.c)
+ */
+typedef	struct	Regmap	Regmap;
+struct	Regmap
+{
+	int32	ptr;	// registers that may hold pointers
+	int32	argp;	// registers that may hold outgoing argument addresses
+};
+
+enum
+{
+	RegmapArgRead = 1<<0,	// instruction reads outgoing arguments or results
+	RegmapArgWrite = 1<<1,	// instruction writes outgoing arguments
+};
+
 struct	Label
 {
 	uchar	used;
@@ -901,6 +926,7 @@ extern	char*	runtimeimport;
 extern	char*	unsafeimport;
 EXTERN	char*	myimportpath;
 EXTERN	Idir*	idirs;
+EXTERN	Importmap*	importmaps;
 EXTERN	char*	localimport;
 EXTERN	char*	asmhdr;
 
@@ -1537,6 +1563,8 @@ Node*	nodarg(Type*, int);
 void	nopout(Prog*);
 void	patch(Prog*, Prog*);
 Prog*	unpatch(Prog*);
+int	regmapinit(Regmap*);
+int	regmapprog(Prog*, Regmap*);
 
 #pragma	varargck	type	"B"	Mpint*
 #pragma	varargck	type	"E"	int
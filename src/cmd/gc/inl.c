@@ -22,6 +22,12 @@
 //  The debug['m'] flag enables diagnostic output.  a single -m is useful for verifying
 //  which calls get inlined or not, more is for debugging, and may go away at any point.
 //
+//  The statements of an inlined body keep their own positions as virtual line numbers
+//  (see linkinlpos in liblink) that also record the call they were inlined at, so that
+//  the inlining tables in the binary let tracebacks and runtime.CallersFrames show
+//  inlined calls as frames of their own.  The export data records the positions
+//  of the statements of inlinable bodies for packages that import them.
+//
 // TODO:
 //   - inline functions with ... args
 //   - handle T.meth(f()) with func f() (t T, arg, arg, )
@@ -48,6 +54,7 @@ static Node*	inlsubst(Node *n);
 static NodeList* inlsubstlist(NodeList *l);
 
 static void	setlno(Node*, int);
+static void	setinlpos(NodeList*, Node*, int);
 
 // Used during inlsubst[list]
 static Node *inlfn;		// function currently being inlined
@@ -746,21 +753,23 @@ mkinlcall1(Node **np, Node *fn, int isddd)
 	inlretlabel = newlabel();
 	inlgen++;
 	body = inlsubstlist(fn->inl);
-
-	body = list(body, nod(OGOTO, inlretlabel, N));	// avoid 'not used' when function doesnt have return
-	body = list(body, nod(OLABEL, inlretlabel, N));
-
 	typechecklist(body, Etop);
+	setinlpos(body, fn, n->lineno);
+
+	ll = list(nil, nod(OGOTO, inlretlabel, N));	// avoid 'not used' when function doesnt have return
+	ll = list(ll, nod(OLABEL, inlretlabel, N));
+	typechecklist(ll, Etop);
+	body = concat(body, ll);
 //dumplist("ninit post", ninit);
 
 	call = nod(OINLCALL, N, N);
 	call->ninit = ninit;
-	call->nbody = body;
 	call->rlist = inlretvars;
 	call->type = n->type;
 	call->typecheck = 1;
 
 	setlno(call, n->lineno);
+	call->nbody = body;
 //dumplist("call body", body);
 
 	*np = call;
@@ -880,6 +889,7 @@ inlsubst(Node *n)
 	char *p;
 	Node *m, *as;
 	NodeList *ll;
+	int32 lno;
 
 	if(n == N)
 		return N;
@@ -903,6 +913,7 @@ inlsubst(Node *n)
 		// Since we don't handle bodies with closures, this return is guaranteed to belong to the current inlined function.
 
 //		dump("Return before substitution", n);
+		lno = setlineno(n);	// the assignments replacing the return are at its line
 		m = nod(OGOTO, inlretlabel, N);
 		m->ninit  = inlsubstlist(n->ninit);
 
@@ -918,6 +929,7 @@ inlsubst(Node *n)
 
 		typechecklist(m->ninit, Etop);
 		typecheck(&m, Etop);
+		lineno = lno;
 //		dump("Return after substitution", m);
 		return m;
 	
@@ -953,6 +965,58 @@ inlsubst(Node *n)
 	return m;
 }
 
+// Give the nodes of the inlined body of a call to fn at line lno virtual
+// line numbers, which record both the line within fn and the call, so that
+// tracebacks can show the inlined call as a frame of its own.
+// The bodies of imported functions carry the positions recorded in the
+// export data (see getimportpos in lex.c); nodes without one, which have
+// the line of the import, are attributed to the line of the call.
+static void setinlposnode(Node*, int32, int, int);
+
+static void
+setinlpos(NodeList *body, Node *fn, int lno)
+{
+	int32 call;
+	NodeList *ll;
+
+	call = linkinlcall(ctxt, lno, linksym(fn->sym));
+	for(ll=body; ll; ll=ll->next)
+		setinlposnode(ll->n, call, lno, fnpkg(fn) != localpkg);
+}
+
+static void
+setinlposlist(NodeList *ll, int32 call, int lno, int imported)
+{
+	for(;ll;ll=ll->next)
+		setinlposnode(ll->n, call, lno, imported);
+}
+
+static void
+setinlposnode(Node *n, int32 call, int lno, int imported)
+{
+	if(!n)
+		return;
+
+	// don't clobber names, see setlno
+	if(n->lineno == 0)
+		n->lineno = lno;
+	else if(n->op != ONAME) {
+		if(imported && n->lineno < LineImp)
+			n->lineno = lno;
+		n->lineno = linkinlpos(ctxt, n->lineno, call);
+	}
+
+	setinlposnode(n->left, call, lno, imported);
+	setinlposnode(n->right, call, lno, imported);
+	setinlposlist(n->list, call, lno, imported);
+	setinlposlist(n->rlist, call, lno, imported);
+	setinlposlist(n->ninit, call, lno, imported);
+	setinlposnode(n->ntest, call, lno, imported);
+	setinlposnode(n->nincr, call, lno, imported);
+	setinlposlist(n->nbody, call, lno, imported);
+	setinlposlist(n->nelse, call, lno, imported);
+}
+
 // Plaster over linenumbers
 static void
 setlnolist(NodeList *ll, int lno)
@@ -28,11 +28,17 @@ static void	ungetc(int);
 static int32	getr(void);
 static int	escchar(int, int*, vlong*);
 static void	addidir(char*);
+static void	addimportmap(char*);
 static int	getlinepragma(void);
+static void	getimportpos(void);
 static char *goos, *goarch, *goroot;
 
 #define	BOM	0xFEFF
 
+// Line number of the tokens in the rest of the current line
+// of export data, set by a position comment (see getimportpos).
+static int32 importpos;
+
 // Compiler experiments.
 // These are controlled by the GOEXPERIMENT environment
 // variable recorded when the compiler is built.
@@ -302,6 +308,7 @@ main(int argc, char *argv[])
 	flagcount("g", "debug code generation", &debug['g']);
 	flagcount("h", "halt on error", &debug['h']);
 	flagcount("i", "debug line number stack", &debug['i']);
+	flagfn1("importmap", "definition: add definition of the form source=actual to import map", addimportmap);
 	flagstr("installsuffix", "pkg directory suffix", &flag_installsuffix);
 	flagcount("j", "debug runtime-initialized variables", &debug['j']);
 	flagcount("l", "disable inlining", &debug['l']);
@@ -597,6 +604,27 @@ addidir(char* dir)
 	(*pp)->dir = dir;
 }
 
+// addimportmap records that an import of the path "source"
+// must be satisfied by the package with import path "actual".
+// The go command uses this to redirect imports to vendored packages.
+static void
+addimportmap(char *s)
+{
+	char *eq;
+	Importmap *m;
+
+	eq = strchr(s, '=');
+	if(eq == nil || eq == s || eq[1] == '\0')
+		sysfatal("-importmap argument must be of the form source=actual");
+	m = mal(sizeof(Importmap));
+	m->from = mal(eq - s + 1);
+	memmove(m->from, s, eq - s);
+	m->from[eq - s] = '\0';
+	m->to = strdup(eq + 1);
+	m->link = importmaps;
+	importmaps = m;
+}
+
 // is this path a local name?  begins with ./ or ../ or /
 static int
 islocalname(Strlit *name)
@@ -694,6 +722,7 @@ importfile(Val *f, int line)
 	int len;
 	Strlit *path;
 	char *cleanbuf, *prefix;
+	Importmap *m;
 
 	USED(line);
 
@@ -740,6 +769,13 @@ importfile(Val *f, int line)
 	}
 	
 	path = f->u.sval;
+	for(m = importmaps; m != nil; m = m->link) {
+		if(strcmp(path->s, m->from) == 0) {
+			path = strlit(m->to);
+			break;
+		}
+	}
+
 	if(islocalname(path)) {
 		if(path->s[0] == '/') {
 			yyerror("import path cannot be absolute path");
@@ -921,6 +957,8 @@ l0:
 	}
 
 	lineno = lexlineno;	/* start of token */
+	if(importpos != 0)
+		lineno = importpos;
 
 	if(c >= Runeself) {
 		/* all multibyte runes are alpha */
@@ -1052,6 +1090,8 @@ l0:
 		if(c1 == '*') {
 			int nl;
 			
+			if(pushedio.bin != nil)
+				getimportpos();
 			nl = 0;
 			for(;;) {
 				c = getr();
@@ -1660,6 +1700,60 @@ out:
 	return c;
 }
 
+// getimportpos reads and interprets a comment in export data like
+//	/*line /home/gopher/src/pkg/file.go:15*/
+// that precedes a statement in the body of an inlinable function
+// (see stmtpos in fmt.c): the tokens in the rest of the line come
+// from line 15 of that file.  The opening slash and star have been
+// read; the rest of the comment is left to be skipped by the caller.
+static void
+getimportpos(void)
+{
+	static char *lastfile;
+	int i, c, n;
+	char *cp, *ep, *linep;
+
+	for(i=0; i<5; i++) {
+		c = getr();
+		if(c != "line "[i]) {
+			ungetc(c);
+			return;
+		}
+	}
+
+	cp = lexbuf;
+	ep = lexbuf+sizeof(lexbuf)-5;
+	linep = nil;
+	for(;;) {
+		c = getr();
+		if(c == EOF || c == '\n' || c == '*')
+			break;
+		if(c == ':')
+			linep = cp;
+		if(cp < ep)
+			*cp++ = c;
+	}
+	ungetc(c);
+	*cp = 0;
+
+	if(linep == nil || linep >= ep || c != '*')
+		return;
+	*linep++ = '\0';
+	n = 0;
+	for(cp=linep; *cp; cp++) {
+		if(*cp < '0' || *cp > '9' || n > 1e8)
+			return;
+		n = n*10 + *cp - '0';
+	}
+	if(n <= 0)
+		return;
+
+	// the file name repeats for all the statements of a function
+	if(lastfile == nil || strcmp(lastfile, lexbuf) != 0)
+		lastfile = strdup(lexbuf);
+	importpos = linkimppos(ctxt, lastfile, n);
+}
+
 static char*
 getimpsym(char **pp)
 {
@@ -1889,6 +1983,8 @@ check:
 	case '\n':
 		if(pushedio.bin == nil)
 			lexlineno++;
+		else
+			importpos = 0;
 		break;
 	}
 	curio.last = c;
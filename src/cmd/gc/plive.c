@@ -122,6 +122,23 @@ struct Liveness {
 	// in the arguments and locals area, indexed by bb->rpo.
 	Array *argslivepointers;
 	Array *livepointers;
+
+	// Whether every instruction that is not an unsafe point gets a stack
+	// map and a register map, so that a signal can preempt the function
+	// there.  If so, livenessregmaps computes, indexed by bb->rpo:
+	//
+	//	regmapin: state of the registers at block entry
+	//	argsin: outgoing arguments possibly written at block entry
+	//	resultsout: results of a call possibly read after block exit
+	//
+	// Between the first write of an outgoing argument and the call, and
+	// between a call and the last read of its results, the values in the
+	// outgoing arguments area are described by no stack map, so those
+	// instructions are unsafe points.
+	int async;
+	Regmap *regmapin;
+	uchar *argsin;
+	uchar *resultsout;
 };
 
 static void*
@@ -821,6 +838,9 @@ newliveness(Node *fn, Prog *ptxt, Array *cfg, Array *vars)
 	result->avarinit = xmalloc(sizeof(Bvec*) * nblocks);
 	result->avarinitany = xmalloc(sizeof(Bvec*) * nblocks);
 	result->avarinitall = xmalloc(sizeof(Bvec*) * nblocks);
+	result->regmapin = xmalloc(sizeof(Regmap) * nblocks);
+	result->argsin = xmalloc(nblocks);
+	result->resultsout = xmalloc(nblocks);
 
 	nvars = arraylength(vars);
 	for(i = 0; i < nblocks; i++) {
@@ -872,6 +892,9 @@ freeliveness(Liveness *lv)
 	free(lv->avarinit);
 	free(lv->avarinitany);
 	free(lv->avarinitall);
+	free(lv->regmapin);
+	free(lv->argsin);
+	free(lv->resultsout);
 
 	free(lv);
 }
@@ -1232,12 +1255,12 @@ unlinkedprog(int as)
 // Construct a new PCDATA instruction associated with and for the purposes of
 // covering an existing instruction.
 static Prog*
-newpcdataprog(Prog *prog, int32 index)
+newpcdataprog(Prog *prog, int32 table, int32 index)
 {
 	Node from, to;
 	Prog *pcdata;
 
-	nodconst(&from, types[TINT32], PCDATA_StackMapIndex);
+	nodconst(&from, types[TINT32], table);
 	nodconst(&to, types[TINT32], index);
 	pcdata = unlinkedprog(APCDATA);
 	pcdata->lineno = prog->lineno;
@@ -1254,6 +1277,189 @@ issafepoint(Prog *prog)
 	return prog->as == ATEXT || prog->as == ACALL;
 }
 
+// Solves for the register maps at block entry, for asynchronous
+// preemption.  The registers that may hold pointers, and whether
+// outgoing arguments may have been written, flow forward from the entry;
+// whether the results of a call may yet be read flows backward from
+// the reads.
+static void
+livenessregmaps(Liveness *lv)
+{
+	BasicBlock *bb, *pred, *succ;
+	Regmap rm, *regmapout;
+	Prog *p;
+	uchar *argsout, *gen, *kill;
+	int32 i, j, nblocks;
+	int change, args, flags, out;
+
+	nblocks = arraylength(lv->cfg);
+	regmapout = xmalloc(sizeof(Regmap) * nblocks);
+	argsout = xmalloc(nblocks);
+	gen = xmalloc(nblocks);
+	kill = xmalloc(nblocks);
+	memset(regmapout, 0, sizeof(Regmap) * nblocks);
+	memset(argsout, 0, nblocks);
+
+	// Iterate through the blocks in reverse postorder
+	// until the entry states stop changing.
+	change = 1;
+	while(change) {
+		change = 0;
+		for(i = 0; i < nblocks; i++) {
+			bb = *(BasicBlock**)arrayget(lv->cfg, i);
+			rm.ptr = 0;
+			rm.argp = 0;
+			if(i == 0)
+				regmapinit(&rm);
+			args = 0;
+			for(j = 0; j < arraylength(bb->pred); j++) {
+				pred = *(BasicBlock**)arrayget(bb->pred, j);
+				rm.ptr |= regmapout[pred->rpo].ptr;
+				rm.argp |= regmapout[pred->rpo].argp;
+				args |= argsout[pred->rpo];
+			}
+			lv->regmapin[bb->rpo] = rm;
+			lv->argsin[bb->rpo] = args;
+			for(p = bb->first;; p = p->link) {
+				flags = regmapprog(p, &rm);
+				if(flags & RegmapArgWrite)
+					args = 1;
+				if(p->as == ACALL)
+					args = 0;
+				if(p == bb->last)
+					break;
+			}
+			if(rm.ptr != regmapout[bb->rpo].ptr || rm.argp != regmapout[bb->rpo].argp || args != argsout[bb->rpo]) {
+				regmapout[bb->rpo] = rm;
+				argsout[bb->rpo] = args;
+				change = 1;
+			}
+		}
+	}
+
+	// A block reads results (gen) if it reads from the outgoing
+	// arguments area before any call, and it is done with them (kill)
+	// if it makes a call first.
+	for(i = 0; i < nblocks; i++) {
+		bb = *(BasicBlock**)arrayget(lv->cfg, i);
+		rm = lv->regmapin[bb->rpo];
+		gen[bb->rpo] = 0;
+		kill[bb->rpo] = 0;
+		lv->resultsout[bb->rpo] = 0;
+		for(p = bb->first;; p = p->link) {
+			flags = regmapprog(p, &rm);
+			if(flags & RegmapArgRead) {
+				gen[bb->rpo] = 1;
+				break;
+			}
+			if(p->as == ACALL) {
+				kill[bb->rpo] = 1;
+				break;
+			}
+			if(p == bb->last)
+				break;
+		}
+	}
+
+	change = 1;
+	while(change) {
+		change = 0;
+		for(i = nblocks - 1; i >= 0; i--) {
+			bb = *(BasicBlock**)arrayget(lv->cfg, i);
+			out = 0;
+			for(j = 0; j < arraylength(bb->succ); j++) {
+				succ = *(BasicBlock**)arrayget(bb->succ, j);
+				if(gen[succ->rpo] || (!kill[succ->rpo] && lv->resultsout[succ->rpo]))
+					out = 1;
+			}
+			if(out != lv->resultsout[bb->rpo]) {
+				lv->resultsout[bb->rpo] = out;
+				change = 1;
+			}
+		}
+	}
+
+	free(regmapout);
+	free(argsout);
+	free(gen);
+	free(kill);
+}
+
+// Returns the number of instructions in bb.
+static int32
+blocklen(BasicBlock *bb)
+{
+	Prog *p;
+	int32 n;
+
+	n = 0;
+	for(p = bb->first;; p = p->link) {
+		n++;
+		if(p == bb->last)
+			break;
+	}
+	return n;
+}
+
+// Computes the PCDATA_RegMap value of each instruction in bb, in program
+// order: the registers that may hold pointers just before the instruction
+// executes, -1 if it is not a safe point for asynchronous preemption, or
+// -2 if it generates no code.  The regmaps array must have room for
+// blocklen(bb) entries.
+static void
+blockregmaps(Liveness *lv, BasicBlock *bb, int32 *regmaps)
+{
+	ProgInfo info;
+	Regmap rm;
+	Prog *p;
+	uchar *reads;	// 1 for a read of the outgoing arguments, 2 for a call
+	int32 i, n;
+	int args, flags, pending;
+
+	n = blocklen(bb);
+	reads = xmalloc(n);
+	rm = lv->regmapin[bb->rpo];
+	args = lv->argsin[bb->rpo];
+	i = 0;
+	for(p = bb->first;; p = p->link) {
+		proginfo(&info, p);
+		if((info.flags & Pseudo) || p->as == ANOP)
+			regmaps[i] = -2;
+		else if(args || p->as == ACALL || p->as == ARET)
+			regmaps[i] = -1;
+		else
+			regmaps[i] = rm.ptr;
+		flags = regmapprog(p, &rm);
+		reads[i] = (flags & RegmapArgRead) != 0;
+		if(flags & RegmapArgWrite)
+			args = 1;
+		if(p->as == ACALL) {
+			reads[i] = 2;
+			args = 0;
+			// The no-op before a call to deferreturn begins
+			// the call's PC-specific data; see livenessepilogue.
+			if(isdeferreturn(p) && i > 0)
+				regmaps[i-1] = -1;
+		}
+		i++;
+		if(p == bb->last)
+			break;
+	}
+
+	// Instructions before a read of call results, back to the call,
+	// would leave the results undescribed by any stack map.
+	pending = lv->resultsout[bb->rpo];
+	for(i = n - 1; i >= 0; i--) {
+		if(reads[i] == 2)
+			pending = 0;
+		else if(reads[i])
+			pending = 1;
+		if(pending && regmaps[i] >= 0)
+			regmaps[i] = -1;
+	}
+	free(reads);
+}
+
 // Initializes the sets for solving the live variables.  Visits all the
 // instructions in each basic block to summarizes the information at each basic
 // block
@@ -1427,6 +1633,65 @@ islive(Node *n, Bvec *args, Bvec *locals)
 	return 0;
 }
 
+// Allocates the pointer maps for a safe point at p and seeds them with
+// the addrtaken variables possibly initialized there (any).  Variables
+// not also certainly initialized there (all) are ambiguously live: they
+// are recorded in ambig, to be zeroed at function entry.  The tmp vector
+// is used as scratch space.
+static void
+newlivepointermaps(Liveness *lv, Prog *p, Bvec *any, Bvec *all, Bvec *ambig, Bvec *tmp)
+{
+	Bvec *args, *locals;
+	Node *n;
+	vlong xoffset;
+	int32 pos;
+
+	// Annotate ambiguously live variables so that they can
+	// be zeroed at function entry.
+	// For now, only enabled when using GOEXPERIMENT=precisestack
+	// during make.bash / all.bash.
+	if(precisestack_enabled) {
+		bvandnot(tmp, any, all);
+		if(!bvisempty(tmp)) {
+			for(pos = 0; pos < tmp->n; pos++) {
+				if(!bvget(tmp, pos))
+					continue;
+				bvset(all, pos); // silence future warnings in this block
+				n = *(Node**)arrayget(lv->vars, pos);
+				if(!n->needzero) {
+					n->needzero = 1;
+					if(debuglive >= 1)
+						warnl(p->lineno, "%N: %lN is ambiguously live", curfn->nname, n);
+					// Record in 'ambiguous' bitmap.
+					xoffset = n->xoffset + stkptrsize;
+					twobitwalktype1(n->type, &xoffset, ambig);
+				}
+			}
+		}
+	}
+
+	// Allocate a bit vector for each class and facet of
+	// value we are tracking.
+
+	// Live stuff first.
+	args = bvalloc(argswords() * BitsPerPointer);
+	arrayadd(lv->argslivepointers, &args);
+	locals = bvalloc(localswords() * BitsPerPointer);
+	arrayadd(lv->livepointers, &locals);
+
+	if(debuglive >= 3) {
+		print("%P\n", p);
+		printvars("avarinitany", any, lv->vars);
+	}
+
+	// Record any values with an "address taken" reaching
+	// this code position as live. Must do now instead of in
+	// livenessepilogue's backward walk because the any/all
+	// calculation requires walking forward over the block,
+	// while the liveout requires walking backward.
+	twobitlivepointermap(lv, any, lv->vars, args, locals);
+}
+
 // Visits all instructions in a basic block and computes a bit vector of live
 // variables at each safe point locations.
 static void
@@ -1436,8 +1701,7 @@ livenessepilogue(Liveness *lv)
 	Bvec *ambig, *livein, *liveout, *uevar, *varkill, *args, *locals, *avarinit, *any, *all;
 	Node *n;
 	Prog *p, *next;
-	int32 i, j, numlive, startmsg, nmsg, nvars, pos;
-	vlong xoffset;
+	int32 i, j, k, numlive, startmsg, nmsg, nvars, pos, regmap, *regmaps;
 	char **msg;
 	Fmt fmt;
 
@@ -1453,6 +1717,8 @@ livenessepilogue(Liveness *lv)
 	msg = nil;
 	nmsg = 0;
 	startmsg = 0;
+	regmaps = nil;
+	k = 0;
 
 	for(i = 0; i < arraylength(lv->cfg); i++) {
 		bb = *(BasicBlock**)arrayget(lv->cfg, i);
@@ -1473,69 +1739,37 @@ livenessepilogue(Liveness *lv)
 			}
 		}
 
+		if(lv->async) {
+			regmaps = xmalloc(blocklen(bb) * sizeof regmaps[0]);
+			blockregmaps(lv, bb, regmaps);
+		}
+
 		// Walk forward through the basic block instructions and
 		// allocate liveness maps for those instructions that need them.
 		// Seed the maps with information about the addrtaken variables.
+		// The map for an asynchronous safe point describes the state
+		// before the instruction executes, the map for a call the state
+		// during the call.
+		k = 0;
 		for(p = bb->first;; p = p->link) {
+			if(regmaps != nil && regmaps[k++] >= 0)
+				newlivepointermaps(lv, p, any, all, ambig, liveout);
+
 			progeffects(p, lv->vars, uevar, varkill, avarinit);
 			bvandnot(any, any, varkill);
 			bvandnot(all, all, varkill);
 			bvor(any, any, avarinit);
 			bvor(all, all, avarinit);
 
-			if(issafepoint(p)) {
-				// Annotate ambiguously live variables so that they can
-				// be zeroed at function entry.
-				// livein and liveout are dead here and used as temporaries.
-				// For now, only enabled when using GOEXPERIMENT=precisestack
-				// during make.bash / all.bash.
-				if(precisestack_enabled) {
-					bvresetall(livein);
-					bvandnot(liveout, any, all);
-					if(!bvisempty(liveout)) {
-						for(pos = 0; pos < liveout->n; pos++) {
-							if(!bvget(liveout, pos))
-								continue;
-							bvset(all, pos); // silence future warnings in this block
-							n = *(Node**)arrayget(lv->vars, pos);
-							if(!n->needzero) {
-								n->needzero = 1;
-								if(debuglive >= 1)
-									warnl(p->lineno, "%N: %lN is ambiguously live", curfn->nname, n);
-								// Record in 'ambiguous' bitmap.
-								xoffset = n->xoffset + stkptrsize;
-								twobitwalktype1(n->type, &xoffset, ambig);
-							}
-						}
-					}
-				}
-	
-				// Allocate a bit vector for each class and facet of
-				// value we are tracking.
-	
-				// Live stuff first.
-				args = bvalloc(argswords() * BitsPerPointer);
-				arrayadd(lv->argslivepointers, &args);
-				locals = bvalloc(localswords() * BitsPerPointer);
-				arrayadd(lv->livepointers, &locals);
-
-				if(debuglive >= 3) {
-					print("%P\n", p);
-					printvars("avarinitany", any, lv->vars);
-				}
-
-				// Record any values with an "address taken" reaching
-				// this code position as live. Must do now instead of below
-				// because the any/all calculation requires walking forward
-				// over the block (as this loop does), while the liveout
-				// requires walking backward (as the next loop does).
-				twobitlivepointermap(lv, any, lv->vars, args, locals);
-			}
+			if(issafepoint(p))
+				newlivepointermaps(lv, p, any, all, ambig, liveout);
 			
 			if(p == bb->last)
 				break;
 		}
 		bb->lastbitmapindex = arraylength(lv->livepointers) - 1;
+		free(regmaps);
+		regmaps = nil;
 	}
 	
 	for(i = 0; i < arraylength(lv->cfg); i++) {
@@ -1557,9 +1791,23 @@ livenessepilogue(Liveness *lv)
 			fatal("livenessepilogue");
 		}
 
+		if(lv->async) {
+			k = blocklen(bb);
+			regmaps = xmalloc(k * sizeof regmaps[0]);
+			blockregmaps(lv, bb, regmaps);
+		}
+
 		bvcopy(livein, lv->liveout[bb->rpo]);
 		for(p = bb->last; p != nil; p = next) {
 			next = p->opt; // splicebefore modifies p->opt
+			regmap = -2;
+			if(regmaps != nil) {
+				regmap = regmaps[--k];
+				// The deferreturn case below leaves a PCDATA
+				// in place of the no-op, which needs nothing more.
+				if(p->as == APCDATA)
+					regmap = -2;
+			}
 			// Propagate liveness information
 			progeffects(p, lv->vars, uevar, varkill, avarinit);
 			bvcopy(liveout, livein);
@@ -1638,15 +1886,38 @@ livenessepilogue(Liveness *lv)
 						// the PCDATA must begin one instruction early too.
 						// The instruction before a call to deferreturn is always a
 						// no-op, to keep PC-specific data unambiguous.
-						splicebefore(lv, bb, newpcdataprog(p->opt, pos), p->opt);
+						splicebefore(lv, bb, newpcdataprog(p->opt, PCDATA_StackMapIndex, pos), p->opt);
+						if(regmaps != nil)
+							splicebefore(lv, bb, newpcdataprog(p->opt, PCDATA_RegMap, -1), p->opt);
 					} else {
-						splicebefore(lv, bb, newpcdataprog(p, pos), p);
+						splicebefore(lv, bb, newpcdataprog(p, PCDATA_StackMapIndex, pos), p);
 					}
 				}
 
 				pos--;
 			}
+
+			// When preempting asynchronously, every instruction that
+			// generates code gets a register map, -1 if it is unsafe,
+			// so that the maps do not depend on how the linker lays out
+			// the blocks.  A safe one also gets a stack map: the variables
+			// live before it executes, the addrtaken ones seeded by the
+			// forward walk above, and the ambiguously live ones, which
+			// are zeroed on entry.
+			if(regmap >= 0) {
+				args = *(Bvec**)arrayget(lv->argslivepointers, pos);
+				locals = *(Bvec**)arrayget(lv->livepointers, pos);
+				twobitlivepointermap(lv, livein, lv->vars, args, locals);
+				bvor(locals, locals, ambig);
+				splicebefore(lv, bb, newpcdataprog(p, PCDATA_StackMapIndex, pos), p);
+				splicebefore(lv, bb, newpcdataprog(p, PCDATA_RegMap, regmap), p);
+				pos--;
+			} else if(regmap == -1) {
+				splicebefore(lv, bb, newpcdataprog(p, PCDATA_RegMap, -1), p);
+			}
 		}
+		free(regmaps);
+		regmaps = nil;
 		if(msg != nil) {
 			for(j=startmsg; j<nmsg; j++) 
 				if(msg[j] != nil)
@@ -1957,6 +2228,7 @@ liveness(Node *fn, Prog *firstp, Sym *argssym, Sym *livesym)
 {
 	Array *cfg, *vars;
 	Liveness *lv;
+	Regmap rm;
 	int debugdelta;
 	NodeList *l;
 
@@ -1979,11 +2251,17 @@ liveness(Node *fn, Prog *firstp, Sym *argssym, Sym *livesym)
 	vars = getvariables(fn);
 	lv = newliveness(fn, firstp, cfg, vars);
 
+	// The runtime and nosplit functions rely on not being
+	// preempted except at calls.
+	lv->async = regmapinit(&rm) && !compiling_runtime && !fn->nosplit;
+
 	// Run the dataflow framework.
 	livenessprologue(lv);
 	if(debuglive >= 3)
 		livenessprintcfg(lv);
 	livenesssolve(lv);
+	if(lv->async)
+		livenessregmaps(lv);
 	if(debuglive >= 3)
 		livenessprintcfg(lv);
 	livenessepilogue(lv);
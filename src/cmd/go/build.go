@@ -1646,7 +1646,7 @@ func (gcToolchain) gc(b *builder, p *Package, archive, obj string, asmhdr bool,
 	extFiles := len(p.CgoFiles) + len(p.CFiles) + len(p.CXXFiles) + len(p.MFiles) + len(p.SFiles) + len(p.SysoFiles) + len(p.SwigFiles) + len(p.SwigCXXFiles)
 	if p.Standard {
 		switch p.ImportPath {
-		case "bytes", "net", "os", "runtime/pprof", "sync", "time":
+		case "bytes", "net", "os", "runtime/metrics", "runtime/pprof", "sync", "time":
 			extFiles++
 		}
 	}
@@ -1657,6 +1657,13 @@ func (gcToolchain) gc(b *builder, p *Package, archive, obj string, asmhdr bool,
 		gcargs = append(gcargs, "-installsuffix", buildContext.InstallSuffix)
 	}
 
+	// Tell the compiler where vendored imports really live.
+	for _, path := range p.Imports {
+		if i, ok := findVendor(path); ok {
+			gcargs = append(gcargs, "-importmap", path[i+len("vendor/"):]+"="+path)
+		}
+	}
+
 	args := stringList(tool(archChar+"g"), "-o", ofile, "-trimpath", b.work, buildGcflags, gcargs, "-D", p.localPrefix, importArgs)
 	if ofile == archive {
 		args = append(args, "-pack")
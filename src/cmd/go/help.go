@@ -299,6 +299,67 @@ Here's an example directory layout:
 Go searches each directory listed in GOPATH to find source code,
 but new packages are always downloaded into the first directory
 in the list.
+
+Internal Directories
+
+Code in or below a directory named "internal" is importable only
+by code in the directory tree rooted at the parent of "internal".
+Here's an extended version of the directory layout above:
+
+    /home/user/gocode/
+        src/
+            crash/
+                bang/              (go code in package bang)
+                    b.go
+            foo/                   (go code in package foo)
+                f.go
+                bar/               (go code in package bar)
+                    x.go
+                internal/
+                    baz/           (go code in package baz)
+                        z.go
+                quux/              (go code in package main)
+                    y.go
+
+
+The code in z.go is imported as foo/internal/baz, but that
+import statement can only appear in source files in the subtree
+rooted at foo. The source files foo/f.go, foo/bar/x.go, and
+foo/quux/y.go can all import "foo/internal/baz", but the source file
+crash/bang/b.go cannot.  The go command reports such imports as
+"use of internal package not allowed" from build, install, list,
+test and vet.
+
+See https://golang.org/s/go14internal for details.
+
+Vendor Directories
+
+Code below a directory named "vendor" is importable only
+by code in the directory tree rooted at the parent of "vendor",
+and only using an import path that omits the prefix up to and
+including the vendor element.
+
+Here's the example from the previous section,
+but with the "quux" package vendored into foo:
+
+    /home/user/gocode/
+        src/
+            foo/
+                bar/               (go code in package bar)
+                    x.go
+                vendor/
+                    quux/          (go code in package quux)
+                        y.go
+
+Code in or below foo can import the vendored package as "quux",
+and it is built and installed as "foo/vendor/quux".  When the
+same import path is vendored in several places, the vendor
+directory closest to the importing code wins: code in foo/bar
+would use foo/bar/vendor/quux over foo/vendor/quux.  Vendored
+copies are searched before the GOROOT and GOPATH roots.
+
+Code must never import a package using a path containing
+"vendor": the import "foo/vendor/quux" is reported as an error.
 	`,
 }
 
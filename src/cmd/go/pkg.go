@@ -142,6 +142,7 @@ type PackageError struct {
 	Pos           string   // position of error
 	Err           string   // the error itself
 	isImportCycle bool     // the error is an import cycle
+	isDisallowed  bool     // the error is an import of an internal or vendored package that is not allowed
 	hard          bool     // whether the error is soft or hard; soft errors are ignored in some places
 }
 
@@ -240,16 +241,23 @@ func loadImport(path string, srcDir string, stk *importStack, importPos []token.
 	// Determine canonical identifier for this package.
 	// For a local import the identifier is the pseudo-import path
 	// we create from the full directory to the package.
+	// A path satisfied by a vendor directory is identified by
+	// the full path to the vendored copy.
 	// Otherwise it is the usual import path.
 	importPath := path
 	isLocal := build.IsLocalImport(path)
 	if isLocal {
 		importPath = dirToImportPath(filepath.Join(srcDir, path))
+	} else {
+		importPath = vendoredImportPath(path, srcDir)
 	}
 	if p := packageCache[importPath]; p != nil {
 		if perr := disallowInternal(srcDir, p, stk); perr != p {
 			return perr
 		}
+		if perr := disallowVendor(path, p, stk); perr != p {
+			return perr
+		}
 		return reusePackage(p, stk)
 	}
 
@@ -282,10 +290,29 @@ func loadImport(path string, srcDir string, stk *importStack, importPos []token.
 	if perr := disallowInternal(srcDir, p, stk); perr != p {
 		return perr
 	}
+	if perr := disallowVendor(path, p, stk); perr != p {
+		return perr
+	}
 
 	return p
 }
 
+// vendoredImportPath returns the import path that path denotes
+// when imported by code in srcDir. If a vendor directory that
+// applies to srcDir supplies path, the result is the full import
+// path of the vendored copy, such as "x/vendor/y" for "y".
+// Otherwise vendoredImportPath returns path unchanged.
+func vendoredImportPath(path, srcDir string) string {
+	if srcDir == "" {
+		return path
+	}
+	bp, err := buildContext.Import(path, srcDir, build.FindOnly)
+	if err != nil {
+		return path
+	}
+	return bp.ImportPath
+}
+
 // reusePackage reuses package p to satisfy the import at the top
 // of the import stack stk.  If this use causes an import loop,
 // reusePackage updates p's error information to record the loop.
@@ -320,12 +347,8 @@ func disallowInternal(srcDir string, p *Package, stk *importStack) *Package {
 	// is disallowed if the importing code is outside the tree
 	// rooted at the parent of the “internal” directory.
 	//
-	// ... For Go 1.4, we will implement the rule first for $GOROOT, but not $GOPATH.
-
-	// Only applies to $GOROOT.
-	if !p.Standard {
-		return p
-	}
+	// The rule applies uniformly to $GOROOT, every $GOPATH entry,
+	// vendored packages and local (relative) imports.
 
 	// The stack includes p.ImportPath.
 	// If that's the only thing on the stack, we started
@@ -354,8 +377,9 @@ func disallowInternal(srcDir string, p *Package, stk *importStack) *Package {
 	// Internal is present, and srcDir is outside parent's tree. Not allowed.
 	perr := *p
 	perr.Error = &PackageError{
-		ImportStack: stk.copy(),
-		Err:         "use of internal package not allowed",
+		ImportStack:  stk.copy(),
+		Err:          "use of internal package not allowed",
+		isDisallowed: true,
 	}
 	perr.Incomplete = true
 	return &perr
@@ -380,6 +404,48 @@ func findInternal(path string) (index int, ok bool) {
 	return 0, false
 }
 
+// disallowVendor checks that p may be imported using the import path path.
+// If the import is allowed, disallowVendor returns the original package p.
+// If not, it returns a new package containing just an appropriate error.
+func disallowVendor(path string, p *Package, stk *importStack) *Package {
+	// The stack includes p.ImportPath.
+	// If that's the only thing on the stack, we started
+	// with a name given on the command line, not an
+	// import. Anything listed on the command line is fine.
+	if len(*stk) == 1 {
+		return p
+	}
+
+	// Paths like x/vendor/y must be imported as y, never as x/vendor/y.
+	if i, ok := findVendor(path); ok {
+		perr := *p
+		perr.Error = &PackageError{
+			ImportStack:  stk.copy(),
+			Err:          "must be imported as " + path[i+len("vendor/"):],
+			isDisallowed: true,
+		}
+		perr.Incomplete = true
+		return &perr
+	}
+	return p
+}
+
+// findVendor looks for the last non-terminating "vendor" path element in the given import path.
+// If there isn't one, findVendor returns ok=false.
+// Otherwise, findVendor returns ok=true and the index of the "vendor".
+func findVendor(path string) (index int, ok bool) {
+	// Two cases, depending on vendor at start of string or not.
+	// The order matters: we must return the index of the final element,
+	// because the final one is where the effective import path starts.
+	switch {
+	case strings.Contains(path, "/vendor/"):
+		return strings.LastIndex(path, "/vendor/") + 1, true
+	case strings.HasPrefix(path, "vendor/"):
+		return 0, true
+	}
+	return 0, false
+}
+
 type targetDir int
 
 const (
@@ -400,10 +466,11 @@ var goTools = map[string]targetDir{
 	"cmd/objdump":                          toTool,
 	"cmd/pack":                             toTool,
 	"cmd/pprof":                            toTool,
+	"cmd/trace":                            toTool,
+	"cmd/vet":                              toTool,
 	"cmd/yacc":                             toTool,
 	"golang.org/x/tools/cmd/cover":         toTool,
 	"golang.org/x/tools/cmd/godoc":         toBin,
-	"golang.org/x/tools/cmd/vet":           toTool,
 	"code.google.com/p/go.tools/cmd/cover": stalePath,
 	"code.google.com/p/go.tools/cmd/godoc": stalePath,
 	"code.google.com/p/go.tools/cmd/vet":   stalePath,
@@ -582,6 +649,19 @@ func (p *Package) load(stk *importStack, bp *build.Package, err error) *Package
 			continue
 		}
 		p1 := loadImport(path, p.Dir, stk, p.build.ImportPos[path])
+		if p1.Error != nil && p1.Error.isDisallowed && p.Error == nil {
+			// Report the forbidden import against the importing
+			// package too, so that commands that do not look at
+			// dependency errors (list, vet, fmt) still see it.
+			perr := *p1.Error
+			if pos := p.build.ImportPos[path]; len(pos) > 0 {
+				pos := pos[0]
+				pos.Filename = shortPath(pos.Filename)
+				perr.Pos = pos.String()
+			}
+			p.Error = &perr
+			p.Incomplete = true
+		}
 		if p1.local {
 			if !p.local && p.Error == nil {
 				p.Error = &PackageError{
@@ -595,6 +675,15 @@ func (p *Package) load(stk *importStack, bp *build.Package, err error) *Package
 			}
 			path = p1.ImportPath
 			importPaths[i] = path
+		} else if path != p1.ImportPath {
+			// Imported from a vendor directory.
+			// Record the full path so the compiler can be
+			// told where to find it (see gcToolchain.gc).
+			path = p1.ImportPath
+			importPaths[i] = path
+			if i < len(p.Imports) {
+				p.Imports[i] = path
+			}
 		}
 		deps[path] = p1
 		imports = append(imports, p1)
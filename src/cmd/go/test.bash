@@ -171,11 +171,100 @@ elif ! grep 'use of internal package not allowed' testdata/std.out >/dev/null; t
 	ok=false
 fi
 
-TEST 'internal packages outside $GOROOT are not respected'
-if ! ./testgo build -v ./testdata/testinternal2; then
-	echo "go build ./testdata/testinternal2 failed"
+TEST 'internal packages imported with relative paths are respected'
+if ./testgo build -v ./testdata/testinternal2 >testdata/std.out 2>&1; then
+	echo "go build ./testdata/testinternal2 succeeded incorrectly"
+	ok=false
+elif ! grep 'use of internal package not allowed' testdata/std.out >/dev/null; then
+	echo "wrong error message for testdata/testinternal2"
+	cat testdata/std.out
+	ok=false
+fi
+
+TEST 'internal packages in $GOPATH are respected'
+export GOPATH=$(pwd)/testdata/testinternal3
+if ! ./testgo build -v p/ok p/ok/deep p/internal/q >testdata/std.out 2>&1; then
+	echo "go build of importers inside p failed"
+	cat testdata/std.out
+	ok=false
+fi
+for cmd in build list vet; do
+	if ./testgo $cmd bad >testdata/std.out 2>&1; then
+		echo "go $cmd bad succeeded incorrectly"
+		ok=false
+	elif ! grep 'use of internal package not allowed' testdata/std.out >/dev/null; then
+		echo "wrong error message for go $cmd bad"
+		cat testdata/std.out
+		ok=false
+	fi
+done
+if ./testgo test p/xbad >testdata/std.out 2>&1; then
+	echo "go test p/xbad succeeded incorrectly"
+	ok=false
+elif ! grep 'use of internal package not allowed' testdata/std.out >/dev/null; then
+	echo "wrong error message for go test p/xbad"
+	cat testdata/std.out
+	ok=false
+fi
+unset GOPATH
+rm -f testdata/std.out
+
+TEST 'vendor directories are searched before GOROOT and GOPATH'
+export GOPATH=$(pwd)/testdata
+if ! ./testgo run testdata/src/vend/hello/hello.go >testdata/std.out 2>&1; then
+	echo "go run vend/hello failed"
+	cat testdata/std.out
+	ok=false
+elif ! grep -q '^hello, world$' testdata/std.out; then
+	echo "go run vend/hello did not use vendored strings package"
+	cat testdata/std.out
+	ok=false
+fi
+if ! ./testgo test vend/hello >testdata/std.out 2>&1; then
+	echo "go test vend/hello failed"
+	cat testdata/std.out
 	ok=false
 fi
+unset GOPATH
+
+TEST 'go list reports vendored import paths'
+export GOPATH=$(pwd)/testdata
+d=$(mktemp -d -t testgoXXX)
+./testgo list -e -f '{{.ImportPath}} {{.Imports}}' vend/hello vend/x >$d/list.out 2>&1 || true
+echo "vend/hello [fmt vend/vendor/strings]" >$d/list.want
+echo "vend/x [vend/x/vendor/p vend/vendor/q vend/x/vendor/r]" >>$d/list.want
+if ! cmp -s $d/list.out $d/list.want; then
+	echo "go list vend/hello vend/x produced unexpected output"
+	diff $d/list.want $d/list.out
+	ok=false
+fi
+unset GOPATH
+rm -rf $d
+
+TEST 'vendored packages are not visible outside their tree'
+export GOPATH=$(pwd)/testdata
+if ./testgo build vend >testdata/std.out 2>&1; then
+	echo "go build vend succeeded incorrectly"
+	ok=false
+elif ! grep -q 'cannot find package "r"' testdata/std.out; then
+	echo "wrong error message for vend"
+	cat testdata/std.out
+	ok=false
+fi
+unset GOPATH
+
+TEST 'vendored packages cannot be imported by their full path'
+export GOPATH=$(pwd)/testdata
+if ./testgo build vend/x/invalid >testdata/std.out 2>&1; then
+	echo "go build vend/x/invalid succeeded incorrectly"
+	ok=false
+elif ! grep -q 'must be imported as p' testdata/std.out; then
+	echo "wrong error message for vend/x/invalid"
+	cat testdata/std.out
+	ok=false
+fi
+unset GOPATH
+rm -f testdata/std.out
 
 # Test that 'go get -u' reports moved packages.
 testmove() {
@@ -206,6 +206,11 @@ control the execution of any test:
 	-timeout t
 	    If a test runs longer than t, panic.
 
+	-trace trace.out
+	    Write an execution trace to the specified file before exiting.
+	    Writes test binary as -c would.
+	    The trace can be inspected with 'go tool trace'.
+
 	-v
 	    Verbose output: log all tests as they are run. Also print all
 	    text from Log and Logf calls even if the test succeeds.
@@ -559,11 +564,15 @@ func (b *builder) test(p *Package) (buildAction, runAction, printAction *action,
 	var imports, ximports []*Package
 	var stk importStack
 	stk.push(p.ImportPath + " (test)")
-	for _, path := range p.TestImports {
+	for i, path := range p.TestImports {
 		p1 := loadImport(path, p.Dir, &stk, p.build.TestImportPos[path])
 		if p1.Error != nil {
 			return nil, nil, nil, p1.Error
 		}
+		if !p1.local {
+			// Record the vendored path, if any.
+			p.TestImports[i] = p1.ImportPath
+		}
 		if contains(p1.Deps, p.ImportPath) {
 			// Same error that loadPackage returns (via reusePackage) in pkg.go.
 			// Can't change that code, because that code is only for loading the
@@ -580,7 +589,7 @@ func (b *builder) test(p *Package) (buildAction, runAction, printAction *action,
 	stk.pop()
 	stk.push(p.ImportPath + "_test")
 	pxtestNeedsPtest := false
-	for _, path := range p.XTestImports {
+	for i, path := range p.XTestImports {
 		if path == p.ImportPath {
 			pxtestNeedsPtest = true
 			continue
@@ -589,6 +598,10 @@ func (b *builder) test(p *Package) (buildAction, runAction, printAction *action,
 		if p1.Error != nil {
 			return nil, nil, nil, p1.Error
 		}
+		if !p1.local {
+			// Record the vendored path, if any.
+			p.XTestImports[i] = p1.ImportPath
+		}
 		ximports = append(ximports, p1)
 	}
 	stk.pop()
@@ -0,0 +1,3 @@
+package vend
+
+import _ "r"
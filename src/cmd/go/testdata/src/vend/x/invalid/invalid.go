@@ -0,0 +1,3 @@
+package invalid
+
+import "vend/x/vendor/p"
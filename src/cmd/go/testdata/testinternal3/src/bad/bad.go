@@ -0,0 +1,5 @@
+package bad
+
+import "p/internal/q"
+
+func F() { q.Q() }
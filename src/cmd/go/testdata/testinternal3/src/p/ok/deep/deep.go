@@ -0,0 +1,5 @@
+package deep
+
+import "p/internal/q"
+
+func F() { q.Q() }
@@ -0,0 +1,5 @@
+package ok
+
+import "p/internal/q"
+
+func F() { q.Q() }
@@ -0,0 +1,11 @@
+package xbad_test
+
+import (
+	"testing"
+
+	"bad"
+)
+
+func TestBad(t *testing.T) {
+	bad.F()
+}
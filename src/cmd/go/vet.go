@@ -4,7 +4,10 @@
 
 package main
 
-import "path/filepath"
+import (
+	"path/filepath"
+	"strings"
+)
 
 func init() {
 	addBuildFlagsNX(cmdVet)
@@ -16,8 +19,11 @@ var cmdVet = &Command{
 	Short:     "run go tool vet on packages",
 	Long: `
 Vet runs the Go vet command on the packages named by the import paths.
+The packages are checked together with their tests, and vet reads
+the packages they import, so that its checks can use facts about
+them, such as which functions are wrappers of fmt.Printf.
 
-For more about vet, see 'godoc golang.org/x/tools/cmd/vet'.
+For more about vet, see 'go doc cmd/vet'.
 For more about specifying packages, see 'go help packages'.
 
 To run the vet tool with specific options, run 'go tool vet'.
@@ -30,21 +36,26 @@ See also: go fmt, go fix.
 }
 
 func runVet(cmd *Command, args []string) {
+	// Vet lists the packages and their dependencies itself,
+	// so that it can analyze them together.  Local packages
+	// are named by directory, and a package given as a list
+	// of files by its files.
+	var paths []string
 	for _, p := range packages(args) {
-		// Vet expects to be given a set of files all from the same package.
-		// Run once for package p and once for package p_test.
-		if len(p.GoFiles)+len(p.CgoFiles)+len(p.TestGoFiles) > 0 {
-			runVetFiles(p, stringList(p.GoFiles, p.CgoFiles, p.TestGoFiles, p.SFiles))
-		}
-		if len(p.XTestGoFiles) > 0 {
-			runVetFiles(p, stringList(p.XTestGoFiles))
+		switch {
+		case p.ImportPath == "command-line-arguments":
+			run(tool("vet"), relPaths(p.gofiles))
+		case p.local:
+			dir := relPaths([]string{p.Dir})[0]
+			if !filepath.IsAbs(dir) && !strings.HasPrefix(dir, ".") {
+				dir = "." + string(filepath.Separator) + dir
+			}
+			paths = append(paths, dir)
+		default:
+			paths = append(paths, p.ImportPath)
 		}
 	}
-}
-
-func runVetFiles(p *Package, files []string) {
-	for i := range files {
-		files[i] = filepath.Join(p.Dir, files[i])
+	if len(paths) > 0 {
+		run(tool("vet"), paths)
 	}
-	run(tool("vet"), relPaths(files))
 }
@@ -337,6 +337,8 @@ objfile(char *file, char *pkg)
 	char pname[150];
 	struct ar_hdr arhdr;
 
+	// The symbols loaded keep pkg as their file,
+	// so it is not freed.
 	pkg = smprint("%i", pkg);
 
 	if(debug['v'] > 1)
@@ -354,7 +356,6 @@ objfile(char *file, char *pkg)
 		Bseek(f, 0L, 0);
 		ldobj(f, pkg, l, file, file, FileObj);
 		Bterm(f);
-		free(pkg);
 		return;
 	}
 	
@@ -415,7 +416,6 @@ objfile(char *file, char *pkg)
 
 out:
 	Bterm(f);
-	free(pkg);
 }
 
 static void
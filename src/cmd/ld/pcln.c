@@ -54,6 +54,57 @@ ftabaddstring(LSym *ftab, char *s)
 	return start;
 }
 
+// inltreeint returns the 32-bit word at offset off in s.
+static int32
+inltreeint(LSym *s, int32 off)
+{
+	uint32 v;
+	uchar *cast;
+	int i;
+
+	cast = (uchar*)&v;
+	for(i=0; i<4; i++)
+		cast[inuxi4[i]] = s->p[off+i];
+	return v;
+}
+
+// addinltree moves the inlining tree in s, as written by the compiler,
+// to ftab and returns its offset there.  The tree in ftab holds the
+// number of nodes followed by the nodes, each four 32-bit words:
+// the index of the parent node or -1, the file number and line number
+// of the inlined call, and the offset in ftab of the name of the
+// function called.  The file numbers in s index the file list of the
+// function, like the values in its pcfile table before renumberfiles.
+static int32
+addinltree(LSym *ftab, Pcln *pcln, LSym *s)
+{
+	int32 i, n, off, start, file;
+	char *name;
+
+	n = inltreeint(s, 0);
+	start = ftab->np;
+	start += -start & 3;
+	symgrow(ctxt, ftab, start+4+n*16);
+	setuint32(ctxt, ftab, start, n);
+	for(i=0; i<n; i++) {
+		off = 4+i*16;
+		file = inltreeint(s, off+4);
+		if(file < 0 || file >= pcln->nfile) {
+			diag("bad file number in inlining tree %s: %d not in range [0, %d)", s->name, file, pcln->nfile);
+			errorexit();
+		}
+		setuint32(ctxt, ftab, start+off, inltreeint(s, off));
+		setuint32(ctxt, ftab, start+off+4, pcln->file[file]->value);
+		setuint32(ctxt, ftab, start+off+8, inltreeint(s, off+8));
+		// The names of functions in the package of s are written
+		// with the "". prefix, like the names of symbols.
+		name = expandpkg((char*)s->p+inltreeint(s, off+12), s->file);
+		setuint32(ctxt, ftab, start+off+12, ftabaddstring(ftab, name));
+		free(name);
+	}
+	return start;
+}
+
 static void
 renumberfiles(Link *ctxt, LSym **files, int nfiles, Pcdata *d)
 {
@@ -191,6 +242,15 @@ pclntab(void)
 			}
 		}
 
+		// The inlining tree goes in the pclntab, so that readers of the
+		// pclntab such as debug/gosym can find it as well as the runtime.
+		// The funcdata word holds its offset in the pclntab.
+		if(pcln->nfuncdata > FUNCDATA_InlTree && pcln->funcdata[FUNCDATA_InlTree] != nil) {
+			pcln->funcdataoff[FUNCDATA_InlTree] = addinltree(ftab, pcln, pcln->funcdata[FUNCDATA_InlTree]);
+			pcln->funcdata[FUNCDATA_InlTree]->reachable = 0;
+			pcln->funcdata[FUNCDATA_InlTree] = nil;
+		}
+
 		// pcdata
 		off = addpctab(ftab, off, &pcln->pcsp);
 		off = addpctab(ftab, off, &pcln->pcfile);
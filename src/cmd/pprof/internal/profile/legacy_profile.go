@@ -379,7 +379,11 @@ func cpuProfile(b []byte, period int64, parse func(b []byte) (uint64, []byte)) (
 		},
 	}
 	var err error
-	if b, _, err = parseCPUSamples(b, parse, true, p); err != nil {
+	var labelSets map[*Sample]uint64
+	if b, _, labelSets, err = parseCPUSamples(b, parse, true, p); err != nil {
+		return nil, err
+	}
+	if b, err = parseCPULabels(b, labelSets); err != nil {
 		return nil, err
 	}
 
@@ -426,17 +430,22 @@ func cpuProfile(b []byte, period int64, parse func(b []byte) (uint64, []byte)) (
 //   2nd word -- 1
 //   3rd word -- 0
 //
+// Samples taken by Go programs with profiler labels end with two
+// extra words: a 0, which is not a valid address, and the identifier
+// of the label set, which is returned in the map of label sets.
+//
 // Addresses from stack traces may point to the next instruction after
 // each call.  Optionally adjust by -1 to land somewhere on the actual
 // call (except for the leaf, which is not a call).
-func parseCPUSamples(b []byte, parse func(b []byte) (uint64, []byte), adjust bool, p *Profile) ([]byte, map[uint64]*Location, error) {
+func parseCPUSamples(b []byte, parse func(b []byte) (uint64, []byte), adjust bool, p *Profile) ([]byte, map[uint64]*Location, map[*Sample]uint64, error) {
 	locs := make(map[uint64]*Location)
+	labelSets := make(map[*Sample]uint64)
 	for len(b) > 0 {
 		var count, nstk uint64
 		count, b = parse(b)
 		nstk, b = parse(b)
 		if b == nil || nstk > uint64(len(b)/4) {
-			return nil, nil, errUnrecognized
+			return nil, nil, nil, errUnrecognized
 		}
 		var sloc []*Location
 		addrs := make([]uint64, nstk)
@@ -448,6 +457,11 @@ func parseCPUSamples(b []byte, parse func(b []byte) (uint64, []byte), adjust boo
 			// End of data marker
 			break
 		}
+		var labelSet uint64
+		if n := len(addrs); n >= 2 && addrs[n-2] == 0 {
+			labelSet = addrs[n-1]
+			addrs = addrs[:n-2]
+		}
 		for i, addr := range addrs {
 			if adjust && i > 0 {
 				addr--
@@ -462,14 +476,62 @@ func parseCPUSamples(b []byte, parse func(b []byte) (uint64, []byte), adjust boo
 			}
 			sloc = append(sloc, loc)
 		}
-		p.Sample = append(p.Sample,
-			&Sample{
-				Value:    []int64{int64(count), int64(count) * int64(p.Period)},
-				Location: sloc,
-			})
+		s := &Sample{
+			Value:    []int64{int64(count), int64(count) * int64(p.Period)},
+			Location: sloc,
+		}
+		if labelSet != 0 {
+			labelSets[s] = labelSet
+		}
+		p.Sample = append(p.Sample, s)
 	}
 	// Reached the end without finding the EOD marker.
-	return b, locs, nil
+	return b, locs, labelSets, nil
+}
+
+// parseCPULabels parses the profiler labels section that Go programs
+// write after the samples of a CPU profile, and attaches the labels
+// to the samples in labelSets.  It returns the data following the
+// section.
+//
+// The section has the form:
+//   --- labels:
+//   <label set id> "<key>" "<value>"
+//   ...
+func parseCPULabels(b []byte, labelSets map[*Sample]uint64) ([]byte, error) {
+	const header = "--- labels:\n"
+	if !bytes.HasPrefix(b, []byte(header)) {
+		if len(labelSets) > 0 {
+			return nil, fmt.Errorf("malformed profile: labeled samples without labels section")
+		}
+		return b, nil
+	}
+	b = b[len(header):]
+	labels := make(map[uint64]map[string][]string)
+	for len(b) > 0 && !bytes.HasPrefix(b, []byte("---")) {
+		var l []byte
+		if i := bytes.IndexByte(b, '\n'); i >= 0 {
+			l, b = b[:i], b[i+1:]
+		} else {
+			l, b = b, nil
+		}
+		var id uint64
+		var key, value string
+		if _, err := fmt.Sscanf(string(l), "%d %q %q", &id, &key, &value); err != nil {
+			return nil, fmt.Errorf("malformed profile label: %q: %v", l, err)
+		}
+		if labels[id] == nil {
+			labels[id] = make(map[string][]string)
+		}
+		labels[id][key] = append(labels[id][key], value)
+	}
+	for s, id := range labelSets {
+		if labels[id] == nil {
+			return nil, fmt.Errorf("malformed profile: unknown label set %d", id)
+		}
+		s.Label = labels[id]
+	}
+	return b, nil
 }
 
 // parseHeap parses a heapz legacy or a growthz profile and
@@ -687,8 +749,8 @@ func scaleHeapSample(count, size, rate int64) (int64, int64) {
 	return int64(float64(count) * scale), int64(float64(size) * scale)
 }
 
-// parseContention parses a contentionz profile and returns a newly
-// populated Profile.
+// parseContention parses a contentionz or mutex profile and returns
+// a newly populated Profile.
 func parseContention(b []byte) (p *Profile, err error) {
 	r := bytes.NewBuffer(b)
 	l, err := r.ReadString('\n')
@@ -696,7 +758,7 @@ func parseContention(b []byte) (p *Profile, err error) {
 		return nil, errUnrecognized
 	}
 
-	if !strings.HasPrefix(l, "--- contention") {
+	if !strings.HasPrefix(l, "--- contention") && !strings.HasPrefix(l, "--- mutex:") {
 		return nil, errUnrecognized
 	}
 
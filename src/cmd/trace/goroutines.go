@@ -0,0 +1,263 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Goroutine-related profiles.
+
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"internal/trace"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+func init() {
+	http.HandleFunc("/goroutines", httpGoroutines)
+	http.HandleFunc("/goroutine", httpGoroutine)
+}
+
+// gtype describes a group of goroutines grouped by start PC.
+type gtype struct {
+	ID       uint64 // Unique identifier (PC).
+	Name     string // Start function.
+	N        int    // Total number of goroutines in this group.
+	ExecTime int64  // Total execution time of all goroutines in this group.
+}
+
+type gtypeList []gtype
+
+func (l gtypeList) Len() int {
+	return len(l)
+}
+
+func (l gtypeList) Less(i, j int) bool {
+	return l[i].ExecTime > l[j].ExecTime
+}
+
+func (l gtypeList) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+}
+
+type gdescList []*trace.GDesc
+
+func (l gdescList) Len() int {
+	return len(l)
+}
+
+func (l gdescList) Less(i, j int) bool {
+	return l[i].TotalTime > l[j].TotalTime
+}
+
+func (l gdescList) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+}
+
+// httpGoroutines serves the list of goroutine groups,
+// or the goroutines of one group if the id parameter is given.
+func httpGoroutines(w http.ResponseWriter, r *http.Request) {
+	events, err := parseEvents()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	gs := trace.GoroutineStats(events)
+
+	if id := r.FormValue("id"); id != "" {
+		pc, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to parse id parameter '%v': %v", id, err), http.StatusBadRequest)
+			return
+		}
+		var glist gdescList
+		for _, g := range gs {
+			if g.PC == pc {
+				glist = append(glist, g)
+			}
+		}
+		sort.Sort(glist)
+		if err := templGoroutines.Execute(w, glist); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	gss := make(map[uint64]gtype)
+	for _, g := range gs {
+		gs1 := gss[g.PC]
+		gs1.ID = g.PC
+		gs1.Name = g.Name
+		if gs1.Name == "" {
+			gs1.Name = "unknown"
+		}
+		gs1.N++
+		gs1.ExecTime += g.ExecTime
+		gss[g.PC] = gs1
+	}
+	var glist gtypeList
+	for k, v := range gss {
+		v.ID = k
+		glist = append(glist, v)
+	}
+	sort.Sort(glist)
+	if err := templGtypes.Execute(w, glist); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+var templGtypes = template.Must(template.New("").Parse(`
+<html>
+<body>
+<table>
+<tr><th>Goroutines</th><th>Count</th><th>Execution time</th></tr>
+{{range $}}
+  <tr><td><a href="/goroutines?id={{.ID}}">{{.Name}}</a></td><td>{{.N}}</td><td>{{.ExecTime}}ns</td></tr>
+{{end}}
+</table>
+</body>
+</html>
+`))
+
+var templGoroutines = template.Must(template.New("").Parse(`
+<html>
+<body>
+{{template "table" $}}
+</body>
+</html>
+{{define "table"}}
+<table border="1" sortable="1">
+<tr>
+<th> Goroutine </th>
+<th> Total time, ns </th>
+<th> Execution time, ns </th>
+<th> Network wait time, ns </th>
+<th> Sync block time, ns </th>
+<th> Blocking syscall time, ns </th>
+<th> Scheduler wait time, ns </th>
+<th> GC sweeping time, ns </th>
+</tr>
+{{range $}}
+  <tr>
+    <td> <a href="/goroutine?id={{.ID}}">{{.ID}}</a> </td>
+    <td> {{.TotalTime}} </td>
+    <td> {{.ExecTime}} </td>
+    <td> {{.IOTime}} </td>
+    <td> {{.BlockTime}} </td>
+    <td> {{.SyscallTime}} </td>
+    <td> {{.SchedWaitTime}} </td>
+    <td> {{.SweepTime}} </td>
+  </tr>
+{{end}}
+</table>
+{{end}}
+`))
+
+// Colors of goroutine states in the goroutine timeline.
+const (
+	colorRunning  = "#0a0"
+	colorRunnable = "#fc0"
+	colorBlocked  = "#c00"
+	colorNet      = "#06c"
+	colorSleep    = "#aaa"
+	colorSyscall  = "#a0a"
+)
+
+// httpGoroutine serves the timeline and statistics of a single goroutine.
+func httpGoroutine(w http.ResponseWriter, r *http.Request) {
+	events, err := parseEvents()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	goid, err := strconv.ParseUint(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to parse id parameter '%v': %v", r.FormValue("id"), err), http.StatusBadRequest)
+		return
+	}
+	g := trace.GoroutineStats(events)[goid]
+	if g == nil {
+		http.Error(w, fmt.Sprintf("goroutine %v not found", goid), http.StatusNotFound)
+		return
+	}
+	win := parseWindow(r, events)
+	rows := goroutineRows(events, goid, win.end)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<html>\n<head><title>Goroutine %v</title></head>\n<body>\n", goid)
+	fmt.Fprintf(w, "<h2>Goroutine %v %s</h2>\n", goid, template.HTMLEscapeString(g.Name))
+	writeNavigation(w, fmt.Sprintf("/goroutine?id=%v&", goid), win)
+	writeSVG(w, rows, win)
+	fmt.Fprintf(w, "<p>Legend: ")
+	for _, l := range []struct{ name, color string }{
+		{"running", colorRunning}, {"runnable", colorRunnable}, {"blocked", colorBlocked},
+		{"network", colorNet}, {"sleeping", colorSleep}, {"syscall", colorSyscall},
+	} {
+		fmt.Fprintf(w, "<span style=\"background:%s\">&nbsp;&nbsp;&nbsp;</span> %s ", l.color, l.name)
+	}
+	fmt.Fprintf(w, "</p>\n")
+	if err := templGoroutines.ExecuteTemplate(w, "table", []*trace.GDesc{g}); err != nil {
+		fmt.Fprintf(w, "<p>%s</p>\n", template.HTMLEscapeString(err.Error()))
+	}
+	fmt.Fprintf(w, "</body>\n</html>\n")
+}
+
+// goroutineRows builds the state and syscall rows of the timeline of goroutine goid.
+func goroutineRows(events []*trace.Event, goid uint64, lastTs int64) []row {
+	state := row{name: "State"}
+	syscalls := row{name: "Syscalls"}
+	add := func(r *row, ev *trace.Event, color, what string) {
+		end := lastTs
+		if ev.Link != nil {
+			end = ev.Link.Ts
+		}
+		title := fmt.Sprintf("%v %v\n%v", what, fmtDur(end-ev.Ts), stackString(ev.Stk))
+		r.spans = append(r.spans, span{ev.Ts, end, color, title, ""})
+	}
+	for _, ev := range events {
+		switch ev.Type {
+		case trace.EvGoCreate:
+			if ev.Args[0] == goid {
+				add(&state, ev, colorRunnable, "runnable after creation")
+			}
+		case trace.EvGoUnblock:
+			if ev.Args[0] == goid {
+				add(&state, ev, colorRunnable, "runnable after unblock")
+			}
+		}
+		if ev.G != goid {
+			continue
+		}
+		switch ev.Type {
+		case trace.EvGoStart:
+			add(&state, ev, colorRunning, "running")
+		case trace.EvGoSched, trace.EvGoPreempt:
+			add(&state, ev, colorRunnable, trace.EventDescriptions[ev.Type].Name)
+		case trace.EvGoSysExit:
+			add(&state, ev, colorRunnable, "runnable after syscall")
+		case trace.EvGoBlockNet:
+			add(&state, ev, colorNet, "blocked on network")
+		case trace.EvGoSleep:
+			add(&state, ev, colorSleep, "sleeping")
+		case trace.EvGoBlock, trace.EvGoBlockSend, trace.EvGoBlockRecv,
+			trace.EvGoBlockSelect, trace.EvGoBlockSync, trace.EvGoBlockCond:
+			add(&state, ev, colorBlocked, trace.EventDescriptions[ev.Type].Name)
+		case trace.EvGoSysCall:
+			if ev.Link != nil {
+				add(&syscalls, ev, colorSyscall, "blocking syscall")
+			}
+		}
+	}
+	return []row{state, syscalls}
+}
+
+// stackString formats stk with one frame per line.
+func stackString(stk []*trace.Frame) string {
+	s := ""
+	for _, f := range stk {
+		s += fmt.Sprintf("%v\n\t%v:%v\n", f.Fn, f.File, f.Line)
+	}
+	return s
+}
@@ -0,0 +1,161 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"html/template"
+	"internal/trace"
+	"net/http"
+	"sort"
+	"time"
+)
+
+func init() {
+	http.HandleFunc("/latency", httpLatency)
+}
+
+// histogram is a log2-bucketed distribution of durations.
+type histogram struct {
+	Name    string
+	Help    string
+	durs    []int64
+	buckets [64]int
+}
+
+func (h *histogram) add(d int64) {
+	if d < 0 {
+		d = 0
+	}
+	h.durs = append(h.durs, d)
+	b := 0
+	for v := d; v > 1; v >>= 1 {
+		b++
+	}
+	h.buckets[b]++
+}
+
+// histBucket is a row of a rendered histogram.
+type histBucket struct {
+	Lo, Hi time.Duration
+	Count  int
+	Width  int // width of the bar, in pixels
+}
+
+// histView is a histogram prepared for rendering.
+type histView struct {
+	Name, Help         string
+	Count              int
+	P50, P90, P99, Max time.Duration
+	Buckets            []histBucket
+}
+
+func (h *histogram) view() histView {
+	v := histView{Name: h.Name, Help: h.Help, Count: len(h.durs)}
+	if len(h.durs) == 0 {
+		return v
+	}
+	durs := append([]int64(nil), h.durs...)
+	sort.Sort(int64s(durs))
+	pct := func(p int) time.Duration {
+		return time.Duration(durs[(len(durs)-1)*p/100])
+	}
+	v.P50, v.P90, v.P99, v.Max = pct(50), pct(90), pct(99), pct(100)
+
+	lo, hi, max := -1, 0, 0
+	for i, n := range h.buckets {
+		if n == 0 {
+			continue
+		}
+		if lo < 0 {
+			lo = i
+		}
+		hi = i
+		if n > max {
+			max = n
+		}
+	}
+	for i := lo; i <= hi; i++ {
+		b := histBucket{Lo: time.Duration(1) << uint(i), Hi: time.Duration(1) << uint(i+1), Count: h.buckets[i]}
+		if i == 0 {
+			b.Lo = 0
+		}
+		b.Width = 400 * b.Count / max
+		v.Buckets = append(v.Buckets, b)
+	}
+	return v
+}
+
+type int64s []int64
+
+func (a int64s) Len() int           { return len(a) }
+func (a int64s) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a int64s) Less(i, j int) bool { return a[i] < a[j] }
+
+// latencies computes the latency distributions of the trace.
+func latencies(events []*trace.Event) []*histogram {
+	sched := &histogram{Name: "Scheduler latency", Help: "time from a goroutine becoming runnable to it starting to run"}
+	block := &histogram{Name: "Synchronization blocking", Help: "time goroutines spend blocked on channels, select, mutexes and condition variables"}
+	netw := &histogram{Name: "Network blocking", Help: "time goroutines spend waiting for network I/O"}
+	sys := &histogram{Name: "Blocking syscalls", Help: "duration of system calls that blocked their thread"}
+	gc := &histogram{Name: "GC pauses", Help: "duration of stop-the-world phases of the garbage collector"}
+	for _, ev := range events {
+		if ev.Link == nil {
+			continue
+		}
+		d := ev.Link.Ts - ev.Ts
+		switch ev.Type {
+		case trace.EvGoCreate, trace.EvGoUnblock, trace.EvGoSched, trace.EvGoPreempt, trace.EvGoSysExit:
+			sched.add(d)
+		case trace.EvGoBlockSend, trace.EvGoBlockRecv, trace.EvGoBlockSelect,
+			trace.EvGoBlockSync, trace.EvGoBlockCond:
+			block.add(d)
+		case trace.EvGoBlockNet:
+			netw.add(d)
+		case trace.EvGoSysCall:
+			sys.add(d)
+		case trace.EvGCSTWStart:
+			gc.add(d)
+		}
+	}
+	return []*histogram{sched, block, netw, sys, gc}
+}
+
+// httpLatency serves the latency histograms.
+func httpLatency(w http.ResponseWriter, r *http.Request) {
+	events, err := parseEvents()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var views []histView
+	for _, h := range latencies(events) {
+		views = append(views, h.view())
+	}
+	if err := templLatency.Execute(w, views); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+var templLatency = template.Must(template.New("").Parse(`
+<html>
+<body>
+{{range $}}
+<h3>{{.Name}}</h3>
+<p>{{.Help}}.</p>
+{{if .Count}}
+<p>{{.Count}} samples; 50%: {{.P50}}, 90%: {{.P90}}, 99%: {{.P99}}, max: {{.Max}}</p>
+<table>
+{{range .Buckets}}
+  <tr><td align="right">{{.Lo}}</td><td>&ndash;</td><td>{{.Hi}}</td><td><div style="background:#06c;height:12px;width:{{.Width}}px"></div></td><td>{{.Count}}</td></tr>
+{{end}}
+</table>
+{{else}}
+<p>No samples.</p>
+{{end}}
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,119 @@
+// Copyright 2015 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeTrace traces a little scheduling, blocking and garbage collection
+// and returns the name of the file holding the trace.
+func writeTrace(t *testing.T) string {
+	f, err := ioutil.TempFile("", "trace-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := pprof.StartTrace(f); err != nil {
+		os.Remove(f.Name())
+		t.Fatalf("failed to start tracing: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	c := make(chan int)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for v := range c {
+				_ = make([]byte, v)
+			}
+		}()
+	}
+	for i := 0; i < 1000; i++ {
+		c <- i
+	}
+	close(c)
+	wg.Wait()
+	time.Sleep(time.Millisecond)
+	runtime.GC()
+
+	pprof.StopTrace()
+	return f.Name()
+}
+
+func get(t *testing.T, url string) string {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: %s\n%s", url, resp.Status, body)
+	}
+	return string(body)
+}
+
+func TestHTTP(t *testing.T) {
+	switch runtime.GOOS {
+	case "android", "nacl":
+		t.Skipf("skipping on %s; cannot run go tool addr2line", runtime.GOOS)
+	}
+
+	traceFile = writeTrace(t)
+	defer os.Remove(traceFile)
+	programBinary = os.Args[0]
+
+	ts := httptest.NewServer(http.DefaultServeMux)
+	defer ts.Close()
+
+	pages := []struct {
+		path string
+		want []string
+	}{
+		{"/", []string{`href="/timeline"`, `href="/goroutines"`, `href="/latency"`}},
+		{"/timeline", []string{"<svg", "GC", "Proc 0"}},
+		{"/goroutines", []string{`href="/goroutines?id=`}},
+		{"/latency", []string{"Scheduler latency", "Synchronization blocking", "GC pauses"}},
+	}
+	for _, p := range pages {
+		body := get(t, ts.URL+p.path)
+		for _, want := range p.want {
+			if !strings.Contains(body, want) {
+				t.Errorf("%s does not contain %q:\n%s", p.path, want, body)
+			}
+		}
+	}
+
+	// Follow the links from the goroutine groups to a single goroutine.
+	groups := get(t, ts.URL+"/goroutines")
+	m := regexp.MustCompile(`href="(/goroutines\?id=[0-9]+)"`).FindStringSubmatch(groups)
+	if m == nil {
+		t.Fatalf("no goroutine group in /goroutines:\n%s", groups)
+	}
+	groupPath := m[1]
+	group := get(t, ts.URL+groupPath)
+	m = regexp.MustCompile(`href="(/goroutine\?id=[0-9]+)"`).FindStringSubmatch(group)
+	if m == nil {
+		t.Fatalf("no goroutine in %s:\n%s", groupPath, group)
+	}
+	if body := get(t, ts.URL+m[1]); !strings.Contains(body, "<svg") {
+		t.Errorf("%s has no timeline:\n%s", m[1], body)
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"internal/trace"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+func init() {
+	http.HandleFunc("/timeline", httpTimeline)
+}
+
+// Geometry of the rendered timelines, in pixels.
+const (
+	timelineWidth = 1200
+	labelWidth    = 120
+	rowHeight     = 20
+	axisHeight    = 20
+)
+
+// span is a time interval drawn as a rectangle on a timeline row.
+// Spans with start == end are drawn as thin marks.
+type span struct {
+	start, end int64
+	color      string
+	title      string
+	href       string
+}
+
+// row is a single horizontal line of a timeline.
+type row struct {
+	name  string
+	spans []span
+}
+
+// window is the time interval [start, end) displayed by a timeline.
+type window struct {
+	start, end int64
+}
+
+// parseWindow extracts the displayed interval from the start and end
+// query parameters (in nanoseconds), defaulting to the whole trace.
+func parseWindow(r *http.Request, events []*trace.Event) window {
+	win := window{0, 1}
+	if len(events) != 0 {
+		win.end = events[len(events)-1].Ts + 1
+	}
+	if v, err := strconv.ParseInt(r.FormValue("start"), 10, 64); err == nil && v >= 0 && v < win.end {
+		win.start = v
+	}
+	if v, err := strconv.ParseInt(r.FormValue("end"), 10, 64); err == nil && v > win.start {
+		win.end = v
+	}
+	return win
+}
+
+// x converts timestamp ts to a horizontal pixel offset.
+func (win window) x(ts int64) float64 {
+	return labelWidth + float64(ts-win.start)*timelineWidth/float64(win.end-win.start)
+}
+
+// httpTimeline serves the processor timeline, with GC phases on top.
+func httpTimeline(w http.ResponseWriter, r *http.Request) {
+	events, err := parseEvents()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	win := parseWindow(r, events)
+	rows := procRows(events, win.end)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<html>\n<head><title>Processor timeline</title></head>\n<body>\n")
+	writeNavigation(w, "/timeline?", win)
+	writeSVG(w, rows, win)
+	fmt.Fprintf(w, "<p>Click on a goroutine to see its timeline.</p>\n</body>\n</html>\n")
+}
+
+// procRows builds the rows of the processor timeline.
+func procRows(events []*trace.Event, lastTs int64) []row {
+	gc := row{name: "GC"}
+	stw := row{name: "STW"}
+	sweep := row{name: "Sweep"}
+	timers := row{name: "Timers"}
+	netpoll := row{name: "Network"}
+	syscalls := row{name: "Syscalls"}
+	procs := make(map[int]*row)
+	names := goroutineNames(events)
+
+	endOf := func(ev *trace.Event) int64 {
+		if ev.Link != nil {
+			return ev.Link.Ts
+		}
+		return lastTs
+	}
+	for _, ev := range events {
+		switch ev.Type {
+		case trace.EvGCStart:
+			gc.spans = append(gc.spans, span{ev.Ts, endOf(ev), "#c00", "GC " + fmtDur(endOf(ev)-ev.Ts), ""})
+		case trace.EvGCSTWStart:
+			stw.spans = append(stw.spans, span{ev.Ts, endOf(ev), "#f80", "stop the world " + fmtDur(endOf(ev)-ev.Ts), ""})
+		case trace.EvGCSweepStart:
+			sweep.spans = append(sweep.spans, span{ev.Ts, endOf(ev), "#fc0", "sweep " + fmtDur(endOf(ev)-ev.Ts), ""})
+		case trace.EvGoUnblock:
+			title := fmt.Sprintf("unblock G%v", ev.Args[0])
+			href := fmt.Sprintf("/goroutine?id=%v", ev.Args[0])
+			switch ev.P {
+			case trace.TimerP:
+				timers.spans = append(timers.spans, span{ev.Ts, ev.Ts, "#000", title, href})
+			case trace.NetpollP:
+				netpoll.spans = append(netpoll.spans, span{ev.Ts, ev.Ts, "#000", title, href})
+			}
+		case trace.EvGoSysExit:
+			title := fmt.Sprintf("G%v returns from syscall", ev.G)
+			href := fmt.Sprintf("/goroutine?id=%v", ev.G)
+			syscalls.spans = append(syscalls.spans, span{ev.Ts, ev.Ts, "#000", title, href})
+		case trace.EvGoStart:
+			if ev.P >= trace.FakeP {
+				break
+			}
+			p := procs[ev.P]
+			if p == nil {
+				p = &row{name: fmt.Sprintf("Proc %v", ev.P)}
+				procs[ev.P] = p
+			}
+			end := endOf(ev)
+			title := fmt.Sprintf("G%v %v\n%v", ev.G, names[ev.G], fmtDur(end-ev.Ts))
+			href := fmt.Sprintf("/goroutine?id=%v", ev.G)
+			p.spans = append(p.spans, span{ev.Ts, end, goroutineColor(ev.G), title, href})
+		}
+	}
+
+	var pids []int
+	for pid := range procs {
+		pids = append(pids, pid)
+	}
+	sort.Ints(pids)
+	rows := []row{gc, stw, sweep}
+	for _, pid := range pids {
+		rows = append(rows, *procs[pid])
+	}
+	return append(rows, timers, netpoll, syscalls)
+}
+
+// writeNavigation writes links for zooming and scrolling the window.
+// base is the page URL including any parameters other than start and end.
+func writeNavigation(w io.Writer, base string, win window) {
+	d := win.end - win.start
+	link := func(name string, start, end int64) {
+		if start < 0 {
+			start = 0
+		}
+		fmt.Fprintf(w, "<a href=\"%sstart=%v&end=%v\">%s</a> ", base, start, end, name)
+	}
+	fmt.Fprintf(w, "<p>Showing %v to %v. ", fmtDur(win.start), fmtDur(win.end))
+	link("[&larr;]", win.start-d/2, win.end-d/2)
+	link("[zoom in]", win.start+d/4, win.end-d/4)
+	link("[zoom out]", win.start-d/2, win.end+d/2)
+	link("[&rarr;]", win.start+d/2, win.end+d/2)
+	fmt.Fprintf(w, "<a href=\"%s\">[all]</a></p>\n", base)
+}
+
+// writeSVG renders rows clipped to the window as an inline SVG image.
+func writeSVG(w io.Writer, rows []row, win window) {
+	height := axisHeight + len(rows)*rowHeight
+	fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" xmlns:xlink=\"http://www.w3.org/1999/xlink\" width=\"%v\" height=\"%v\" font-family=\"sans-serif\" font-size=\"12\">\n",
+		labelWidth+timelineWidth+10, height)
+
+	// Time axis with ten ticks.
+	for i := 0; i <= 10; i++ {
+		ts := win.start + (win.end-win.start)*int64(i)/10
+		x := win.x(ts)
+		fmt.Fprintf(w, "<line x1=\"%.1f\" y1=\"%v\" x2=\"%.1f\" y2=\"%v\" stroke=\"#ddd\"/>\n", x, axisHeight-5, x, height)
+		if i < 10 {
+			fmt.Fprintf(w, "<text x=\"%.1f\" y=\"%v\">%s</text>\n", x+2, axisHeight-8, fmtDur(ts))
+		}
+	}
+
+	for i, r := range rows {
+		y := axisHeight + i*rowHeight
+		fmt.Fprintf(w, "<text x=\"2\" y=\"%v\">%s</text>\n", y+rowHeight-6, template.HTMLEscapeString(r.name))
+		// Spans narrower than a pixel that fall onto an already painted
+		// pixel are skipped, which keeps the image size proportional to
+		// its width rather than to the number of events.
+		drawn := float64(-1)
+		for _, s := range r.spans {
+			if s.end < win.start || s.start >= win.end {
+				continue
+			}
+			x0, x1 := win.x(s.start), win.x(s.end)
+			if x0 < labelWidth {
+				x0 = labelWidth
+			}
+			if max := float64(labelWidth + timelineWidth); x1 > max {
+				x1 = max
+			}
+			if x1-x0 < 1 {
+				if x0 < drawn {
+					continue
+				}
+				x1 = x0 + 1
+			}
+			if x1 > drawn {
+				drawn = x1
+			}
+			if s.href != "" {
+				fmt.Fprintf(w, "<a xlink:href=\"%s\">", template.HTMLEscapeString(s.href))
+			}
+			fmt.Fprintf(w, "<rect x=\"%.1f\" y=\"%v\" width=\"%.1f\" height=\"%v\" fill=\"%s\"><title>%s</title></rect>",
+				x0, y+2, x1-x0, rowHeight-4, s.color, template.HTMLEscapeString(s.title))
+			if s.href != "" {
+				fmt.Fprintf(w, "</a>")
+			}
+			fmt.Fprintf(w, "\n")
+		}
+	}
+	fmt.Fprintf(w, "</svg>\n")
+}
+
+// goroutineColor returns a stable, distinct color for goroutine goid.
+func goroutineColor(goid uint64) string {
+	return fmt.Sprintf("hsl(%v,60%%,60%%)", goid*67%360)
+}
+
+// goroutineNames returns the function names of all goroutines in the trace.
+func goroutineNames(events []*trace.Event) map[uint64]string {
+	names := make(map[uint64]string)
+	for goid, g := range trace.GoroutineStats(events) {
+		names[goid] = g.Name
+	}
+	return names
+}
+
+// fmtDur formats a nanosecond duration or timestamp for display.
+func fmtDur(ns int64) string {
+	return time.Duration(ns).String()
+}
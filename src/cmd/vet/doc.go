@@ -0,0 +1,104 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Vet examines Go source code and reports suspicious constructs, such as
+Printf calls whose arguments do not align with the format string.  Vet
+uses heuristics that do not guarantee all reports are genuine problems,
+but it can find errors not caught by the compilers.
+
+Vet is normally invoked using the go command by running "go vet":
+
+	go vet
+
+vets the package in the current directory.
+
+	go vet package/path/name
+
+vets the package whose path is provided.
+
+Use "go help packages" to see other ways of specifying which packages
+to vet.
+
+Vet's exit code is 2 for erroneous invocation of the tool, 1 if a
+problem was reported, and 0 otherwise.  Note that the tool does not
+check every possible problem and depends on unreliable heuristics,
+so it should be used as guidance only, not as a firm indicator of
+program correctness.
+
+By default, all checks are performed.  If any flags are explicitly
+set to true, only those checks are run.  Conversely, if any flag is
+explicitly set to false, only those checks are disabled.  Thus
+-printf=true runs the printf check, and -printf=false runs all checks
+except the printf check.
+
+Available checks:
+
+Printf family
+
+Flag: -printf
+
+Suspicious calls to functions in the Printf family: a format string
+whose directives do not match the number of arguments, an unknown
+verb, a call of Println with a format directive, and so on.  Vet also
+checks the calls of the functions and methods it recognizes as
+wrappers of those functions, including wrappers declared in the
+packages imported by the package being checked.
+
+Copying locks
+
+Flag: -copylocks
+
+Locks that are erroneously passed by value: parameters, receivers,
+assignments, call arguments, return values and composite literal
+elements that copy a value of a type containing a sync.Mutex or
+another type of package sync or sync/atomic that must not be copied.
+
+Loop closures
+
+Flag: -loopclosure
+
+References to loop variables from within a function literal that is
+started as a goroutine or deferred as the last statement of the loop
+body, and that may thus run after the variables have changed.
+
+Lost cancelation
+
+Flag: -lostcancel
+
+Cancelation functions returned by context.WithCancel, WithTimeout and
+WithDeadline that are discarded or never used, which leaks the
+context until its parent is cancelled.
+
+Unused results
+
+Flag: -unusedresult
+
+Calls of well-known functions without side effects, such as
+fmt.Sprintf and strings.TrimSpace, whose results are discarded.
+
+Other flags
+
+These flags configure the behavior of vet:
+
+	-v
+		Verbose mode.
+	-tags
+		A space-separated list of build tags to consider satisfied
+		when listing the packages.
+
+Given a list of .go files, vet checks them as a single package,
+without the help of the facts about the packages they import.
+
+Analyzers
+
+Each check is an analyzer, in the sense of the internal package
+cmd/vet/internal/analysis: a function that inspects the syntax of
+one package at a time, and that may record facts about the objects
+of a package for the benefit of the checks of the packages that
+import it.  To this end vet also applies the analyzers that record
+facts to the dependencies of the packages being checked, and
+reports only the problems of the latter.
+*/
+package main
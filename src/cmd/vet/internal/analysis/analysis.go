@@ -0,0 +1,212 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package analysis defines the interface between a modular static
+// analysis and a driver program that runs it, such as cmd/vet.
+//
+// An Analyzer describes an analysis: its name and documentation, the
+// other analyzers whose results it requires, the types of the facts it
+// records, and the function that runs it on a package.  The driver
+// runs each Analyzer on a package once the analyzers it requires have
+// run on the same package, and once the Analyzer itself has run on
+// every package imported by it, so that facts recorded about the
+// imported packages are available to it.
+//
+// The analyzers work on syntax trees.  Pass.ObjectOf resolves the
+// identifiers and qualified identifiers denoting package-level
+// objects, which is enough for analyzers that look for calls of
+// particular functions or uses of particular types.
+package analysis
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"reflect"
+)
+
+// An Analyzer describes an analysis function and its options.
+type Analyzer struct {
+	// Name is the name of the analyzer, a valid Go identifier.
+	// The driver uses it to name the flag that enables the analyzer.
+	Name string
+
+	// Doc is the documentation for the analyzer.
+	// Its first line is a summary.
+	Doc string
+
+	// Requires is a set of analyzers that must run successfully
+	// before this one on a given package.  Their results are
+	// available to Run in Pass.ResultOf.
+	Requires []*Analyzer
+
+	// FactTypes lists the types of facts the analyzer records,
+	// each given as a pointer to its zero value.  An analyzer with
+	// facts is run on every package imported, directly or not, by
+	// the packages being analyzed, so that it can compute the facts
+	// about them; its diagnostics there are discarded.
+	FactTypes []Fact
+
+	// Run applies the analyzer to a package.
+	// It returns an error if the analyzer failed.
+	// The result is made available to the analyzers that require
+	// this one.
+	Run func(*Pass) (interface{}, error)
+}
+
+func (a *Analyzer) String() string { return a.Name }
+
+// A Fact is an intermediate fact produced during analysis.
+//
+// Each fact is associated with a named, package-level object (see
+// Pass.ObjectOf) or with a package, and is visible to the analyses of
+// the packages that import it, directly or not.
+//
+// A Fact type must be a pointer.  The marker method AFact
+// distinguishes facts from other values.
+type Fact interface {
+	AFact()
+}
+
+// A Package is a parsed Go package to be analyzed.
+type Package struct {
+	Path    string              // import path
+	Name    string              // package name
+	Files   []*ast.File         // syntax trees of the files of the package
+	Imports map[string]*Package // imported packages, by import path
+}
+
+func (p *Package) String() string { return p.Path }
+
+// A Diagnostic is a message associated with a source location.
+type Diagnostic struct {
+	Pos     token.Pos
+	Message string
+}
+
+// A Pass provides information to the Run function that applies a
+// specific analyzer to a single Go package.
+//
+// The Run function should not call any of the Pass functions
+// concurrently.
+type Pass struct {
+	Analyzer *Analyzer // the identity of the current analyzer
+
+	Fset  *token.FileSet // file position information
+	Files []*ast.File    // the abstract syntax tree of each file
+	Pkg   *Package       // the package being analyzed
+
+	// ResultOf provides the inputs to this analysis pass, which are
+	// the corresponding results of its prerequisite analyzers.
+	ResultOf map[*Analyzer]interface{}
+
+	// Report reports a Diagnostic, a finding about a specific
+	// location in the analyzed source code.
+	Report func(Diagnostic)
+
+	facts   *factStore
+	visible map[string]bool // paths of Pkg and the packages it imports
+	scope   *pkgScope       // lazily initialized by ObjectOf
+}
+
+// Reportf is a helper function that reports a Diagnostic using the
+// specified position and formatted error message.
+func (pass *Pass) Reportf(pos token.Pos, format string, args ...interface{}) {
+	pass.Report(Diagnostic{Pos: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// An ObjectFact is a fact about a named object,
+// as returned by AllObjectFacts.
+type ObjectFact struct {
+	PkgPath string // import path of the package declaring the object
+	Name    string // name of the object, see Pass.ObjectOf
+	Fact    Fact
+}
+
+// ExportObjectFact associates fact with the object of the current
+// package with the given name.  It panics if the type of fact is not
+// among the FactTypes of the analyzer.
+func (pass *Pass) ExportObjectFact(name string, fact Fact) {
+	pass.checkFactType(fact)
+	pass.facts.set(factKey{pass.Pkg.Path, name, reflect.TypeOf(fact)}, fact)
+}
+
+// ImportObjectFact retrieves the fact of the type of fact associated
+// with the named object of the package with the given import path.
+// If there is one, ImportObjectFact copies it into fact and reports
+// true.
+func (pass *Pass) ImportObjectFact(pkgPath, name string, fact Fact) bool {
+	pass.checkFactType(fact)
+	if !pass.visible[pkgPath] {
+		return false
+	}
+	return pass.facts.get(factKey{pkgPath, name, reflect.TypeOf(fact)}, fact)
+}
+
+// ExportPackageFact associates fact with the current package.
+func (pass *Pass) ExportPackageFact(fact Fact) {
+	pass.ExportObjectFact("", fact)
+}
+
+// ImportPackageFact retrieves the fact of the type of fact associated
+// with the package with the given import path.  If there is one,
+// ImportPackageFact copies it into fact and reports true.
+func (pass *Pass) ImportPackageFact(pkgPath string, fact Fact) bool {
+	return pass.ImportObjectFact(pkgPath, "", fact)
+}
+
+// AllObjectFacts returns the object facts of the types of the
+// analyzer exported so far, by the current package and by the
+// packages it imports, in no particular order.
+func (pass *Pass) AllObjectFacts() []ObjectFact {
+	var facts []ObjectFact
+	for k, f := range pass.facts.m {
+		if k.name != "" && pass.visible[k.pkgPath] && pass.hasFactType(k.typ) {
+			facts = append(facts, ObjectFact{k.pkgPath, k.name, f})
+		}
+	}
+	return facts
+}
+
+func (pass *Pass) hasFactType(t reflect.Type) bool {
+	for _, f := range pass.Analyzer.FactTypes {
+		if reflect.TypeOf(f) == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (pass *Pass) checkFactType(fact Fact) {
+	if !pass.hasFactType(reflect.TypeOf(fact)) {
+		panic(fmt.Sprintf("analysis: %s: fact type %T not declared in FactTypes", pass.Analyzer, fact))
+	}
+}
+
+// factKey identifies a fact: facts are stored per object and type.
+type factKey struct {
+	pkgPath string
+	name    string // "" for a package fact
+	typ     reflect.Type
+}
+
+// A factStore holds the facts exported during a run of the analyzers.
+type factStore struct {
+	m map[factKey]Fact
+}
+
+func (s *factStore) set(k factKey, fact Fact) {
+	if s.m == nil {
+		s.m = make(map[factKey]Fact)
+	}
+	s.m[k] = fact
+}
+
+func (s *factStore) get(k factKey, fact Fact) bool {
+	f, ok := s.m[k]
+	if ok {
+		reflect.ValueOf(fact).Elem().Set(reflect.ValueOf(f).Elem())
+	}
+	return ok
+}
@@ -0,0 +1,133 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"cmd/vet/internal/analysis"
+)
+
+func noop(*analysis.Pass) (interface{}, error) { return nil, nil }
+
+type badFact struct{}
+
+func (badFact) AFact() {}
+
+func TestValidate(t *testing.T) {
+	a := &analysis.Analyzer{Name: "a", Doc: "a", Run: noop}
+	b := &analysis.Analyzer{Name: "b", Doc: "b", Run: noop, Requires: []*analysis.Analyzer{a}}
+	cyc1 := &analysis.Analyzer{Name: "cyc1", Doc: "c", Run: noop}
+	cyc2 := &analysis.Analyzer{Name: "cyc2", Doc: "c", Run: noop, Requires: []*analysis.Analyzer{cyc1}}
+	cyc1.Requires = []*analysis.Analyzer{cyc2}
+
+	tests := []struct {
+		analyzers []*analysis.Analyzer
+		err       string
+	}{
+		{[]*analysis.Analyzer{a, b}, ""},
+		{[]*analysis.Analyzer{cyc1}, "cycle detected"},
+		{[]*analysis.Analyzer{{Name: "1x", Doc: "x", Run: noop}}, "invalid analyzer name"},
+		{[]*analysis.Analyzer{a, {Name: "a", Doc: "a", Run: noop}}, "duplicate analyzer name"},
+		{[]*analysis.Analyzer{{Name: "x", Run: noop}}, "undocumented"},
+		{[]*analysis.Analyzer{{Name: "x", Doc: "x"}}, "nil Run"},
+		{[]*analysis.Analyzer{{Name: "x", Doc: "x", Run: noop, FactTypes: []analysis.Fact{badFact{}}}}, "not a pointer"},
+	}
+	for _, tt := range tests {
+		err := analysis.Validate(tt.analyzers)
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("Validate(%v) = %v, want nil", tt.analyzers, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Validate(%v) = %v, want error containing %q", tt.analyzers, err, tt.err)
+		}
+	}
+}
+
+// A fact recording that a function returns a constant.
+type isConst struct{ Value string }
+
+func (*isConst) AFact() {}
+
+// constAnalyzer exports isConst facts for functions returning a
+// literal, and reports calls of the functions with such facts.
+var constAnalyzer = &analysis.Analyzer{
+	Name:      "const",
+	Doc:       "report calls of functions returning constants",
+	FactTypes: []analysis.Fact{new(isConst)},
+	Run: func(pass *analysis.Pass) (interface{}, error) {
+		for _, f := range pass.Files {
+			for _, decl := range f.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || len(fn.Body.List) != 1 {
+					continue
+				}
+				if ret, ok := fn.Body.List[0].(*ast.ReturnStmt); ok && len(ret.Results) == 1 {
+					if lit, ok := ret.Results[0].(*ast.BasicLit); ok {
+						pass.ExportObjectFact(fn.Name.Name, &isConst{lit.Value})
+					}
+				}
+			}
+			ast.Inspect(f, func(n ast.Node) bool {
+				if call, ok := n.(*ast.CallExpr); ok {
+					if pkgPath, name, ok := pass.ObjectOf(call.Fun); ok {
+						var fact isConst
+						if pass.ImportObjectFact(pkgPath, name, &fact) {
+							pass.Reportf(call.Pos(), "%s.%s returns %s", pkgPath, name, fact.Value)
+						}
+					}
+				}
+				return true
+			})
+		}
+		return nil, nil
+	},
+}
+
+func TestFacts(t *testing.T) {
+	fset := token.NewFileSet()
+	parse := func(path, src string, imports ...*analysis.Package) *analysis.Package {
+		f, err := parser.ParseFile(fset, path+".go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := &analysis.Package{
+			Path:    path,
+			Name:    f.Name.Name,
+			Files:   []*ast.File{f},
+			Imports: make(map[string]*analysis.Package),
+		}
+		for _, imp := range imports {
+			p.Imports[imp.Path] = imp
+		}
+		return p
+	}
+	a := parse("a", `package a; func One() int { return 1 }; func Two() int { x := 2; return x }`)
+	b := parse("x/b", `package b; import "a"; func F() { a.One(); a.Two(); g() }; func g() string { return "g" }`, a)
+	c := parse("c", `package c; func c() { b.F() }`) // does not import a
+
+	findings, err := analysis.Run(fset, []*analysis.Package{b, c}, []*analysis.Analyzer{constAnalyzer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range findings {
+		got = append(got, fset.Position(f.Pos).String()+": "+f.Message)
+	}
+	want := []string{
+		`x/b.go:1:35: a.One returns 1`,
+		`x/b.go:1:53: x/b.g returns "g"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
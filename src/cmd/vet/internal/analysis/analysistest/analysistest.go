@@ -0,0 +1,206 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package analysistest provides utilities for testing analyzers.
+package analysistest
+
+import (
+	"fmt"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"text/scanner"
+
+	"cmd/vet/internal/analysis"
+)
+
+// Run applies an analysis to the packages denoted by the import paths
+// pkgs, found in the src directory of dir, a GOPATH-style tree of test
+// data, and checks that the findings of the analyzer match the
+// expectations in the comments of the packages.
+//
+// An expectation is a comment of the form
+//
+//	// want "regexp" ...
+//
+// on the line of the expected finding.  The regular expressions, which
+// are Go string literals, must match the messages of the findings on
+// the line, one each.
+//
+// Imported packages that are not in dir are loaded from GOROOT.
+func Run(t *testing.T, dir string, a *analysis.Analyzer, pkgs ...string) []analysis.Finding {
+	l := &loader{
+		fset: token.NewFileSet(),
+		dir:  filepath.Join(dir, "src"),
+		pkgs: make(map[string]*analysis.Package),
+	}
+	var roots []*analysis.Package
+	for _, path := range pkgs {
+		p, err := l.load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, p)
+	}
+	findings, err := analysis.Run(l.fset, roots, []*analysis.Analyzer{a})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Match the findings against the expectations.
+	type key struct {
+		file string
+		line int
+	}
+	want := make(map[key][]*regexp.Regexp)
+	for _, p := range roots {
+		for _, f := range p.Files {
+			for _, cg := range f.Comments {
+				for _, c := range cg.List {
+					text := strings.TrimPrefix(c.Text, "//")
+					if text == c.Text {
+						continue // not a // comment
+					}
+					text = strings.TrimSpace(text)
+					if !strings.HasPrefix(text, "want ") {
+						continue
+					}
+					posn := l.fset.Position(c.Pos())
+					rxs, err := parseExpectations(text[len("want "):])
+					if err != nil {
+						t.Errorf("%s: in 'want' comment: %s", posn, err)
+						continue
+					}
+					k := key{posn.Filename, posn.Line}
+					want[k] = append(want[k], rxs...)
+				}
+			}
+		}
+	}
+	for _, f := range findings {
+		posn := l.fset.Position(f.Pos)
+		k := key{posn.Filename, posn.Line}
+		rxs := want[k]
+		matched := false
+		for i, rx := range rxs {
+			if rx.MatchString(f.Message) {
+				want[k] = append(rxs[:i:i], rxs[i+1:]...)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			t.Errorf("%v: unexpected diagnostic: %v", posn, f.Message)
+		}
+	}
+	var missing []string
+	for k, rxs := range want {
+		for _, rx := range rxs {
+			missing = append(missing, fmt.Sprintf("%s:%d: no diagnostic was reported matching %#q", k.file, k.line, rx))
+		}
+	}
+	sort.Strings(missing)
+	for _, m := range missing {
+		t.Error(m)
+	}
+	return findings
+}
+
+// parseExpectations parses the Go string literals of a want comment.
+func parseExpectations(text string) ([]*regexp.Regexp, error) {
+	var s scanner.Scanner
+	s.Init(strings.NewReader(text))
+	s.Error = func(*scanner.Scanner, string) {}
+	var rxs []*regexp.Regexp
+	for {
+		switch tok := s.Scan(); tok {
+		case scanner.EOF:
+			if len(rxs) == 0 {
+				return nil, fmt.Errorf("no expectations")
+			}
+			return rxs, nil
+		case scanner.String, scanner.RawString:
+			pattern, err := strconv.Unquote(s.TokenText())
+			if err != nil {
+				return nil, err
+			}
+			rx, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, err
+			}
+			rxs = append(rxs, rx)
+		default:
+			return nil, fmt.Errorf("unexpected %s", scanner.TokenString(tok))
+		}
+	}
+}
+
+// A loader loads the packages of the test data and their imports.
+type loader struct {
+	fset *token.FileSet
+	dir  string
+	pkgs map[string]*analysis.Package
+}
+
+func (l *loader) load(path string) (*analysis.Package, error) {
+	if p, ok := l.pkgs[path]; ok {
+		if p == nil {
+			return nil, fmt.Errorf("import cycle through %s", path)
+		}
+		return p, nil
+	}
+	l.pkgs[path] = nil
+
+	var files []string
+	dir := filepath.Join(l.dir, filepath.FromSlash(path))
+	if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+		files, _ = filepath.Glob(filepath.Join(dir, "*.go"))
+	} else {
+		bp, err := build.Import(path, "", 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range append(bp.GoFiles, bp.CgoFiles...) {
+			files = append(files, filepath.Join(bp.Dir, name))
+		}
+	}
+
+	p := &analysis.Package{
+		Path:    path,
+		Imports: make(map[string]*analysis.Package),
+	}
+	for _, name := range files {
+		f, err := parser.ParseFile(l.fset, name, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		p.Name = f.Name.Name
+		p.Files = append(p.Files, f)
+	}
+	if len(p.Files) == 0 {
+		return nil, fmt.Errorf("no Go files for package %s", path)
+	}
+	for _, f := range p.Files {
+		for _, imp := range f.Imports {
+			ipath, _ := strconv.Unquote(imp.Path.Value)
+			if ipath == "C" || ipath == "unsafe" || p.Imports[ipath] != nil {
+				continue
+			}
+			ip, err := l.load(ipath)
+			if err != nil {
+				return nil, err
+			}
+			p.Imports[ipath] = ip
+		}
+	}
+	l.pkgs[path] = p
+	return p, nil
+}
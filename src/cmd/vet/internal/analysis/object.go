@@ -0,0 +1,171 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"go/ast"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+)
+
+// A pkgScope records the package-level declarations of a package
+// and, per file, the names under which imported packages are known.
+type pkgScope struct {
+	names    map[string]bool      // package-level names, including T.M for methods
+	toplevel map[interface{}]bool // package-level declaration nodes
+	files    []*fileScope         // sorted by position
+}
+
+type fileScope struct {
+	file    *ast.File
+	imports map[string]string // package name to import path
+}
+
+func (pass *Pass) pkgScope() *pkgScope {
+	if pass.scope != nil {
+		return pass.scope
+	}
+	s := &pkgScope{
+		names:    make(map[string]bool),
+		toplevel: make(map[interface{}]bool),
+	}
+	for _, f := range pass.Files {
+		fs := &fileScope{file: f, imports: make(map[string]string)}
+		for _, imp := range f.Imports {
+			p, err := strconv.Unquote(imp.Path.Value)
+			if err != nil {
+				continue
+			}
+			var name string
+			switch {
+			case imp.Name != nil:
+				name = imp.Name.Name
+			case pass.Pkg.Imports[p] != nil:
+				name = pass.Pkg.Imports[p].Name
+			default:
+				name = path.Base(p)
+			}
+			if name != "_" && name != "." {
+				fs.imports[name] = p
+			}
+		}
+		s.files = append(s.files, fs)
+
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				s.toplevel[decl] = true
+				if decl.Recv == nil {
+					s.names[decl.Name.Name] = true
+				} else if t := RecvTypeName(decl); t != "" {
+					s.names[t+"."+decl.Name.Name] = true
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					s.toplevel[spec] = true
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						s.names[spec.Name.Name] = true
+					case *ast.ValueSpec:
+						for _, id := range spec.Names {
+							s.names[id.Name] = true
+						}
+					}
+				}
+			}
+		}
+	}
+	sort.Sort(byPos(s.files))
+	pass.scope = s
+	return s
+}
+
+type byPos []*fileScope
+
+func (x byPos) Len() int           { return len(x) }
+func (x byPos) Swap(i, j int)      { x[i], x[j] = x[j], x[i] }
+func (x byPos) Less(i, j int) bool { return x[i].file.Pos() < x[j].file.Pos() }
+
+// fileAt returns the scope of the file containing pos.
+func (s *pkgScope) fileAt(pos token.Pos) *fileScope {
+	i := sort.Search(len(s.files), func(i int) bool { return s.files[i].file.End() >= pos })
+	if i < len(s.files) && s.files[i].file.Pos() <= pos {
+		return s.files[i]
+	}
+	return nil
+}
+
+// ObjectOf returns the import path of the package declaring the
+// package-level object that x denotes, and the name of the object.
+// X may be an identifier or a qualified identifier, such as fmt.Printf.
+// The result is ok if x denotes an object of the package being
+// analyzed or of a package imported by the file containing x.
+//
+// Objects are named as in their declarations, except that a method is
+// named by the base type of its receiver and its own name joined by a
+// dot, as in "Buffer.Write".  ObjectOf does not know the types of
+// expressions, so it cannot resolve selectors of fields and methods.
+func (pass *Pass) ObjectOf(x ast.Expr) (pkgPath, name string, ok bool) {
+	s := pass.pkgScope()
+	switch x := Unparen(x).(type) {
+	case *ast.Ident:
+		if x.Obj != nil && !s.toplevel[x.Obj.Decl] {
+			// A local declaration.
+			return "", "", false
+		}
+		if !s.names[x.Name] {
+			// A predeclared identifier, or an undeclared one.
+			return "", "", false
+		}
+		return pass.Pkg.Path, x.Name, true
+
+	case *ast.SelectorExpr:
+		id, ok := x.X.(*ast.Ident)
+		if !ok || id.Obj != nil {
+			return "", "", false
+		}
+		fs := s.fileAt(x.Pos())
+		if fs == nil {
+			return "", "", false
+		}
+		p, ok := fs.imports[id.Name]
+		if !ok || s.names[id.Name] {
+			// Not a package, or one shadowed by a
+			// package-level declaration.
+			return "", "", false
+		}
+		return p, x.Sel.Name, true
+	}
+	return "", "", false
+}
+
+// RecvTypeName returns the name of the base type of the receiver of
+// the method fn, or "" if fn is not a method.
+func RecvTypeName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return ""
+	}
+	t := fn.Recv.List[0].Type
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+	if id, ok := Unparen(t).(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+// Unparen returns x with any enclosing parentheses stripped.
+func Unparen(x ast.Expr) ast.Expr {
+	for {
+		p, ok := x.(*ast.ParenExpr)
+		if !ok {
+			return x
+		}
+		x = p.X
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"fmt"
+	"go/token"
+	"reflect"
+	"sort"
+)
+
+// Validate reports an error if any of the analyzers is misconfigured.
+// Checks include:
+// that the name is a valid identifier;
+// that analyzer names are unique;
+// that the Requires graph is acyclic;
+// that analyzer fact types are unique and pointers.
+func Validate(analyzers []*Analyzer) error {
+	names := make(map[string]bool)
+	factTypes := make(map[reflect.Type]*Analyzer)
+
+	// Traverse the Requires graph, depth first.
+	const (
+		white = iota
+		grey
+		black
+	)
+	color := make(map[*Analyzer]int)
+	var visit func(a *Analyzer) error
+	visit = func(a *Analyzer) error {
+		if a == nil {
+			return fmt.Errorf("nil *Analyzer")
+		}
+		switch color[a] {
+		case grey:
+			return fmt.Errorf("cycle detected involving %s", a)
+		case black:
+			return nil
+		}
+		color[a] = grey
+
+		if !validIdent(a.Name) {
+			return fmt.Errorf("invalid analyzer name %q", a.Name)
+		}
+		if names[a.Name] {
+			return fmt.Errorf("duplicate analyzer name %q", a.Name)
+		}
+		names[a.Name] = true
+		if a.Doc == "" {
+			return fmt.Errorf("analyzer %s is undocumented", a)
+		}
+		if a.Run == nil {
+			return fmt.Errorf("analyzer %s has nil Run", a)
+		}
+		for _, f := range a.FactTypes {
+			t := reflect.TypeOf(f)
+			if t == nil || t.Kind() != reflect.Ptr {
+				return fmt.Errorf("%s: fact type %s is not a pointer", a, t)
+			}
+			if prev := factTypes[t]; prev != nil {
+				return fmt.Errorf("fact type %s registered by two analyzers: %s, %s", t, a, prev)
+			}
+			factTypes[t] = a
+		}
+
+		for _, req := range a.Requires {
+			if err := visit(req); err != nil {
+				return err
+			}
+		}
+		color[a] = black
+		return nil
+	}
+	for _, a := range analyzers {
+		if err := visit(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validIdent(name string) bool {
+	for i, r := range name {
+		if !(r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || i > 0 && '0' <= r && r <= '9') {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// A Finding is a diagnostic reported by an analyzer about a package.
+type Finding struct {
+	Analyzer *Analyzer
+	Package  *Package
+	Diagnostic
+}
+
+// Run applies the analyzers to the root packages and returns their
+// findings, sorted by position.  Analyzers with facts are also
+// applied to the packages imported by the roots, directly or not, but
+// their findings there are discarded.  The Files of all the packages
+// must have been parsed using fset.
+//
+// Run returns an error if the analyzers are invalid or if any of them
+// failed on one of the roots.  Failures on other packages are ignored,
+// at the cost of the facts that the failing analyzers would have
+// recorded.
+func Run(fset *token.FileSet, roots []*Package, analyzers []*Analyzer) ([]Finding, error) {
+	if err := Validate(analyzers); err != nil {
+		return nil, err
+	}
+	r := &runner{
+		fset:    fset,
+		facts:   new(factStore),
+		roots:   make(map[*Package]bool),
+		visible: make(map[*Package]map[string]bool),
+	}
+	for _, p := range roots {
+		r.roots[p] = true
+	}
+
+	// The analyzers to apply to each root, in an order
+	// satisfying their requirements, and the subset of
+	// them to apply to the imported packages.
+	var all, withFacts []*Analyzer
+	seen := make(map[*Analyzer]bool)
+	var add func(a *Analyzer)
+	add = func(a *Analyzer) {
+		if seen[a] {
+			return
+		}
+		seen[a] = true
+		for _, req := range a.Requires {
+			add(req)
+		}
+		all = append(all, a)
+	}
+	for _, a := range analyzers {
+		add(a)
+	}
+	needed := make(map[*Analyzer]bool)
+	var need func(a *Analyzer)
+	need = func(a *Analyzer) {
+		needed[a] = true
+		for _, req := range a.Requires {
+			need(req)
+		}
+	}
+	for _, a := range all {
+		if len(a.FactTypes) > 0 {
+			need(a)
+		}
+	}
+	for _, a := range all {
+		if needed[a] {
+			withFacts = append(withFacts, a)
+		}
+	}
+
+	// Visit the packages in dependency order.
+	done := make(map[*Package]bool)
+	var err error
+	var visit func(p *Package)
+	visit = func(p *Package) {
+		if done[p] {
+			return
+		}
+		done[p] = true
+		for _, path := range sortedImports(p) {
+			visit(p.Imports[path])
+		}
+		if r.roots[p] {
+			if e := r.apply(p, all); e != nil && err == nil {
+				err = e
+			}
+		} else if len(withFacts) > 0 {
+			r.apply(p, withFacts)
+		}
+	}
+	for _, p := range roots {
+		visit(p)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(byFindingPos(r.findings))
+	return r.findings, nil
+}
+
+type runner struct {
+	fset     *token.FileSet
+	facts    *factStore
+	roots    map[*Package]bool
+	visible  map[*Package]map[string]bool
+	findings []Finding
+}
+
+// apply applies the analyzers, which are in an order satisfying their
+// requirements, to p.
+func (r *runner) apply(p *Package, analyzers []*Analyzer) error {
+	results := make(map[*Analyzer]interface{})
+	failed := make(map[*Analyzer]bool)
+	var firstErr error
+Analyzers:
+	for _, a := range analyzers {
+		inputs := make(map[*Analyzer]interface{})
+		for _, req := range a.Requires {
+			if failed[req] {
+				failed[a] = true
+				continue Analyzers
+			}
+			inputs[req] = results[req]
+		}
+		pass := &Pass{
+			Analyzer: a,
+			Fset:     r.fset,
+			Files:    p.Files,
+			Pkg:      p,
+			ResultOf: inputs,
+			facts:    r.facts,
+			visible:  r.visibleFrom(p),
+		}
+		a := a
+		pass.Report = func(d Diagnostic) {
+			if r.roots[p] {
+				r.findings = append(r.findings, Finding{a, p, d})
+			}
+		}
+		res, err := a.Run(pass)
+		if err != nil {
+			failed[a] = true
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: analysis %s failed: %v", p, a, err)
+			}
+			continue
+		}
+		results[a] = res
+	}
+	return firstErr
+}
+
+// visibleFrom returns the set of the paths of p and of the packages
+// it imports, directly or not.
+func (r *runner) visibleFrom(p *Package) map[string]bool {
+	if v := r.visible[p]; v != nil {
+		return v
+	}
+	v := map[string]bool{p.Path: true}
+	for _, imp := range p.Imports {
+		for path := range r.visibleFrom(imp) {
+			v[path] = true
+		}
+	}
+	r.visible[p] = v
+	return v
+}
+
+func sortedImports(p *Package) []string {
+	var paths []string
+	for path := range p.Imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+type byFindingPos []Finding
+
+func (x byFindingPos) Len() int           { return len(x) }
+func (x byFindingPos) Swap(i, j int)      { x[i], x[j] = x[j], x[i] }
+func (x byFindingPos) Less(i, j int) bool { return x[i].Pos < x[j].Pos }
@@ -0,0 +1,338 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package copylock defines an Analyzer that checks for locks
+// erroneously passed by value.
+package copylock
+
+import (
+	"go/ast"
+	"go/token"
+
+	"cmd/vet/internal/analysis"
+	"cmd/vet/internal/passes/inspect"
+)
+
+const Doc = `check for locks erroneously passed by value
+
+Inadvertently copying a value containing a lock, such as sync.Mutex or
+sync.WaitGroup, may cause both copies to malfunction.  Generally such
+values should be referred to through a pointer.
+
+A type is a lock if it is one of the types of packages sync and
+sync/atomic that must not be copied after first use, if it has a
+Lock method with a pointer receiver, or if it is a struct or array
+type containing a lock by value.  Because the checker does not know
+the types of expressions, it reports the copies of variables whose
+declared type is a lock.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:      "copylocks",
+	Doc:       Doc,
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	FactTypes: []analysis.Fact{new(isLock)},
+	Run:       run,
+}
+
+// isLock is a fact recording that a named type is a lock.
+type isLock struct {
+	Path string // the lock it contains, or "" for a lock itself
+}
+
+func (*isLock) AFact() {}
+
+// locks lists the types of the standard library that must not be
+// copied, by import path.
+var locks = map[string][]string{
+	"sync": {"Cond", "Map", "Mutex", "Once", "Pool", "RWMutex", "WaitGroup"},
+	"sync/atomic": {"Bool", "Int32", "Int64", "Pointer", "Uint32", "Uint64",
+		"Uintptr", "Value"},
+}
+
+func isStdLock(pkgPath, name string) bool {
+	for _, n := range locks[pkgPath] {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+type checker struct {
+	pass  *analysis.Pass
+	local map[string]string // lock types of the package, with their lock paths
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	c := &checker{pass: pass, local: make(map[string]string)}
+	c.findLocks()
+
+	insp := pass.ResultOf[inspect.Analyzer].(*inspect.Inspector)
+	nodeFilter := []ast.Node{
+		(*ast.AssignStmt)(nil),
+		(*ast.CallExpr)(nil),
+		(*ast.CompositeLit)(nil),
+		(*ast.FuncDecl)(nil),
+		(*ast.FuncLit)(nil),
+		(*ast.ReturnStmt)(nil),
+		(*ast.ValueSpec)(nil),
+	}
+	insp.Preorder(nodeFilter, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			c.checkAssign(n)
+		case *ast.CallExpr:
+			c.checkCall(n)
+		case *ast.CompositeLit:
+			c.checkCompositeLit(n)
+		case *ast.FuncDecl:
+			c.checkFunc(n.Name.Name, n.Recv, n.Type)
+		case *ast.FuncLit:
+			c.checkFunc("func", nil, n.Type)
+		case *ast.ReturnStmt:
+			c.checkReturn(n)
+		case *ast.ValueSpec:
+			c.checkValueSpec(n)
+		}
+	})
+	return nil, nil
+}
+
+// findLocks finds the lock types declared in the package and exports
+// facts about them.  A struct may contain a lock declared later, so
+// the search repeats until no new lock type is found.
+func (c *checker) findLocks() {
+	var specs []*ast.TypeSpec
+	for _, f := range c.pass.Files {
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				// A Lock method with a pointer receiver.
+				if decl.Name.Name != "Lock" || decl.Recv == nil || len(decl.Recv.List) == 0 {
+					continue
+				}
+				if _, ok := decl.Recv.List[0].Type.(*ast.StarExpr); ok {
+					if t := analysis.RecvTypeName(decl); t != "" {
+						c.local[t] = ""
+					}
+				}
+			case *ast.GenDecl:
+				if decl.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range decl.Specs {
+					specs = append(specs, spec.(*ast.TypeSpec))
+				}
+			}
+		}
+	}
+	for _, name := range locks[c.pass.Pkg.Path] {
+		c.local[name] = ""
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, spec := range specs {
+			if _, ok := c.local[spec.Name.Name]; ok {
+				continue
+			}
+			if path := c.lockPath(spec.Type); path != "" {
+				c.local[spec.Name.Name] = path
+				changed = true
+			}
+		}
+	}
+	for name, path := range c.local {
+		c.pass.ExportObjectFact(name, &isLock{Path: path})
+	}
+}
+
+// lockPath returns a description of the lock that a value of the type
+// denoted by x contains, or "" if it does not contain one.
+func (c *checker) lockPath(x ast.Expr) string {
+	x = analysis.Unparen(x)
+	switch t := x.(type) {
+	case *ast.Ident, *ast.SelectorExpr:
+		pkgPath, name, ok := c.pass.ObjectOf(t)
+		if !ok {
+			return ""
+		}
+		var path string
+		switch {
+		case pkgPath == c.pass.Pkg.Path:
+			if path, ok = c.local[name]; !ok {
+				return ""
+			}
+		case isStdLock(pkgPath, name):
+			path = ""
+		default:
+			var fact isLock
+			if !c.pass.ImportObjectFact(pkgPath, name, &fact) {
+				return ""
+			}
+			path = fact.Path
+		}
+		if path == "" {
+			return typeName(t)
+		}
+		return typeName(t) + " contains " + path
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "" // a slice
+		}
+		return c.lockPath(t.Elt)
+	case *ast.StructType:
+		for _, field := range t.Fields.List {
+			if path := c.lockPath(field.Type); path != "" {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+func typeName(x ast.Expr) string {
+	switch x := x.(type) {
+	case *ast.Ident:
+		return x.Name
+	case *ast.SelectorExpr:
+		if id, ok := x.X.(*ast.Ident); ok {
+			return id.Name + "." + x.Sel.Name
+		}
+	}
+	return "type"
+}
+
+// exprLockPath returns the lock path of the value of x if x is a
+// variable, or a pointer indirection of one, whose declared type is
+// a lock.  It returns "" for other expressions, including composite
+// literals, whose values are fresh.
+func (c *checker) exprLockPath(x ast.Expr) string {
+	x = analysis.Unparen(x)
+	indirect := false
+	if star, ok := x.(*ast.StarExpr); ok {
+		x = analysis.Unparen(star.X)
+		indirect = true
+	}
+	id, ok := x.(*ast.Ident)
+	if !ok || id.Obj == nil || id.Obj.Kind != ast.Var {
+		return ""
+	}
+	t := declaredType(id)
+	if t == nil {
+		return ""
+	}
+	if indirect {
+		star, ok := t.(*ast.StarExpr)
+		if !ok {
+			return ""
+		}
+		t = star.X
+	}
+	return c.lockPath(t)
+}
+
+// declaredType returns the type expression in the declaration of the
+// variable id, if any.
+func declaredType(id *ast.Ident) ast.Expr {
+	switch decl := id.Obj.Decl.(type) {
+	case *ast.Field:
+		return decl.Type
+	case *ast.ValueSpec:
+		return decl.Type
+	case *ast.AssignStmt:
+		// x := T{...}
+		if len(decl.Lhs) != len(decl.Rhs) {
+			return nil
+		}
+		for i, lhs := range decl.Lhs {
+			if lhs, ok := lhs.(*ast.Ident); ok && lhs.Obj == id.Obj {
+				if lit, ok := analysis.Unparen(decl.Rhs[i]).(*ast.CompositeLit); ok {
+					return lit.Type
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (c *checker) checkFunc(name string, recv *ast.FieldList, typ *ast.FuncType) {
+	if recv != nil && len(recv.List) > 0 {
+		if path := c.lockPath(recv.List[0].Type); path != "" {
+			c.pass.Reportf(recv.Pos(), "%s passes lock by value: %s", name, path)
+		}
+	}
+	if typ.Params != nil {
+		for _, field := range typ.Params.List {
+			if path := c.lockPath(field.Type); path != "" {
+				c.pass.Reportf(field.Type.Pos(), "%s passes lock by value: %s", name, path)
+			}
+		}
+	}
+}
+
+func (c *checker) checkAssign(as *ast.AssignStmt) {
+	for i, x := range as.Rhs {
+		if path := c.exprLockPath(x); path != "" && i < len(as.Lhs) {
+			c.pass.Reportf(x.Pos(), "assignment copies lock value to %s: %s", exprString(as.Lhs[i]), path)
+		}
+	}
+}
+
+func (c *checker) checkValueSpec(vs *ast.ValueSpec) {
+	for i, x := range vs.Values {
+		if path := c.exprLockPath(x); path != "" && i < len(vs.Names) {
+			c.pass.Reportf(x.Pos(), "variable declaration copies lock value to %s: %s", vs.Names[i].Name, path)
+		}
+	}
+}
+
+func (c *checker) checkCall(call *ast.CallExpr) {
+	if id, ok := analysis.Unparen(call.Fun).(*ast.Ident); ok && id.Obj == nil {
+		switch id.Name {
+		case "len", "cap", "new":
+			return // builtins
+		}
+	}
+	for _, x := range call.Args {
+		if path := c.exprLockPath(x); path != "" {
+			c.pass.Reportf(x.Pos(), "call of %s copies lock value: %s", exprString(call.Fun), path)
+		}
+	}
+}
+
+func (c *checker) checkReturn(ret *ast.ReturnStmt) {
+	for _, x := range ret.Results {
+		if path := c.exprLockPath(x); path != "" {
+			c.pass.Reportf(x.Pos(), "return copies lock value: %s", path)
+		}
+	}
+}
+
+func (c *checker) checkCompositeLit(lit *ast.CompositeLit) {
+	for _, x := range lit.Elts {
+		if kv, ok := x.(*ast.KeyValueExpr); ok {
+			x = kv.Value
+		}
+		if path := c.exprLockPath(x); path != "" {
+			c.pass.Reportf(x.Pos(), "literal copies lock value from %s: %s", exprString(x), path)
+		}
+	}
+}
+
+// exprString returns a short description of x for messages.
+func exprString(x ast.Expr) string {
+	switch x := analysis.Unparen(x).(type) {
+	case *ast.Ident:
+		return x.Name
+	case *ast.SelectorExpr:
+		return exprString(x.X) + "." + x.Sel.Name
+	case *ast.StarExpr:
+		return "*" + exprString(x.X)
+	case *ast.IndexExpr:
+		return exprString(x.X) + "[...]"
+	case *ast.CallExpr:
+		return exprString(x.Fun) + "(...)"
+	}
+	return "expression"
+}
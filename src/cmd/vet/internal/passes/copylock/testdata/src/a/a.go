@@ -0,0 +1,66 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the copylock checker.
+
+package a
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Counter contains a lock declared after it.
+type Counter struct {
+	l Locker
+	n int
+}
+
+type Locker struct {
+	m [2]sync.Mutex
+}
+
+// SpinLock has a Lock method with a pointer receiver.
+type SpinLock struct {
+	v uint32
+}
+
+func (s *SpinLock) Lock() {
+	for !atomic.CompareAndSwapUint32(&s.v, 0, 1) {
+	}
+}
+
+type Ptrs struct {
+	mu *sync.Mutex
+	wg []sync.WaitGroup
+}
+
+func (c Counter) Get() int { return c.n } // want `Get passes lock by value: Counter contains Locker contains sync.Mutex`
+
+func (c *Counter) Inc() { c.n++ }
+
+func ByValue(wg sync.WaitGroup, p Ptrs, s SpinLock) { // want `ByValue passes lock by value: sync.WaitGroup` `ByValue passes lock by value: SpinLock`
+}
+
+func ByPointer(wg *sync.WaitGroup, m map[string]sync.Mutex) {}
+
+func Copies() Counter {
+	var mu sync.Mutex
+	var v atomic.Value
+	var p *sync.Mutex = &mu
+	c := Counter{}
+	mu2 := mu    // want `assignment copies lock value to mu2: sync.Mutex`
+	v2 := v      // want `assignment copies lock value to v2: atomic.Value`
+	var mu3 = *p // want `variable declaration copies lock value to mu3: sync.Mutex`
+	ByValue2(mu) // want `call of ByValue2 copies lock value: sync.Mutex`
+	ByPointer2(&mu)
+	_ = []sync.Mutex{mu} // want `literal copies lock value from mu: sync.Mutex`
+	_ = Ptrs{mu: &mu}
+	f := func(c Counter) {} // want `func passes lock by value: Counter contains Locker contains sync.Mutex`
+	_, _, _, _ = mu2, v2, mu3, f
+	return c // want `return copies lock value: Counter contains Locker contains sync.Mutex`
+}
+
+func ByValue2(interface{})   {}
+func ByPointer2(interface{}) {}
@@ -0,0 +1,23 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the copylock checker of
+// lock types declared in other packages.
+
+package b
+
+import "a"
+
+type Wrapper struct {
+	c a.Counter
+}
+
+func F(c a.Counter, p *a.Counter, s a.SpinLock) { // want `F passes lock by value: a.Counter contains Locker contains sync.Mutex` `F passes lock by value: a.SpinLock`
+	w := Wrapper{c: *p} // want `literal copies lock value from \*p: a.Counter contains Locker contains sync.Mutex`
+	G(w)                // want `call of G copies lock value: Wrapper contains a.Counter contains Locker contains sync.Mutex`
+}
+
+func G(interface{}) {}
+
+func (a *Wrapper) Ok(x a.Ptrs) {}
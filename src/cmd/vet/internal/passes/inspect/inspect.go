@@ -0,0 +1,116 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package inspect defines an Analyzer that provides an Inspector of
+// the syntax trees of a package, for analyzers that need to visit
+// particular kinds of nodes.
+//
+// The Inspector walks the syntax trees once, when it is built, and
+// records the nodes in the order of a depth-first traversal, so that
+// each of the analyzers requiring it visits the nodes it is interested
+// in without walking the trees again.
+package inspect
+
+import (
+	"go/ast"
+	"reflect"
+
+	"cmd/vet/internal/analysis"
+)
+
+// Analyzer provides an Inspector as its result.
+var Analyzer = &analysis.Analyzer{
+	Name: "inspect",
+	Doc:  "optimize AST traversal for later passes",
+	Run: func(pass *analysis.Pass) (interface{}, error) {
+		return New(pass.Files), nil
+	},
+}
+
+// An Inspector provides methods for visiting the nodes of a set of
+// syntax trees.
+type Inspector struct {
+	events []event
+}
+
+// An event records the entry to a node (index > i) or the exit from
+// it (index < i) during the traversal.
+type event struct {
+	node  ast.Node
+	typ   reflect.Type
+	index int // index of the matching event
+}
+
+// New returns an Inspector of the files.
+func New(files []*ast.File) *Inspector {
+	var events []event
+	var stack []int
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			if n != nil {
+				stack = append(stack, len(events))
+				events = append(events, event{node: n, typ: reflect.TypeOf(n)})
+			} else {
+				push := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				events[push].index = len(events)
+				events = append(events, event{node: events[push].node, index: push})
+			}
+			return true
+		})
+	}
+	return &Inspector{events}
+}
+
+// Preorder calls f for each node of the types of the elements of
+// types, or of all types if types is empty, in depth-first order.
+func (in *Inspector) Preorder(types []ast.Node, f func(ast.Node)) {
+	mask := typeSet(types)
+	for i, ev := range in.events {
+		if ev.index > i && (mask == nil || mask[ev.typ]) {
+			f(ev.node)
+		}
+	}
+}
+
+// WithStack visits the nodes like Preorder, but also calls f on the
+// way out of each node, and passes to f the stack of the enclosing
+// nodes, outermost first, ending with n itself.  On the way in,
+// f returns whether to visit the children of n.
+func (in *Inspector) WithStack(types []ast.Node, f func(n ast.Node, push bool, stack []ast.Node) (proceed bool)) {
+	mask := typeSet(types)
+	var stack []ast.Node
+	for i := 0; i < len(in.events); {
+		ev := in.events[i]
+		if ev.index > i {
+			// push
+			stack = append(stack, ev.node)
+			if mask == nil || mask[ev.typ] {
+				if !f(ev.node, true, stack) {
+					i = ev.index // jump to the corresponding pop
+					continue
+				}
+			}
+			i++
+		} else {
+			// pop
+			if mask == nil || mask[reflect.TypeOf(ev.node)] {
+				f(ev.node, false, stack)
+			}
+			stack = stack[:len(stack)-1]
+			i++
+		}
+	}
+}
+
+func typeSet(types []ast.Node) map[reflect.Type]bool {
+	if len(types) == 0 {
+		return nil
+	}
+	m := make(map[reflect.Type]bool)
+	for _, t := range types {
+		m[reflect.TypeOf(t)] = true
+	}
+	return m
+}
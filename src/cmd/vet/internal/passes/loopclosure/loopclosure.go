@@ -0,0 +1,110 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package loopclosure defines an Analyzer that checks for references to
+// enclosing loop variables from within nested functions.
+package loopclosure
+
+import (
+	"go/ast"
+
+	"cmd/vet/internal/analysis"
+	"cmd/vet/internal/passes/inspect"
+)
+
+const Doc = `check references to loop variables from within nested functions
+
+A function literal that is started as a goroutine or deferred as the
+last statement of a loop body runs after the iteration that created
+it, when the loop variables it refers to may have changed:
+
+	for i, v := range list {
+		go func() {
+			use(i, v) // i and v are shared by all the goroutines
+		}()
+	}
+
+The same holds of a function literal passed as the last argument to
+a Go method called last in the loop body, as with errgroup.Group.Go.
+Pass the variables to the function as arguments, or copy them into
+new variables declared in the loop body.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "loopclosure",
+	Doc:      Doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspect.Inspector)
+	nodeFilter := []ast.Node{
+		(*ast.RangeStmt)(nil),
+		(*ast.ForStmt)(nil),
+	}
+	insp.Preorder(nodeFilter, func(n ast.Node) {
+		// Find the variables updated by the loop statement.
+		var vars []*ast.Ident
+		addVar := func(x ast.Expr) {
+			if id, ok := x.(*ast.Ident); ok && id.Name != "_" {
+				vars = append(vars, id)
+			}
+		}
+		var body *ast.BlockStmt
+		switch n := n.(type) {
+		case *ast.RangeStmt:
+			body = n.Body
+			if n.Key != nil {
+				addVar(n.Key)
+			}
+			if n.Value != nil {
+				addVar(n.Value)
+			}
+		case *ast.ForStmt:
+			body = n.Body
+			if as, ok := n.Post.(*ast.AssignStmt); ok {
+				for _, x := range as.Lhs {
+					addVar(x)
+				}
+			} else if inc, ok := n.Post.(*ast.IncDecStmt); ok {
+				addVar(inc.X)
+			}
+		}
+		if len(vars) == 0 || len(body.List) == 0 {
+			return
+		}
+
+		// Inspect the function literal deferred, started as a
+		// goroutine or passed to a Go method at the end of the body.
+		var lit *ast.FuncLit
+		switch s := body.List[len(body.List)-1].(type) {
+		case *ast.GoStmt:
+			lit, _ = s.Call.Fun.(*ast.FuncLit)
+		case *ast.DeferStmt:
+			lit, _ = s.Call.Fun.(*ast.FuncLit)
+		case *ast.ExprStmt:
+			if call, ok := s.X.(*ast.CallExpr); ok && len(call.Args) > 0 {
+				if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Go" {
+					lit, _ = call.Args[len(call.Args)-1].(*ast.FuncLit)
+				}
+			}
+		}
+		if lit == nil {
+			return
+		}
+		ast.Inspect(lit.Body, func(n ast.Node) bool {
+			id, ok := n.(*ast.Ident)
+			if !ok || id.Obj == nil {
+				return true
+			}
+			for _, v := range vars {
+				if v.Obj == id.Obj {
+					pass.Reportf(id.Pos(), "loop variable %s captured by func literal", id.Name)
+				}
+			}
+			return true
+		})
+	})
+	return nil, nil
+}
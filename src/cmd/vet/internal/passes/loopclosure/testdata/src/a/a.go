@@ -0,0 +1,72 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the loopclosure checker.
+
+package a
+
+import "sync/errgroup"
+
+func _() {
+	var s []int
+	for i, v := range s {
+		go func() {
+			println(i) // want "loop variable i captured by func literal"
+			println(v) // want "loop variable v captured by func literal"
+		}()
+	}
+	for i, v := range s {
+		defer func() {
+			println(i) // want "loop variable i captured by func literal"
+			println(v) // want "loop variable v captured by func literal"
+		}()
+	}
+	for i := range s {
+		go func() {
+			println(i) // want "loop variable i captured by func literal"
+		}()
+	}
+	for _, v := range s {
+		go func() {
+			println(v) // want "loop variable v captured by func literal"
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		go func() {
+			println(i) // want "loop variable i captured by func literal"
+		}()
+	}
+	var g errgroup.Group
+	for _, v := range s {
+		g.Go(func() error {
+			println(v) // want "loop variable v captured by func literal"
+			return nil
+		})
+	}
+
+	// Correct uses.
+	for i, v := range s {
+		go func(i, v int) {
+			println(i, v)
+		}(i, v)
+	}
+	for _, v := range s {
+		v := v
+		go func() {
+			println(v)
+		}()
+	}
+	for i := range s {
+		go func() {
+			println(i)
+		}()
+		println("not last")
+	}
+	for _, v := range s {
+		go func() {
+			v := 1
+			println(v)
+		}()
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lostcancel defines an Analyzer that checks for failure to
+// call a context cancelation function.
+package lostcancel
+
+import (
+	"go/ast"
+
+	"cmd/vet/internal/analysis"
+	"cmd/vet/internal/passes/inspect"
+)
+
+const Doc = `check cancel func returned by context.WithCancel is called
+
+The cancelation function returned by context.WithCancel, WithTimeout,
+and WithDeadline must be called or the new context will remain live
+until its parent context is cancelled.  (The background context is
+never cancelled.)
+
+The checker reports a cancelation function that is discarded by
+assigning it to the blank identifier, or assigned to a variable of
+the function that is not used anywhere else in the function.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "lostcancel",
+	Doc:      Doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// contextPackages lists the import paths of the context packages.
+var contextPackages = []string{"context", "golang.org/x/net/context"}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspect.Inspector)
+	nodeFilter := []ast.Node{
+		(*ast.FuncDecl)(nil),
+		(*ast.FuncLit)(nil),
+	}
+	insp.Preorder(nodeFilter, func(n ast.Node) {
+		var body *ast.BlockStmt
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			body = n.Body
+		case *ast.FuncLit:
+			body = n.Body
+		}
+		if body != nil {
+			checkFunc(pass, body)
+		}
+	})
+	return nil, nil
+}
+
+// checkFunc checks the calls of the context functions in the body of
+// a function, but not in the function literals it contains, which are
+// checked on their own.
+func checkFunc(pass *analysis.Pass, body *ast.BlockStmt) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		var lhs []ast.Expr
+		var rhs []ast.Expr
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.AssignStmt:
+			lhs, rhs = n.Lhs, n.Rhs
+		case *ast.ValueSpec:
+			for _, id := range n.Names {
+				lhs = append(lhs, id)
+			}
+			rhs = n.Values
+		default:
+			return true
+		}
+		if len(lhs) != 2 || len(rhs) != 1 {
+			return true
+		}
+		call, ok := rhs[0].(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		name, ok := contextFunc(pass, call.Fun)
+		if !ok {
+			return true
+		}
+		id, ok := lhs[1].(*ast.Ident)
+		if !ok {
+			return true // a field or element, which may be used anywhere
+		}
+		if id.Name == "_" {
+			pass.Reportf(id.Pos(), "the cancel function returned by context.%s should be called, not discarded, to avoid a context leak", name)
+		} else if id.Obj != nil && !usedElsewhere(body, id) {
+			pass.Reportf(id.Pos(), "the %s function returned by context.%s is not used (context leak)", id.Name, name)
+		}
+		return true
+	})
+}
+
+// contextFunc reports whether fun denotes one of the context functions
+// that return a cancelation function, and its name.
+func contextFunc(pass *analysis.Pass, fun ast.Expr) (string, bool) {
+	pkgPath, name, ok := pass.ObjectOf(fun)
+	if !ok {
+		return "", false
+	}
+	switch name {
+	case "WithCancel", "WithTimeout", "WithDeadline":
+		for _, p := range contextPackages {
+			if pkgPath == p {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// usedElsewhere reports whether the variable denoted by id is
+// referred to in body other than by id itself.
+func usedElsewhere(body *ast.BlockStmt, id *ast.Ident) bool {
+	used := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if x, ok := n.(*ast.Ident); ok && x != id && x.Obj == id.Obj {
+			used = true
+		}
+		return !used
+	})
+	return used
+}
@@ -0,0 +1,61 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the lostcancel checker.
+
+package a
+
+import (
+	"context"
+	"time"
+)
+
+var bg = context.Background()
+
+func _() {
+	ctx, _ := context.WithCancel(bg) // want "the cancel function returned by context.WithCancel should be called, not discarded, to avoid a context leak"
+	_ = ctx
+}
+
+func _() {
+	ctx, cancel := context.WithTimeout(bg, time.Second) // want "the cancel function returned by context.WithTimeout is not used \\(context leak\\)"
+	_ = ctx
+}
+
+func _() {
+	var ctx, cancel = context.WithDeadline(bg, time.Now()) // want "the cancel function returned by context.WithDeadline is not used"
+	_ = ctx
+}
+
+func _() {
+	ctx, cancel := context.WithCancel(bg)
+	defer cancel()
+	_ = ctx
+}
+
+func _() context.CancelFunc {
+	_, cancel := context.WithCancel(bg)
+	return cancel
+}
+
+func _() {
+	ctx, cancel := context.WithCancel(bg)
+	go func() {
+		<-time.After(time.Second)
+		cancel()
+	}()
+	_ = ctx
+
+	func() {
+		_, _ = context.WithCancel(ctx) // want "should be called, not discarded"
+	}()
+}
+
+type server struct {
+	cancel context.CancelFunc
+}
+
+func (s *server) start() {
+	_, s.cancel = context.WithCancel(bg)
+}
@@ -0,0 +1,21 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package context is a fake of the context package
+// for the tests of the lostcancel checker.
+package context
+
+import "time"
+
+type Context interface{}
+
+type CancelFunc func()
+
+func Background() Context { return nil }
+
+func WithCancel(parent Context) (Context, CancelFunc) { return parent, func() {} }
+
+func WithTimeout(parent Context, d time.Duration) (Context, CancelFunc) { return parent, func() {} }
+
+func WithDeadline(parent Context, t time.Time) (Context, CancelFunc) { return parent, func() {} }
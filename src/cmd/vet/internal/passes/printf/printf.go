@@ -0,0 +1,428 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package printf defines an Analyzer that checks consistency
+// of Printf format strings and arguments.
+package printf
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"cmd/vet/internal/analysis"
+	"cmd/vet/internal/passes/inspect"
+)
+
+const Doc = `check consistency of Printf format strings and arguments
+
+The check applies to calls of the formatting functions such as
+fmt.Printf and fmt.Sprintf, as well as any detected wrappers of
+those functions.
+
+A function is a wrapper of Printf if its last two parameters are
+a format string and a variadic ...interface{} list of arguments,
+and it passes them on to a Printf-like function or wrapper, as in
+
+	func Errorf(format string, args ...interface{}) {
+		log.Print(fmt.Sprintf(format, args...))
+	}
+
+Similarly, a function is a wrapper of Print if it passes its final
+...interface{} parameter on to a Print-like function or wrapper.
+Wrappers are recognized across package boundaries.  Calls of methods
+are checked if a wrapper method of the same name has been found.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:      "printf",
+	Doc:       Doc,
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	FactTypes: []analysis.Fact{new(isWrapper)},
+	Run:       run,
+}
+
+// Kind is the kind of a fmt function or wrapper.
+type Kind int
+
+const (
+	KindNone   Kind = iota
+	KindPrintf      // a format string and its arguments
+	KindPrint       // a list of operands
+)
+
+// isWrapper is a fact recording that a function or method is
+// a wrapper of a Printf-like or Print-like function.
+type isWrapper struct {
+	Kind        Kind
+	FormatIndex int // index of the format parameter, for KindPrintf
+}
+
+func (*isWrapper) AFact() {}
+
+// isPrint records the formatting functions of package fmt,
+// with the index of their format parameter, or -1 for those
+// that take a list of operands.
+var isPrint = map[string]int{
+	"Errorf":   0,
+	"Fprintf":  1,
+	"Printf":   0,
+	"Sprintf":  0,
+	"Fprint":   -1,
+	"Fprintln": -1,
+	"Print":    -1,
+	"Println":  -1,
+	"Sprint":   -1,
+	"Sprintln": -1,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	c := &checker{
+		pass:    pass,
+		local:   make(map[string]isWrapper),
+		methods: make(map[string]isWrapper),
+	}
+	for _, f := range pass.AllObjectFacts() {
+		if i := strings.IndexByte(f.Name, '.'); i >= 0 {
+			c.addMethod(f.Name[i+1:], *f.Fact.(*isWrapper))
+		}
+	}
+	c.findWrappers()
+
+	insp := pass.ResultOf[inspect.Analyzer].(*inspect.Inspector)
+	insp.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		w, ok := c.callee(call)
+		if !ok {
+			return
+		}
+		name := funcName(call.Fun)
+		switch w.Kind {
+		case KindPrintf:
+			c.checkPrintf(call, name, w.FormatIndex)
+		case KindPrint:
+			c.checkPrint(call, name)
+		}
+	})
+	return nil, nil
+}
+
+type checker struct {
+	pass    *analysis.Pass
+	local   map[string]isWrapper // wrappers of the current package, by object name
+	methods map[string]isWrapper // wrapper methods, by method name
+}
+
+// addMethod records w as the kind of the methods named name, unless
+// methods of that name with a different kind have been seen.
+func (c *checker) addMethod(name string, w isWrapper) {
+	if prev, ok := c.methods[name]; ok && prev != w {
+		w = isWrapper{Kind: KindNone}
+	}
+	c.methods[name] = w
+}
+
+// callee reports the kind of function called by call.  Calls of
+// methods are resolved by name.
+func (c *checker) callee(call *ast.CallExpr) (isWrapper, bool) {
+	pass := c.pass
+	if pkgPath, name, ok := pass.ObjectOf(call.Fun); ok {
+		var w isWrapper
+		switch {
+		case pkgPath == "fmt":
+			i, ok := isPrint[name]
+			if !ok {
+				return w, false
+			}
+			if i < 0 {
+				return isWrapper{Kind: KindPrint}, true
+			}
+			return isWrapper{Kind: KindPrintf, FormatIndex: i}, true
+		case pkgPath == pass.Pkg.Path:
+			w, ok = c.local[name]
+			return w, ok
+		default:
+			ok = pass.ImportObjectFact(pkgPath, name, &w)
+			return w, ok
+		}
+	}
+	if sel, ok := analysis.Unparen(call.Fun).(*ast.SelectorExpr); ok {
+		w, ok := c.methods[sel.Sel.Name]
+		return w, ok && w.Kind != KindNone
+	}
+	return isWrapper{}, false
+}
+
+// findWrappers finds the wrappers declared in the package and exports
+// facts about them.  A wrapper may call another one declared later, so
+// the search repeats until no new wrapper is found.
+func (c *checker) findWrappers() {
+	var funcs []*ast.FuncDecl
+	for _, f := range c.pass.Files {
+		for _, decl := range f.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+				funcs = append(funcs, fn)
+			}
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, fn := range funcs {
+			name := fn.Name.Name
+			if t := analysis.RecvTypeName(fn); t != "" {
+				name = t + "." + name
+			} else if fn.Recv != nil {
+				continue
+			}
+			if _, ok := c.local[name]; ok {
+				continue
+			}
+			if w, ok := c.wrapperKind(fn); ok {
+				c.local[name] = w
+				if fn.Recv != nil {
+					c.addMethod(fn.Name.Name, w)
+				}
+				changed = true
+			}
+		}
+	}
+	for name, w := range c.local {
+		w := w
+		c.pass.ExportObjectFact(name, &w)
+	}
+}
+
+// wrapperKind reports whether fn is a wrapper of a Printf-like or
+// Print-like function, and which.
+func (c *checker) wrapperKind(fn *ast.FuncDecl) (isWrapper, bool) {
+	// Flatten the parameters.
+	var params []*ast.Ident
+	var last *ast.Field
+	for _, field := range fn.Type.Params.List {
+		last = field
+		if len(field.Names) == 0 {
+			params = append(params, nil)
+		}
+		params = append(params, field.Names...)
+	}
+	if len(params) == 0 {
+		return isWrapper{}, false
+	}
+	ell, ok := last.Type.(*ast.Ellipsis)
+	if !ok || !isEmptyInterface(ell.Elt) {
+		return isWrapper{}, false
+	}
+	args := params[len(params)-1]
+	if args == nil || args.Obj == nil {
+		return isWrapper{}, false
+	}
+	var format *ast.Ident
+	if n := len(params); n >= 2 && params[n-2] != nil && params[n-2].Obj != nil {
+		if field, ok := params[n-2].Obj.Decl.(*ast.Field); ok {
+			if id, ok := field.Type.(*ast.Ident); ok && id.Name == "string" {
+				format = params[n-2]
+			}
+		}
+	}
+
+	var result isWrapper
+	found := false
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+		if _, ok := n.(*ast.FuncLit); ok {
+			return false
+		}
+		call, ok := n.(*ast.CallExpr)
+		if !ok || !call.Ellipsis.IsValid() || len(call.Args) == 0 {
+			return true
+		}
+		if !isParam(call.Args[len(call.Args)-1], args) {
+			return true
+		}
+		w, ok := c.callee(call)
+		if !ok {
+			return true
+		}
+		switch w.Kind {
+		case KindPrintf:
+			if format == nil || len(call.Args) != w.FormatIndex+2 || !isParam(call.Args[w.FormatIndex], format) {
+				return true
+			}
+			result = isWrapper{Kind: KindPrintf, FormatIndex: len(params) - 2}
+		case KindPrint:
+			result = isWrapper{Kind: KindPrint}
+		default:
+			return true
+		}
+		found = true
+		return false
+	})
+	return result, found
+}
+
+func isParam(x ast.Expr, param *ast.Ident) bool {
+	id, ok := analysis.Unparen(x).(*ast.Ident)
+	return ok && id.Obj == param.Obj
+}
+
+func isEmptyInterface(x ast.Expr) bool {
+	it, ok := x.(*ast.InterfaceType)
+	return ok && len(it.Methods.List) == 0
+}
+
+// funcName returns the name of the called function or method.
+func funcName(x ast.Expr) string {
+	switch x := analysis.Unparen(x).(type) {
+	case *ast.Ident:
+		return x.Name
+	case *ast.SelectorExpr:
+		return x.Sel.Name
+	}
+	return "function"
+}
+
+// stringConstant returns the value of x if it is a string literal
+// or a concatenation of string literals.
+func stringConstant(x ast.Expr) (string, bool) {
+	switch x := analysis.Unparen(x).(type) {
+	case *ast.BasicLit:
+		if x.Kind != token.STRING {
+			return "", false
+		}
+		s, err := strconv.Unquote(x.Value)
+		return s, err == nil
+	case *ast.BinaryExpr:
+		if x.Op != token.ADD {
+			return "", false
+		}
+		l, ok := stringConstant(x.X)
+		if !ok {
+			return "", false
+		}
+		r, ok := stringConstant(x.Y)
+		return l + r, ok
+	}
+	return "", false
+}
+
+const verbs = "bcdeEfFgGopqsStTUvxX"
+
+// checkPrintf checks a call of a Printf-like function whose format
+// is the argument at index formatIndex.
+func (c *checker) checkPrintf(call *ast.CallExpr, name string, formatIndex int) {
+	if formatIndex >= len(call.Args) {
+		return
+	}
+	format, ok := stringConstant(call.Args[formatIndex])
+	if !ok {
+		return
+	}
+	firstArg := formatIndex + 1 // index of the first operand in call.Args
+	nargs := len(call.Args) - firstArg
+	if !strings.Contains(format, "%") {
+		if nargs > 0 && !call.Ellipsis.IsValid() {
+			c.pass.Reportf(call.Lparen, "no formatting directive in %s call", name)
+		}
+		return
+	}
+
+	argNum := 0      // operands consumed so far
+	indexed := false // explicit argument indexes are in use
+	for i := 0; i < len(format); {
+		if format[i] != '%' {
+			i++
+			continue
+		}
+		start := i
+		i++
+		for i < len(format) && strings.IndexByte("+-# 0", format[i]) >= 0 {
+			i++
+		}
+		// An argument index, a width and a precision, either of
+		// which may be a *, and another argument index.
+		index := func() bool {
+			if i < len(format) && format[i] == '[' {
+				j := strings.IndexByte(format[i:], ']')
+				if j < 0 {
+					return false
+				}
+				indexed = true
+				i += j + 1
+			}
+			return true
+		}
+		number := func() {
+			if i < len(format) && format[i] == '*' {
+				argNum++
+				i++
+				return
+			}
+			for i < len(format) && '0' <= format[i] && format[i] <= '9' {
+				i++
+			}
+		}
+		ok := index()
+		number()
+		if i < len(format) && format[i] == '.' {
+			i++
+			ok = ok && index()
+			number()
+		}
+		if !ok || !index() {
+			c.pass.Reportf(call.Lparen, "unterminated argument index in %s(%q)", name, format)
+			return
+		}
+		if i >= len(format) {
+			c.pass.Reportf(call.Lparen, "missing verb at end of format string in %s call", name)
+			return
+		}
+		verb, w := utf8.DecodeRuneInString(format[i:])
+		i += w
+		if verb == '%' {
+			continue
+		}
+		if !strings.ContainsRune(verbs, verb) {
+			c.pass.Reportf(call.Lparen, "unrecognized printf verb %q in %s call", verb, name)
+			return
+		}
+		argNum++
+		if !indexed && !call.Ellipsis.IsValid() && argNum > nargs {
+			c.pass.Reportf(call.Lparen, "missing argument for %s(%q): format reads arg %d, have only %d args", name, format[start:i], argNum, nargs)
+			return
+		}
+	}
+	if !indexed && !call.Ellipsis.IsValid() && argNum < nargs {
+		c.pass.Reportf(call.Lparen, "wrong number of args for format in %s call: %d needed but %d args", name, argNum, nargs)
+	}
+}
+
+// checkPrint checks a call of a Print-like function.
+func (c *checker) checkPrint(call *ast.CallExpr, name string) {
+	if len(call.Args) == 0 {
+		return
+	}
+	args := call.Args
+	if pkgPath, fn, _ := c.pass.ObjectOf(call.Fun); pkgPath == "fmt" && strings.HasPrefix(fn, "Fprint") {
+		// Skip the io.Writer.
+		args = args[1:]
+		if len(args) == 0 {
+			return
+		}
+	}
+	if s, ok := stringConstant(args[0]); ok {
+		if i := strings.IndexByte(s, '%'); i >= 0 && i+1 < len(s) {
+			if verb, _ := utf8.DecodeRuneInString(s[i+1:]); strings.ContainsRune(verbs, verb) {
+				c.pass.Reportf(call.Lparen, "possible formatting directive in %s call", name)
+			}
+		}
+	}
+	if strings.HasSuffix(name, "ln") && !call.Ellipsis.IsValid() {
+		if s, ok := stringConstant(args[len(args)-1]); ok && strings.HasSuffix(s, "\n") {
+			c.pass.Reportf(call.Lparen, "%s call ends with newline", name)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package printf_test
+
+import (
+	"testing"
+
+	"cmd/vet/internal/analysis/analysistest"
+	"cmd/vet/internal/passes/printf"
+)
+
+func Test(t *testing.T) {
+	analysistest.Run(t, "testdata", printf.Analyzer, "a", "b")
+}
@@ -0,0 +1,77 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the printf checker.
+
+package a
+
+import (
+	"fmt"
+	"os"
+)
+
+func PrintfTests() {
+	var i int
+	var s string
+	fmt.Printf("%d %s\n", i, s)
+	fmt.Printf("%5.2f %*d %%\n", 3.0, 4, i)
+	fmt.Printf("%[2]d %[1]d\n", i, i)
+	fmt.Printf("%v", []interface{}{i, s}...)
+	fmt.Fprintf(os.Stderr, "%q\n", s)
+	fmt.Printf("%d\n")                 // want `missing argument for Printf\("%d"\): format reads arg 1, have only 0 args`
+	fmt.Printf("%d %d\n", i)           // want `missing argument for Printf\("%d"\): format reads arg 2, have only 1 args`
+	fmt.Printf("%*d\n", i)             // want `missing argument for Printf\("%\*d"\): format reads arg 2, have only 1 args`
+	fmt.Printf("%d\n", i, s)           // want `wrong number of args for format in Printf call: 1 needed but 2 args`
+	fmt.Printf("hello\n", i)           // want `no formatting directive in Printf call`
+	fmt.Printf("%z\n", i)              // want `unrecognized printf verb 'z' in Printf call`
+	fmt.Printf("%d %", i)              // want `missing verb at end of format string in Printf call`
+	fmt.Fprintf(os.Stderr, "%s %s", s) // want `missing argument for Fprintf\("%s"\): format reads arg 2, have only 1 args`
+	_ = fmt.Sprintf("%s"+" %d", s)     // want `missing argument for Sprintf\("%d"\): format reads arg 2, have only 1 args`
+	_ = fmt.Errorf("%d", i, i)         // want `wrong number of args for format in Errorf call: 1 needed but 2 args`
+	fmt.Println("value:", i)
+	fmt.Println("%d", i)             // want `possible formatting directive in Println call`
+	fmt.Println(s, "\n")             // want `Println call ends with newline`
+	fmt.Fprintln(os.Stderr, "%s", s) // want `possible formatting directive in Fprintln call`
+	fmt.Print("100%")
+	fmt.Print("done\n")
+
+	Logf("%d", i)
+	Logf("%d")   // want `missing argument for Logf\("%d"\): format reads arg 1, have only 0 args`
+	Log("%d", i) // want `possible formatting directive in Log call`
+	Warnf(3, "%s", s)
+	Warnf(3, "%s") // want `missing argument for Warnf\("%s"\): format reads arg 1, have only 0 args`
+
+	var l Logger
+	l.Printf("%s", s)
+	l.Printf("%s %s", s) // want `missing argument for Printf\("%s"\): format reads arg 2, have only 1 args`
+	l.Println("%x")      // want `possible formatting directive in Println call`
+	notAWrapper("%d")
+}
+
+// Logf is a wrapper of Printf.
+func Logf(format string, args ...interface{}) {
+	fmt.Fprint(os.Stderr, fmt.Sprintf(format, args...))
+}
+
+// Warnf is a wrapper of Printf through Logf, declared after it.
+func Warnf(level int, format string, args ...interface{}) {
+	if level > 2 {
+		Logf(format, args...)
+	}
+}
+
+// Log is a wrapper of Print.
+func Log(args ...interface{}) {
+	fmt.Fprint(os.Stderr, args...)
+}
+
+func notAWrapper(format string, args ...interface{}) {
+	fmt.Println(format, args)
+}
+
+type Logger struct{}
+
+func (l *Logger) Printf(format string, args ...interface{}) { Logf(format, args...) }
+
+func (l *Logger) Println(args ...interface{}) { fmt.Println(args...) }
@@ -0,0 +1,23 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the printf checker of
+// wrappers declared in other packages.
+
+package b
+
+import (
+	"a"
+	lg "log"
+)
+
+func F(l *a.Logger) {
+	a.Logf("%d %d", 1, 2)
+	a.Logf("%d %d", 1) // want `missing argument for Logf\("%d"\): format reads arg 2, have only 1 args`
+	a.Warnf(1, "x", 2) // want `no formatting directive in Warnf call`
+	l.Printf("%q", 'x')
+	l.Printf("%q")        // want `missing argument for Printf\("%q"\): format reads arg 1, have only 0 args`
+	lg.Printf("%d")       // want `missing argument for Printf\("%d"\): format reads arg 1, have only 0 args`
+	lg.Println("a", "\n") // want `Println call ends with newline`
+}
@@ -0,0 +1,47 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the unusedresult checker.
+
+package a
+
+import (
+	"errors"
+	"fmt"
+	str "strings"
+)
+
+func _() {
+	fmt.Errorf("") // want "result of fmt.Errorf call not used"
+	_ = fmt.Errorf("")
+
+	errors.New("abc") // want "result of errors.New call not used"
+
+	err := errors.New("abc")
+	_ = err
+
+	err.Error() // want `result of Error method call not used`
+
+	var b fmt.Stringer
+	b.String() // want `result of String method call not used`
+
+	fmt.Sprint("")     // want "result of fmt.Sprint call not used"
+	fmt.Sprintf("")    // want "result of fmt.Sprintf call not used"
+	(fmt.Sprintln)("") // want "result of fmt.Sprintln call not used"
+
+	str.TrimSpace(" x ") // want "result of strings.TrimSpace call not used"
+	s := str.ToUpper("x")
+	_ = s
+
+	fmt.Print("")
+	fmt.Println(fmt.Sprint(""))
+}
+
+type T struct{}
+
+func (T) Error(msg string) {}
+
+func _(t T) {
+	t.Error("msg")
+}
@@ -0,0 +1,85 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package unusedresult defines an Analyzer that checks for unused
+// results of calls to certain pure functions.
+package unusedresult
+
+import (
+	"go/ast"
+	"sort"
+	"strings"
+
+	"cmd/vet/internal/analysis"
+	"cmd/vet/internal/passes/inspect"
+)
+
+const Doc = `check for unused results of calls to some functions
+
+Some functions, such as fmt.Sprintf and strings.TrimSpace, have no
+effect other than their result, so calling one of them without
+using the result is a mistake.  The checker also reports calls of
+methods named Error or String, without arguments, whose results are
+unused.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "unusedresult",
+	Doc:      Doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// funcs lists the functions whose results must be used,
+// by import path.
+var funcs = map[string][]string{
+	"errors": {"New"},
+	"fmt":    {"Errorf", "Sprint", "Sprintf", "Sprintln"},
+	"sort":   {"Reverse"},
+	"strings": {
+		"Contains", "ContainsAny", "ContainsRune", "Count", "EqualFold",
+		"Fields", "FieldsFunc", "HasPrefix", "HasSuffix", "Index",
+		"IndexAny", "IndexByte", "IndexFunc", "IndexRune", "Join",
+		"LastIndex", "LastIndexAny", "LastIndexFunc", "Map", "Repeat",
+		"Replace", "Split", "SplitAfter", "SplitAfterN", "SplitN",
+		"Title", "ToLower", "ToLowerSpecial", "ToTitle",
+		"ToTitleSpecial", "ToUpper", "ToUpperSpecial", "Trim",
+		"TrimFunc", "TrimLeft", "TrimLeftFunc", "TrimPrefix",
+		"TrimRight", "TrimRightFunc", "TrimSpace", "TrimSuffix",
+	},
+}
+
+func init() {
+	for _, names := range funcs {
+		sort.Strings(names)
+	}
+}
+
+func mustUse(pkgPath, name string) bool {
+	names := funcs[pkgPath]
+	i := sort.SearchStrings(names, name)
+	return i < len(names) && names[i] == name
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspect.Inspector)
+	insp.Preorder([]ast.Node{(*ast.ExprStmt)(nil)}, func(n ast.Node) {
+		call, ok := analysis.Unparen(n.(*ast.ExprStmt).X).(*ast.CallExpr)
+		if !ok {
+			return
+		}
+		if pkgPath, name, ok := pass.ObjectOf(call.Fun); ok {
+			if mustUse(pkgPath, name) {
+				pass.Reportf(call.Lparen, "result of %s.%s call not used", pkgPath[strings.LastIndex(pkgPath, "/")+1:], name)
+			}
+			return
+		}
+		if sel, ok := analysis.Unparen(call.Fun).(*ast.SelectorExpr); ok && len(call.Args) == 0 {
+			switch sel.Sel.Name {
+			case "Error", "String":
+				pass.Reportf(call.Lparen, "result of %s method call not used", sel.Sel.Name)
+			}
+		}
+	})
+	return nil, nil
+}
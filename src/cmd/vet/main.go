@@ -0,0 +1,383 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cmd/vet/internal/analysis"
+	"cmd/vet/internal/passes/copylock"
+	"cmd/vet/internal/passes/loopclosure"
+	"cmd/vet/internal/passes/lostcancel"
+	"cmd/vet/internal/passes/printf"
+	"cmd/vet/internal/passes/unusedresult"
+)
+
+// analyzers lists the analyzers that vet can apply,
+// each enabled by the flag of its name.
+var analyzers = []*analysis.Analyzer{
+	copylock.Analyzer,
+	loopclosure.Analyzer,
+	lostcancel.Analyzer,
+	printf.Analyzer,
+	unusedresult.Analyzer,
+}
+
+var (
+	tags    = flag.String("tags", "", "space-separated list of build tags to consider satisfied")
+	verbose = flag.Bool("v", false, "verbose")
+)
+
+// enabled records the setting of the flag of each analyzer.
+var enabled = make(map[*analysis.Analyzer]*triState)
+
+var exitCode = 0
+
+// A triState is a boolean flag that records whether it was set.
+type triState int
+
+const (
+	unset triState = iota
+	setTrue
+	setFalse
+)
+
+func (ts *triState) Get() interface{} {
+	return *ts == setTrue
+}
+
+func (ts *triState) Set(value string) error {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	if b {
+		*ts = setTrue
+	} else {
+		*ts = setFalse
+	}
+	return nil
+}
+
+func (ts *triState) String() string {
+	switch *ts {
+	case setTrue:
+		return "true"
+	case setFalse:
+		return "false"
+	}
+	return "unset"
+}
+
+func (ts *triState) IsBoolFlag() bool {
+	return true
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of vet:\n")
+	fmt.Fprintf(os.Stderr, "\tvet [flags] package...  # check the packages, like go list\n")
+	fmt.Fprintf(os.Stderr, "\tvet [flags] files...    # check a set of files of one package\n")
+	fmt.Fprintf(os.Stderr, "By default, all checks are performed.\n")
+	fmt.Fprintf(os.Stderr, "If any flags are explicitly set to true, only those checks are run.\n")
+	fmt.Fprintf(os.Stderr, "If any flags are explicitly set to false, only those checks are disabled.\n")
+	fmt.Fprintf(os.Stderr, "Thus -printf=true runs just the printf check, and -printf=false runs all\n")
+	fmt.Fprintf(os.Stderr, "checks except the printf check.\n\n")
+	fmt.Fprintf(os.Stderr, "For more information run\n")
+	fmt.Fprintf(os.Stderr, "\tgo doc cmd/vet\n\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	for _, a := range analyzers {
+		ts := new(triState)
+		enabled[a] = ts
+		doc := a.Doc
+		if i := strings.Index(doc, "\n"); i >= 0 {
+			doc = doc[:i]
+		}
+		flag.Var(ts, a.Name, doc)
+	}
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+	}
+
+	// If any flag is set true, run only those checks;
+	// otherwise run all checks except those set false.
+	anyTrue := false
+	for _, ts := range enabled {
+		if *ts == setTrue {
+			anyTrue = true
+		}
+	}
+	var run []*analysis.Analyzer
+	for _, a := range analyzers {
+		if *enabled[a] == setTrue || !anyTrue && *enabled[a] == unset {
+			run = append(run, a)
+		}
+	}
+	facts := false
+	for _, a := range run {
+		if len(a.FactTypes) > 0 {
+			facts = true
+		}
+	}
+
+	fset := token.NewFileSet()
+	var roots []*analysis.Package
+	if allGoFiles(flag.Args()) {
+		p, err := parseFiles(fset, "command-line-arguments", flag.Args(), true)
+		if err != nil {
+			errorf("%v", err)
+			os.Exit(exitCode)
+		}
+		roots = []*analysis.Package{p}
+	} else {
+		roots = loadPackages(fset, flag.Args(), facts)
+	}
+
+	if *verbose {
+		for _, p := range roots {
+			fmt.Fprintf(os.Stderr, "vet: checking %s\n", p)
+		}
+	}
+	findings, err := analysis.Run(fset, roots, run)
+	if err != nil {
+		errorf("%v", err)
+	}
+	for _, f := range findings {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", fset.Position(f.Pos), f.Message)
+		exitCode = 1
+	}
+	os.Exit(exitCode)
+}
+
+// errorf reports an error and sets the exit code.
+func errorf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "vet: "+format+"\n", args...)
+	exitCode = 1
+}
+
+func allGoFiles(args []string) bool {
+	for _, arg := range args {
+		if !strings.HasSuffix(arg, ".go") {
+			return false
+		}
+	}
+	return true
+}
+
+// parseFiles parses the named files as a package with the given
+// import path.  It returns the first parse error only if strict is
+// set; otherwise it keeps whatever syntax the parser recovered.
+func parseFiles(fset *token.FileSet, path string, files []string, strict bool) (*analysis.Package, error) {
+	p := &analysis.Package{
+		Path:    path,
+		Imports: make(map[string]*analysis.Package),
+	}
+	for _, name := range files {
+		f, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+		if err != nil && strict {
+			if list, ok := err.(scanner.ErrorList); ok && len(list) > 0 {
+				err = list[0]
+			}
+			return nil, err
+		}
+		if f == nil {
+			continue
+		}
+		if p.Name == "" {
+			p.Name = f.Name.Name
+		} else if f.Name.Name != p.Name && strict {
+			return nil, fmt.Errorf("%s: package %s; expected %s", name, f.Name.Name, p.Name)
+		}
+		p.Files = append(p.Files, f)
+	}
+	return p, nil
+}
+
+// A listedPackage is a package as described by go list -json.
+type listedPackage struct {
+	ImportPath   string
+	Name         string
+	Dir          string
+	GoFiles      []string
+	CgoFiles     []string
+	TestGoFiles  []string
+	XTestGoFiles []string
+	Imports      []string
+	TestImports  []string
+	XTestImports []string
+	Deps         []string
+	Error        *struct{ Err string }
+}
+
+// goList runs go list -json on the packages.
+func goList(paths []string) []*listedPackage {
+	args := []string{"list", "-e", "-json"}
+	if *tags != "" {
+		args = append(args, "-tags", *tags)
+	}
+	args = append(args, paths...)
+	cmd := exec.Command(filepath.Join(runtime.GOROOT(), "bin", "go"), args...)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		errorf("go list: %v", err)
+		os.Exit(exitCode)
+	}
+	var list []*listedPackage
+	dec := json.NewDecoder(&stdout)
+	for {
+		p := new(listedPackage)
+		if err := dec.Decode(p); err == io.EOF {
+			break
+		} else if err != nil {
+			errorf("go list: %v", err)
+			os.Exit(exitCode)
+		}
+		list = append(list, p)
+	}
+	return list
+}
+
+// loadPackages lists the packages denoted by the patterns and returns
+// them, with their tests, as packages to analyze.  The packages they
+// import are loaded too, and parsed if parse is set, so that analyzers
+// with facts can be applied to them.
+func loadPackages(fset *token.FileSet, patterns []string, parse bool) []*analysis.Package {
+	roots := goList(patterns)
+	listed := make(map[string]*listedPackage)
+	for _, lp := range roots {
+		listed[lp.ImportPath] = lp
+	}
+
+	// List the dependencies of the packages and their tests until
+	// all are known.  Deps is transitive, so this takes two rounds
+	// at most.
+	var missing []string
+	need := func(paths []string) {
+		for _, path := range paths {
+			if path != "C" && listed[path] == nil {
+				listed[path] = &listedPackage{} // placeholder
+				missing = append(missing, path)
+			}
+		}
+	}
+	for _, lp := range roots {
+		need(lp.Deps)
+		need(lp.TestImports)
+		need(lp.XTestImports)
+	}
+	for len(missing) > 0 {
+		paths := missing
+		missing = nil
+		sort.Strings(paths)
+		for _, lp := range goList(paths) {
+			listed[lp.ImportPath] = lp
+			need(lp.Deps)
+		}
+	}
+
+	// Build the packages of the dependencies on demand.
+	pkgs := make(map[string]*analysis.Package)
+	var get func(path string) *analysis.Package
+	get = func(path string) *analysis.Package {
+		if p, ok := pkgs[path]; ok {
+			return p
+		}
+		lp := listed[path]
+		if lp == nil || lp.ImportPath == "" {
+			pkgs[path] = nil
+			return nil
+		}
+		p := &analysis.Package{
+			Path:    lp.ImportPath,
+			Name:    lp.Name,
+			Imports: make(map[string]*analysis.Package),
+		}
+		pkgs[path] = p
+		if parse && lp.Error == nil {
+			files := filesIn(lp.Dir, lp.GoFiles, lp.CgoFiles)
+			if q, err := parseFiles(fset, lp.ImportPath, files, false); err == nil {
+				p.Files = q.Files
+			}
+		}
+		addImports(p, lp.Imports, get)
+		return p
+	}
+
+	var result []*analysis.Package
+	for _, lp := range roots {
+		if lp.Error != nil {
+			errorf("%s", lp.Error.Err)
+			continue
+		}
+		files := filesIn(lp.Dir, lp.GoFiles, lp.CgoFiles, lp.TestGoFiles)
+		var p *analysis.Package
+		if len(files) > 0 {
+			var err error
+			p, err = parseFiles(fset, lp.ImportPath, files, true)
+			if err != nil {
+				errorf("%v", err)
+				continue
+			}
+			addImports(p, lp.Imports, get)
+			addImports(p, lp.TestImports, get)
+			result = append(result, p)
+		}
+		if len(lp.XTestGoFiles) > 0 {
+			xp, err := parseFiles(fset, lp.ImportPath+"_test", filesIn(lp.Dir, lp.XTestGoFiles), true)
+			if err != nil {
+				errorf("%v", err)
+				continue
+			}
+			addImports(xp, lp.XTestImports, func(path string) *analysis.Package {
+				if path == lp.ImportPath && p != nil {
+					return p
+				}
+				return get(path)
+			})
+			result = append(result, xp)
+		}
+	}
+	return result
+}
+
+func addImports(p *analysis.Package, paths []string, get func(string) *analysis.Package) {
+	for _, path := range paths {
+		if imp := get(path); imp != nil {
+			p.Imports[path] = imp
+		}
+	}
+}
+
+func filesIn(dir string, lists ...[]string) []string {
+	var files []string
+	for _, list := range lists {
+		for _, name := range list {
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	return files
+}
@@ -0,0 +1,62 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package argon2
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// Test vectors from the Argon2 specification, using a secret key and
+// associated data as well as a password and salt.
+func TestVectors(t *testing.T) {
+	password := bytes.Repeat([]byte{0x01}, 32)
+	salt := bytes.Repeat([]byte{0x02}, 16)
+	secret := bytes.Repeat([]byte{0x03}, 8)
+	data := bytes.Repeat([]byte{0x04}, 12)
+
+	tests := []struct {
+		mode int
+		want string
+	}{
+		{argon2d, "512b391b6f1162975371d30919734294f868e3be3984f3c1a13a4db9fabe4acb"},
+		{argon2i, "c814d9d1dc7f37aa13f0d77f2494bda1c8de6b016dd388d29952a4c4672b6ce8"},
+		{argon2id, "0d640df58d78766c08c037a34a8b53c9d01ef0452d75b65eb52520e96b01e659"},
+	}
+	for _, tt := range tests {
+		key := deriveKey(tt.mode, password, salt, secret, data, 3, 32, 4, 32)
+		if got := hex.EncodeToString(key); got != tt.want {
+			t.Errorf("mode %d: got %s, want %s", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	password, salt := []byte("password"), []byte("somesalt")
+	for _, keyLen := range []uint32{4, 32, 64, 65, 100} {
+		a := Key(password, salt, 2, 64, 2, keyLen)
+		b := IDKey(password, salt, 2, 64, 2, keyLen)
+		if len(a) != int(keyLen) || len(b) != int(keyLen) {
+			t.Errorf("keyLen %d: got lengths %d and %d", keyLen, len(a), len(b))
+		}
+		if bytes.Equal(a, b) {
+			t.Errorf("keyLen %d: Argon2i and Argon2id keys are equal", keyLen)
+		}
+		if !bytes.Equal(a, Key(password, salt, 2, 64, 2, keyLen)) {
+			t.Errorf("keyLen %d: Key is not deterministic", keyLen)
+		}
+	}
+	if bytes.Equal(IDKey(password, salt, 1, 64, 1, 32), IDKey(password, salt, 2, 64, 1, 32)) {
+		t.Error("the time parameter does not change the key")
+	}
+}
+
+func BenchmarkIDKey(b *testing.B) {
+	password, salt := []byte("password"), []byte("choosing random salts is hard")
+	for i := 0; i < b.N; i++ {
+		IDKey(password, salt, 1, 64*1024, 4, 32)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package argon2
+
+// The compression function G of Argon2 permutes the rows and then the
+// columns of a 1 KiB block, viewed as an 8x8 matrix of 16-byte registers,
+// with BlaMka, a variant of the BLAKE2b round function that adds a
+// multiplication to each addition.
+
+func processBlock(out, in1, in2 *block) {
+	processBlockGeneric(out, in1, in2, false)
+}
+
+func processBlockXOR(out, in1, in2 *block) {
+	processBlockGeneric(out, in1, in2, true)
+}
+
+func processBlockGeneric(out, in1, in2 *block, xor bool) {
+	var t block
+	for i := range t {
+		t[i] = in1[i] ^ in2[i]
+	}
+	var v [16]uint64
+	for i := 0; i < blockLength; i += 16 {
+		copy(v[:], t[i:i+16])
+		blamka(&v)
+		copy(t[i:i+16], v[:])
+	}
+	for i := 0; i < blockLength/8; i += 2 {
+		for j := 0; j < 8; j++ {
+			v[2*j] = t[16*j+i]
+			v[2*j+1] = t[16*j+i+1]
+		}
+		blamka(&v)
+		for j := 0; j < 8; j++ {
+			t[16*j+i] = v[2*j]
+			t[16*j+i+1] = v[2*j+1]
+		}
+	}
+	if xor {
+		for i := range t {
+			out[i] ^= in1[i] ^ in2[i] ^ t[i]
+		}
+	} else {
+		for i := range t {
+			out[i] = in1[i] ^ in2[i] ^ t[i]
+		}
+	}
+}
+
+// blamka applies one BlaMka round to the 16 words in v.
+func blamka(v *[16]uint64) {
+	blamkaG(v, 0, 4, 8, 12)
+	blamkaG(v, 1, 5, 9, 13)
+	blamkaG(v, 2, 6, 10, 14)
+	blamkaG(v, 3, 7, 11, 15)
+	blamkaG(v, 0, 5, 10, 15)
+	blamkaG(v, 1, 6, 11, 12)
+	blamkaG(v, 2, 7, 8, 13)
+	blamkaG(v, 3, 4, 9, 14)
+}
+
+func blamkaG(v *[16]uint64, a, b, c, d int) {
+	v[a] += v[b] + 2*uint64(uint32(v[a]))*uint64(uint32(v[b]))
+	v[d] ^= v[a]
+	v[d] = v[d]>>32 | v[d]<<32
+	v[c] += v[d] + 2*uint64(uint32(v[c]))*uint64(uint32(v[d]))
+	v[b] ^= v[c]
+	v[b] = v[b]>>24 | v[b]<<40
+	v[a] += v[b] + 2*uint64(uint32(v[a]))*uint64(uint32(v[b]))
+	v[d] ^= v[a]
+	v[d] = v[d]>>16 | v[d]<<48
+	v[c] += v[d] + 2*uint64(uint32(v[c]))*uint64(uint32(v[d]))
+	v[b] ^= v[c]
+	v[b] = v[b]>>63 | v[b]<<1
+}
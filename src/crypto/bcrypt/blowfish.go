@@ -0,0 +1,109 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bcrypt
+
+// bcrypt is built on eksblowfish, the Blowfish cipher of Bruce Schneier
+// with an expensive key schedule that mixes in a salt. Only encryption is
+// needed.
+
+// blowfish is a Blowfish cipher whose key schedule has been run.
+type blowfish struct {
+	p              [18]uint32
+	s0, s1, s2, s3 [256]uint32
+}
+
+// newSaltedBlowfish returns a Blowfish cipher initialized with key and
+// salt, the first step of the eksblowfish key schedule.
+func newSaltedBlowfish(key, salt []byte) *blowfish {
+	c := &blowfish{p: initP, s0: initS0, s1: initS1, s2: initS2, s3: initS3}
+	c.expandKeyWithSalt(key, salt)
+	return c
+}
+
+// getNextWord returns the next big-endian uint32 value from the byte slice
+// at the given position in a circular manner, updating the position.
+func getNextWord(b []byte, pos *int) uint32 {
+	var w uint32
+	j := *pos
+	for i := 0; i < 4; i++ {
+		w = w<<8 | uint32(b[j])
+		j++
+		if j >= len(b) {
+			j = 0
+		}
+	}
+	*pos = j
+	return w
+}
+
+// expandKey performs the standard Blowfish key schedule with key on the
+// current state of c.
+func (c *blowfish) expandKey(key []byte) {
+	j := 0
+	for i := range c.p {
+		c.p[i] ^= getNextWord(key, &j)
+	}
+
+	var l, r uint32
+	for i := 0; i < 18; i += 2 {
+		l, r = c.encryptBlock(l, r)
+		c.p[i], c.p[i+1] = l, r
+	}
+	for _, s := range []*[256]uint32{&c.s0, &c.s1, &c.s2, &c.s3} {
+		for i := 0; i < 256; i += 2 {
+			l, r = c.encryptBlock(l, r)
+			s[i], s[i+1] = l, r
+		}
+	}
+}
+
+// expandKeyWithSalt is like expandKey but also mixes the salt into the
+// blocks being encrypted, as eksblowfish specifies.
+func (c *blowfish) expandKeyWithSalt(key, salt []byte) {
+	j := 0
+	for i := range c.p {
+		c.p[i] ^= getNextWord(key, &j)
+	}
+
+	j = 0
+	var l, r uint32
+	for i := 0; i < 18; i += 2 {
+		l ^= getNextWord(salt, &j)
+		r ^= getNextWord(salt, &j)
+		l, r = c.encryptBlock(l, r)
+		c.p[i], c.p[i+1] = l, r
+	}
+	for _, s := range []*[256]uint32{&c.s0, &c.s1, &c.s2, &c.s3} {
+		for i := 0; i < 256; i += 2 {
+			l ^= getNextWord(salt, &j)
+			r ^= getNextWord(salt, &j)
+			l, r = c.encryptBlock(l, r)
+			s[i], s[i+1] = l, r
+		}
+	}
+}
+
+func (c *blowfish) f(x uint32) uint32 {
+	return ((c.s0[byte(x>>24)] + c.s1[byte(x>>16)]) ^ c.s2[byte(x>>8)]) + c.s3[byte(x)]
+}
+
+func (c *blowfish) encryptBlock(l, r uint32) (uint32, uint32) {
+	xl, xr := l^c.p[0], r
+	for i := 1; i < 17; i += 2 {
+		xr ^= c.f(xl) ^ c.p[i]
+		xl ^= c.f(xr) ^ c.p[i+1]
+	}
+	xr ^= c.p[17]
+	return xr, xl
+}
+
+// encrypt encrypts the 8-byte block in src into dst, which may overlap.
+func (c *blowfish) encrypt(dst, src []byte) {
+	l := uint32(src[0])<<24 | uint32(src[1])<<16 | uint32(src[2])<<8 | uint32(src[3])
+	r := uint32(src[4])<<24 | uint32(src[5])<<16 | uint32(src[6])<<8 | uint32(src[7])
+	l, r = c.encryptBlock(l, r)
+	dst[0], dst[1], dst[2], dst[3] = byte(l>>24), byte(l>>16), byte(l>>8), byte(l)
+	dst[4], dst[5], dst[6], dst[7] = byte(r>>24), byte(r>>16), byte(r>>8), byte(r)
+}
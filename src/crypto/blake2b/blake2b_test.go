@@ -0,0 +1,194 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package blake2b
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+// The inputs are the bytes 0, 1, 2, ... of the given length and, for the
+// keyed hashes, the key is the bytes 0, 1, ..., 63, as in the known-answer
+// tests of the BLAKE2 reference implementation.
+var keyed = []struct {
+	length int
+	sum    string
+}{
+	{0, "10ebb67700b1868efb4417987acf4690ae9d972fb7a590c2f02871799aaa4786b5e996e8f0f4eb981fc214b005f42d2ff4233499391653df7aefcbc13fc51568"},
+	{1, "961f6dd1e4dd30f63901690c512e78e4b45e4742ed197c3c5e45c549fd25f2e4187b0bc9fe30492b16b0d0bc4ef9b0f34c7003fac09a5ef1532e69430234cebd"},
+	{2, "da2cfbe2d8409a0f38026113884f84b50156371ae304c4430173d08a99d9fb1b983164a3770706d537f49e0c916d9f32b95cc37a95b99d857436f0232c88a965"},
+	{3, "33d0825dddf7ada99b0e7e307104ad07ca9cfd9692214f1561356315e784f3e5a17e364ae9dbb14cb2036df932b77f4b292761365fb328de7afdc6d8998f5fc1"},
+	{63, "bd965bf31e87d70327536f2a341cebc4768eca275fa05ef98f7f1b71a0351298de006fba73fe6733ed01d75801b4a928e54231b38e38c562b2e33ea1284992fa"},
+	{64, "65676d800617972fbd87e4b9514e1c67402b7a331096d3bfac22f1abb95374abc942f16e9ab0ead33b87c91968a6e509e119ff07787b3ef483e1dcdccf6e3022"},
+	{65, "939fa189699c5d2c81ddd1ffc1fa207c970b6a3685bb29ce1d3e99d42f2f7442da53e95a72907314f4588399a3ff5b0a92beb3f6be2694f9f86ecf2952d5b41c"},
+	{127, "76d2d819c92bce55fa8e092ab1bf9b9eab237a25267986cacf2b8ee14d214d730dc9a5aa2d7b596e86a1fd8fa0804c77402d2fcd45083688b218b1cdfa0dcbcb"},
+	{128, "72065ee4dd91c2d8509fa1fc28a37c7fc9fa7d5b3f8ad3d0d7a25626b57b1b44788d4caf806290425f9890a3a2a35a905ab4b37acfd0da6e4517b2525c9651e4"},
+	{129, "64475dfe7600d7171bea0b394e27c9b00d8e74dd1e416a79473682ad3dfdbb706631558055cfc8a40e07bd015a4540dcdea15883cbbf31412df1de1cd4152b91"},
+	{255, "142709d62e28fcccd0af97fad0f8465b971e82201dc51070faa0372aa43e92484be1c1e73ba10906d5d1853db6a4106e0a7bf9800d373d6dee2d46d62ef2a461"},
+}
+
+var unkeyed = []struct {
+	length int
+	sum    string
+}{
+	{0, "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce"},
+	{1, "2fa3f686df876995167e7c2e5d74c4c7b6e48f8068fe0e44208344d480f7904c36963e44115fe3eb2a3ac8694c28bcb4f5a0f3276f2e79487d8219057a506e4b"},
+	{2, "1c08798dc641aba9dee435e22519a4729a09b2bfe0ff00ef2dcd8ed6f8a07d15eaf4aee52bbf18ab5608a6190f70b90486c8a7d4873710b1115d3debbb4327b5"},
+	{3, "40a374727302d9a4769c17b5f409ff32f58aa24ff122d7603e4fda1509e919d4107a52c57570a6d94e50967aea573b11f86f473f537565c66f7039830a85d186"},
+	{63, "d10bf9a15b1c9fc8d41f89bb140bf0be08d2f3666176d13baac4d381358ad074c9d4748c300520eb026daeaea7c5b158892fde4e8ec17dc998dcd507df26eb63"},
+	{64, "2fc6e69fa26a89a5ed269092cb9b2a449a4409a7a44011eecad13d7c4b0456602d402fa5844f1a7a758136ce3d5d8d0e8b86921ffff4f692dd95bdc8e5ff0052"},
+	{65, "fcbe8be7dcb49a32dbdf239459e26308b84dff1ea480df8d104eeff34b46fae98627b450c2267d48c0946a697c5b59531452ac0484f1c84e3a33d0c339bb2e28"},
+	{127, "b6292669ccd38d5f01caae96ba272c76a879a45743afa0725d83b9ebb26665b731f1848c52f11972b6644f554c064fa90780dbbbf3a89d4fc31f67df3e5857ef"},
+	{128, "2319e3789c47e2daa5fe807f61bec2a1a6537fa03f19ff32e87eecbfd64b7e0e8ccff439ac333b040f19b0c4ddd11a61e24ac1fe0f10a039806c5dcc0da3d115"},
+	{129, "f59711d44a031d5f97a9413c065d1e614c417ede998590325f49bad2fd444d3e4418be19aec4e11449ac1a57207898bc57d76a1bcf3566292c20c683a5c4648f"},
+	{255, "5b21c5fd8868367612474fa2e70e9cfa2201ffeee8fafab5797ad58fefa17c9b5b107da4a3db6320baaf2c8617d5a51df914ae88da3867c2d41f0cc14fa67928"},
+}
+
+var input, key = make([]byte, 255), make([]byte, 64)
+
+func init() {
+	for i := range input {
+		input[i] = byte(i)
+	}
+	for i := range key {
+		key[i] = byte(i)
+	}
+}
+
+func TestHashes(t *testing.T) {
+	for _, tt := range keyed {
+		h, err := New512(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.Write(input[:tt.length])
+		if got := hex.EncodeToString(h.Sum(nil)); got != tt.sum {
+			t.Errorf("keyed hash of %d bytes = %s, want %s", tt.length, got, tt.sum)
+		}
+	}
+	for _, tt := range unkeyed {
+		h, err := New512(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Write the input a byte at a time to exercise the buffering.
+		for i := 0; i < tt.length; i++ {
+			h.Write(input[i : i+1])
+		}
+		if got := hex.EncodeToString(h.Sum(nil)); got != tt.sum {
+			t.Errorf("hash of %d bytes = %s, want %s", tt.length, got, tt.sum)
+		}
+		// Sum does not change the state, and Reset restores the initial one.
+		h.Write(input[:1])
+		h.Reset()
+		h.Write(input[:tt.length])
+		if got := hex.EncodeToString(h.Sum(nil)); got != tt.sum {
+			t.Errorf("after Reset, hash of %d bytes = %s, want %s", tt.length, got, tt.sum)
+		}
+	}
+	for _, tt := range unkeyed {
+		sum := Sum512(input[:tt.length])
+		if got := hex.EncodeToString(sum[:]); got != tt.sum {
+			t.Errorf("Sum512(%d bytes) = %s, want %s", tt.length, got, tt.sum)
+		}
+	}
+	abc := []byte("abc")
+	if sum := Sum256(abc); hex.EncodeToString(sum[:]) != "bddd813c634239723171ef3fee98579b94964e3bb1cb3e427262c8c068d52319" {
+		t.Errorf("Sum256(abc) = %x", sum)
+	}
+	if sum := Sum384(abc); hex.EncodeToString(sum[:]) != "6f56a82c8e7ef526dfe182eb5212f7db9df1317e57815dbda46083fc30f54ee6c66ba83be64b302d7cba6ce15bb556f4" {
+		t.Errorf("Sum384(abc) = %x", sum)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := New(0, nil); err == nil {
+		t.Error("New accepted a hash size of 0")
+	}
+	if _, err := New(Size+1, nil); err == nil {
+		t.Error("New accepted a hash size of 65")
+	}
+	if _, err := New512(make([]byte, Size+1)); err == nil {
+		t.Error("New512 accepted a 65-byte key")
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	for _, h := range []crypto.Hash{crypto.BLAKE2b_256, crypto.BLAKE2b_384, crypto.BLAKE2b_512} {
+		if !h.Available() {
+			t.Errorf("hash %d is not registered", h)
+			continue
+		}
+		if size := h.New().Size(); size != h.Size() {
+			t.Errorf("hash %d: Size() = %d, want %d", h, size, h.Size())
+		}
+	}
+}
+
+func TestHashBlocksGeneric(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	blocks := make([]byte, 4*BlockSize)
+	for i := 0; i < 100; i++ {
+		for j := range blocks {
+			blocks[j] = byte(r.Int())
+		}
+		var h1, h2 [8]uint64
+		for j := range h1 {
+			h1[j] = uint64(r.Int63())
+		}
+		h2 = h1
+		c1 := [2]uint64{^uint64(0) - uint64(BlockSize), 0}
+		c2 := c1
+		flag := uint64(0)
+		if i%2 == 1 {
+			flag = 0xFFFFFFFFFFFFFFFF
+		}
+		n := (i%4 + 1) * BlockSize
+		hashBlocks(&h1, &c1, flag, blocks[:n])
+		hashBlocksGeneric(&h2, &c2, flag, blocks[:n])
+		if h1 != h2 || c1 != c2 {
+			t.Fatalf("hashBlocks and hashBlocksGeneric differ: %x, %x != %x, %x", h1, c1, h2, c2)
+		}
+	}
+}
+
+func TestSumMatchesNew(t *testing.T) {
+	h, _ := New512(nil)
+	for n := 0; n <= len(input); n++ {
+		h.Reset()
+		h.Write(input[:n])
+		sum := Sum512(input[:n])
+		if !bytes.Equal(h.Sum(nil), sum[:]) {
+			t.Fatalf("Sum512 and New512 differ for %d bytes", n)
+		}
+	}
+}
+
+var bench = make([]byte, 8192)
+
+func benchmarkSum(b *testing.B, size int) {
+	b.SetBytes(int64(size))
+	for i := 0; i < b.N; i++ {
+		Sum512(bench[:size])
+	}
+}
+
+func benchmarkHashBlocks(b *testing.B, hashBlocks func(*[8]uint64, *[2]uint64, uint64, []byte)) {
+	var h [8]uint64
+	var c [2]uint64
+	b.SetBytes(int64(len(bench)))
+	for i := 0; i < b.N; i++ {
+		hashBlocks(&h, &c, 0, bench)
+	}
+}
+
+func BenchmarkSum64(b *testing.B)             { benchmarkSum(b, 64) }
+func BenchmarkSum1K(b *testing.B)             { benchmarkSum(b, 1024) }
+func BenchmarkSum8K(b *testing.B)             { benchmarkSum(b, 8192) }
+func BenchmarkHashBlocks(b *testing.B)        { benchmarkHashBlocks(b, hashBlocks) }
+func BenchmarkHashBlocksGeneric(b *testing.B) { benchmarkHashBlocks(b, hashBlocksGeneric) }
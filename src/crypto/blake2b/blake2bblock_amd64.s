@@ -0,0 +1,172 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+#include "textflag.h"
+
+// BLAKE2b compression for AMD64. See hashBlocksGeneric in blake2bblock.go
+// for the Go equivalent.
+//
+// The working vector v[0..15] lives on the stack at BP. Each G2 computes
+// two of the four G functions of a column or diagonal step side by side,
+// one in AX-DX and one in R8-R11, taking the message words straight from
+// the block at SI.
+
+// G on v[a0], v[b0], v[c0], v[d0] with message words x0 and y0, and
+// on v[a1], v[b1], v[c1], v[d1] with message words x1 and y1.
+#define G2(a0, b0, c0, d0, x0, y0, a1, b1, c1, d1, x1, y1) \
+	MOVQ	(a0*8)(BP), AX; \
+	MOVQ	(a1*8)(BP), R8; \
+	MOVQ	(b0*8)(BP), BX; \
+	MOVQ	(b1*8)(BP), R9; \
+	MOVQ	(c0*8)(BP), CX; \
+	MOVQ	(c1*8)(BP), R10; \
+	MOVQ	(d0*8)(BP), DX; \
+	MOVQ	(d1*8)(BP), R11; \
+	ADDQ	BX, AX; \
+	ADDQ	R9, R8; \
+	ADDQ	(x0*8)(SI), AX; \
+	ADDQ	(x1*8)(SI), R8; \
+	XORQ	AX, DX; \
+	XORQ	R8, R11; \
+	RORQ	$32, DX; \
+	RORQ	$32, R11; \
+	ADDQ	DX, CX; \
+	ADDQ	R11, R10; \
+	XORQ	CX, BX; \
+	XORQ	R10, R9; \
+	RORQ	$24, BX; \
+	RORQ	$24, R9; \
+	ADDQ	BX, AX; \
+	ADDQ	R9, R8; \
+	ADDQ	(y0*8)(SI), AX; \
+	ADDQ	(y1*8)(SI), R8; \
+	XORQ	AX, DX; \
+	XORQ	R8, R11; \
+	RORQ	$16, DX; \
+	RORQ	$16, R11; \
+	ADDQ	DX, CX; \
+	ADDQ	R11, R10; \
+	XORQ	CX, BX; \
+	XORQ	R10, R9; \
+	RORQ	$63, BX; \
+	RORQ	$63, R9; \
+	MOVQ	AX, (a0*8)(BP); \
+	MOVQ	R8, (a1*8)(BP); \
+	MOVQ	BX, (b0*8)(BP); \
+	MOVQ	R9, (b1*8)(BP); \
+	MOVQ	CX, (c0*8)(BP); \
+	MOVQ	R10, (c1*8)(BP); \
+	MOVQ	DX, (d0*8)(BP); \
+	MOVQ	R11, (d1*8)(BP)
+
+#define ROUND(s0, s1, s2, s3, s4, s5, s6, s7, s8, s9, s10, s11, s12, s13, s14, s15) \
+	G2(0, 4, 8, 12, s0, s1, 1, 5, 9, 13, s2, s3); \
+	G2(2, 6, 10, 14, s4, s5, 3, 7, 11, 15, s6, s7); \
+	G2(0, 5, 10, 15, s8, s9, 1, 6, 11, 12, s10, s11); \
+	G2(2, 7, 8, 13, s12, s13, 3, 4, 9, 14, s14, s15)
+
+// func hashBlocks(h *[8]uint64, c *[2]uint64, flag uint64, blocks []byte)
+TEXT ·hashBlocks(SB),NOSPLIT,$128-48
+	MOVQ	h+0(FP), DI
+	MOVQ	c+8(FP), BX
+	MOVQ	0(BX), R12
+	MOVQ	8(BX), R13
+	MOVQ	flag+16(FP), R14
+	MOVQ	blocks_base+24(FP), SI
+	MOVQ	blocks_len+32(FP), R15
+	ADDQ	SI, R15
+	MOVQ	SP, BP
+	CMPQ	SI, R15
+	JEQ	done
+
+loop:
+	ADDQ	$128, R12
+	ADCQ	$0, R13
+
+	// v[0:8] = h, v[8:16] = iv ^ (0, 0, 0, 0, c[0], c[1], flag, 0)
+	MOVQ	(0*8)(DI), AX
+	MOVQ	AX, (0*8)(BP)
+	MOVQ	(1*8)(DI), AX
+	MOVQ	AX, (1*8)(BP)
+	MOVQ	(2*8)(DI), AX
+	MOVQ	AX, (2*8)(BP)
+	MOVQ	(3*8)(DI), AX
+	MOVQ	AX, (3*8)(BP)
+	MOVQ	(4*8)(DI), AX
+	MOVQ	AX, (4*8)(BP)
+	MOVQ	(5*8)(DI), AX
+	MOVQ	AX, (5*8)(BP)
+	MOVQ	(6*8)(DI), AX
+	MOVQ	AX, (6*8)(BP)
+	MOVQ	(7*8)(DI), AX
+	MOVQ	AX, (7*8)(BP)
+	MOVQ	$0x6a09e667f3bcc908, AX
+	MOVQ	AX, (8*8)(BP)
+	MOVQ	$0xbb67ae8584caa73b, AX
+	MOVQ	AX, (9*8)(BP)
+	MOVQ	$0x3c6ef372fe94f82b, AX
+	MOVQ	AX, (10*8)(BP)
+	MOVQ	$0xa54ff53a5f1d36f1, AX
+	MOVQ	AX, (11*8)(BP)
+	MOVQ	$0x510e527fade682d1, AX
+	XORQ	R12, AX
+	MOVQ	AX, (12*8)(BP)
+	MOVQ	$0x9b05688c2b3e6c1f, AX
+	XORQ	R13, AX
+	MOVQ	AX, (13*8)(BP)
+	MOVQ	$0x1f83d9abfb41bd6b, AX
+	XORQ	R14, AX
+	MOVQ	AX, (14*8)(BP)
+	MOVQ	$0x5be0cd19137e2179, AX
+	MOVQ	AX, (15*8)(BP)
+
+	ROUND(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15)
+	ROUND(14, 10, 4, 8, 9, 15, 13, 6, 1, 12, 0, 2, 11, 7, 5, 3)
+	ROUND(11, 8, 12, 0, 5, 2, 15, 13, 10, 14, 3, 6, 7, 1, 9, 4)
+	ROUND(7, 9, 3, 1, 13, 12, 11, 14, 2, 6, 5, 10, 4, 0, 15, 8)
+	ROUND(9, 0, 5, 7, 2, 4, 10, 15, 14, 1, 11, 12, 6, 8, 3, 13)
+	ROUND(2, 12, 6, 10, 0, 11, 8, 3, 4, 13, 7, 5, 15, 14, 1, 9)
+	ROUND(12, 5, 1, 15, 14, 13, 4, 10, 0, 7, 6, 3, 9, 2, 8, 11)
+	ROUND(13, 11, 7, 14, 12, 1, 3, 9, 5, 0, 15, 4, 8, 6, 2, 10)
+	ROUND(6, 15, 14, 9, 11, 3, 0, 8, 12, 2, 13, 7, 1, 4, 10, 5)
+	ROUND(10, 2, 8, 4, 7, 6, 1, 5, 15, 11, 9, 14, 3, 12, 13, 0)
+	ROUND(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15)
+	ROUND(14, 10, 4, 8, 9, 15, 13, 6, 1, 12, 0, 2, 11, 7, 5, 3)
+
+	// h ^= v[0:8] ^ v[8:16]
+	MOVQ	(0*8)(BP), AX
+	XORQ	(8*8)(BP), AX
+	XORQ	AX, (0*8)(DI)
+	MOVQ	(1*8)(BP), AX
+	XORQ	(9*8)(BP), AX
+	XORQ	AX, (1*8)(DI)
+	MOVQ	(2*8)(BP), AX
+	XORQ	(10*8)(BP), AX
+	XORQ	AX, (2*8)(DI)
+	MOVQ	(3*8)(BP), AX
+	XORQ	(11*8)(BP), AX
+	XORQ	AX, (3*8)(DI)
+	MOVQ	(4*8)(BP), AX
+	XORQ	(12*8)(BP), AX
+	XORQ	AX, (4*8)(DI)
+	MOVQ	(5*8)(BP), AX
+	XORQ	(13*8)(BP), AX
+	XORQ	AX, (5*8)(DI)
+	MOVQ	(6*8)(BP), AX
+	XORQ	(14*8)(BP), AX
+	XORQ	AX, (6*8)(DI)
+	MOVQ	(7*8)(BP), AX
+	XORQ	(15*8)(BP), AX
+	XORQ	AX, (7*8)(DI)
+
+	ADDQ	$128, SI
+	CMPQ	SI, R15
+	JB	loop
+
+	MOVQ	c+8(FP), BX
+	MOVQ	R12, 0(BX)
+	MOVQ	R13, 8(BX)
+
+done:
+	RET
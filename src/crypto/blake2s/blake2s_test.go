@@ -0,0 +1,192 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package blake2s
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+// The inputs are the bytes 0, 1, 2, ... of the given length and, for the
+// keyed hashes, the key is the bytes 0, 1, ..., 31, as in the known-answer
+// tests of the BLAKE2 reference implementation.
+var keyed = []struct {
+	length int
+	sum    string
+}{
+	{0, "48a8997da407876b3d79c0d92325ad3b89cbb754d86ab71aee047ad345fd2c49"},
+	{1, "40d15fee7c328830166ac3f918650f807e7e01e177258cdc0a39b11f598066f1"},
+	{2, "6bb71300644cd3991b26ccd4d274acd1adeab8b1d7914546c1198bbe9fc9d803"},
+	{3, "1d220dbe2ee134661fdf6d9e74b41704710556f2f6e5a091b227697445dbea6b"},
+	{31, "b6156f72d380ee9ea6acd190464f2307a5c179ef01fd71f99f2d0f7a57360aea"},
+	{32, "c03bc642b20959cbe133a0303e0c1abff3e31ec8e1a328ec8565c36decff5265"},
+	{33, "2c3e08176f760c6264c3a2cd66fec6c3d78de43fc192457b2a4a660a1e0eb22b"},
+	{63, "c65382513f07460da39833cb666c5ed82e61b9e998f4b0c4287cee56c3cc9bcd"},
+	{64, "8975b0577fd35566d750b362b0897a26c399136df07bababbde6203ff2954ed4"},
+	{65, "21fe0ceb0052be7fb0f004187cacd7de67fa6eb0938d927677f2398c132317a8"},
+	{255, "3fb735061abc519dfe979e54c1ee5bfad0a9d858b3315bad34bde999efd724dd"},
+}
+
+var unkeyed = []struct {
+	length int
+	sum    string
+}{
+	{0, "69217a3079908094e11121d042354a7c1f55b6482ca1a51e1b250dfd1ed0eef9"},
+	{1, "e34d74dbaf4ff4c6abd871cc220451d2ea2648846c7757fbaac82fe51ad64bea"},
+	{2, "ddad9ab15dac4549ba42f49d262496bef6c0bae1dd342a8808f8ea267c6e210c"},
+	{3, "e8f91c6ef232a041452ab0e149070cdd7dd1769e75b3a5921be37876c45c9900"},
+	{31, "aba4ad9b480b9df3d08ca5e87b0c2440d4e4ea21224c2eb42cbae469d089b931"},
+	{32, "05825607d7fdf2d82ef4c3c8c2aea961ad98d60edff7d018983e21204c0d93d1"},
+	{33, "a742f8b6af82d8a6ca2357c5f1cf91defbd066267d75c048b352366585025962"},
+	{63, "e57cb79487dd57902432b250733813bd96a84efce59f650fac26e6696aefafc3"},
+	{64, "56f34e8b96557e90c1f24b52d0c89d51086acf1b00f634cf1dde9233b8eaaa3e"},
+	{65, "1b53ee94aaf34e4b159d48de352c7f0661d0a40edff95a0b1639b4090e974472"},
+	{255, "f03f5789d3336b80d002d59fdf918bdb775b00956ed5528e86aa994acb38fe2d"},
+}
+
+var input, key = make([]byte, 255), make([]byte, 32)
+
+func init() {
+	for i := range input {
+		input[i] = byte(i)
+	}
+	for i := range key {
+		key[i] = byte(i)
+	}
+}
+
+func TestHashes(t *testing.T) {
+	for _, tt := range keyed {
+		h, err := New256(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.Write(input[:tt.length])
+		if got := hex.EncodeToString(h.Sum(nil)); got != tt.sum {
+			t.Errorf("keyed hash of %d bytes = %s, want %s", tt.length, got, tt.sum)
+		}
+	}
+	for _, tt := range unkeyed {
+		h, err := New256(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Write the input a byte at a time to exercise the buffering.
+		for i := 0; i < tt.length; i++ {
+			h.Write(input[i : i+1])
+		}
+		if got := hex.EncodeToString(h.Sum(nil)); got != tt.sum {
+			t.Errorf("hash of %d bytes = %s, want %s", tt.length, got, tt.sum)
+		}
+		// Sum does not change the state, and Reset restores the initial one.
+		h.Write(input[:1])
+		h.Reset()
+		h.Write(input[:tt.length])
+		if got := hex.EncodeToString(h.Sum(nil)); got != tt.sum {
+			t.Errorf("after Reset, hash of %d bytes = %s, want %s", tt.length, got, tt.sum)
+		}
+	}
+	for _, tt := range unkeyed {
+		sum := Sum256(input[:tt.length])
+		if got := hex.EncodeToString(sum[:]); got != tt.sum {
+			t.Errorf("Sum256(%d bytes) = %s, want %s", tt.length, got, tt.sum)
+		}
+	}
+	h, err := New128([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Write([]byte("abc"))
+	if got := hex.EncodeToString(h.Sum(nil)); got != "9af4e6ccbbfafb7c9dbc6088ca27f3da" {
+		t.Errorf("BLAKE2s-128 MAC of abc = %s", got)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := New128(nil); err == nil {
+		t.Error("New128 accepted a nil key")
+	}
+	if _, err := New256(make([]byte, Size+1)); err == nil {
+		t.Error("New256 accepted a 33-byte key")
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	for _, h := range []crypto.Hash{crypto.BLAKE2s_256} {
+		if !h.Available() {
+			t.Errorf("hash %d is not registered", h)
+			continue
+		}
+		if size := h.New().Size(); size != h.Size() {
+			t.Errorf("hash %d: Size() = %d, want %d", h, size, h.Size())
+		}
+	}
+}
+
+func TestHashBlocksGeneric(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	blocks := make([]byte, 4*BlockSize)
+	for i := 0; i < 100; i++ {
+		for j := range blocks {
+			blocks[j] = byte(r.Int())
+		}
+		var h1, h2 [8]uint32
+		for j := range h1 {
+			h1[j] = uint32(r.Int63())
+		}
+		h2 = h1
+		c1 := [2]uint32{^uint32(0) - uint32(BlockSize), 0}
+		c2 := c1
+		flag := uint32(0)
+		if i%2 == 1 {
+			flag = 0xFFFFFFFF
+		}
+		n := (i%4 + 1) * BlockSize
+		hashBlocks(&h1, &c1, flag, blocks[:n])
+		hashBlocksGeneric(&h2, &c2, flag, blocks[:n])
+		if h1 != h2 || c1 != c2 {
+			t.Fatalf("hashBlocks and hashBlocksGeneric differ: %x, %x != %x, %x", h1, c1, h2, c2)
+		}
+	}
+}
+
+func TestSumMatchesNew(t *testing.T) {
+	h, _ := New256(nil)
+	for n := 0; n <= len(input); n++ {
+		h.Reset()
+		h.Write(input[:n])
+		sum := Sum256(input[:n])
+		if !bytes.Equal(h.Sum(nil), sum[:]) {
+			t.Fatalf("Sum256 and New256 differ for %d bytes", n)
+		}
+	}
+}
+
+var bench = make([]byte, 8192)
+
+func benchmarkSum(b *testing.B, size int) {
+	b.SetBytes(int64(size))
+	for i := 0; i < b.N; i++ {
+		Sum256(bench[:size])
+	}
+}
+
+func benchmarkHashBlocks(b *testing.B, hashBlocks func(*[8]uint32, *[2]uint32, uint32, []byte)) {
+	var h [8]uint32
+	var c [2]uint32
+	b.SetBytes(int64(len(bench)))
+	for i := 0; i < b.N; i++ {
+		hashBlocks(&h, &c, 0, bench)
+	}
+}
+
+func BenchmarkSum64(b *testing.B)             { benchmarkSum(b, 64) }
+func BenchmarkSum1K(b *testing.B)             { benchmarkSum(b, 1024) }
+func BenchmarkSum8K(b *testing.B)             { benchmarkSum(b, 8192) }
+func BenchmarkHashBlocks(b *testing.B)        { benchmarkHashBlocks(b, hashBlocks) }
+func BenchmarkHashBlocksGeneric(b *testing.B) { benchmarkHashBlocks(b, hashBlocksGeneric) }
@@ -0,0 +1,170 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+#include "textflag.h"
+
+// BLAKE2s compression for AMD64. See hashBlocksGeneric in blake2sblock.go
+// for the Go equivalent.
+//
+// The working vector v[0..15] lives on the stack at BP. Each G2 computes
+// two of the four G functions of a column or diagonal step side by side,
+// one in AX-DX and one in R8-R11, taking the message words straight from
+// the block at SI.
+
+// G on v[a0], v[b0], v[c0], v[d0] with message words x0 and y0, and
+// on v[a1], v[b1], v[c1], v[d1] with message words x1 and y1.
+#define G2(a0, b0, c0, d0, x0, y0, a1, b1, c1, d1, x1, y1) \
+	MOVL	(a0*4)(BP), AX; \
+	MOVL	(a1*4)(BP), R8; \
+	MOVL	(b0*4)(BP), BX; \
+	MOVL	(b1*4)(BP), R9; \
+	MOVL	(c0*4)(BP), CX; \
+	MOVL	(c1*4)(BP), R10; \
+	MOVL	(d0*4)(BP), DX; \
+	MOVL	(d1*4)(BP), R11; \
+	ADDL	BX, AX; \
+	ADDL	R9, R8; \
+	ADDL	(x0*4)(SI), AX; \
+	ADDL	(x1*4)(SI), R8; \
+	XORL	AX, DX; \
+	XORL	R8, R11; \
+	RORL	$16, DX; \
+	RORL	$16, R11; \
+	ADDL	DX, CX; \
+	ADDL	R11, R10; \
+	XORL	CX, BX; \
+	XORL	R10, R9; \
+	RORL	$12, BX; \
+	RORL	$12, R9; \
+	ADDL	BX, AX; \
+	ADDL	R9, R8; \
+	ADDL	(y0*4)(SI), AX; \
+	ADDL	(y1*4)(SI), R8; \
+	XORL	AX, DX; \
+	XORL	R8, R11; \
+	RORL	$8, DX; \
+	RORL	$8, R11; \
+	ADDL	DX, CX; \
+	ADDL	R11, R10; \
+	XORL	CX, BX; \
+	XORL	R10, R9; \
+	RORL	$7, BX; \
+	RORL	$7, R9; \
+	MOVL	AX, (a0*4)(BP); \
+	MOVL	R8, (a1*4)(BP); \
+	MOVL	BX, (b0*4)(BP); \
+	MOVL	R9, (b1*4)(BP); \
+	MOVL	CX, (c0*4)(BP); \
+	MOVL	R10, (c1*4)(BP); \
+	MOVL	DX, (d0*4)(BP); \
+	MOVL	R11, (d1*4)(BP)
+
+#define ROUND(s0, s1, s2, s3, s4, s5, s6, s7, s8, s9, s10, s11, s12, s13, s14, s15) \
+	G2(0, 4, 8, 12, s0, s1, 1, 5, 9, 13, s2, s3); \
+	G2(2, 6, 10, 14, s4, s5, 3, 7, 11, 15, s6, s7); \
+	G2(0, 5, 10, 15, s8, s9, 1, 6, 11, 12, s10, s11); \
+	G2(2, 7, 8, 13, s12, s13, 3, 4, 9, 14, s14, s15)
+
+// func hashBlocks(h *[8]uint32, c *[2]uint32, flag uint32, blocks []byte)
+TEXT ·hashBlocks(SB),NOSPLIT,$64-48
+	MOVQ	h+0(FP), DI
+	MOVQ	c+8(FP), BX
+	MOVL	0(BX), R12
+	MOVL	4(BX), R13
+	MOVL	flag+16(FP), R14
+	MOVQ	blocks_base+24(FP), SI
+	MOVQ	blocks_len+32(FP), R15
+	ADDQ	SI, R15
+	MOVQ	SP, BP
+	CMPQ	SI, R15
+	JEQ	done
+
+loop:
+	ADDL	$64, R12
+	ADCL	$0, R13
+
+	// v[0:8] = h, v[8:16] = iv ^ (0, 0, 0, 0, c[0], c[1], flag, 0)
+	MOVL	(0*4)(DI), AX
+	MOVL	AX, (0*4)(BP)
+	MOVL	(1*4)(DI), AX
+	MOVL	AX, (1*4)(BP)
+	MOVL	(2*4)(DI), AX
+	MOVL	AX, (2*4)(BP)
+	MOVL	(3*4)(DI), AX
+	MOVL	AX, (3*4)(BP)
+	MOVL	(4*4)(DI), AX
+	MOVL	AX, (4*4)(BP)
+	MOVL	(5*4)(DI), AX
+	MOVL	AX, (5*4)(BP)
+	MOVL	(6*4)(DI), AX
+	MOVL	AX, (6*4)(BP)
+	MOVL	(7*4)(DI), AX
+	MOVL	AX, (7*4)(BP)
+	MOVL	$0x6a09e667, AX
+	MOVL	AX, (8*4)(BP)
+	MOVL	$0xbb67ae85, AX
+	MOVL	AX, (9*4)(BP)
+	MOVL	$0x3c6ef372, AX
+	MOVL	AX, (10*4)(BP)
+	MOVL	$0xa54ff53a, AX
+	MOVL	AX, (11*4)(BP)
+	MOVL	$0x510e527f, AX
+	XORL	R12, AX
+	MOVL	AX, (12*4)(BP)
+	MOVL	$0x9b05688c, AX
+	XORL	R13, AX
+	MOVL	AX, (13*4)(BP)
+	MOVL	$0x1f83d9ab, AX
+	XORL	R14, AX
+	MOVL	AX, (14*4)(BP)
+	MOVL	$0x5be0cd19, AX
+	MOVL	AX, (15*4)(BP)
+
+	ROUND(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15)
+	ROUND(14, 10, 4, 8, 9, 15, 13, 6, 1, 12, 0, 2, 11, 7, 5, 3)
+	ROUND(11, 8, 12, 0, 5, 2, 15, 13, 10, 14, 3, 6, 7, 1, 9, 4)
+	ROUND(7, 9, 3, 1, 13, 12, 11, 14, 2, 6, 5, 10, 4, 0, 15, 8)
+	ROUND(9, 0, 5, 7, 2, 4, 10, 15, 14, 1, 11, 12, 6, 8, 3, 13)
+	ROUND(2, 12, 6, 10, 0, 11, 8, 3, 4, 13, 7, 5, 15, 14, 1, 9)
+	ROUND(12, 5, 1, 15, 14, 13, 4, 10, 0, 7, 6, 3, 9, 2, 8, 11)
+	ROUND(13, 11, 7, 14, 12, 1, 3, 9, 5, 0, 15, 4, 8, 6, 2, 10)
+	ROUND(6, 15, 14, 9, 11, 3, 0, 8, 12, 2, 13, 7, 1, 4, 10, 5)
+	ROUND(10, 2, 8, 4, 7, 6, 1, 5, 15, 11, 9, 14, 3, 12, 13, 0)
+
+	// h ^= v[0:8] ^ v[8:16]
+	MOVL	(0*4)(BP), AX
+	XORL	(8*4)(BP), AX
+	XORL	AX, (0*4)(DI)
+	MOVL	(1*4)(BP), AX
+	XORL	(9*4)(BP), AX
+	XORL	AX, (1*4)(DI)
+	MOVL	(2*4)(BP), AX
+	XORL	(10*4)(BP), AX
+	XORL	AX, (2*4)(DI)
+	MOVL	(3*4)(BP), AX
+	XORL	(11*4)(BP), AX
+	XORL	AX, (3*4)(DI)
+	MOVL	(4*4)(BP), AX
+	XORL	(12*4)(BP), AX
+	XORL	AX, (4*4)(DI)
+	MOVL	(5*4)(BP), AX
+	XORL	(13*4)(BP), AX
+	XORL	AX, (5*4)(DI)
+	MOVL	(6*4)(BP), AX
+	XORL	(14*4)(BP), AX
+	XORL	AX, (6*4)(DI)
+	MOVL	(7*4)(BP), AX
+	XORL	(15*4)(BP), AX
+	XORL	AX, (7*4)(DI)
+
+	ADDQ	$64, SI
+	CMPQ	SI, R15
+	JB	loop
+
+	MOVQ	c+8(FP), BX
+	MOVL	R12, 0(BX)
+	MOVL	R13, 4(BX)
+
+done:
+	RET
@@ -0,0 +1,90 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package hkdf implements the HMAC-based Extract-and-Expand Key Derivation
+// Function (HKDF) as defined in RFC 5869.
+//
+// HKDF derives keys from a secret that already has enough entropy, such as
+// a Diffie-Hellman shared secret; it is not suitable for passwords, for
+// which see the pbkdf2, scrypt, argon2 and bcrypt packages.  The secret is
+// first extracted into a fixed-length pseudorandom key, which is then
+// expanded into as many keys as needed, each labeled by its own info.
+package hkdf
+
+import (
+	"crypto/hmac"
+	"errors"
+	"hash"
+	"io"
+)
+
+// Extract generates a pseudorandom key from secret and salt for use with
+// Expand.  If salt is nil, a string of hash.Size() zeros is used, as RFC
+// 5869 specifies.
+func Extract(hash func() hash.Hash, secret, salt []byte) []byte {
+	if salt == nil {
+		salt = make([]byte, hash().Size())
+	}
+	extractor := hmac.New(hash, salt)
+	extractor.Write(secret)
+	return extractor.Sum(nil)
+}
+
+type hkdf struct {
+	expander hash.Hash
+	size     int
+
+	info    []byte
+	counter byte
+
+	prev []byte
+	buf  []byte
+}
+
+func (f *hkdf) Read(p []byte) (int, error) {
+	// Check whether enough data can be generated.
+	need := len(p)
+	remains := len(f.buf) + int(255-f.counter+1)*f.size
+	if remains < need {
+		return 0, errors.New("hkdf: entropy limit reached")
+	}
+	// Read any leftover from the buffer.
+	n := copy(p, f.buf)
+	p = p[n:]
+
+	// Fill the rest of the buffer.
+	for len(p) > 0 {
+		f.expander.Reset()
+		f.expander.Write(f.prev)
+		f.expander.Write(f.info)
+		f.expander.Write([]byte{f.counter})
+		f.prev = f.expander.Sum(f.prev[:0])
+		f.counter++
+
+		// Copy the new batch into p.
+		f.buf = f.prev
+		n = copy(p, f.buf)
+		p = p[n:]
+	}
+	// Save leftovers for the next run.
+	f.buf = f.buf[n:]
+
+	return need, nil
+}
+
+// Expand returns a Reader from which keys can be read, derived from
+// pseudorandomKey, typically the result of Extract, and info, which binds
+// the keys to their purpose.  At most 255 times the hash size bytes can be
+// read; beyond that, Read returns an error.
+func Expand(hash func() hash.Hash, pseudorandomKey, info []byte) io.Reader {
+	expander := hmac.New(hash, pseudorandomKey)
+	return &hkdf{expander, expander.Size(), info, 1, nil, nil}
+}
+
+// New returns a Reader from which keys can be read, derived from secret,
+// salt and info by Extract followed by Expand.
+func New(hash func() hash.Hash, secret, salt, info []byte) io.Reader {
+	prk := Extract(hash, secret, salt)
+	return Expand(hash, prk, info)
+}
@@ -0,0 +1,102 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hkdf
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"testing"
+)
+
+type hkdfTest struct {
+	hash   func() hash.Hash
+	secret string
+	salt   string
+	info   string
+	prk    string
+	out    string
+}
+
+// Test cases from RFC 5869, appendix A.
+var hkdfTests = []hkdfTest{
+	{
+		sha256.New,
+		"0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b",
+		"000102030405060708090a0b0c",
+		"f0f1f2f3f4f5f6f7f8f9",
+		"077709362c2e32df0ddc3f0dc47bba6390b6c73bb50f9c3122ec844ad7c2b3e5",
+		"3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865",
+	},
+	{
+		sha256.New,
+		"0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b",
+		"",
+		"",
+		"19ef24a32c717b167f33a91d6f648bdf96596776afdb6377ac434c1c293ccb04",
+		"8da4e775a563c18f715f802a063c5a31b8a11f5c5ee1879ec3454e5f3c738d2d9d201395faa4b61a96c8",
+	},
+	{
+		sha1.New,
+		"0b0b0b0b0b0b0b0b0b0b0b",
+		"000102030405060708090a0b0c",
+		"f0f1f2f3f4f5f6f7f8f9",
+		"9b6c18c432a7bf8f0e71c8eb88f4b30baa2ba243",
+		"085a01ea1b10f36933068b56efa5ad81a4f14b822f5b091568a9cdd4f155fda2c22e422478d305f3f896",
+	},
+}
+
+func TestHKDF(t *testing.T) {
+	for i, tt := range hkdfTests {
+		secret, _ := hex.DecodeString(tt.secret)
+		salt, _ := hex.DecodeString(tt.salt)
+		info, _ := hex.DecodeString(tt.info)
+		want, _ := hex.DecodeString(tt.out)
+
+		if prk := hex.EncodeToString(Extract(tt.hash, secret, salt)); prk != tt.prk {
+			t.Errorf("#%d: Extract = %s, want %s", i, prk, tt.prk)
+		}
+
+		out := make([]byte, len(want))
+		if _, err := io.ReadFull(New(tt.hash, secret, salt, info), out); err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(out, want) {
+			t.Errorf("#%d: got %x, want %x", i, out, want)
+		}
+
+		// Reading in small pieces gives the same result.
+		r := New(tt.hash, secret, salt, info)
+		out = out[:0]
+		for len(out) < len(want) {
+			piece := make([]byte, 7)
+			if rest := len(want) - len(out); rest < len(piece) {
+				piece = piece[:rest]
+			}
+			if _, err := io.ReadFull(r, piece); err != nil {
+				t.Fatalf("#%d: %v", i, err)
+			}
+			out = append(out, piece...)
+		}
+		if !bytes.Equal(out, want) {
+			t.Errorf("#%d: in pieces, got %x, want %x", i, out, want)
+		}
+	}
+}
+
+func TestHKDFLimit(t *testing.T) {
+	r := New(sha1.New, []byte("secret"), nil, nil)
+	limit := 255 * sha1.Size
+	if _, err := io.ReadFull(r, make([]byte, limit)); err != nil {
+		t.Fatalf("reading %d bytes: %v", limit, err)
+	}
+	if n, err := r.Read(make([]byte, 1)); n != 0 || err == nil {
+		t.Errorf("reading past the limit: got %d, %v", n, err)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scrypt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+type testVector struct {
+	password string
+	salt     string
+	N, r, p  int
+	output   string
+}
+
+var good = []testVector{
+	{"password", "salt", 2, 10, 10, "482c858e229055e62f41e0ec819a5ee18bdb87251a534f75acd95ac5e50a"},
+	{"password", "salt", 16, 100, 100, "88bd5edb52d1dd00188772ad36171290224e74829525b18d7323a57f9196"},
+	{"this is a long \000 password", "and this is a long \000 salt", 16384, 8, 1,
+		"c3f182ee2dec846e70a6942fb529985a3a09765ef04c612923b17f18555a37076deb2b9830d69de5492651e4506ae5776d96d40f67aaee37e1777b8ad5c3111432bb3b6f7e1264401879e641ae"},
+	{"p", "s", 2, 1, 1, "48"},
+	// Test vectors from RFC 7914, section 12.
+	{"", "", 16, 1, 1, "77d6576238657b203b19ca42c18a0497f16b4844e3074ae8dfdffa3fede21442fcd0069ded0948f8326a753a0fc81f17e8d3e0fb2e0d3628cf35e20c38d18906"},
+	{"password", "NaCl", 1024, 8, 16, "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640"},
+	{"pleaseletmein", "SodiumChloride", 16384, 8, 1, "7023bdcb3afd7348461c06cd81fd38ebfda8fbba904f8e3ea9b543f6545da1f2d5432955613f0fcf62d49705242a9af9e61e85dc0d651e40dfcf017b45575887"},
+}
+
+var bad = []testVector{
+	{"p", "s", 0, 1, 1, ""},                    // N == 0
+	{"p", "s", 1, 1, 1, ""},                    // N == 1
+	{"p", "s", 7, 8, 1, ""},                    // N is not power of 2
+	{"p", "s", 16, maxInt / 2, maxInt / 2, ""}, // p * r too large
+	{"p", "s", 16, 0, 1, ""},                   // r == 0
+}
+
+func TestKey(t *testing.T) {
+	for i, v := range good {
+		want, _ := hex.DecodeString(v.output)
+		k, err := Key([]byte(v.password), []byte(v.salt), v.N, v.r, v.p, len(want))
+		if err != nil {
+			t.Errorf("%d: got unexpected error: %s", i, err)
+		}
+		if !bytes.Equal(want, k) {
+			t.Errorf("%d: expected %x, got %x", i, want, k)
+		}
+	}
+	for i, v := range bad {
+		_, err := Key([]byte(v.password), []byte(v.salt), v.N, v.r, v.p, 32)
+		if err == nil {
+			t.Errorf("%d: expected error, got nil", i)
+		}
+	}
+}
+
+func BenchmarkKey(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Key([]byte("password"), []byte("salt"), 16384, 8, 1, 64)
+	}
+}
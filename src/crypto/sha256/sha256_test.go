@@ -7,7 +7,10 @@
 package sha256
 
 import (
+	"bytes"
+	"encoding"
 	"fmt"
+	"hash"
 	"io"
 	"testing"
 )
@@ -132,6 +135,56 @@ func TestGolden(t *testing.T) {
 	}
 }
 
+func TestGoldenMarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		newHash func() hash.Hash
+		golden  []sha256Test
+	}{
+		{"224", New224, golden224},
+		{"256", New, golden},
+	}
+	for _, tt := range tests {
+		for _, g := range tt.golden {
+			h := tt.newHash()
+			h2 := tt.newHash()
+
+			io.WriteString(h, g.in[:len(g.in)/2])
+
+			state, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+			if err != nil {
+				t.Errorf("sha%s: could not marshal: %v", tt.name, err)
+				continue
+			}
+
+			if err := h2.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+				t.Errorf("sha%s: could not unmarshal: %v", tt.name, err)
+				continue
+			}
+
+			io.WriteString(h, g.in[len(g.in)/2:])
+			io.WriteString(h2, g.in[len(g.in)/2:])
+
+			if actual, actual2 := h.Sum(nil), h2.Sum(nil); !bytes.Equal(actual, actual2) {
+				t.Errorf("sha%s(%q) = 0x%x != marshaled 0x%x", tt.name, g.in, actual, actual2)
+			}
+		}
+	}
+}
+
+func TestMarshalTypeMismatch(t *testing.T) {
+	h1 := New()
+	h2 := New224()
+
+	state1, err := h1.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		t.Fatalf("could not marshal: %v", err)
+	}
+	if err := h2.(encoding.BinaryUnmarshaler).UnmarshalBinary(state1); err == nil {
+		t.Errorf("no error when unmarshaling SHA-256 state into SHA-224 digest")
+	}
+}
+
 func TestSize(t *testing.T) {
 	c := New()
 	if got := c.Size(); got != Size {
@@ -0,0 +1,85 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sha3
+
+// rc stores the round constants for use in the ι step.
+var rc = [24]uint64{
+	0x0000000000000001,
+	0x0000000000008082,
+	0x800000000000808A,
+	0x8000000080008000,
+	0x000000000000808B,
+	0x0000000080000001,
+	0x8000000080008081,
+	0x8000000000008009,
+	0x000000000000008A,
+	0x0000000000000088,
+	0x0000000080008009,
+	0x000000008000000A,
+	0x000000008000808B,
+	0x800000000000008B,
+	0x8000000000008089,
+	0x8000000000008003,
+	0x8000000000008002,
+	0x8000000000000080,
+	0x000000000000800A,
+	0x800000008000000A,
+	0x8000000080008081,
+	0x8000000000008080,
+	0x0000000080000001,
+	0x8000000080008008,
+}
+
+// rotc and piln give, for each step of the walk that the ρ and π steps
+// take through the lanes starting at lane 1, the rotation applied to the
+// lane and the lane it moves to.
+var (
+	rotc = [24]uint{1, 3, 6, 10, 15, 21, 28, 36, 45, 55, 2, 14, 27, 41, 56, 8, 25, 43, 62, 18, 39, 61, 20, 44}
+	piln = [24]int{10, 7, 11, 17, 18, 3, 5, 16, 8, 21, 24, 4, 15, 23, 19, 13, 12, 2, 20, 14, 22, 9, 6, 1}
+)
+
+// keccakF1600Generic applies the Keccak permutation to a 1600b-wide
+// state represented as a slice of 25 uint64s, lane (x, y) being a[x+5*y].
+func keccakF1600Generic(a *[25]uint64) {
+	for round := 0; round < 24; round++ {
+		// θ step
+		c0 := a[0] ^ a[5] ^ a[10] ^ a[15] ^ a[20]
+		c1 := a[1] ^ a[6] ^ a[11] ^ a[16] ^ a[21]
+		c2 := a[2] ^ a[7] ^ a[12] ^ a[17] ^ a[22]
+		c3 := a[3] ^ a[8] ^ a[13] ^ a[18] ^ a[23]
+		c4 := a[4] ^ a[9] ^ a[14] ^ a[19] ^ a[24]
+		d0 := c4 ^ (c1<<1 | c1>>63)
+		d1 := c0 ^ (c2<<1 | c2>>63)
+		d2 := c1 ^ (c3<<1 | c3>>63)
+		d3 := c2 ^ (c4<<1 | c4>>63)
+		d4 := c3 ^ (c0<<1 | c0>>63)
+		for y := 0; y < 25; y += 5 {
+			a[y] ^= d0
+			a[y+1] ^= d1
+			a[y+2] ^= d2
+			a[y+3] ^= d3
+			a[y+4] ^= d4
+		}
+
+		// ρ and π steps
+		t := a[1]
+		for i, j := range piln {
+			t, a[j] = a[j], t<<rotc[i]|t>>(64-rotc[i])
+		}
+
+		// χ step
+		for y := 0; y < 25; y += 5 {
+			b0, b1, b2, b3, b4 := a[y], a[y+1], a[y+2], a[y+3], a[y+4]
+			a[y] = b0 ^ (^b1 & b2)
+			a[y+1] = b1 ^ (^b2 & b3)
+			a[y+2] = b2 ^ (^b3 & b4)
+			a[y+3] = b3 ^ (^b4 & b0)
+			a[y+4] = b4 ^ (^b0 & b1)
+		}
+
+		// ι step
+		a[0] ^= rc[round]
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+#include "textflag.h"
+
+// Keccak-f[1600] for AMD64. See keccakF1600Generic in keccakf.go for the
+// Go equivalent.
+//
+// A round reads the state at src and writes the permuted state to dst.
+// The θ step leaves the column parities C[x] in R8-R12 and the values
+// D[x] = C[x-1] ^ rotl(C[x+1], 1) in R13, R14, R15, AX and BX. Then, for
+// each plane of the output, the ρ and π steps gather its five lanes into
+// R8-R12, and the χ step combines them and stores them in dst. The rounds
+// alternate between the caller's state and a copy on the stack.
+
+// C = src[x] ^ src[x+5] ^ src[x+10] ^ src[x+15] ^ src[x+20]
+#define COLUMN(src, x, C) \
+	MOVQ	(x*8)(src), C; \
+	XORQ	((x+5)*8)(src), C; \
+	XORQ	((x+10)*8)(src), C; \
+	XORQ	((x+15)*8)(src), C; \
+	XORQ	((x+20)*8)(src), C
+
+// D = Cprev ^ rotl(Cnext, 1)
+#define THETA_D(Cprev, Cnext, D) \
+	MOVQ	Cnext, D; \
+	ROLQ	$1, D; \
+	XORQ	Cprev, D
+
+// B = rotl(src[i] ^ D, r)
+#define RHO_PI(src, i, D, r, B) \
+	MOVQ	(i*8)(src), B; \
+	XORQ	D, B; \
+	ROLQ	$r, B
+
+// B = src[i] ^ D, for lane 0, which is not rotated
+#define RHO_PI0(src, i, D, B) \
+	MOVQ	(i*8)(src), B; \
+	XORQ	D, B
+
+// dst[i] = B0 ^ (^B1 & B2)
+#define CHI(B0, B1, B2, dst, i) \
+	MOVQ	B1, CX; \
+	NOTQ	CX; \
+	ANDQ	B2, CX; \
+	XORQ	B0, CX; \
+	MOVQ	CX, (i*8)(dst)
+
+// dst[0] ^= round constant at SI, and advance SI to the next one
+#define IOTA(dst) \
+	MOVQ	(SI), CX; \
+	XORQ	CX, (0*8)(dst); \
+	ADDQ	$8, SI
+
+#define ROUND(src, dst) \
+	COLUMN(src, 0, R8); \
+	COLUMN(src, 1, R9); \
+	COLUMN(src, 2, R10); \
+	COLUMN(src, 3, R11); \
+	COLUMN(src, 4, R12); \
+	THETA_D(R12, R9, R13); \
+	THETA_D(R8, R10, R14); \
+	THETA_D(R9, R11, R15); \
+	THETA_D(R10, R12, AX); \
+	THETA_D(R11, R8, BX); \
+	RHO_PI0(src, 0, R13, R8); \
+	RHO_PI(src, 6, R14, 44, R9); \
+	RHO_PI(src, 12, R15, 43, R10); \
+	RHO_PI(src, 18, AX, 21, R11); \
+	RHO_PI(src, 24, BX, 14, R12); \
+	CHI(R8, R9, R10, dst, 0); \
+	CHI(R9, R10, R11, dst, 1); \
+	CHI(R10, R11, R12, dst, 2); \
+	CHI(R11, R12, R8, dst, 3); \
+	CHI(R12, R8, R9, dst, 4); \
+	IOTA(dst); \
+	RHO_PI(src, 3, AX, 28, R8); \
+	RHO_PI(src, 9, BX, 20, R9); \
+	RHO_PI(src, 10, R13, 3, R10); \
+	RHO_PI(src, 16, R14, 45, R11); \
+	RHO_PI(src, 22, R15, 61, R12); \
+	CHI(R8, R9, R10, dst, 5); \
+	CHI(R9, R10, R11, dst, 6); \
+	CHI(R10, R11, R12, dst, 7); \
+	CHI(R11, R12, R8, dst, 8); \
+	CHI(R12, R8, R9, dst, 9); \
+	RHO_PI(src, 1, R14, 1, R8); \
+	RHO_PI(src, 7, R15, 6, R9); \
+	RHO_PI(src, 13, AX, 25, R10); \
+	RHO_PI(src, 19, BX, 8, R11); \
+	RHO_PI(src, 20, R13, 18, R12); \
+	CHI(R8, R9, R10, dst, 10); \
+	CHI(R9, R10, R11, dst, 11); \
+	CHI(R10, R11, R12, dst, 12); \
+	CHI(R11, R12, R8, dst, 13); \
+	CHI(R12, R8, R9, dst, 14); \
+	RHO_PI(src, 4, BX, 27, R8); \
+	RHO_PI(src, 5, R13, 36, R9); \
+	RHO_PI(src, 11, R14, 10, R10); \
+	RHO_PI(src, 17, R15, 15, R11); \
+	RHO_PI(src, 23, AX, 56, R12); \
+	CHI(R8, R9, R10, dst, 15); \
+	CHI(R9, R10, R11, dst, 16); \
+	CHI(R10, R11, R12, dst, 17); \
+	CHI(R11, R12, R8, dst, 18); \
+	CHI(R12, R8, R9, dst, 19); \
+	RHO_PI(src, 2, R15, 62, R8); \
+	RHO_PI(src, 8, AX, 55, R9); \
+	RHO_PI(src, 14, BX, 39, R10); \
+	RHO_PI(src, 15, R13, 41, R11); \
+	RHO_PI(src, 21, R14, 2, R12); \
+	CHI(R8, R9, R10, dst, 20); \
+	CHI(R9, R10, R11, dst, 21); \
+	CHI(R10, R11, R12, dst, 22); \
+	CHI(R11, R12, R8, dst, 23); \
+	CHI(R12, R8, R9, dst, 24)
+
+// func keccakF1600(a *[25]uint64)
+TEXT ·keccakF1600(SB),NOSPLIT,$200-8
+	MOVQ	a+0(FP), DI
+	MOVQ	SP, BP
+	LEAQ	·rc(SB), SI
+	MOVQ	$12, DX
+
+loop:
+	ROUND(DI, BP)
+	ROUND(BP, DI)
+	DECQ	DX
+	JNE	loop
+
+	RET
@@ -0,0 +1,273 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sha3
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/hex"
+	"hash"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// Digests of the example messages that NIST published for FIPS-202.
+var testVectors = []struct {
+	in                             string
+	sha224, sha256, sha384, sha512 string
+	shake128, shake256             string
+}{
+	{
+		"",
+		"6b4e03423667dbb73b6e15454f0eb1abd4597f9a1b078e3f5b5a6bc7",
+		"a7ffc6f8bf1ed76651c14756a061d662f580ff4de43b49fa82d80a4b80f8434a",
+		"0c63a75b845e4f7d01107d852e4c2485c51a50aaaa94fc61995e71bbee983a2ac3713831264adb47fb6bd1e058d5f004",
+		"a69f73cca23a9ac5c8b567dc185a756e97c982164fe25859e0d1dcc1475c80a615b2123af1f5f94c11e3e9402c3ac558f500199d95b6d3e301758586281dcd26",
+		"7f9c2ba4e88f827d616045507605853ed73b8093f6efbc88eb1a6eacfa66ef26",
+		"46b9dd2b0ba88d13233b3feb743eeb243fcd52ea62b81b82b50c27646ed5762fd75dc4ddd8c0f200cb05019d67b592f6fc821c49479ab48640292eacb3b7c4be",
+	},
+	{
+		"abc",
+		"e642824c3f8cf24ad09234ee7d3c766fc9a3a5168d0c94ad73b46fdf",
+		"3a985da74fe225b2045c172d6bd390bd855f086e3e9d525b46bfe24511431532",
+		"ec01498288516fc926459f58e2c6ad8df9b473cb0fc08c2596da7cf0e49be4b298d88cea927ac7f539f1edf228376d25",
+		"b751850b1a57168a5693cd924b6b096e08f621827444f70d884f5d0240d2712e10e116e9192af3c91a7ec57647e3934057340b4cf408d5a56592f8274eec53f0",
+		"5881092dd818bf5cf8a3ddb793fbcba74097d5c526a6d35f97b83351940f2cc8",
+		"483366601360a8771c6863080cc4114d8db44530f8f1e1ee4f94ea37e78b5739d5a15bef186a5386c75744c0527e1faa9f8726e462a12a4feb06bd8801e751e4",
+	},
+	{
+		"abcdbcdecdefdefgefghfghighijhijkijkljklmklmnlmnomnopnopq",
+		"8a24108b154ada21c9fd5574494479ba5c7e7ab76ef264ead0fcce33",
+		"41c0dba2a9d6240849100376a8235e2c82e1b9998a999e21db32dd97496d3376",
+		"991c665755eb3a4b6bbdfb75c78a492e8c56a22c5c4d7e429bfdbc32b9d4ad5aa04a1f076e62fea19eef51acd0657c22",
+		"04a371e84ecfb5b8b77cb48610fca8182dd457ce6f326a0fd3d7ec2f1e91636dee691fbe0c985302ba1b0d8dc78c086346b533b49c030d99a27daf1139d6e75e",
+		"1a96182b50fb8c7e74e0a707788f55e98209b8d91fade8f32f8dd5cff7bf21f5",
+		"4d8c2dd2435a0128eefbb8c36f6f87133a7911e18d979ee1ae6be5d4fd2e332940d8688a4e6a59aa8060f1f9bc996c05aca3c696a8b66279dc672c740bb224ec",
+	},
+	{
+		strings.Repeat("\xa3", 200),
+		"9376816aba503f72f96ce7eb65ac095deee3be4bf9bbc2a1cb7e11e0",
+		"79f38adec5c20307a98ef76e8324afbfd46cfd81b22e3973c65fa1bd9de31787",
+		"1881de2ca7e41ef95dc4732b8f5f002b189cc1e42b74168ed1732649ce1dbcdd76197a31fd55ee989f2d7050dd473e8f",
+		"e76dfad22084a8b1467fcf2ffa58361bec7628edf5f3fdc0e4805dc48caeeca81b7c13c30adf52a3659584739a2df46be589c51ca1a4a8416df6545a1ce8ba00",
+		"131ab8d2b594946b9c81333f9bb6e0ce75c3b93104fa3469d3917457385da037",
+		"cd8a920ed141aa0407a22d59288652e9d9f1a7ee0c1e7c1ca699424da84a904d2d700caae7396ece96604440577da4f3aa22aeb8857f961c4cd8e06f0ae6610b",
+	},
+}
+
+func TestVectors(t *testing.T) {
+	for _, tt := range testVectors {
+		in := []byte(tt.in)
+		hashes := []struct {
+			name string
+			sum  []byte
+			want string
+		}{
+			{"SHA3-224", sum(New224(), in), tt.sha224},
+			{"SHA3-256", sum(New256(), in), tt.sha256},
+			{"SHA3-384", sum(New384(), in), tt.sha384},
+			{"SHA3-512", sum(New512(), in), tt.sha512},
+			{"SHAKE128", shake(NewShake128(), in, 32), tt.shake128},
+			{"SHAKE256", shake(NewShake256(), in, 64), tt.shake256},
+		}
+		for _, h := range hashes {
+			if got := hex.EncodeToString(h.sum); got != h.want {
+				t.Errorf("%s(%.10q) = %s, want %s", h.name, tt.in, got, h.want)
+			}
+		}
+	}
+}
+
+func sum(h hash.Hash, in []byte) []byte {
+	h.Write(in)
+	return h.Sum(nil)
+}
+
+func shake(h ShakeHash, in []byte, n int) []byte {
+	h.Write(in)
+	out := make([]byte, n)
+	h.Read(out)
+	return out
+}
+
+func TestSumFunctions(t *testing.T) {
+	in := []byte("abc")
+	if s := Sum224(in); !bytes.Equal(s[:], sum(New224(), in)) {
+		t.Error("Sum224 differs from New224")
+	}
+	if s := Sum256(in); !bytes.Equal(s[:], sum(New256(), in)) {
+		t.Error("Sum256 differs from New256")
+	}
+	if s := Sum384(in); !bytes.Equal(s[:], sum(New384(), in)) {
+		t.Error("Sum384 differs from New384")
+	}
+	if s := Sum512(in); !bytes.Equal(s[:], sum(New512(), in)) {
+		t.Error("Sum512 differs from New512")
+	}
+	out := make([]byte, 32)
+	if ShakeSum128(out, in); !bytes.Equal(out, shake(NewShake128(), in, 32)) {
+		t.Error("ShakeSum128 differs from NewShake128")
+	}
+	if ShakeSum256(out, in); !bytes.Equal(out, shake(NewShake256(), in, 32)) {
+		t.Error("ShakeSum256 differs from NewShake256")
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	for _, h := range []crypto.Hash{crypto.SHA3_224, crypto.SHA3_256, crypto.SHA3_384, crypto.SHA3_512} {
+		if !h.Available() {
+			t.Errorf("hash %d is not registered", h)
+			continue
+		}
+		if size := h.New().Size(); size != h.Size() {
+			t.Errorf("hash %d: Size() = %d, want %d", h, size, h.Size())
+		}
+	}
+}
+
+// TestUnalignedWrite tests that writing data in an arbitrary pattern with
+// small input buffers gives the same result as writing it all at once.
+func TestUnalignedWrite(t *testing.T) {
+	buf := make([]byte, 2000)
+	for i := range buf {
+		buf[i] = byte(i)
+	}
+	for _, newHash := range []func() hash.Hash{New224, New256, New384, New512} {
+		want := sum(newHash(), buf)
+		d := newHash()
+		for i := 0; i < len(buf); {
+			// Cycle through offsets which make a 137 byte sequence.
+			// Because 137 is prime this sequence should exercise all corner cases.
+			offsets := [17]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 1}
+			for _, j := range offsets {
+				if v := len(buf) - i; v < j {
+					j = v
+				}
+				d.Write(buf[i : i+j])
+				i += j
+			}
+		}
+		if got := d.Sum(nil); !bytes.Equal(got, want) {
+			t.Errorf("unaligned writes: got %x, want %x", got, want)
+		}
+	}
+}
+
+// TestSqueezing checks that squeezing the full output a single time
+// produces the same output as repeatedly squeezing the instance.
+func TestSqueezing(t *testing.T) {
+	for _, newShake := range []func() ShakeHash{NewShake128, NewShake256} {
+		want := shake(newShake(), []byte("squeeze"), 1000)
+		d := newShake()
+		d.Write([]byte("squeeze"))
+		var got []byte
+		one := make([]byte, 1)
+		for len(got) < len(want) {
+			d.Read(one)
+			got = append(got, one...)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("squeezing byte by byte: got %x, want %x", got, want)
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	for _, newShake := range []func() ShakeHash{NewShake128, NewShake256} {
+		d := newShake()
+		d.Write([]byte("prefix"))
+		c := d.Clone()
+		if !bytes.Equal(shake(c, []byte("suffix"), 100), shake(d, []byte("suffix"), 100)) {
+			t.Error("clone of absorbing state differs")
+		}
+
+		// Clone after some output has been read.
+		c = d.Clone()
+		a, b := make([]byte, 300), make([]byte, 300)
+		c.Read(a)
+		d.Read(b)
+		if !bytes.Equal(a, b) {
+			t.Error("clone of squeezing state differs")
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	d := New256()
+	d.Write([]byte("garbage"))
+	d.Reset()
+	if got, want := hex.EncodeToString(sum(d, []byte("abc"))), testVectors[1].sha256; got != want {
+		t.Errorf("after Reset, got %s, want %s", got, want)
+	}
+}
+
+func TestKeccakF1600Generic(t *testing.T) {
+	var a, b [25]uint64
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		for j := range a {
+			a[j] = uint64(r.Int63())<<1 ^ uint64(r.Int63())
+		}
+		b = a
+		keccakF1600(&a)
+		keccakF1600Generic(&b)
+		if a != b {
+			t.Fatalf("keccakF1600 and keccakF1600Generic differ: %x != %x", a, b)
+		}
+	}
+}
+
+func TestWriteAfterRead(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Write after Read did not panic")
+		}
+	}()
+	d := NewShake128()
+	d.Read(make([]byte, 1))
+	d.Write([]byte("x"))
+}
+
+var bench = New256()
+var buf = make([]byte, 8192)
+
+func benchmarkSize(b *testing.B, size int) {
+	b.SetBytes(int64(size))
+	sum := make([]byte, bench.Size())
+	for i := 0; i < b.N; i++ {
+		bench.Reset()
+		bench.Write(buf[:size])
+		bench.Sum(sum[:0])
+	}
+}
+
+func BenchmarkHash8Bytes(b *testing.B) {
+	benchmarkSize(b, 8)
+}
+
+func BenchmarkHash1K(b *testing.B) {
+	benchmarkSize(b, 1024)
+}
+
+func BenchmarkHash8K(b *testing.B) {
+	benchmarkSize(b, 8192)
+}
+
+func BenchmarkPermutationFunction(b *testing.B) {
+	b.SetBytes(int64(200))
+	var lanes [25]uint64
+	for i := 0; i < b.N; i++ {
+		keccakF1600(&lanes)
+	}
+}
+
+func BenchmarkPermutationFunctionGeneric(b *testing.B) {
+	b.SetBytes(int64(200))
+	var lanes [25]uint64
+	for i := 0; i < b.N; i++ {
+		keccakF1600Generic(&lanes)
+	}
+}
@@ -7,7 +7,10 @@
 package sha512
 
 import (
+	"bytes"
+	"encoding"
 	"fmt"
+	"hash"
 	"io"
 	"testing"
 )
@@ -85,6 +88,74 @@ var golden384 = []sha512Test{
 	{"1764b700eb1ead52a2fc33cc28975c2180f1b8faa5038d94cffa8d78154aab16e91dd787e7b0303948ebed62561542c8", "How can you write a big system without C++?  -Paul Glick"},
 }
 
+var golden512_224 = []sha512Test{
+	{"6ed0dd02806fa89e25de060c19d3ac86cabb87d6a0ddd05c333b84f4", ""},
+	{"d5cdb9ccc769a5121d4175f2bfdd13d6310e0d3d361ea75d82108327", "a"},
+	{"b35878d07bfedf39fc638af08547eb5d1072d8546319f247b442fbf5", "ab"},
+	{"4634270f707b6a54daae7530460842e20e37ed265ceee9a43e8924aa", "abc"},
+	{"0c9f157ab030fb06e957c14e3938dc5908962e5dd7b66f04a36fc534", "abcd"},
+	{"880e79bb0a1d2c9b7528d851edb6b8342c58c831de98123b432a4515", "abcde"},
+	{"236c829cfea4fd6d4de61ad15fcf34dca62342adaf9f2001c16f29b8", "abcdef"},
+	{"4767af672b3ed107f25018dc22d6fa4b07d156e13b720971e2c4f6bf", "abcdefg"},
+	{"792e25e0ae286d123a38950007e037d3122e76c4ee201668c385edab", "abcdefgh"},
+	{"56b275d36127dc070cda4019baf2ce2579a25d8c67fa2bc9be61b539", "abcdefghi"},
+	{"f809423cbb25e81a2a64aecee2cd5fdc7d91d5db583901fbf1db3116", "abcdefghij"},
+	{"4c46e10b5b72204e509c3c06072cea970bc020cd45a61a0acdfa97ac", "Discard medicine more than two years old."},
+	{"cb0cef13c1848d91a6d02637c7c520de1914ad4a7aea824671cc328e", "He who has a shady past knows that nice guys finish last."},
+	{"6c7bd0f3a6544ea698006c2ea583a85f80ea2913590a186db8bb2f1b", "I wouldn't marry him with a ten foot pole."},
+	{"981323be3eca6ccfa598e58dd74ed8cb05d5f7f6653b7604b684f904", "Free! Free!/A trip/to Mars/for 900/empty jars/Burma Shave"},
+	{"e6fbf82df5138bf361e826903cadf0612cb2986649ba47a57e1bca99", "The days of the digital watch are numbered.  -Tom Stoppard"},
+	{"6ec2cb2ecafc1a9bddaf4caf57344d853e6ded398927d5694fd7714f", "Nepal premier won't resign."},
+	{"7f62f36e716e0badaf4a4658da9d09bea26357a1bc6aeb8cf7c3ae35", "For every action there is an equal and opposite government program."},
+	{"45adffcb86a05ee4d91263a6115dda011b805d442c60836963cb8378", "His money is twice tainted: 'taint yours and 'taint mine."},
+	{"51cb518f1f68daa901a3075a0a5e1acc755b4e5c82cb47687537f880", "There is no reason for any individual to have a computer in their home. -Ken Olsen, 1977"},
+	{"3b59c5e64b0da7bfc18d7017bf458d90f2c83601ff1afc6263ac0993", "It's a tiny change to the code and not completely disgusting. - Bob Manchek"},
+	{"6a9525c0fac0f91b489bc4f0f539b9ec4a156a4e98bc15b655c2c881", "size:  a.out:  bad magic"},
+	{"a1b2b2905b1527d682049c6a76e35c7d8c72551abfe7833ac1be595f", "The major problem is with sendmail.  -Mark Horton"},
+	{"76cf045c76a5f2e3d64d56c3cdba6a25479334611bc375460526f8c1", "Give me a rock, paper and scissors and I will move the world.  CCFestoon"},
+	{"4473671daeecfdb6f6c5bc06b26374aa5e497cc37119fe14144c430c", "If the enemy is within range, then so are you."},
+	{"6accb6394758523fcd453d47d37ebd10868957a0a9e81c796736abf8", "It's well we cannot hear the screams/That we create in others' dreams."},
+	{"6f173f4b6eac7f2a73eaa0833c4563752df2c869dc00b7d30219e12e", "You remind me of a TV show, but that's all right: I watch it anyway."},
+	{"db05bf4d0f73325208755f4af96cfac6cb3db5dbfc323d675d68f938", "C is as portable as Stonehedge!!"},
+	{"05ffa71bb02e855de1aaee1777b3bdbaf7507646f19c4c6aa29933d0", "Even if I could be Shakespeare, I think I should still choose to be Faraday. - A. Huxley"},
+	{"3ad3c89e15b91e6273534c5d18adadbb528e7b840b288f64e81b8c6d", "The fugacity of a constituent in a mixture of gases at a given temperature is proportional to its mole fraction.  Lewis-Randall Rule"},
+	{"e3763669d1b760c1be7bfcb6625f92300a8430419d1dbad57ec9f53c", "How can you write a big system without C++?  -Paul Glick"},
+}
+
+var golden512_256 = []sha512Test{
+	{"c672b8d1ef56ed28ab87c3622c5114069bdd3ad7b8f9737498d0c01ecef0967a", ""},
+	{"455e518824bc0601f9fb858ff5c37d417d67c2f8e0df2babe4808858aea830f8", "a"},
+	{"22d4d37ec6370571af7109fb12eae79673d5f7c83e6e677083faa3cfac3b2c14", "ab"},
+	{"53048e2681941ef99b2e29b76b4c7dabe4c2d0c634fc6d46e0e2f13107e7af23", "abc"},
+	{"d2891c7978be0e24948f37caa415b87cb5cbe2b26b7bad9dc6391b8a6f6ddcc9", "abcd"},
+	{"de8322b46e78b67d4431997070703e9764e03a1237b896fd8b379ed4576e8363", "abcde"},
+	{"e4fdcb11d1ac14e698743acd8805174cea5ddc0d312e3e47f6372032571bad84", "abcdef"},
+	{"a8117f680bdceb5d1443617cbdae9255f6900075422326a972fdd2f65ba9bee3", "abcdefg"},
+	{"a29b9645d2a02a8b582888d044199787220e316bf2e89d1422d3df26bf545bbe", "abcdefgh"},
+	{"b955095330f9c8188d11884ec1679dc44c9c5b25ff9bda700416df9cdd39188f", "abcdefghi"},
+	{"550762913d51eefbcd1a55068fcfc9b154fd11c1078b996df0d926ea59d2a68d", "abcdefghij"},
+	{"690c8ad3916cefd3ad29226d9875965e3ee9ec0d4482eacc248f2ff4aa0d8e5b", "Discard medicine more than two years old."},
+	{"25938ca49f7ef1178ce81620842b65e576245fcaed86026a36b516b80bb86b3b", "He who has a shady past knows that nice guys finish last."},
+	{"698e420c3a7038e53d8e73f4be2b02e03b93464ac1a61ebe69f557079921ef65", "I wouldn't marry him with a ten foot pole."},
+	{"839b414d7e3900ee243aa3d1f9b6955720e64041f5ab9bedd3eb0a08da5a2ca8", "Free! Free!/A trip/to Mars/for 900/empty jars/Burma Shave"},
+	{"5625ecb9d284e54c00b257b67a8cacb25a78db2845c60ef2d29e43c84f236e8e", "The days of the digital watch are numbered.  -Tom Stoppard"},
+	{"9b81d06bca2f985e6ad3249096ff3c0f2a9ec5bb16ef530d738d19d81e7806f2", "Nepal premier won't resign."},
+	{"08241df8d91edfcd68bb1a1dada6e0ae1475a5c6e7b8f12d8e24ca43a38240a9", "For every action there is an equal and opposite government program."},
+	{"4ff74d9213a8117745f5d37b5353a774ec81c5dfe65c4c8986a56fc01f2c551e", "His money is twice tainted: 'taint yours and 'taint mine."},
+	{"b5baf747c307f98849ec881cf0d48605ae4edd386372aea9b26e71db517e650b", "There is no reason for any individual to have a computer in their home. -Ken Olsen, 1977"},
+	{"7eef0538ebd7ecf18611d23b0e1cd26a74d65b929a2e374197dc66e755ca4944", "It's a tiny change to the code and not completely disgusting. - Bob Manchek"},
+	{"d05600964f83f55323104aadab434f32391c029718a7690d08ddb2d7e8708443", "size:  a.out:  bad magic"},
+	{"53ed5f9b5c0b674ac0f3425d9f9a5d462655b07cc90f5d0f692eec093884a607", "The major problem is with sendmail.  -Mark Horton"},
+	{"5a0147685a44eea2435dbd582724efca7637acd9c428e5e1a05115bc3bc2a0e0", "Give me a rock, paper and scissors and I will move the world.  CCFestoon"},
+	{"1152c9b27a99dbf4057d21438f4e63dd0cd0977d5ff12317c64d3b97fcac875a", "If the enemy is within range, then so are you."},
+	{"105e890f5d5cf1748d9a7b4cdaf58b69855779deebc2097747c2210a17b2cb51", "It's well we cannot hear the screams/That we create in others' dreams."},
+	{"74644ead770da1434365cd912656fe1aca2056d3039d39f10eb1151bddb32cf3", "You remind me of a TV show, but that's all right: I watch it anyway."},
+	{"50a234625de5587581883dad9ef399460928032a5ea6bd005d7dc7b68d8cc3d6", "C is as portable as Stonehedge!!"},
+	{"a7a3846005f8a9935a0a2d43e7fd56d95132a9a3609bf3296ef80b8218acffa0", "Even if I could be Shakespeare, I think I should still choose to be Faraday. - A. Huxley"},
+	{"688ff03e367680757aa9906cb1e2ad218c51f4526dc0426ea229a5ba9d002c69", "The fugacity of a constituent in a mixture of gases at a given temperature is proportional to its mole fraction.  Lewis-Randall Rule"},
+	{"3fa46d52094b01021cff5af9a438982b887a5793f624c0a6644149b6b7c3f485", "How can you write a big system without C++?  -Paul Glick"},
+}
+
 func TestGolden(t *testing.T) {
 	for i := 0; i < len(golden); i++ {
 		g := golden[i]
@@ -130,6 +201,105 @@ func TestGolden(t *testing.T) {
 			c.Reset()
 		}
 	}
+	for i := 0; i < len(golden512_224); i++ {
+		g := golden512_224[i]
+		s := fmt.Sprintf("%x", Sum512_224([]byte(g.in)))
+		if s != g.out {
+			t.Fatalf("Sum512_224 function: sha512_224(%s) = %s want %s", g.in, s, g.out)
+		}
+		c := New512_224()
+		for j := 0; j < 3; j++ {
+			if j < 2 {
+				io.WriteString(c, g.in)
+			} else {
+				io.WriteString(c, g.in[0:len(g.in)/2])
+				c.Sum(nil)
+				io.WriteString(c, g.in[len(g.in)/2:])
+			}
+			s := fmt.Sprintf("%x", c.Sum(nil))
+			if s != g.out {
+				t.Fatalf("sha512_224[%d](%s) = %s want %s", j, g.in, s, g.out)
+			}
+			c.Reset()
+		}
+	}
+	for i := 0; i < len(golden512_256); i++ {
+		g := golden512_256[i]
+		s := fmt.Sprintf("%x", Sum512_256([]byte(g.in)))
+		if s != g.out {
+			t.Fatalf("Sum512_256 function: sha512_256(%s) = %s want %s", g.in, s, g.out)
+		}
+		c := New512_256()
+		for j := 0; j < 3; j++ {
+			if j < 2 {
+				io.WriteString(c, g.in)
+			} else {
+				io.WriteString(c, g.in[0:len(g.in)/2])
+				c.Sum(nil)
+				io.WriteString(c, g.in[len(g.in)/2:])
+			}
+			s := fmt.Sprintf("%x", c.Sum(nil))
+			if s != g.out {
+				t.Fatalf("sha512_256[%d](%s) = %s want %s", j, g.in, s, g.out)
+			}
+			c.Reset()
+		}
+	}
+}
+
+func TestGoldenMarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		newHash func() hash.Hash
+		golden  []sha512Test
+	}{
+		{"384", New384, golden384},
+		{"512_224", New512_224, golden512_224},
+		{"512_256", New512_256, golden512_256},
+		{"512", New, golden},
+	}
+	for _, tt := range tests {
+		for _, g := range tt.golden {
+			h := tt.newHash()
+			h2 := tt.newHash()
+
+			io.WriteString(h, g.in[:len(g.in)/2])
+
+			state, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+			if err != nil {
+				t.Errorf("sha%s: could not marshal: %v", tt.name, err)
+				continue
+			}
+
+			if err := h2.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+				t.Errorf("sha%s: could not unmarshal: %v", tt.name, err)
+				continue
+			}
+
+			io.WriteString(h, g.in[len(g.in)/2:])
+			io.WriteString(h2, g.in[len(g.in)/2:])
+
+			if actual, actual2 := h.Sum(nil), h2.Sum(nil); !bytes.Equal(actual, actual2) {
+				t.Errorf("sha%s(%q) = 0x%x != marshaled 0x%x", tt.name, g.in, actual, actual2)
+			}
+		}
+	}
+}
+
+func TestMarshalTypeMismatch(t *testing.T) {
+	h1 := New()
+	h2 := New512_224()
+
+	state1, err := h1.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		t.Fatalf("could not marshal: %v", err)
+	}
+	if err := h2.(encoding.BinaryUnmarshaler).UnmarshalBinary(state1); err == nil {
+		t.Errorf("no error when unmarshaling SHA-512 state into SHA-512/224 digest")
+	}
+	if err := h1.(encoding.BinaryUnmarshaler).UnmarshalBinary(state1[:len(state1)-1]); err == nil {
+		t.Errorf("no error when unmarshaling truncated state")
+	}
 }
 
 func TestSize(t *testing.T) {
@@ -141,6 +311,14 @@ func TestSize(t *testing.T) {
 	if got := c.Size(); got != Size384 {
 		t.Errorf("New384.Size = %d; want %d", got, Size384)
 	}
+	c = New512_224()
+	if got := c.Size(); got != Size224 {
+		t.Errorf("New512_224.Size = %d; want %d", got, Size224)
+	}
+	c = New512_256()
+	if got := c.Size(); got != Size256 {
+		t.Errorf("New512_256.Size = %d; want %d", got, Size256)
+	}
 }
 
 func TestBlockSize(t *testing.T) {
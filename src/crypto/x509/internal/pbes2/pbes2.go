@@ -0,0 +1,167 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pbes2 implements PBES2, the password-based encryption scheme of
+// PKCS #5 v2.0 described in RFC 2898, with PBKDF2 and block ciphers in CBC
+// mode, for the encrypted private keys of PKCS #8 and PKCS #12.
+package pbes2
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/pbkdf2"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+)
+
+// OID identifies PBES2 as an encryption algorithm.
+var OID = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+
+var (
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+)
+
+// ErrUnsupported is returned by NewCipher for parameters that name a key
+// derivation function, pseudorandom function or cipher that is not
+// implemented.
+var ErrUnsupported = errors.New("pbes2: unsupported algorithm")
+
+// MaxIterations is the largest PBKDF2 iteration count that NewCipher
+// accepts, so that parameters from untrusted input cannot make key
+// derivation run for an unbounded time.
+const MaxIterations = 1 << 24
+
+// prfs are the pseudorandom functions that PBKDF2 may use, which are
+// HMAC with the given hash functions.
+var prfs = []struct {
+	hash crypto.Hash
+	oid  asn1.ObjectIdentifier
+}{
+	{crypto.SHA1, asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}},
+	{crypto.SHA224, asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 8}},
+	{crypto.SHA256, oidHMACWithSHA256},
+	{crypto.SHA384, asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}},
+	{crypto.SHA512, asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}},
+}
+
+// A Cipher is a block cipher that PBES2 may use, in CBC mode.
+type Cipher struct {
+	oid        asn1.ObjectIdentifier
+	cipherFunc func(key []byte) (cipher.Block, error)
+	keySize    int
+}
+
+// The ciphers that PBES2 may use.
+var (
+	DESCBC     = &Cipher{asn1.ObjectIdentifier{1, 3, 14, 3, 2, 7}, des.NewCipher, 8}
+	DESEDE3CBC = &Cipher{asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}, des.NewTripleDESCipher, 24}
+	AES128CBC  = &Cipher{asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}, aes.NewCipher, 16}
+	AES192CBC  = &Cipher{asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}, aes.NewCipher, 24}
+	AES256CBC  = &Cipher{asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}, aes.NewCipher, 32}
+)
+
+var ciphers = []*Cipher{DESCBC, DESEDE3CBC, AES128CBC, AES192CBC, AES256CBC}
+
+type params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// NewCipher parses the DER-encoded PBES2 parameters der and returns the
+// block cipher they name, keyed with the key derived from password, and
+// the IV to use with it in CBC mode.
+func NewCipher(der, password []byte) (cipher.Block, []byte, error) {
+	var p params
+	if _, err := asn1.Unmarshal(der, &p); err != nil {
+		return nil, nil, err
+	}
+	if !p.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, nil, ErrUnsupported
+	}
+	var kdfParams pbkdf2Params
+	if _, err := asn1.Unmarshal(p.KeyDerivationFunc.Parameters.FullBytes, &kdfParams); err != nil {
+		return nil, nil, err
+	}
+	if kdfParams.IterationCount < 1 || kdfParams.IterationCount > MaxIterations {
+		return nil, nil, errors.New("pbes2: invalid PBKDF2 iteration count")
+	}
+	prf := crypto.SHA1
+	if kdfParams.PRF.Algorithm != nil {
+		prf = 0
+		for _, f := range prfs {
+			if f.oid.Equal(kdfParams.PRF.Algorithm) {
+				prf = f.hash
+			}
+		}
+		if prf == 0 || !prf.Available() {
+			return nil, nil, ErrUnsupported
+		}
+	}
+
+	var c *Cipher
+	for _, cc := range ciphers {
+		if cc.oid.Equal(p.EncryptionScheme.Algorithm) {
+			c = cc
+		}
+	}
+	if c == nil {
+		return nil, nil, ErrUnsupported
+	}
+	if kdfParams.KeyLength != 0 && kdfParams.KeyLength != c.keySize {
+		return nil, nil, errors.New("pbes2: incorrect PBKDF2 key length")
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(p.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, nil, err
+	}
+
+	key := pbkdf2.Key(password, kdfParams.Salt, kdfParams.IterationCount, c.keySize, prf.New)
+	block, err := c.cipherFunc(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, nil, errors.New("pbes2: incorrect IV size")
+	}
+	return block, iv, nil
+}
+
+// MarshalParams returns the DER encoding of the PBES2 parameters for
+// encrypting with c and the given IV under a key derived from the password
+// by PBKDF2 with HMAC-SHA256, the given salt and iteration count.
+func MarshalParams(c *Cipher, salt, iv []byte, iterations int) ([]byte, error) {
+	var p params
+	var err error
+	p.KeyDerivationFunc.Algorithm = oidPBKDF2
+	kdfParams := pbkdf2Params{
+		Salt:           salt,
+		IterationCount: iterations,
+		PRF: pkix.AlgorithmIdentifier{
+			Algorithm:  oidHMACWithSHA256,
+			Parameters: asn1.RawValue{Tag: asn1.TagNull},
+		},
+	}
+	if p.KeyDerivationFunc.Parameters.FullBytes, err = asn1.Marshal(kdfParams); err != nil {
+		return nil, err
+	}
+	p.EncryptionScheme.Algorithm = c.oid
+	if p.EncryptionScheme.Parameters.FullBytes, err = asn1.Marshal(iv); err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(p)
+}
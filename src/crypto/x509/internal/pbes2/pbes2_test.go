@@ -0,0 +1,40 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pbes2
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewCipher(t *testing.T) {
+	salt := []byte("saltsaltsaltsalt")
+	iv := bytes.Repeat([]byte{1}, 16)
+	params, err := MarshalParams(AES256CBC, salt, iv, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, gotIV, err := NewCipher(params, []byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.BlockSize() != 16 || !bytes.Equal(gotIV, iv) {
+		t.Errorf("got block size %d and IV %x, want 16 and %x", block.BlockSize(), gotIV, iv)
+	}
+}
+
+func TestNewCipherIterations(t *testing.T) {
+	salt := []byte("saltsaltsaltsalt")
+	iv := bytes.Repeat([]byte{1}, 16)
+	for _, n := range []int{0, -1, MaxIterations + 1, 1<<31 - 1} {
+		params, err := MarshalParams(AES128CBC, salt, iv, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := NewCipher(params, []byte("password")); err == nil {
+			t.Errorf("NewCipher accepted an iteration count of %d", n)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package x509
+
+// PKCS#8 private keys are encrypted with PBES2, the password-based
+// encryption scheme of PKCS#5 v2.0, described in RFC 2898.
+
+import (
+	"crypto/cipher"
+	"crypto/x509/internal/pbes2"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"io"
+)
+
+// pbes2Iterations is the PBKDF2 iteration count used for encryption.
+const pbes2Iterations = 2048
+
+// encryptedPrivateKeyInfo reflects an ASN.1, PKCS#8 EncryptedPrivateKeyInfo.
+// See RFC 5208, section 6.
+type encryptedPrivateKeyInfo struct {
+	Algo          pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// DecryptPKCS8PrivateKey takes a PEM block of type "ENCRYPTED PRIVATE KEY",
+// holding a PKCS#8 private key encrypted with PBES2, and the password used
+// to encrypt it, and returns the private key. If an incorrect password is
+// detected an IncorrectPasswordError is returned. See RFC 5208 and RFC 2898.
+func DecryptPKCS8PrivateKey(b *pem.Block, password []byte) (key interface{}, err error) {
+	var info encryptedPrivateKeyInfo
+	if rest, err := asn1.Unmarshal(b.Bytes, &info); err != nil {
+		return nil, err
+	} else if len(rest) != 0 {
+		return nil, asn1.SyntaxError{Msg: "trailing data"}
+	}
+	if !info.Algo.Algorithm.Equal(pbes2.OID) {
+		return nil, errors.New("x509: unsupported PKCS#8 encryption algorithm")
+	}
+	block, iv, err := pbes2.NewCipher(info.Algo.Parameters.FullBytes, password)
+	if err == pbes2.ErrUnsupported {
+		return nil, errors.New("x509: unsupported PKCS#8 encryption algorithm")
+	}
+	if err != nil {
+		return nil, err
+	}
+	bs := block.BlockSize()
+	if len(info.EncryptedData)%bs != 0 {
+		return nil, errors.New("x509: invalid padding")
+	}
+	data := make([]byte, len(info.EncryptedData))
+	dec := cipher.NewCBCDecrypter(block, iv)
+	dec.CryptBlocks(data, info.EncryptedData)
+	der, err := removePadding(data, bs)
+	if err != nil {
+		return nil, err
+	}
+	key, err = ParsePKCS8PrivateKey(der)
+	if err != nil {
+		// Padding that happens to be valid also hides an incorrect
+		// password, about once in 256 tries for random data.
+		return nil, IncorrectPasswordError
+	}
+	return key, nil
+}
+
+// EncryptPKCS8PrivateKey returns a PEM block of type "ENCRYPTED PRIVATE KEY"
+// holding the given private key, which must be an *rsa.PrivateKey or an
+// *ecdsa.PrivateKey, in PKCS#8 form encrypted with PBES2. The key is derived
+// from the password with PBKDF2 and HMAC-SHA256, and alg selects the cipher.
+func EncryptPKCS8PrivateKey(rand io.Reader, key interface{}, password []byte, alg PEMCipher) (*pem.Block, error) {
+	ciph := cipherByKey(alg)
+	if ciph == nil {
+		return nil, errors.New("x509: unknown encryption mode")
+	}
+	der, err := MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(rand, salt); err != nil {
+		return nil, errors.New("x509: cannot generate salt: " + err.Error())
+	}
+	iv := make([]byte, ciph.blockSize)
+	if _, err := io.ReadFull(rand, iv); err != nil {
+		return nil, errors.New("x509: cannot generate IV: " + err.Error())
+	}
+
+	var info encryptedPrivateKeyInfo
+	info.Algo.Algorithm = pbes2.OID
+	if info.Algo.Parameters.FullBytes, err = pbes2.MarshalParams(ciph.pbes2Cipher, salt, iv, pbes2Iterations); err != nil {
+		return nil, err
+	}
+	block, _, err := pbes2.NewCipher(info.Algo.Parameters.FullBytes, password)
+	if err != nil {
+		return nil, err
+	}
+	enc := cipher.NewCBCEncrypter(block, iv)
+	info.EncryptedData = addPadding(der, ciph.blockSize)
+	enc.CryptBlocks(info.EncryptedData, info.EncryptedData)
+
+	b, err := asn1.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+	return &pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: b}, nil
+}
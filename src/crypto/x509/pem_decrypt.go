@@ -13,6 +13,7 @@ import (
 	"crypto/cipher"
 	"crypto/des"
 	"crypto/md5"
+	"crypto/x509/internal/pbes2"
 	"encoding/hex"
 	"encoding/pem"
 	"errors"
@@ -39,40 +40,47 @@ type rfc1423Algo struct {
 	cipherFunc func(key []byte) (cipher.Block, error)
 	keySize    int
 	blockSize  int
+	// pbes2Cipher is the same cipher as an encryption scheme for PBES2.
+	pbes2Cipher *pbes2.Cipher
 }
 
 // rfc1423Algos holds a slice of the possible ways to encrypt a PEM
 // block.  The ivSize numbers were taken from the OpenSSL source.
 var rfc1423Algos = []rfc1423Algo{{
-	cipher:     PEMCipherDES,
-	name:       "DES-CBC",
-	cipherFunc: des.NewCipher,
-	keySize:    8,
-	blockSize:  des.BlockSize,
+	cipher:      PEMCipherDES,
+	name:        "DES-CBC",
+	cipherFunc:  des.NewCipher,
+	keySize:     8,
+	blockSize:   des.BlockSize,
+	pbes2Cipher: pbes2.DESCBC,
 }, {
-	cipher:     PEMCipher3DES,
-	name:       "DES-EDE3-CBC",
-	cipherFunc: des.NewTripleDESCipher,
-	keySize:    24,
-	blockSize:  des.BlockSize,
+	cipher:      PEMCipher3DES,
+	name:        "DES-EDE3-CBC",
+	cipherFunc:  des.NewTripleDESCipher,
+	keySize:     24,
+	blockSize:   des.BlockSize,
+	pbes2Cipher: pbes2.DESEDE3CBC,
 }, {
-	cipher:     PEMCipherAES128,
-	name:       "AES-128-CBC",
-	cipherFunc: aes.NewCipher,
-	keySize:    16,
-	blockSize:  aes.BlockSize,
+	cipher:      PEMCipherAES128,
+	name:        "AES-128-CBC",
+	cipherFunc:  aes.NewCipher,
+	keySize:     16,
+	blockSize:   aes.BlockSize,
+	pbes2Cipher: pbes2.AES128CBC,
 }, {
-	cipher:     PEMCipherAES192,
-	name:       "AES-192-CBC",
-	cipherFunc: aes.NewCipher,
-	keySize:    24,
-	blockSize:  aes.BlockSize,
+	cipher:      PEMCipherAES192,
+	name:        "AES-192-CBC",
+	cipherFunc:  aes.NewCipher,
+	keySize:     24,
+	blockSize:   aes.BlockSize,
+	pbes2Cipher: pbes2.AES192CBC,
 }, {
-	cipher:     PEMCipherAES256,
-	name:       "AES-256-CBC",
-	cipherFunc: aes.NewCipher,
-	keySize:    32,
-	blockSize:  aes.BlockSize,
+	cipher:      PEMCipherAES256,
+	name:        "AES-256-CBC",
+	cipherFunc:  aes.NewCipher,
+	keySize:     32,
+	blockSize:   aes.BlockSize,
+	pbes2Cipher: pbes2.AES256CBC,
 },
 }
 
@@ -144,22 +152,27 @@ func DecryptPEMBlock(b *pem.Block, password []byte) ([]byte, error) {
 	data := make([]byte, len(b.Bytes))
 	dec := cipher.NewCBCDecrypter(block, iv)
 	dec.CryptBlocks(data, b.Bytes)
+	return removePadding(data, ciph.blockSize)
+}
 
-	// Blocks are padded using a scheme where the last n bytes of padding are all
-	// equal to n. It can pad from 1 to blocksize bytes inclusive. See RFC 1423.
-	// For example:
-	//	[x y z 2 2]
-	//	[x y 7 7 7 7 7 7 7]
-	// If we detect a bad padding, we assume it is an invalid password.
+// removePadding removes the padding from decrypted data.
+//
+// Blocks are padded using a scheme where the last n bytes of padding are all
+// equal to n. It can pad from 1 to blocksize bytes inclusive. See RFC 1423.
+// For example:
+//	[x y z 2 2]
+//	[x y 7 7 7 7 7 7 7]
+// If we detect a bad padding, we assume it is an invalid password.
+func removePadding(data []byte, blockSize int) ([]byte, error) {
 	dlen := len(data)
-	if dlen == 0 || dlen%ciph.blockSize != 0 {
+	if dlen == 0 || dlen%blockSize != 0 {
 		return nil, errors.New("x509: invalid padding")
 	}
 	last := int(data[dlen-1])
 	if dlen < last {
 		return nil, IncorrectPasswordError
 	}
-	if last == 0 || last > ciph.blockSize {
+	if last == 0 || last > blockSize {
 		return nil, IncorrectPasswordError
 	}
 	for _, val := range data[dlen-last:] {
@@ -190,16 +203,7 @@ func EncryptPEMBlock(rand io.Reader, blockType string, data, password []byte, al
 		return nil, err
 	}
 	enc := cipher.NewCBCEncrypter(block, iv)
-	pad := ciph.blockSize - len(data)%ciph.blockSize
-	encrypted := make([]byte, len(data), len(data)+pad)
-	// We could save this copy by encrypting all the whole blocks in
-	// the data separately, but it doesn't seem worth the additional
-	// code.
-	copy(encrypted, data)
-	// See RFC 1423, section 1.1
-	for i := 0; i < pad; i++ {
-		encrypted = append(encrypted, byte(pad))
-	}
+	encrypted := addPadding(data, ciph.blockSize)
 	enc.CryptBlocks(encrypted, encrypted)
 
 	return &pem.Block{
@@ -212,6 +216,22 @@ func EncryptPEMBlock(rand io.Reader, blockType string, data, password []byte, al
 	}, nil
 }
 
+// addPadding returns a copy of data padded to a multiple of blockSize, as
+// removePadding expects.
+func addPadding(data []byte, blockSize int) []byte {
+	pad := blockSize - len(data)%blockSize
+	padded := make([]byte, len(data), len(data)+pad)
+	// We could save this copy by encrypting all the whole blocks in
+	// the data separately, but it doesn't seem worth the additional
+	// code.
+	copy(padded, data)
+	// See RFC 1423, section 1.1
+	for i := 0; i < pad; i++ {
+		padded = append(padded, byte(pad))
+	}
+	return padded
+}
+
 func cipherByName(name string) *rfc1423Algo {
 	for i := range rfc1423Algos {
 		alg := &rfc1423Algos[i]
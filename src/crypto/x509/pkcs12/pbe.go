@@ -0,0 +1,236 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkcs12
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/x509/internal/pbes2"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+	"unicode/utf16"
+)
+
+var (
+	oidPBEWithSHAAnd3KeyTripleDESCBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 3}
+	oidPBEWithSHAAnd2KeyTripleDESCBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 4}
+)
+
+// bmpString returns s encoded as a BMPString followed by two zero bytes,
+// the form in which PKCS #12 key derivation takes passwords
+// (RFC 7292, appendix B.1).
+func bmpString(s string) []byte {
+	u := utf16.Encode([]rune(s))
+	b := make([]byte, 0, 2*len(u)+2)
+	for _, c := range u {
+		b = append(b, byte(c>>8), byte(c))
+	}
+	return append(b, 0, 0)
+}
+
+// Purposes of the keys derived by pbkdf (RFC 7292, appendix B.3).
+const (
+	keyID = 1
+	ivID  = 2
+	macID = 3
+)
+
+var one = big.NewInt(1)
+
+// pbkdf derives n bytes of key material from a password, encoded by
+// bmpString, as described in RFC 7292, appendix B.2.
+func pbkdf(h crypto.Hash, password, salt []byte, iterations int, id byte, n int) []byte {
+	hh := h.New()
+	u, v := hh.Size(), hh.BlockSize()
+
+	// fill returns b repeated to fill a multiple of v bytes.
+	fill := func(b []byte) []byte {
+		out := make([]byte, v*((len(b)+v-1)/v))
+		for i := range out {
+			out[i] = b[i%len(b)]
+		}
+		return out
+	}
+	D := make([]byte, v)
+	for i := range D {
+		D[i] = id
+	}
+	var I []byte
+	if len(salt) > 0 {
+		I = append(I, fill(salt)...)
+	}
+	if len(password) > 0 {
+		I = append(I, fill(password)...)
+	}
+
+	var out []byte
+	for {
+		hh.Reset()
+		hh.Write(D)
+		hh.Write(I)
+		A := hh.Sum(nil)
+		for i := 1; i < iterations; i++ {
+			hh.Reset()
+			hh.Write(A)
+			A = hh.Sum(A[:0])
+		}
+		out = append(out, A...)
+		if len(out) >= n {
+			return out[:n]
+		}
+
+		// Set each v-byte block I_j of I to (I_j + B + 1) mod 2^8v,
+		// where B is A repeated to v bytes.
+		B := new(big.Int).SetBytes(fill(A[:u])[:v])
+		B.Add(B, one)
+		Ij := new(big.Int)
+		for j := 0; j < len(I); j += v {
+			Ij.SetBytes(I[j : j+v])
+			Ij.Add(Ij, B)
+			b := Ij.Bytes()
+			if len(b) > v {
+				b = b[len(b)-v:]
+			}
+			for k := range I[j : j+v-len(b)] {
+				I[j+k] = 0
+			}
+			copy(I[j+v-len(b):j+v], b)
+		}
+	}
+}
+
+// maxIterations bounds the iteration counts of key derivation read from a
+// file, so that a file, which need not carry a MAC, cannot make decoding
+// run for an unbounded time.
+const maxIterations = 1 << 24
+
+// pbeParams are the parameters of the PKCS #12 password-based encryption
+// algorithms.
+type pbeParams struct {
+	Salt       []byte
+	Iterations int
+}
+
+// cipherFor returns the block cipher and IV described by alg for the given
+// password.
+func cipherFor(alg pkix.AlgorithmIdentifier, password string) (cipher.Block, []byte, error) {
+	switch {
+	case alg.Algorithm.Equal(oidPBEWithSHAAnd3KeyTripleDESCBC), alg.Algorithm.Equal(oidPBEWithSHAAnd2KeyTripleDESCBC):
+		var params pbeParams
+		if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &params); err != nil {
+			return nil, nil, err
+		}
+		if params.Iterations < 1 || params.Iterations > maxIterations {
+			return nil, nil, errors.New("pkcs12: invalid iteration count")
+		}
+		pw := bmpString(password)
+		key := pbkdf(crypto.SHA1, pw, params.Salt, params.Iterations, keyID, 24)
+		if alg.Algorithm.Equal(oidPBEWithSHAAnd2KeyTripleDESCBC) {
+			copy(key[16:], key[:8])
+		}
+		iv := pbkdf(crypto.SHA1, pw, params.Salt, params.Iterations, ivID, 8)
+		block, err := des.NewTripleDESCipher(key)
+		return block, iv, err
+
+	case alg.Algorithm.Equal(pbes2.OID):
+		// PBES2 takes the password as it is, not as a BMPString.
+		block, iv, err := pbes2.NewCipher(alg.Parameters.FullBytes, []byte(password))
+		if err == pbes2.ErrUnsupported {
+			err = ErrUnsupportedAlgorithm
+		}
+		return block, iv, err
+	}
+	return nil, nil, ErrUnsupportedAlgorithm
+}
+
+// decrypt decrypts data, which is encrypted with the password-based
+// encryption algorithm alg.
+func decrypt(alg pkix.AlgorithmIdentifier, password string, data []byte) ([]byte, error) {
+	block, iv, err := cipherFor(alg, password)
+	if err != nil {
+		return nil, err
+	}
+	bs := block.BlockSize()
+	if len(data) == 0 || len(data)%bs != 0 {
+		return nil, errors.New("pkcs12: encrypted data is not a multiple of the block size")
+	}
+	out := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, data)
+
+	// A wrong password almost always shows up as bad padding.
+	pad := int(out[len(out)-1])
+	if pad == 0 || pad > bs {
+		return nil, ErrIncorrectPassword
+	}
+	for _, v := range out[len(out)-pad:] {
+		if int(v) != pad {
+			return nil, ErrIncorrectPassword
+		}
+	}
+	return out[:len(out)-pad], nil
+}
+
+// encrypt encrypts data with a password-based encryption algorithm chosen
+// by opts and returns the encoding of the algorithm identifier and the
+// encrypted data.
+func encrypt(rand io.Reader, opts *EncodeOptions, password string, data []byte) (algID, encrypted []byte, err error) {
+	salt := make([]byte, 8)
+	if _, err := io.ReadFull(rand, salt); err != nil {
+		return nil, nil, err
+	}
+
+	var b asn1.Builder
+	switch opts.Algorithm {
+	case PBEWithSHA1And3DES:
+		b.AddSequence(func(b *asn1.Builder) {
+			b.AddObjectIdentifier(oidPBEWithSHAAnd3KeyTripleDESCBC)
+			b.AddSequence(func(b *asn1.Builder) {
+				b.AddOctetString(salt)
+				b.AddInt64(int64(opts.Iterations))
+			})
+		})
+	case PBES2WithAES256:
+		iv := make([]byte, aes.BlockSize)
+		if _, err := io.ReadFull(rand, iv); err != nil {
+			return nil, nil, err
+		}
+		params, err := pbes2.MarshalParams(pbes2.AES256CBC, salt, iv, opts.Iterations)
+		if err != nil {
+			return nil, nil, err
+		}
+		b.AddSequence(func(b *asn1.Builder) {
+			b.AddObjectIdentifier(pbes2.OID)
+			b.AddRaw(params)
+		})
+	default:
+		return nil, nil, ErrUnsupportedAlgorithm
+	}
+	if algID, err = b.Bytes(); err != nil {
+		return nil, nil, err
+	}
+
+	var alg pkix.AlgorithmIdentifier
+	if _, err := asn1.Unmarshal(algID, &alg); err != nil {
+		return nil, nil, err
+	}
+	block, iv, err := cipherFor(alg, password)
+	if err != nil {
+		return nil, nil, err
+	}
+	bs := block.BlockSize()
+	pad := bs - len(data)%bs
+	encrypted = make([]byte, len(data), len(data)+pad)
+	copy(encrypted, data)
+	for i := 0; i < pad; i++ {
+		encrypted = append(encrypted, byte(pad))
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, encrypted)
+	return algID, encrypted, nil
+}
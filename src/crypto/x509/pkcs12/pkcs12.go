@@ -0,0 +1,677 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pkcs12 reads and writes PKCS #12 files, as defined in RFC 7292,
+// also known as PFX files, which hold a private key together with its
+// certificate chain.
+//
+// Files protected with the legacy PKCS #12 algorithm based on SHA-1 and
+// triple DES and with PBES2 (PBKDF2 and AES or triple DES) can be read.
+// Files protected with RC2 or with public keys are not supported.
+package pkcs12
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkcs7"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io"
+)
+
+var (
+	oidKeyBag              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 1}
+	oidPKCS8ShroudedKeyBag = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	oidCertBag             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	oidSafeContentsBag     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 6}
+
+	oidCertTypeX509Certificate = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+
+	oidFriendlyName = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 20}
+	oidLocalKeyID   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 21}
+)
+
+// macAlgorithms are the digest algorithms that the MAC of a file may use.
+var macAlgorithms = []struct {
+	hash crypto.Hash
+	oid  asn1.ObjectIdentifier
+}{
+	{crypto.SHA1, asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}},
+	{crypto.SHA256, asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}},
+	{crypto.SHA384, asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}},
+	{crypto.SHA512, asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}},
+}
+
+// ErrIncorrectPassword is returned when the password does not match the
+// MAC of a file or fails to decrypt it.
+var ErrIncorrectPassword = errors.New("pkcs12: decryption password incorrect")
+
+// ErrUnsupportedAlgorithm is returned when a file uses an encryption or
+// MAC algorithm that this package does not implement.
+var ErrUnsupportedAlgorithm = errors.New("pkcs12: unsupported algorithm")
+
+// A KeyStore is the contents of a PKCS #12 file.
+type KeyStore struct {
+	// PrivateKey is an *rsa.PrivateKey or *ecdsa.PrivateKey, or nil if
+	// the file holds only certificates.
+	PrivateKey crypto.PrivateKey
+
+	// Certificate is the certificate for PrivateKey.
+	Certificate *x509.Certificate
+
+	// CACerts are the other certificates, typically those of the chain
+	// from Certificate to a root.
+	CACerts []*x509.Certificate
+
+	// FriendlyName is the name given to the private key or, failing that,
+	// to Certificate.
+	FriendlyName string
+}
+
+// TLSCertificate returns the key and certificate chain of ks as a
+// tls.Certificate.
+func (ks *KeyStore) TLSCertificate() tls.Certificate {
+	var cert tls.Certificate
+	if ks.Certificate != nil {
+		cert.Certificate = append(cert.Certificate, ks.Certificate.Raw)
+	}
+	for _, c := range ks.CACerts {
+		cert.Certificate = append(cert.Certificate, c.Raw)
+	}
+	cert.PrivateKey = ks.PrivateKey
+	cert.Leaf = ks.Certificate
+	return cert
+}
+
+// bag is a parsed SafeBag holding a key or certificate.
+type bag struct {
+	key          crypto.PrivateKey
+	cert         *x509.Certificate
+	friendlyName string
+	localKeyID   []byte
+}
+
+// Decode parses a BER- or DER-encoded PKCS #12 file protected with
+// password.  If the file has a MAC, it is checked first.
+func Decode(pfxData []byte, password string) (*KeyStore, error) {
+	p := asn1.NewParser(pfxData)
+	pfx, err := p.ReadSequence()
+	if err != nil {
+		return nil, err
+	}
+	if version, err := pfx.ReadInt64(); err != nil {
+		return nil, err
+	} else if version != 3 {
+		return nil, errors.New("pkcs12: unsupported version")
+	}
+	authSafe, err := pfx.ReadRawValue()
+	if err != nil {
+		return nil, err
+	}
+	contentType, content, err := pkcs7.ParseContentInfo(authSafe.FullBytes)
+	if err != nil {
+		return nil, err
+	}
+	if !contentType.Equal(pkcs7.OIDData) || content == nil {
+		// Files protected with public keys have signed content.
+		return nil, errors.New("pkcs12: only password integrity mode is supported")
+	}
+	authSafeData, err := asn1.NewParser(content).ReadOctetString()
+	if err != nil {
+		return nil, err
+	}
+	if !pfx.Empty() {
+		if err := verifyMAC(pfx, authSafeData, password); err != nil {
+			return nil, err
+		}
+	}
+
+	var bags []bag
+	cis, err := asn1.NewParser(authSafeData).ReadSequence()
+	if err != nil {
+		return nil, err
+	}
+	for !cis.Empty() {
+		ci, err := cis.ReadRawValue()
+		if err != nil {
+			return nil, err
+		}
+		safeContents, err := decodeAuthSafeContent(ci.FullBytes, password)
+		if err != nil {
+			return nil, err
+		}
+		if bags, err = decodeSafeContents(bags, safeContents, password); err != nil {
+			return nil, err
+		}
+	}
+	return newKeyStore(bags)
+}
+
+func verifyMAC(p *asn1.Parser, data []byte, password string) error {
+	macData, err := p.ReadSequence()
+	if err != nil {
+		return err
+	}
+	digestInfo, err := macData.ReadSequence()
+	if err != nil {
+		return err
+	}
+	var alg pkix.AlgorithmIdentifier
+	if err := digestInfo.ReadValue(&alg, ""); err != nil {
+		return err
+	}
+	digest, err := digestInfo.ReadOctetString()
+	if err != nil {
+		return err
+	}
+	salt, err := macData.ReadOctetString()
+	if err != nil {
+		return err
+	}
+	iterations := int64(1)
+	if !macData.Empty() {
+		if iterations, err = macData.ReadInt64(); err != nil {
+			return err
+		}
+	}
+	if iterations < 1 || iterations > maxIterations {
+		return errors.New("pkcs12: invalid iteration count")
+	}
+
+	var h crypto.Hash
+	for _, m := range macAlgorithms {
+		if m.oid.Equal(alg.Algorithm) {
+			h = m.hash
+		}
+	}
+	if h == 0 || !h.Available() {
+		return ErrUnsupportedAlgorithm
+	}
+	key := pbkdf(h, bmpString(password), salt, int(iterations), macID, h.Size())
+	mac := hmac.New(h.New, key)
+	mac.Write(data)
+	if !hmac.Equal(mac.Sum(nil), digest) {
+		return ErrIncorrectPassword
+	}
+	return nil
+}
+
+// decodeAuthSafeContent returns the SafeContents held, in the clear or
+// encrypted with password, in one of the ContentInfos of an
+// AuthenticatedSafe.
+func decodeAuthSafeContent(ci []byte, password string) ([]byte, error) {
+	contentType, content, err := pkcs7.ParseContentInfo(ci)
+	if err != nil {
+		return nil, err
+	}
+	if content == nil {
+		return nil, errors.New("pkcs12: missing content")
+	}
+	p := asn1.NewParser(content)
+	switch {
+	case contentType.Equal(pkcs7.OIDData):
+		return p.ReadOctetString()
+
+	case contentType.Equal(pkcs7.OIDEncryptedData):
+		ed, err := p.ReadSequence()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := ed.ReadInt64(); err != nil {
+			return nil, err
+		}
+		eci, err := ed.ReadSequence()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := eci.ReadObjectIdentifier(); err != nil {
+			return nil, err
+		}
+		var alg pkix.AlgorithmIdentifier
+		if err := eci.ReadValue(&alg, ""); err != nil {
+			return nil, err
+		}
+		encrypted, err := eci.ReadImplicitOctetString(0)
+		if err != nil {
+			return nil, err
+		}
+		return decrypt(alg, password, encrypted)
+	}
+	return nil, pkcs7.ErrUnsupportedContentType
+}
+
+// decodeSafeContents appends the keys and certificates in a SafeContents to
+// bags.  Bags of other types are skipped.
+func decodeSafeContents(bags []bag, data []byte, password string) ([]bag, error) {
+	seq, err := asn1.NewParser(data).ReadSequence()
+	if err != nil {
+		return nil, err
+	}
+	for !seq.Empty() {
+		safeBag, err := seq.ReadSequence()
+		if err != nil {
+			return nil, err
+		}
+		bagID, err := safeBag.ReadObjectIdentifier()
+		if err != nil {
+			return nil, err
+		}
+		value, err := safeBag.ReadElement(asn1.ClassContextSpecific, 0)
+		if err != nil {
+			return nil, err
+		}
+		var b bag
+		switch {
+		case bagID.Equal(oidKeyBag):
+			rv, err := value.ReadRawValue()
+			if err != nil {
+				return nil, err
+			}
+			if b.key, err = x509.ParsePKCS8PrivateKey(rv.FullBytes); err != nil {
+				return nil, err
+			}
+
+		case bagID.Equal(oidPKCS8ShroudedKeyBag):
+			epki, err := value.ReadSequence()
+			if err != nil {
+				return nil, err
+			}
+			var alg pkix.AlgorithmIdentifier
+			if err := epki.ReadValue(&alg, ""); err != nil {
+				return nil, err
+			}
+			encrypted, err := epki.ReadOctetString()
+			if err != nil {
+				return nil, err
+			}
+			der, err := decrypt(alg, password, encrypted)
+			if err != nil {
+				return nil, err
+			}
+			if b.key, err = x509.ParsePKCS8PrivateKey(der); err != nil {
+				return nil, err
+			}
+
+		case bagID.Equal(oidCertBag):
+			certBag, err := value.ReadSequence()
+			if err != nil {
+				return nil, err
+			}
+			certID, err := certBag.ReadObjectIdentifier()
+			if err != nil {
+				return nil, err
+			}
+			if !certID.Equal(oidCertTypeX509Certificate) {
+				continue
+			}
+			certValue, err := certBag.ReadElement(asn1.ClassContextSpecific, 0)
+			if err != nil {
+				return nil, err
+			}
+			der, err := certValue.ReadOctetString()
+			if err != nil {
+				return nil, err
+			}
+			if b.cert, err = x509.ParseCertificate(der); err != nil {
+				return nil, err
+			}
+
+		case bagID.Equal(oidSafeContentsBag):
+			rv, err := value.ReadRawValue()
+			if err != nil {
+				return nil, err
+			}
+			if bags, err = decodeSafeContents(bags, rv.FullBytes, password); err != nil {
+				return nil, err
+			}
+			continue
+
+		default:
+			continue
+		}
+
+		if !safeBag.Empty() {
+			attrs, err := safeBag.ReadSet()
+			if err != nil {
+				return nil, err
+			}
+			if err := b.decodeAttributes(attrs); err != nil {
+				return nil, err
+			}
+		}
+		bags = append(bags, b)
+	}
+	return bags, nil
+}
+
+func (b *bag) decodeAttributes(attrs *asn1.Parser) error {
+	for !attrs.Empty() {
+		attr, err := attrs.ReadSequence()
+		if err != nil {
+			return err
+		}
+		oid, err := attr.ReadObjectIdentifier()
+		if err != nil {
+			return err
+		}
+		values, err := attr.ReadSet()
+		if err != nil {
+			return err
+		}
+		switch {
+		case oid.Equal(oidFriendlyName):
+			err = values.ReadValue(&b.friendlyName, "")
+		case oid.Equal(oidLocalKeyID):
+			b.localKeyID, err = values.ReadOctetString()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newKeyStore sorts bags into a KeyStore.  The certificate for the private
+// key is the one with the same local key ID or, failing that, with the
+// same public key.
+func newKeyStore(bags []bag) (*KeyStore, error) {
+	ks := new(KeyStore)
+	var keyBag *bag
+	for i := range bags {
+		if bags[i].key != nil {
+			if keyBag != nil {
+				return nil, errors.New("pkcs12: more than one private key")
+			}
+			keyBag = &bags[i]
+		}
+	}
+
+	leaf := -1
+	if keyBag != nil {
+		ks.PrivateKey = keyBag.key
+		ks.FriendlyName = keyBag.friendlyName
+		for i, b := range bags {
+			if b.cert != nil && keyBag.localKeyID != nil && bytes.Equal(b.localKeyID, keyBag.localKeyID) {
+				leaf = i
+				break
+			}
+		}
+		if leaf < 0 {
+			pub, err := publicKeyDER(keyBag.key)
+			if err != nil {
+				return nil, err
+			}
+			for i, b := range bags {
+				if b.cert != nil && bytes.Equal(b.cert.RawSubjectPublicKeyInfo, pub) {
+					leaf = i
+					break
+				}
+			}
+		}
+		if leaf < 0 {
+			return nil, errors.New("pkcs12: no certificate for the private key")
+		}
+		ks.Certificate = bags[leaf].cert
+		if ks.FriendlyName == "" {
+			ks.FriendlyName = bags[leaf].friendlyName
+		}
+	}
+	for i, b := range bags {
+		if b.cert != nil && i != leaf {
+			ks.CACerts = append(ks.CACerts, b.cert)
+		}
+	}
+	return ks, nil
+}
+
+// publicKeyDER returns the encoded SubjectPublicKeyInfo for the public part
+// of key.
+func publicKeyDER(key crypto.PrivateKey) ([]byte, error) {
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("pkcs12: unknown private key type")
+	}
+	return x509.MarshalPKIXPublicKey(signer.Public())
+}
+
+// Algorithm is a password-based encryption algorithm for Encode.
+type Algorithm int
+
+// Possible values for EncodeOptions.Algorithm.
+const (
+	_ Algorithm = iota
+
+	// PBES2WithAES256 is PBES2 with PBKDF2-HMAC-SHA256 and AES-256 in
+	// CBC mode, with an HMAC-SHA256 MAC.
+	PBES2WithAES256
+
+	// PBEWithSHA1And3DES is the legacy PKCS #12 algorithm based on SHA-1
+	// and triple DES in CBC mode, with an HMAC-SHA1 MAC.  It is for
+	// readers that do not support PBES2.
+	PBEWithSHA1And3DES
+)
+
+// EncodeOptions contains optional parameters for Encode.
+type EncodeOptions struct {
+	// Algorithm protects the key and certificates.  The default is
+	// PBES2WithAES256.
+	Algorithm Algorithm
+
+	// Iterations is the iteration count for key derivation.  The default
+	// is 2048.
+	Iterations int
+}
+
+// Encode returns a DER-encoded PKCS #12 file holding the contents of ks,
+// protected by password.  The private key is encrypted in a shrouded key
+// bag and the certificates in an encrypted safe, and the whole file has a
+// MAC.  If ks has no private key, only the certificates are stored.  If
+// opts is nil, the defaults described in EncodeOptions apply.
+func Encode(rand io.Reader, ks *KeyStore, password string, opts *EncodeOptions) ([]byte, error) {
+	o := EncodeOptions{Algorithm: PBES2WithAES256, Iterations: 2048}
+	if opts != nil {
+		if opts.Algorithm != 0 {
+			o.Algorithm = opts.Algorithm
+		}
+		if opts.Iterations != 0 {
+			o.Iterations = opts.Iterations
+		}
+	}
+	if o.Iterations < 1 {
+		return nil, errors.New("pkcs12: invalid iteration count")
+	}
+	macHash := crypto.SHA256
+	if o.Algorithm == PBEWithSHA1And3DES {
+		macHash = crypto.SHA1
+	}
+
+	var localKeyID []byte
+	if ks.PrivateKey != nil {
+		if ks.Certificate == nil {
+			return nil, errors.New("pkcs12: private key without a certificate")
+		}
+		pub, err := publicKeyDER(ks.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(pub, ks.Certificate.RawSubjectPublicKeyInfo) {
+			return nil, errors.New("pkcs12: private key does not match certificate")
+		}
+		h := sha1.Sum(ks.Certificate.Raw)
+		localKeyID = h[:]
+	}
+
+	// The certificates go in an encrypted SafeContents.
+	var certs asn1.Builder
+	certs.AddSequence(func(b *asn1.Builder) {
+		if ks.Certificate != nil {
+			addCertBag(b, ks.Certificate, ks.FriendlyName, localKeyID)
+		}
+		for _, c := range ks.CACerts {
+			addCertBag(b, c, "", nil)
+		}
+	})
+	certsData, err := certs.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	algID, encrypted, err := encrypt(rand, &o, password, certsData)
+	if err != nil {
+		return nil, err
+	}
+	var ed asn1.Builder
+	ed.AddSequence(func(b *asn1.Builder) {
+		b.AddInt64(0)
+		b.AddSequence(func(b *asn1.Builder) {
+			b.AddObjectIdentifier(pkcs7.OIDData)
+			b.AddRaw(algID)
+			b.AddPrimitive(asn1.ClassContextSpecific, 0, encrypted)
+		})
+	})
+	edData, err := ed.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	certsCI, err := pkcs7.MarshalContentInfo(pkcs7.OIDEncryptedData, edData)
+	if err != nil {
+		return nil, err
+	}
+
+	// The key, encrypted on its own, goes in a SafeContents in the clear.
+	var keyCI []byte
+	if ks.PrivateKey != nil {
+		pkcs8, err := x509.MarshalPKCS8PrivateKey(ks.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		algID, encrypted, err := encrypt(rand, &o, password, pkcs8)
+		if err != nil {
+			return nil, err
+		}
+		var keys asn1.Builder
+		keys.AddSequence(func(b *asn1.Builder) {
+			b.AddSequence(func(b *asn1.Builder) {
+				b.AddObjectIdentifier(oidPKCS8ShroudedKeyBag)
+				b.AddExplicit(0, func(b *asn1.Builder) {
+					b.AddSequence(func(b *asn1.Builder) {
+						b.AddRaw(algID)
+						b.AddOctetString(encrypted)
+					})
+				})
+				addAttributes(b, ks.FriendlyName, localKeyID)
+			})
+		})
+		keysData, err := keys.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		if keyCI, err = marshalData(keysData); err != nil {
+			return nil, err
+		}
+	}
+
+	var as asn1.Builder
+	as.AddSequence(func(b *asn1.Builder) {
+		b.AddRaw(certsCI)
+		if keyCI != nil {
+			b.AddRaw(keyCI)
+		}
+	})
+	authSafe, err := as.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	authSafeCI, err := marshalData(authSafe)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 8)
+	if _, err := io.ReadFull(rand, salt); err != nil {
+		return nil, err
+	}
+	key := pbkdf(macHash, bmpString(password), salt, o.Iterations, macID, macHash.Size())
+	mac := hmac.New(macHash.New, key)
+	mac.Write(authSafe)
+	var macOID asn1.ObjectIdentifier
+	for _, m := range macAlgorithms {
+		if m.hash == macHash {
+			macOID = m.oid
+		}
+	}
+
+	var pfx asn1.Builder
+	pfx.AddSequence(func(b *asn1.Builder) {
+		b.AddInt64(3)
+		b.AddRaw(authSafeCI)
+		b.AddSequence(func(b *asn1.Builder) {
+			b.AddSequence(func(b *asn1.Builder) {
+				b.AddSequence(func(b *asn1.Builder) {
+					b.AddObjectIdentifier(macOID)
+					b.AddNull()
+				})
+				b.AddOctetString(mac.Sum(nil))
+			})
+			b.AddOctetString(salt)
+			b.AddInt64(int64(o.Iterations))
+		})
+	})
+	return pfx.Bytes()
+}
+
+// marshalData returns a ContentInfo holding data.
+func marshalData(data []byte) ([]byte, error) {
+	var b asn1.Builder
+	b.AddOctetString(data)
+	content, err := b.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return pkcs7.MarshalContentInfo(pkcs7.OIDData, content)
+}
+
+func addCertBag(b *asn1.Builder, cert *x509.Certificate, friendlyName string, localKeyID []byte) {
+	b.AddSequence(func(b *asn1.Builder) {
+		b.AddObjectIdentifier(oidCertBag)
+		b.AddExplicit(0, func(b *asn1.Builder) {
+			b.AddSequence(func(b *asn1.Builder) {
+				b.AddObjectIdentifier(oidCertTypeX509Certificate)
+				b.AddExplicit(0, func(b *asn1.Builder) {
+					b.AddOctetString(cert.Raw)
+				})
+			})
+		})
+		addAttributes(b, friendlyName, localKeyID)
+	})
+}
+
+// addAttributes adds the attributes of a SafeBag, if there are any.
+func addAttributes(b *asn1.Builder, friendlyName string, localKeyID []byte) {
+	if friendlyName == "" && localKeyID == nil {
+		return
+	}
+	b.AddSetOf(func(b *asn1.Builder) {
+		if friendlyName != "" {
+			b.AddSequence(func(b *asn1.Builder) {
+				b.AddObjectIdentifier(oidFriendlyName)
+				b.AddSet(func(b *asn1.Builder) {
+					b.AddValue(friendlyName, "bmp")
+				})
+			})
+		}
+		if localKeyID != nil {
+			b.AddSequence(func(b *asn1.Builder) {
+				b.AddObjectIdentifier(oidLocalKeyID)
+				b.AddSet(func(b *asn1.Builder) {
+					b.AddOctetString(localKeyID)
+				})
+			})
+		}
+	})
+}
@@ -0,0 +1,232 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkcs12
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkcs7"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"io/ioutil"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// The files in testdata were generated with OpenSSL from a key and
+// certificate for "PKCS12 Test Leaf" and the certificate of its issuer,
+// "PKCS12 Test CA", with the password "secret":
+//	openssl pkcs12 -export -name "Leaf Ω" -out pbes2-aes256.p12
+//	openssl pkcs12 -export -name "Leaf Ω" -certpbe PBE-SHA1-3DES -keypbe PBE-SHA1-3DES -macalg sha1 -out sha1-3des.p12
+var testFiles = []string{"testdata/pbes2-aes256.p12", "testdata/sha1-3des.p12"}
+
+func TestDecodeOpenSSL(t *testing.T) {
+	for _, file := range testFiles {
+		pfx, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ks, err := Decode(pfx, "secret")
+		if err != nil {
+			t.Errorf("%s: %v", file, err)
+			continue
+		}
+		if ks.Certificate == nil || ks.Certificate.Subject.CommonName != "PKCS12 Test Leaf" {
+			t.Errorf("%s: wrong certificate: %+v", file, ks.Certificate)
+			continue
+		}
+		if len(ks.CACerts) != 1 || ks.CACerts[0].Subject.CommonName != "PKCS12 Test CA" {
+			t.Errorf("%s: wrong CA certificates: %+v", file, ks.CACerts)
+		}
+		if ks.FriendlyName != "Leaf Ω" {
+			t.Errorf("%s: friendly name is %q", file, ks.FriendlyName)
+		}
+		key, ok := ks.PrivateKey.(*rsa.PrivateKey)
+		if !ok || key.PublicKey.N.Cmp(ks.Certificate.PublicKey.(*rsa.PublicKey).N) != 0 {
+			t.Errorf("%s: wrong private key", file)
+		}
+		if err := ks.Certificate.CheckSignatureFrom(ks.CACerts[0]); err != nil {
+			t.Errorf("%s: %v", file, err)
+		}
+
+		cert := ks.TLSCertificate()
+		if len(cert.Certificate) != 2 || !bytes.Equal(cert.Certificate[0], ks.Certificate.Raw) ||
+			cert.Leaf != ks.Certificate || cert.PrivateKey != ks.PrivateKey {
+			t.Errorf("%s: bad TLS certificate: %+v", file, cert)
+		}
+
+		if _, err := Decode(pfx, "wrong"); err != ErrIncorrectPassword {
+			t.Errorf("%s: with wrong password, got error %v, want ErrIncorrectPassword", file, err)
+		}
+	}
+}
+
+func testCertificate(t *testing.T, cn string, priv crypto.Signer) *x509.Certificate {
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Unix(1000, 0),
+		NotAfter:     time.Unix(100000, 0),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, priv.Public(), priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestEncodeDecode(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := testCertificate(t, "ca", caKey)
+
+	keys := []crypto.Signer{rsaKey, ecdsaKey}
+	for _, key := range keys {
+		for _, alg := range []Algorithm{0, PBES2WithAES256, PBEWithSHA1And3DES} {
+			in := &KeyStore{
+				PrivateKey:   key,
+				Certificate:  testCertificate(t, "leaf", key),
+				CACerts:      []*x509.Certificate{ca},
+				FriendlyName: "my key",
+			}
+			pfx, err := Encode(rand.Reader, in, "pässword", &EncodeOptions{Algorithm: alg, Iterations: 100})
+			if err != nil {
+				t.Errorf("%T, algorithm %d: Encode: %v", key, alg, err)
+				continue
+			}
+			out, err := Decode(pfx, "pässword")
+			if err != nil {
+				t.Errorf("%T, algorithm %d: Decode: %v", key, alg, err)
+				continue
+			}
+			if !bytes.Equal(out.Certificate.Raw, in.Certificate.Raw) || len(out.CACerts) != 1 ||
+				!bytes.Equal(out.CACerts[0].Raw, ca.Raw) || out.FriendlyName != in.FriendlyName {
+				t.Errorf("%T, algorithm %d: got %+v", key, alg, out)
+			}
+			pub, err := publicKeyDER(out.PrivateKey)
+			if err != nil || !bytes.Equal(pub, in.Certificate.RawSubjectPublicKeyInfo) {
+				t.Errorf("%T, algorithm %d: wrong private key", key, alg)
+			}
+			if _, err := Decode(pfx, "password"); err != ErrIncorrectPassword {
+				t.Errorf("%T, algorithm %d: with wrong password, got error %v", key, alg, err)
+			}
+		}
+	}
+
+	// A file may hold only certificates.
+	pfx, err := Encode(rand.Reader, &KeyStore{CACerts: []*x509.Certificate{ca}}, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Decode(pfx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.PrivateKey != nil || out.Certificate != nil || len(out.CACerts) != 1 {
+		t.Errorf("got %+v", out)
+	}
+
+	// The key must match the certificate.
+	if _, err := Encode(rand.Reader, &KeyStore{PrivateKey: rsaKey, Certificate: ca}, "", nil); err == nil {
+		t.Error("Encode accepted a key that does not match the certificate")
+	}
+}
+
+func TestDecodeIterations(t *testing.T) {
+	// A file without a MAC, so that nothing checks the parameters of its
+	// encrypted content before they are used.
+	var authSafe asn1.Builder
+	authSafe.AddSequence(func(b *asn1.Builder) {
+		b.AddSequence(func(b *asn1.Builder) {
+			b.AddObjectIdentifier(pkcs7.OIDEncryptedData)
+			b.AddExplicit(0, func(b *asn1.Builder) {
+				b.AddSequence(func(b *asn1.Builder) {
+					b.AddInt64(0)
+					b.AddSequence(func(b *asn1.Builder) {
+						b.AddObjectIdentifier(pkcs7.OIDData)
+						b.AddSequence(func(b *asn1.Builder) {
+							b.AddObjectIdentifier(oidPBEWithSHAAnd3KeyTripleDESCBC)
+							b.AddSequence(func(b *asn1.Builder) {
+								b.AddOctetString(make([]byte, 8))
+								b.AddInt64(1 << 30)
+							})
+						})
+						b.AddPrimitive(asn1.ClassContextSpecific, 0, make([]byte, 8))
+					})
+				})
+			})
+		})
+	})
+	content, err := authSafe.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pfx asn1.Builder
+	pfx.AddSequence(func(b *asn1.Builder) {
+		b.AddInt64(3)
+		b.AddSequence(func(b *asn1.Builder) {
+			b.AddObjectIdentifier(pkcs7.OIDData)
+			b.AddExplicit(0, func(b *asn1.Builder) {
+				b.AddOctetString(content)
+			})
+		})
+	})
+	der, err := pfx.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decode(der, ""); err == nil || err == ErrIncorrectPassword {
+		t.Errorf("Decode of a file with 1<<30 iterations: got error %v, want an invalid iteration count", err)
+	}
+}
+
+func TestPBKDF(t *testing.T) {
+	// Results from OpenSSL's PKCS12KDF, given the password as a BMPString.
+	tests := []struct {
+		hash       crypto.Hash
+		password   string
+		salt       string
+		iterations int
+		id         byte
+		out        string
+	}{
+		{crypto.SHA1, "sesame", "ffffffffffffffff", 2048, keyID, "7cd9fd3e2b3be7691a44e3bef0f9ea0fb9b897d4e325d9d1"},
+		{crypto.SHA256, "sesame", "0102030405060708", 3, macID, "d90e2eac689525eb1b78f85c12c23d3c9e2a24b2cc9887fddacf14b435b6186eb326625e696ffa52"},
+	}
+	for _, tt := range tests {
+		salt, _ := hex.DecodeString(tt.salt)
+		out := pbkdf(tt.hash, bmpString(tt.password), salt, tt.iterations, tt.id, len(tt.out)/2)
+		if got := hex.EncodeToString(out); got != tt.out {
+			t.Errorf("pbkdf(%v, %q) = %s, want %s", tt.hash, tt.password, got, tt.out)
+		}
+	}
+}
+
+func TestBMPString(t *testing.T) {
+	if got, want := hex.EncodeToString(bmpString("Beavis")), "0042006500610076006900730000"; got != want {
+		t.Errorf("bmpString = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,312 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkcs7
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io"
+)
+
+// Cipher is a content-encryption algorithm for enveloped data.
+type Cipher int
+
+// Possible values for the Encrypt content-encryption algorithm.
+const (
+	_ Cipher = iota
+	AES128CBC
+	AES192CBC
+	AES256CBC
+	DESEDE3CBC
+)
+
+// contentCipher describes a block cipher in CBC mode, which takes an
+// OCTET STRING holding the IV as its parameters.
+type contentCipher struct {
+	cipher     Cipher
+	oid        asn1.ObjectIdentifier
+	cipherFunc func(key []byte) (cipher.Block, error)
+	keySize    int
+	blockSize  int
+}
+
+var contentCiphers = []contentCipher{{
+	cipher:     AES128CBC,
+	oid:        asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2},
+	cipherFunc: aes.NewCipher,
+	keySize:    16,
+	blockSize:  aes.BlockSize,
+}, {
+	cipher:     AES192CBC,
+	oid:        asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22},
+	cipherFunc: aes.NewCipher,
+	keySize:    24,
+	blockSize:  aes.BlockSize,
+}, {
+	cipher:     AES256CBC,
+	oid:        asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42},
+	cipherFunc: aes.NewCipher,
+	keySize:    32,
+	blockSize:  aes.BlockSize,
+}, {
+	cipher:     DESEDE3CBC,
+	oid:        asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7},
+	cipherFunc: des.NewTripleDESCipher,
+	keySize:    24,
+	blockSize:  des.BlockSize,
+},
+}
+
+// Encrypt returns a DER-encoded ContentInfo holding enveloped data: content
+// encrypted under a fresh key with the given cipher, and that key encrypted
+// for each of the recipients with RSA PKCS #1 v1.5.  The recipients'
+// certificates must have RSA public keys.  If c is zero, AES256CBC is used.
+func Encrypt(rand io.Reader, content []byte, recipients []*x509.Certificate, c Cipher) ([]byte, error) {
+	if c == 0 {
+		c = AES256CBC
+	}
+	var ciph *contentCipher
+	for i := range contentCiphers {
+		if contentCiphers[i].cipher == c {
+			ciph = &contentCiphers[i]
+		}
+	}
+	if ciph == nil {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	if len(recipients) == 0 {
+		return nil, errors.New("pkcs7: no recipients")
+	}
+
+	key := make([]byte, ciph.keySize)
+	if _, err := io.ReadFull(rand, key); err != nil {
+		return nil, err
+	}
+	iv := make([]byte, ciph.blockSize)
+	if _, err := io.ReadFull(rand, iv); err != nil {
+		return nil, err
+	}
+	block, err := ciph.cipherFunc(key)
+	if err != nil {
+		return nil, err
+	}
+	// Pad the content as described in RFC 5652, section 6.3.
+	pad := ciph.blockSize - len(content)%ciph.blockSize
+	encrypted := make([]byte, len(content), len(content)+pad)
+	copy(encrypted, content)
+	for i := 0; i < pad; i++ {
+		encrypted = append(encrypted, byte(pad))
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, encrypted)
+
+	var b asn1.Builder
+	b.AddSequence(func(b *asn1.Builder) {
+		b.AddInt64(0)
+		b.AddSetOf(func(b *asn1.Builder) {
+			for _, cert := range recipients {
+				pub, ok := cert.PublicKey.(*rsa.PublicKey)
+				if !ok {
+					b.SetError(ErrUnsupportedAlgorithm)
+					return
+				}
+				encryptedKey, err := rsa.EncryptPKCS1v15(rand, pub, key)
+				if err != nil {
+					b.SetError(err)
+					return
+				}
+				b.AddSequence(func(b *asn1.Builder) {
+					b.AddInt64(0)
+					addIssuerAndSerialNumber(b, cert)
+					addAlgorithmIdentifier(b, oidPublicKeyRSA, true)
+					b.AddOctetString(encryptedKey)
+				})
+			}
+		})
+		b.AddSequence(func(b *asn1.Builder) {
+			b.AddObjectIdentifier(OIDData)
+			b.AddSequence(func(b *asn1.Builder) {
+				b.AddObjectIdentifier(ciph.oid)
+				b.AddOctetString(iv)
+			})
+			b.AddPrimitive(asn1.ClassContextSpecific, 0, encrypted)
+		})
+	})
+	envelopedData, err := b.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return MarshalContentInfo(OIDEnvelopedData, envelopedData)
+}
+
+// ErrNotRecipient is returned by Decrypt when the message is not encrypted
+// for the given certificate.
+var ErrNotRecipient = errors.New("pkcs7: not encrypted for this certificate")
+
+// Decrypt decrypts a BER- or DER-encoded ContentInfo holding enveloped data
+// and returns the content.  The message must be encrypted for cert, whose
+// private key is key.  Only RSA keys, of type *rsa.PrivateKey, are
+// supported.
+func Decrypt(data []byte, cert *x509.Certificate, key crypto.PrivateKey) ([]byte, error) {
+	priv, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	contentType, content, err := ParseContentInfo(data)
+	if err != nil {
+		return nil, err
+	}
+	if !contentType.Equal(OIDEnvelopedData) || content == nil {
+		return nil, ErrUnsupportedContentType
+	}
+
+	p := asn1.NewParser(content)
+	seq, err := p.ReadSequence()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := seq.ReadInt64(); err != nil {
+		return nil, err
+	}
+	// originatorInfo has an implicit [0] tag and is ignored.
+	if _, _, err := seq.ReadOptionalExplicit(0); err != nil {
+		return nil, err
+	}
+	recipientInfos, err := seq.ReadSet()
+	if err != nil {
+		return nil, err
+	}
+	var encryptedKey []byte
+	for !recipientInfos.Empty() && encryptedKey == nil {
+		// Recipients other than those using key transport have
+		// tagged RecipientInfo choices and are skipped.
+		if !recipientInfos.Peek(asn1.ClassUniversal, asn1.TagSequence) {
+			if _, err := recipientInfos.ReadRawValue(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if encryptedKey, err = readKeyTransRecipientInfo(recipientInfos, cert); err != nil {
+			return nil, err
+		}
+	}
+	if encryptedKey == nil {
+		return nil, ErrNotRecipient
+	}
+
+	eci, err := seq.ReadSequence()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := eci.ReadObjectIdentifier(); err != nil {
+		return nil, err
+	}
+	var alg pkix.AlgorithmIdentifier
+	if err := eci.ReadValue(&alg, ""); err != nil {
+		return nil, err
+	}
+	var ciph *contentCipher
+	for i := range contentCiphers {
+		if contentCiphers[i].oid.Equal(alg.Algorithm) {
+			ciph = &contentCiphers[i]
+		}
+	}
+	if ciph == nil {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &iv); err != nil {
+		return nil, err
+	}
+	if len(iv) != ciph.blockSize {
+		return nil, errors.New("pkcs7: invalid IV")
+	}
+	encrypted, err := eci.ReadImplicitOctetString(0)
+	if err != nil {
+		return nil, err
+	}
+
+	// A random key is substituted for a wrongly padded one, so that the
+	// failure cannot be told apart from one during content decryption
+	// (RFC 3218, section 2.3.2).
+	cek := make([]byte, ciph.keySize)
+	if _, err := io.ReadFull(rand.Reader, cek); err != nil {
+		return nil, err
+	}
+	if err := rsa.DecryptPKCS1v15SessionKey(rand.Reader, priv, encryptedKey, cek); err != nil {
+		return nil, err
+	}
+	block, err := ciph.cipherFunc(cek)
+	if err != nil {
+		return nil, err
+	}
+	if len(encrypted) == 0 || len(encrypted)%ciph.blockSize != 0 {
+		return nil, errors.New("pkcs7: encrypted content is not a multiple of the block size")
+	}
+	out := make([]byte, len(encrypted))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, encrypted)
+
+	pad := int(out[len(out)-1])
+	if pad == 0 || pad > ciph.blockSize {
+		return nil, errDecryption
+	}
+	for _, v := range out[len(out)-pad:] {
+		if int(v) != pad {
+			return nil, errDecryption
+		}
+	}
+	return out[:len(out)-pad], nil
+}
+
+var errDecryption = errors.New("pkcs7: decryption failed")
+
+// readKeyTransRecipientInfo reads a KeyTransRecipientInfo and returns its
+// encrypted key if it names cert, or nil otherwise.
+func readKeyTransRecipientInfo(p *asn1.Parser, cert *x509.Certificate) ([]byte, error) {
+	ktri, err := p.ReadSequence()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ktri.ReadInt64(); err != nil {
+		return nil, err
+	}
+	var matches bool
+	if ktri.Peek(asn1.ClassContextSpecific, 0) {
+		ski, err := ktri.ReadImplicitOctetString(0)
+		if err != nil {
+			return nil, err
+		}
+		matches = len(ski) > 0 && bytes.Equal(ski, cert.SubjectKeyId)
+	} else {
+		var ias issuerAndSerialNumber
+		if err := ktri.ReadValue(&ias, ""); err != nil {
+			return nil, err
+		}
+		matches = ias.matches(cert)
+	}
+	var alg pkix.AlgorithmIdentifier
+	if err := ktri.ReadValue(&alg, ""); err != nil {
+		return nil, err
+	}
+	encryptedKey, err := ktri.ReadOctetString()
+	if err != nil {
+		return nil, err
+	}
+	if !matches {
+		return nil, nil
+	}
+	if !alg.Algorithm.Equal(oidPublicKeyRSA) {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	return encryptedKey, nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pkcs7 implements the signed-data and enveloped-data content types
+// of the Cryptographic Message Syntax (CMS), as defined in RFC 5652, which
+// extends PKCS #7 version 1.5 (RFC 2315).
+//
+// Messages are produced in DER.  Parsing also accepts BER, including
+// indefinite lengths and constructed OCTET STRINGs, since many
+// implementations produce it.
+package pkcs7
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+)
+
+// Object identifiers of the CMS content types.
+var (
+	OIDData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	OIDSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	OIDEnvelopedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 3}
+	OIDEncryptedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 6}
+)
+
+var (
+	oidAttributeContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAttributeMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidAttributeSigningTime   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+
+	oidPublicKeyRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidPublicKeyDSA   = asn1.ObjectIdentifier{1, 2, 840, 10040, 4, 1}
+	oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+)
+
+// digestAlgorithms maps the hash functions that may be used for signing
+// to their object identifiers.
+var digestAlgorithms = []struct {
+	hash crypto.Hash
+	oid  asn1.ObjectIdentifier
+}{
+	{crypto.SHA1, asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}},
+	{crypto.SHA256, asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}},
+	{crypto.SHA384, asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}},
+	{crypto.SHA512, asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}},
+}
+
+func oidFromHash(h crypto.Hash) (asn1.ObjectIdentifier, bool) {
+	for _, d := range digestAlgorithms {
+		if d.hash == h {
+			return d.oid, true
+		}
+	}
+	return nil, false
+}
+
+func hashFromOID(oid asn1.ObjectIdentifier) (crypto.Hash, bool) {
+	for _, d := range digestAlgorithms {
+		if d.oid.Equal(oid) {
+			return d.hash, true
+		}
+	}
+	return 0, false
+}
+
+// ErrUnsupportedContentType is returned when a message does not have the
+// content type that the called function handles.
+var ErrUnsupportedContentType = errors.New("pkcs7: unsupported content type")
+
+// ErrUnsupportedAlgorithm is returned when a message uses a digest,
+// signature or encryption algorithm that this package does not implement.
+var ErrUnsupportedAlgorithm = errors.New("pkcs7: unsupported algorithm")
+
+// ParseContentInfo parses a ContentInfo structure, the outermost layer of
+// every CMS message, and returns its content type and the encoding of its
+// content.  The content is nil if it is absent.
+func ParseContentInfo(data []byte) (contentType asn1.ObjectIdentifier, content []byte, err error) {
+	p := asn1.NewParser(data)
+	ci, err := p.ReadSequence()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !p.Empty() {
+		return nil, nil, asn1.SyntaxError{Msg: "trailing data"}
+	}
+	if contentType, err = ci.ReadObjectIdentifier(); err != nil {
+		return nil, nil, err
+	}
+	explicit, ok, err := ci.ReadOptionalExplicit(0)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ok {
+		rv, err := explicit.ReadRawValue()
+		if err != nil {
+			return nil, nil, err
+		}
+		content = rv.FullBytes
+	}
+	return contentType, content, nil
+}
+
+// MarshalContentInfo returns the DER encoding of a ContentInfo structure
+// with the given content type and content, which must be a single encoded
+// element.  If content is nil it is omitted.
+func MarshalContentInfo(contentType asn1.ObjectIdentifier, content []byte) ([]byte, error) {
+	var b asn1.Builder
+	b.AddSequence(func(b *asn1.Builder) {
+		b.AddObjectIdentifier(contentType)
+		if content != nil {
+			b.AddExplicit(0, func(b *asn1.Builder) {
+				b.AddRaw(content)
+			})
+		}
+	})
+	return b.Bytes()
+}
+
+// issuerAndSerialNumber identifies a certificate by its issuer and serial
+// number.
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+func (ias *issuerAndSerialNumber) matches(cert *x509.Certificate) bool {
+	return bytes.Equal(ias.Issuer.FullBytes, cert.RawIssuer) && ias.SerialNumber.Cmp(cert.SerialNumber) == 0
+}
+
+func addIssuerAndSerialNumber(b *asn1.Builder, cert *x509.Certificate) {
+	b.AddSequence(func(b *asn1.Builder) {
+		b.AddRaw(cert.RawIssuer)
+		b.AddBigInt(cert.SerialNumber)
+	})
+}
@@ -0,0 +1,261 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkcs7
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"time"
+)
+
+// signatureAlgorithms lists the signature algorithm identifiers found in
+// SignerInfo structures.  Some name only the public key algorithm, in which
+// case hash is zero and the digest algorithm of the SignerInfo applies.
+var signatureAlgorithms = []struct {
+	oid    asn1.ObjectIdentifier
+	pubKey x509.PublicKeyAlgorithm
+	hash   crypto.Hash
+}{
+	{oidPublicKeyRSA, x509.RSA, 0},
+	{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 5}, x509.RSA, crypto.SHA1},
+	{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}, x509.RSA, crypto.SHA256},
+	{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}, x509.RSA, crypto.SHA384},
+	{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}, x509.RSA, crypto.SHA512},
+	{oidPublicKeyDSA, x509.DSA, 0},
+	{asn1.ObjectIdentifier{1, 2, 840, 10040, 4, 3}, x509.DSA, crypto.SHA1},
+	{asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 2}, x509.DSA, crypto.SHA256},
+	{oidPublicKeyECDSA, x509.ECDSA, 0},
+	{asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 1}, x509.ECDSA, crypto.SHA1},
+	{asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}, x509.ECDSA, crypto.SHA256},
+	{asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}, x509.ECDSA, crypto.SHA384},
+	{asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}, x509.ECDSA, crypto.SHA512},
+}
+
+// x509SignatureAlgorithm returns the x509.SignatureAlgorithm that combines
+// the given public key algorithm and hash function.
+func x509SignatureAlgorithm(pubKey x509.PublicKeyAlgorithm, hash crypto.Hash) x509.SignatureAlgorithm {
+	switch pubKey {
+	case x509.RSA:
+		switch hash {
+		case crypto.SHA1:
+			return x509.SHA1WithRSA
+		case crypto.SHA256:
+			return x509.SHA256WithRSA
+		case crypto.SHA384:
+			return x509.SHA384WithRSA
+		case crypto.SHA512:
+			return x509.SHA512WithRSA
+		}
+	case x509.DSA:
+		switch hash {
+		case crypto.SHA1:
+			return x509.DSAWithSHA1
+		case crypto.SHA256:
+			return x509.DSAWithSHA256
+		}
+	case x509.ECDSA:
+		switch hash {
+		case crypto.SHA1:
+			return x509.ECDSAWithSHA1
+		case crypto.SHA256:
+			return x509.ECDSAWithSHA256
+		case crypto.SHA384:
+			return x509.ECDSAWithSHA384
+		case crypto.SHA512:
+			return x509.ECDSAWithSHA512
+		}
+	}
+	return x509.UnknownSignatureAlgorithm
+}
+
+// A Signer is a party that signs a message: a certificate and the private
+// key for its public key.
+type Signer struct {
+	Certificate *x509.Certificate
+	Key         crypto.Signer // an *rsa.PrivateKey or *ecdsa.PrivateKey
+}
+
+// SignOptions contains optional parameters for Sign.
+type SignOptions struct {
+	// Hash is the digest algorithm.  The default is SHA-256.
+	Hash crypto.Hash
+
+	// ContentType is the type of the content being signed.  The default
+	// is OIDData.
+	ContentType asn1.ObjectIdentifier
+
+	// Detached causes the content to be left out of the message, so that
+	// it must be supplied separately to verify the signatures.
+	Detached bool
+
+	// Certificates are included in the message in addition to those of the
+	// signers, typically to supply intermediate certificates.
+	Certificates []*x509.Certificate
+
+	// SigningTime, if not zero, is recorded as a signed attribute.
+	SigningTime time.Time
+}
+
+// Sign returns a DER-encoded ContentInfo holding signed data: content with
+// a signature by each of the signers.  The random source is passed to the
+// signing keys.  If opts is nil, the defaults described in SignOptions
+// apply.
+func Sign(rand io.Reader, content []byte, signers []Signer, opts *SignOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &SignOptions{}
+	}
+	hash := opts.Hash
+	if hash == 0 {
+		hash = crypto.SHA256
+	}
+	digestOID, ok := oidFromHash(hash)
+	if !ok || !hash.Available() {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	contentType := opts.ContentType
+	if contentType == nil {
+		contentType = OIDData
+	}
+	if len(signers) == 0 {
+		return nil, errors.New("pkcs7: no signers")
+	}
+
+	h := hash.New()
+	h.Write(content)
+	digest := h.Sum(nil)
+
+	var signerInfos [][]byte
+	for _, s := range signers {
+		si, err := signerInfo(rand, s, hash, digestOID, digest, contentType, opts.SigningTime)
+		if err != nil {
+			return nil, err
+		}
+		signerInfos = append(signerInfos, si)
+	}
+
+	// RFC 5652, section 5.1.
+	version := int64(1)
+	if !contentType.Equal(OIDData) {
+		version = 3
+	}
+
+	var b asn1.Builder
+	b.AddSequence(func(b *asn1.Builder) {
+		b.AddInt64(version)
+		b.AddSetOf(func(b *asn1.Builder) {
+			addAlgorithmIdentifier(b, digestOID, false)
+		})
+		b.AddSequence(func(b *asn1.Builder) {
+			b.AddObjectIdentifier(contentType)
+			if !opts.Detached {
+				b.AddExplicit(0, func(b *asn1.Builder) {
+					b.AddOctetString(content)
+				})
+			}
+		})
+		b.AddElement(asn1.ClassContextSpecific, 0, func(b *asn1.Builder) {
+			for _, s := range signers {
+				b.AddRaw(s.Certificate.Raw)
+			}
+			for _, c := range opts.Certificates {
+				b.AddRaw(c.Raw)
+			}
+		})
+		b.AddSetOf(func(b *asn1.Builder) {
+			for _, si := range signerInfos {
+				b.AddRaw(si)
+			}
+		})
+	})
+	signedData, err := b.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return MarshalContentInfo(OIDSignedData, signedData)
+}
+
+// signerInfo returns the encoding of a SignerInfo for s.
+func signerInfo(rand io.Reader, s Signer, hash crypto.Hash, digestOID asn1.ObjectIdentifier, digest []byte, contentType asn1.ObjectIdentifier, signingTime time.Time) ([]byte, error) {
+	if s.Certificate == nil || s.Key == nil {
+		return nil, errors.New("pkcs7: signer lacks a certificate or key")
+	}
+
+	var sigOID asn1.ObjectIdentifier
+	switch s.Key.Public().(type) {
+	case *rsa.PublicKey:
+		sigOID = oidPublicKeyRSA
+	case *ecdsa.PublicKey:
+		for _, alg := range signatureAlgorithms {
+			if alg.pubKey == x509.ECDSA && alg.hash == hash {
+				sigOID = alg.oid
+				break
+			}
+		}
+	}
+	if sigOID == nil {
+		return nil, ErrUnsupportedAlgorithm
+	}
+
+	// The signed attributes are encoded as a SET OF for signing and with
+	// an implicit [0] tag in the SignerInfo (RFC 5652, section 5.4).
+	var b asn1.Builder
+	b.AddSetOf(func(b *asn1.Builder) {
+		addAttribute(b, oidAttributeContentType, func(b *asn1.Builder) {
+			b.AddObjectIdentifier(contentType)
+		})
+		addAttribute(b, oidAttributeMessageDigest, func(b *asn1.Builder) {
+			b.AddOctetString(digest)
+		})
+		if !signingTime.IsZero() {
+			addAttribute(b, oidAttributeSigningTime, func(b *asn1.Builder) {
+				b.AddValue(signingTime.UTC(), "")
+			})
+		}
+	})
+	attrs, err := b.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	h := hash.New()
+	h.Write(attrs)
+	signature, err := s.Key.Sign(rand, h.Sum(nil), hash)
+	if err != nil {
+		return nil, err
+	}
+
+	b = asn1.Builder{}
+	b.AddSequence(func(b *asn1.Builder) {
+		b.AddInt64(1)
+		addIssuerAndSerialNumber(b, s.Certificate)
+		addAlgorithmIdentifier(b, digestOID, false)
+		b.AddRaw([]byte{0xa0})
+		b.AddRaw(attrs[1:])
+		addAlgorithmIdentifier(b, sigOID, sigOID.Equal(oidPublicKeyRSA))
+		b.AddOctetString(signature)
+	})
+	return b.Bytes()
+}
+
+func addAttribute(b *asn1.Builder, oid asn1.ObjectIdentifier, value func(*asn1.Builder)) {
+	b.AddSequence(func(b *asn1.Builder) {
+		b.AddObjectIdentifier(oid)
+		b.AddSetOf(value)
+	})
+}
+
+func addAlgorithmIdentifier(b *asn1.Builder, oid asn1.ObjectIdentifier, nullParams bool) {
+	b.AddSequence(func(b *asn1.Builder) {
+		b.AddObjectIdentifier(oid)
+		if nullParams {
+			b.AddNull()
+		}
+	})
+}
@@ -0,0 +1,307 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkcs7
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"time"
+)
+
+// SignedData is a parsed signed-data message.
+type SignedData struct {
+	ContentType  asn1.ObjectIdentifier
+	Content      []byte // nil if the signatures are detached
+	Certificates []*x509.Certificate
+	Signers      []*SignerInfo
+}
+
+// SignerInfo describes one of the signatures of a SignedData.
+type SignerInfo struct {
+	// The signer is identified either by the issuer and serial number
+	// of its certificate or by its subject key identifier.
+	RawIssuer    []byte
+	SerialNumber *big.Int
+	SubjectKeyId []byte
+
+	// Hash is the digest algorithm, or zero if it is not supported.
+	Hash crypto.Hash
+
+	// SigningTime is the value of the signing time attribute, if any.
+	SigningTime time.Time
+
+	// Certificate is the signer's certificate, if the message contains it.
+	Certificate *x509.Certificate
+
+	sigAlgorithm  asn1.ObjectIdentifier
+	signature     []byte
+	signedAttrs   []byte // DER encoding of the signed attributes as a SET OF
+	contentType   asn1.ObjectIdentifier
+	messageDigest []byte
+}
+
+// ParseSignedData parses a BER- or DER-encoded ContentInfo holding signed
+// data.  The signatures are not checked.
+func ParseSignedData(data []byte) (*SignedData, error) {
+	contentType, content, err := ParseContentInfo(data)
+	if err != nil {
+		return nil, err
+	}
+	if !contentType.Equal(OIDSignedData) || content == nil {
+		return nil, ErrUnsupportedContentType
+	}
+
+	p := asn1.NewParser(content)
+	seq, err := p.ReadSequence()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := seq.ReadInt64(); err != nil {
+		return nil, err
+	}
+	if _, err := seq.ReadSet(); err != nil {
+		return nil, err
+	}
+
+	sd := new(SignedData)
+	encap, err := seq.ReadSequence()
+	if err != nil {
+		return nil, err
+	}
+	if sd.ContentType, err = encap.ReadObjectIdentifier(); err != nil {
+		return nil, err
+	}
+	econtent, ok, err := encap.ReadOptionalExplicit(0)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if sd.Content, err = readContent(econtent); err != nil {
+			return nil, err
+		}
+	}
+
+	// The certificates and crls fields are sets with implicit tags, so
+	// their elements can be read as if the tags were explicit.
+	if certs, ok, err := seq.ReadOptionalExplicit(0); err != nil {
+		return nil, err
+	} else if ok {
+		for !certs.Empty() {
+			rv, err := certs.ReadRawValue()
+			if err != nil {
+				return nil, err
+			}
+			// Other certificate formats, such as attribute
+			// certificates, have different tags and are skipped.
+			if rv.Class != asn1.ClassUniversal || rv.Tag != asn1.TagSequence {
+				continue
+			}
+			cert, err := x509.ParseCertificate(rv.FullBytes)
+			if err != nil {
+				return nil, err
+			}
+			sd.Certificates = append(sd.Certificates, cert)
+		}
+	}
+	if _, _, err := seq.ReadOptionalExplicit(1); err != nil {
+		return nil, err
+	}
+
+	infos, err := seq.ReadSet()
+	if err != nil {
+		return nil, err
+	}
+	for !infos.Empty() {
+		si, err := parseSignerInfo(infos)
+		if err != nil {
+			return nil, err
+		}
+		for _, cert := range sd.Certificates {
+			if si.identifies(cert) {
+				si.Certificate = cert
+				break
+			}
+		}
+		sd.Signers = append(sd.Signers, si)
+	}
+	return sd, nil
+}
+
+// readContent reads the content of an EncapsulatedContentInfo.  It is an
+// OCTET STRING, except in some messages following PKCS #7 version 1.5,
+// where it may be of any type; then the contents octets of the value are
+// the content (RFC 2315, section 9.3).
+func readContent(p *asn1.Parser) ([]byte, error) {
+	if p.Peek(asn1.ClassUniversal, asn1.TagOctetString) {
+		return p.ReadOctetString()
+	}
+	rv, err := p.ReadRawValue()
+	if err != nil {
+		return nil, err
+	}
+	return rv.Bytes, nil
+}
+
+func parseSignerInfo(p *asn1.Parser) (*SignerInfo, error) {
+	seq, err := p.ReadSequence()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := seq.ReadInt64(); err != nil {
+		return nil, err
+	}
+
+	si := new(SignerInfo)
+	if seq.Peek(asn1.ClassContextSpecific, 0) {
+		if si.SubjectKeyId, err = seq.ReadImplicitOctetString(0); err != nil {
+			return nil, err
+		}
+	} else {
+		var ias issuerAndSerialNumber
+		if err := seq.ReadValue(&ias, ""); err != nil {
+			return nil, err
+		}
+		si.RawIssuer = ias.Issuer.FullBytes
+		si.SerialNumber = ias.SerialNumber
+	}
+
+	var digestAlgorithm pkix.AlgorithmIdentifier
+	if err := seq.ReadValue(&digestAlgorithm, ""); err != nil {
+		return nil, err
+	}
+	si.Hash, _ = hashFromOID(digestAlgorithm.Algorithm)
+
+	if seq.Peek(asn1.ClassContextSpecific, 0) {
+		rv, err := seq.ReadRawValue()
+		if err != nil {
+			return nil, err
+		}
+		if err := si.parseSignedAttributes(rv.Bytes); err != nil {
+			return nil, err
+		}
+		// The signature covers the attributes encoded as a SET OF.
+		si.signedAttrs = append([]byte{0x31}, rv.FullBytes[1:]...)
+	}
+
+	var sigAlgorithm pkix.AlgorithmIdentifier
+	if err := seq.ReadValue(&sigAlgorithm, ""); err != nil {
+		return nil, err
+	}
+	si.sigAlgorithm = sigAlgorithm.Algorithm
+	if si.signature, err = seq.ReadOctetString(); err != nil {
+		return nil, err
+	}
+	return si, nil
+}
+
+func (si *SignerInfo) parseSignedAttributes(data []byte) error {
+	p := asn1.NewParser(data)
+	for !p.Empty() {
+		attr, err := p.ReadSequence()
+		if err != nil {
+			return err
+		}
+		oid, err := attr.ReadObjectIdentifier()
+		if err != nil {
+			return err
+		}
+		values, err := attr.ReadSet()
+		if err != nil {
+			return err
+		}
+		switch {
+		case oid.Equal(oidAttributeContentType):
+			si.contentType, err = values.ReadObjectIdentifier()
+		case oid.Equal(oidAttributeMessageDigest):
+			si.messageDigest, err = values.ReadOctetString()
+		case oid.Equal(oidAttributeSigningTime):
+			err = values.ReadValue(&si.SigningTime, "")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// identifies reports whether si names cert as the signer's certificate.
+func (si *SignerInfo) identifies(cert *x509.Certificate) bool {
+	if si.SubjectKeyId != nil {
+		return bytes.Equal(si.SubjectKeyId, cert.SubjectKeyId)
+	}
+	ias := issuerAndSerialNumber{asn1.RawValue{FullBytes: si.RawIssuer}, si.SerialNumber}
+	return ias.matches(cert)
+}
+
+// Verify checks the signatures of sd over its content.  It does not check
+// that the signers' certificates are valid; callers should use
+// x509.Certificate.Verify, with sd.Certificates as intermediates, for that.
+func (sd *SignedData) Verify() error {
+	if sd.Content == nil {
+		return errors.New("pkcs7: content is detached")
+	}
+	return sd.verify(sd.Content)
+}
+
+// VerifyDetached checks the signatures of sd over content, which was
+// supplied separately from the message.
+func (sd *SignedData) VerifyDetached(content []byte) error {
+	return sd.verify(content)
+}
+
+func (sd *SignedData) verify(content []byte) error {
+	if len(sd.Signers) == 0 {
+		return errors.New("pkcs7: message has no signers")
+	}
+	for _, si := range sd.Signers {
+		if err := si.verify(sd.ContentType, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (si *SignerInfo) verify(contentType asn1.ObjectIdentifier, content []byte) error {
+	cert := si.Certificate
+	if cert == nil {
+		return errors.New("pkcs7: signer's certificate not found")
+	}
+	if si.Hash == 0 || !si.Hash.Available() {
+		return ErrUnsupportedAlgorithm
+	}
+	sigHash := crypto.Hash(0)
+	for _, alg := range signatureAlgorithms {
+		if alg.oid.Equal(si.sigAlgorithm) && alg.pubKey == cert.PublicKeyAlgorithm {
+			sigHash = alg.hash
+			if sigHash == 0 {
+				sigHash = si.Hash
+			}
+			break
+		}
+	}
+	algo := x509SignatureAlgorithm(cert.PublicKeyAlgorithm, sigHash)
+	if algo == x509.UnknownSignatureAlgorithm {
+		return ErrUnsupportedAlgorithm
+	}
+
+	signed := content
+	if si.signedAttrs != nil {
+		h := si.Hash.New()
+		h.Write(content)
+		if si.messageDigest == nil || !bytes.Equal(si.messageDigest, h.Sum(nil)) {
+			return errors.New("pkcs7: message digest mismatch")
+		}
+		if !si.contentType.Equal(contentType) {
+			return errors.New("pkcs7: content type attribute mismatch")
+		}
+		signed = si.signedAttrs
+	}
+	return cert.CheckSignature(algo, signed, si.signature)
+}
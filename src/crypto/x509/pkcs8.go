@@ -5,6 +5,8 @@
 package x509
 
 import (
+	"crypto/ecdsa"
+	"crypto/rsa"
 	"crypto/x509/pkix"
 	"encoding/asn1"
 	"errors"
@@ -52,3 +54,31 @@ func ParsePKCS8PrivateKey(der []byte) (key interface{}, err error) {
 		return nil, fmt.Errorf("x509: PKCS#8 wrapping contained private key with unknown algorithm: %v", privKey.Algo.Algorithm)
 	}
 }
+
+// MarshalPKCS8PrivateKey converts a private key to PKCS#8 encoded form.
+// The key must be an *rsa.PrivateKey or an *ecdsa.PrivateKey. See RFC5208.
+func MarshalPKCS8PrivateKey(key interface{}) ([]byte, error) {
+	var privKey pkcs8
+	var err error
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if _, privKey.Algo, err = marshalPublicKey(&k.PublicKey); err != nil {
+			return nil, err
+		}
+		privKey.PrivateKey = MarshalPKCS1PrivateKey(k)
+
+	case *ecdsa.PrivateKey:
+		if _, privKey.Algo, err = marshalPublicKey(&k.PublicKey); err != nil {
+			return nil, err
+		}
+		// The curve is named by the algorithm parameters, so it is
+		// left out of the ECPrivateKey, as RFC 5915 suggests.
+		if privKey.PrivateKey, err = marshalECPrivateKeyWithOID(k, nil); err != nil {
+			return nil, errors.New("x509: failed to marshal EC private key while building PKCS#8: " + err.Error())
+		}
+
+	default:
+		return nil, fmt.Errorf("x509: unknown key type while marshaling PKCS#8: %T", key)
+	}
+	return asn1.Marshal(privKey)
+}
@@ -5,6 +5,8 @@
 package x509
 
 import (
+	"bytes"
+	"crypto/dsa"
 	"encoding/hex"
 	"testing"
 )
@@ -26,3 +28,25 @@ func TestPKCS8(t *testing.T) {
 		t.Errorf("failed to decode PKCS8 with EC private key: %s", err)
 	}
 }
+
+func TestMarshalPKCS8(t *testing.T) {
+	for _, h := range []string{pkcs8RSAPrivateKeyHex, pkcs8ECPrivateKeyHex} {
+		derBytes, _ := hex.DecodeString(h)
+		key, err := ParsePKCS8PrivateKey(derBytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Errorf("failed to marshal %T: %s", key, err)
+			continue
+		}
+		if !bytes.Equal(der, derBytes) {
+			t.Errorf("%T: marshaled PKCS#8 differs:\n got %x\nwant %x", key, der, derBytes)
+		}
+	}
+
+	if _, err := MarshalPKCS8PrivateKey(new(dsa.PrivateKey)); err == nil {
+		t.Error("MarshalPKCS8PrivateKey accepted a DSA key")
+	}
+}
@@ -39,6 +39,12 @@ func MarshalECPrivateKey(key *ecdsa.PrivateKey) ([]byte, error) {
 	if !ok {
 		return nil, errors.New("x509: unknown elliptic curve")
 	}
+	return marshalECPrivateKeyWithOID(key, oid)
+}
+
+// marshalECPrivateKeyWithOID marshals an EC private key into ASN.1, DER
+// format and sets the curve ID to the given OID, or omits it if OID is nil.
+func marshalECPrivateKeyWithOID(key *ecdsa.PrivateKey, oid asn1.ObjectIdentifier) ([]byte, error) {
 	return asn1.Marshal(ecPrivateKey{
 		Version:       1,
 		PrivateKey:    key.D.Bytes(),
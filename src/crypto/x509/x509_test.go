@@ -246,6 +246,22 @@ func TestCertificateParse(t *testing.T) {
 	}
 }
 
+func TestParseCertificateBER(t *testing.T) {
+	s, _ := hex.DecodeString(certBytes)
+	der := s[:4+0x322] // the first certificate, 30 82 03 22 ...
+	if _, err := ParseCertificate(der); err != nil {
+		t.Fatal(err)
+	}
+
+	// The same certificate, with the outer SEQUENCE in the BER
+	// indefinite-length form, is not DER and must be rejected.
+	ber := append([]byte{0x30, 0x80}, der[4:]...)
+	ber = append(ber, 0x00, 0x00)
+	if _, err := ParseCertificate(ber); err == nil {
+		t.Error("ParseCertificate accepted a BER-encoded certificate")
+	}
+}
+
 var certBytes = "308203223082028ba00302010202106edf0d9499fd4533dd1297fc42a93be1300d06092a864886" +
 	"f70d0101050500304c310b3009060355040613025a4131253023060355040a131c546861777465" +
 	"20436f6e73756c74696e67202850747929204c74642e311630140603550403130d546861777465" +
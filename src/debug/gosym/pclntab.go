@@ -451,3 +451,66 @@ func (t *LineTable) go12MapFiles(m map[string]*Obj, obj *Obj) {
 		m[file] = obj
 	}
 }
+
+// Indexes of the tables that describe inlined calls, as in
+// runtime/funcdata.h.
+const (
+	go12PCDataInlTreeIndex = 1
+	go12FuncDataInlTree    = 3
+)
+
+// go12PCToFrames maps program counter to the frames at it for the
+// Go 1.2 pcln table: the calls inlined at pc, innermost first,
+// followed by the function containing pc.
+func (t *LineTable) go12PCToFrames(pc uint64) (frames []Frame) {
+	defer func() {
+		if recover() != nil {
+			frames = nil
+		}
+	}()
+
+	f := t.findFunc(pc)
+	if f == nil {
+		return nil
+	}
+	entry := t.uintptr(f)
+	filetab := t.binary.Uint32(f[t.ptrsize+4*4:])
+	linetab := t.binary.Uint32(f[t.ptrsize+5*4:])
+	file := ""
+	if fno := t.pcvalue(filetab, entry, pc); fno > 0 {
+		file = t.string(t.binary.Uint32(t.filetab[4*fno:]))
+	}
+	line := int(t.pcvalue(linetab, entry, pc))
+
+	// The func structure continues with npcdata and nfuncdata,
+	// the pcdata offsets, and then the pointer-aligned funcdata.
+	npcdata := t.binary.Uint32(f[t.ptrsize+6*4:])
+	nfuncdata := t.binary.Uint32(f[t.ptrsize+7*4:])
+	if npcdata > go12PCDataInlTreeIndex && nfuncdata > go12FuncDataInlTree {
+		off := t.ptrsize + 8*4 + npcdata*4
+		off += -off & (t.ptrsize - 1)
+		tree := uint32(t.uintptr(f[off+go12FuncDataInlTree*t.ptrsize:]))
+		ix := t.pcvalue(t.binary.Uint32(f[t.ptrsize+8*4+go12PCDataInlTreeIndex*4:]), entry, pc)
+		if tree != 0 {
+			n := int32(t.binary.Uint32(t.Data[tree:]))
+			for ix >= 0 && ix < n {
+				// Each node is the parent index, file number,
+				// line and name offset of an inlined call.
+				c := t.Data[tree+4+uint32(ix)*16:]
+				frames = append(frames, Frame{
+					Func: t.string(t.binary.Uint32(c[12:])),
+					File: file,
+					Line: line,
+				})
+				file = t.string(t.binary.Uint32(t.filetab[4*t.binary.Uint32(c[4:]):]))
+				line = int(int32(t.binary.Uint32(c[8:])))
+				ix = int32(t.binary.Uint32(c))
+			}
+		}
+	}
+	return append(frames, Frame{
+		Func: t.string(t.binary.Uint32(f[t.ptrsize:])),
+		File: file,
+		Line: line,
+	})
+}
@@ -272,3 +272,54 @@ func TestPCLine(t *testing.T) {
 		off = pc + 1 - text.Addr
 	}
 }
+
+// pcToFramesIndex is small enough for the compiler to inline.
+func pcToFramesIndex(b []byte, i int) byte {
+	return b[i]
+}
+
+// pcToFramesCaller returns the program counters of the goroutine
+// as it panics in the call to pcToFramesIndex inlined in it.
+func pcToFramesCaller(b []byte, i int) (pcs []uintptr) {
+	defer func() {
+		recover()
+		pcs = make([]uintptr, 20)
+		pcs = pcs[:runtime.Callers(0, pcs)]
+	}()
+	pcToFramesIndex(b, i)
+	return nil
+}
+
+func TestPCToFrames(t *testing.T) {
+	if !dotest(true) {
+		return
+	}
+	defer endtest()
+
+	tab := getTable(t)
+	if tab.go12line == nil {
+		t.Skip("inlining tables exist only in the Go 1.2 symbol table")
+	}
+	for _, pc := range pcToFramesCaller(nil, 1) {
+		fn := tab.PCToFunc(uint64(pc))
+		if fn == nil || !strings.HasSuffix(fn.Name, ".pcToFramesCaller") {
+			continue
+		}
+		frames := tab.PCToFrames(uint64(pc - 1))
+		if len(frames) != 2 ||
+			!strings.HasSuffix(frames[0].Func, ".pcToFramesIndex") ||
+			frames[1].Func != fn.Name {
+			t.Fatalf("PCToFrames(%#x) = %+v, want pcToFramesIndex inlined in %s", pc-1, frames, fn.Name)
+		}
+		for _, f := range frames {
+			if !strings.HasSuffix(f.File, "pclntab_test.go") || f.Line <= 0 {
+				t.Errorf("PCToFrames(%#x): bad position in %+v", pc-1, f)
+			}
+		}
+		if frames[0].Line >= frames[1].Line {
+			t.Errorf("PCToFrames(%#x): inlined body at line %d not before call at line %d", pc-1, frames[0].Line, frames[1].Line)
+		}
+		return
+	}
+	t.Fatalf("pcToFramesCaller not found on the stack")
+}
@@ -502,6 +502,31 @@ func (t *Table) PCToLine(pc uint64) (file string, line int, fn *Func) {
 	return
 }
 
+// A Frame is one of the logical frames at a program counter:
+// a call that the compiler inlined at it, or the function containing it.
+type Frame struct {
+	Func string // name of the function called
+	File string
+	Line int
+}
+
+// PCToFrames looks up the frames at a program counter.  The frames are
+// the calls that the compiler inlined at pc, innermost first, followed
+// by the function containing pc.  The file and line of each frame are
+// those of pc for the first frame and those of the call for the others.
+// If there is no information, it returns nil.
+func (t *Table) PCToFrames(pc uint64) []Frame {
+	fn := t.PCToFunc(pc)
+	if fn == nil {
+		return nil
+	}
+	if t.go12line != nil {
+		return t.go12line.go12PCToFrames(pc)
+	}
+	file, line := fn.Obj.lineFromAline(fn.LineTable.PCToLine(pc))
+	return []Frame{{Func: fn.Name, File: file, Line: line}}
+}
+
 // LineToPC looks up the first program counter on the given line in
 // the named file.  It returns UnknownPathError or UnknownLineError if
 // there is an error looking up this line.
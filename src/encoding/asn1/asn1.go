@@ -3,7 +3,8 @@
 // license that can be found in the LICENSE file.
 
 // Package asn1 implements parsing of DER-encoded ASN.1 data structures,
-// as defined in ITU-T Rec X.690.
+// as defined in ITU-T Rec X.690.  A Parser, and Unmarshal when asked to,
+// also accepts the indefinite-length form of BER for constructed values.
 //
 // See also ``A Layman's Guide to a Subset of ASN.1, BER, and DER,''
 // http://luca.ntop.org/Teaching/Appunti/asn1.html.
@@ -25,6 +26,8 @@ import (
 	"reflect"
 	"strconv"
 	"time"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 // A StructuralError suggests that the ASN.1 data is valid, but the Go type
@@ -252,6 +255,86 @@ func parseObjectIdentifier(bytes []byte) (s []int, err error) {
 	return
 }
 
+// A BigObjectIdentifier represents an ASN.1 OBJECT IDENTIFIER whose
+// components may be too large for an int, such as the identifiers derived
+// from UUIDs in the arc 2.25.
+type BigObjectIdentifier []*big.Int
+
+// Equal reports whether oi and other represent the same identifier.
+func (oi BigObjectIdentifier) Equal(other BigObjectIdentifier) bool {
+	if len(oi) != len(other) {
+		return false
+	}
+	for i := range oi {
+		if oi[i].Cmp(other[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (oi BigObjectIdentifier) String() string {
+	var s string
+	for i, v := range oi {
+		if i > 0 {
+			s += "."
+		}
+		s += v.String()
+	}
+	return s
+}
+
+// ObjectIdentifier returns oi as an ObjectIdentifier.  It reports false
+// if a component of oi is negative or too large for an int.
+func (oi BigObjectIdentifier) ObjectIdentifier() (ObjectIdentifier, bool) {
+	r := make(ObjectIdentifier, len(oi))
+	for i, v := range oi {
+		if v.Sign() < 0 || v.BitLen() >= strconv.IntSize {
+			return nil, false
+		}
+		r[i] = int(v.Int64())
+	}
+	return r, true
+}
+
+// parseBigObjectIdentifier is like parseObjectIdentifier but places no limit
+// on the size of the components.
+func parseBigObjectIdentifier(bytes []byte) (s BigObjectIdentifier, err error) {
+	if len(bytes) == 0 {
+		err = SyntaxError{"zero length OBJECT IDENTIFIER"}
+		return
+	}
+	for offset := 0; offset < len(bytes); {
+		v := new(big.Int)
+		for {
+			if offset == len(bytes) {
+				err = SyntaxError{"truncated base 128 integer"}
+				return
+			}
+			b := bytes[offset]
+			offset++
+			v.Lsh(v, 7)
+			v.Or(v, big.NewInt(int64(b&0x7f)))
+			if b&0x80 == 0 {
+				break
+			}
+		}
+		if len(s) > 0 {
+			s = append(s, v)
+			continue
+		}
+		// The first component is 40*value1 + value2, as in
+		// parseObjectIdentifier.
+		if v.Cmp(big.NewInt(80)) < 0 {
+			x := v.Int64()
+			s = append(s, big.NewInt(x/40), big.NewInt(x%40))
+		} else {
+			s = append(s, big.NewInt(2), v.Sub(v, big.NewInt(80)))
+		}
+	}
+	return
+}
+
 // ENUMERATED
 
 // An Enumerated is represented as a plain int.
@@ -368,6 +451,47 @@ func parseUTF8String(bytes []byte) (ret string, err error) {
 	return string(bytes), nil
 }
 
+// BMPString
+
+// parseBMPString parses an ASN.1 BMPString (UCS-2, big-endian) from the
+// given byte array and returns it.
+func parseBMPString(bytes []byte) (ret string, err error) {
+	if len(bytes)%2 != 0 {
+		err = SyntaxError{"odd-length BMPString"}
+		return
+	}
+	s := make([]uint16, len(bytes)/2)
+	for i := range s {
+		s[i] = uint16(bytes[2*i])<<8 | uint16(bytes[2*i+1])
+		if utf16.IsSurrogate(rune(s[i])) {
+			err = SyntaxError{"BMPString contains surrogate"}
+			return
+		}
+	}
+	return string(utf16.Decode(s)), nil
+}
+
+// UniversalString
+
+// parseUniversalString parses an ASN.1 UniversalString (UCS-4, big-endian)
+// from the given byte array and returns it.
+func parseUniversalString(bytes []byte) (ret string, err error) {
+	if len(bytes)%4 != 0 {
+		err = SyntaxError{"UniversalString length not a multiple of four"}
+		return
+	}
+	r := make([]rune, len(bytes)/4)
+	for i := range r {
+		b := bytes[4*i:]
+		r[i] = rune(b[0])<<24 | rune(b[1])<<16 | rune(b[2])<<8 | rune(b[3])
+		if !utf8.ValidRune(r[i]) {
+			err = SyntaxError{"UniversalString contains invalid character"}
+			return
+		}
+	}
+	return string(r), nil
+}
+
 // A RawValue represents an undecoded ASN.1 object.
 type RawValue struct {
 	Class, Tag int
@@ -388,6 +512,17 @@ type RawContent []byte
 // SET OF (tag 17) are mapped to SEQUENCE and SEQUENCE OF (tag 16) since we
 // don't distinguish between ordered and unordered objects in this code.
 func parseTagAndLength(bytes []byte, initOffset int) (ret tagAndLength, offset int, err error) {
+	return parseTagAndLengthForm(bytes, initOffset, false)
+}
+
+// indefiniteLength is the length that parseTagAndLengthForm returns for
+// an element in the BER indefinite-length form.
+const indefiniteLength = -1
+
+// parseTagAndLengthForm is like parseTagAndLength but, if indefinite is
+// set, accepts the indefinite-length form for constructed elements and
+// returns indefiniteLength as their length.
+func parseTagAndLengthForm(bytes []byte, initOffset int, indefinite bool) (ret tagAndLength, offset int, err error) {
 	offset = initOffset
 	b := bytes[offset]
 	offset++
@@ -416,7 +551,14 @@ func parseTagAndLength(bytes []byte, initOffset int) (ret tagAndLength, offset i
 		// Bottom 7 bits give the number of length bytes to follow.
 		numBytes := int(b & 0x7f)
 		if numBytes == 0 {
-			err = SyntaxError{"indefinite length found (not DER)"}
+			switch {
+			case !indefinite:
+				err = SyntaxError{"indefinite length found (not DER)"}
+			case !ret.isCompound:
+				err = SyntaxError{"indefinite length on primitive element"}
+			default:
+				ret.length = indefiniteLength
+			}
 			return
 		}
 		ret.length = 0
@@ -446,10 +588,54 @@ func parseTagAndLength(bytes []byte, initOffset int) (ret tagAndLength, offset i
 	return
 }
 
+// parseElement parses the tag and length of the element at the given offset
+// into a byte slice, accepting the BER indefinite-length form for
+// constructed elements if ber is set.  It returns the parsed data, in which
+// the length is that of the contents, the offset of the contents, and the
+// offset just past the element, including any end-of-contents marker.
+func parseElement(bytes []byte, initOffset int, ber bool) (ret tagAndLength, offset, end int, err error) {
+	if initOffset >= len(bytes) {
+		err = SyntaxError{"data truncated"}
+		return
+	}
+	ret, offset, err = parseTagAndLengthForm(bytes, initOffset, ber)
+	if err != nil {
+		return
+	}
+	if ret.length != indefiniteLength {
+		if invalidLength(offset, ret.length, len(bytes)) {
+			err = SyntaxError{"data truncated"}
+			return
+		}
+		end = offset + ret.length
+		return
+	}
+
+	// The contents run up to an end-of-contents marker, two zero
+	// bytes, skipping nested elements, which may themselves be of
+	// indefinite length.
+	end = offset
+	for {
+		if end+2 > len(bytes) {
+			err = SyntaxError{"missing end-of-contents marker"}
+			return
+		}
+		if bytes[end] == 0 && bytes[end+1] == 0 {
+			ret.length = end - offset
+			end += 2
+			return
+		}
+		_, _, end, err = parseElement(bytes, end, true)
+		if err != nil {
+			return
+		}
+	}
+}
+
 // parseSequenceOf is used for SEQUENCE OF and SET OF values. It tries to parse
 // a number of ASN.1 values from the given byte slice and returns them as a
 // slice of Go values of the given type.
-func parseSequenceOf(bytes []byte, sliceType reflect.Type, elemType reflect.Type) (ret reflect.Value, err error) {
+func parseSequenceOf(bytes []byte, sliceType reflect.Type, elemType reflect.Type, ber bool) (ret reflect.Value, err error) {
 	expectedTag, compoundType, ok := getUniversalType(elemType)
 	if !ok {
 		err = StructuralError{"unknown Go type for slice"}
@@ -461,34 +647,29 @@ func parseSequenceOf(bytes []byte, sliceType reflect.Type, elemType reflect.Type
 	numElements := 0
 	for offset := 0; offset < len(bytes); {
 		var t tagAndLength
-		t, offset, err = parseTagAndLength(bytes, offset)
+		t, _, offset, err = parseElement(bytes, offset, ber)
 		if err != nil {
 			return
 		}
 		switch t.tag {
-		case tagIA5String, tagGeneralString, tagT61String, tagUTF8String:
+		case TagIA5String, TagGeneralString, TagT61String, TagUTF8String, TagBMPString, TagUniversalString:
 			// We pretend that various other string types are
 			// PRINTABLE STRINGs so that a sequence of them can be
 			// parsed into a []string.
-			t.tag = tagPrintableString
-		case tagGeneralizedTime, tagUTCTime:
+			t.tag = TagPrintableString
+		case TagGeneralizedTime, TagUTCTime:
 			// Likewise, both time types are treated the same.
-			t.tag = tagUTCTime
+			t.tag = TagUTCTime
 		}
 
-		if t.class != classUniversal || t.isCompound != compoundType || t.tag != expectedTag {
+		if t.class != ClassUniversal || t.isCompound != compoundType || t.tag != expectedTag {
 			err = StructuralError{"sequence tag mismatch"}
 			return
 		}
-		if invalidLength(offset, t.length, len(bytes)) {
-			err = SyntaxError{"truncated sequence"}
-			return
-		}
-		offset += t.length
 		numElements++
 	}
 	ret = reflect.MakeSlice(sliceType, numElements, numElements)
-	params := fieldParameters{}
+	params := fieldParameters{ber: ber}
 	offset := 0
 	for i := 0; i < numElements; i++ {
 		offset, err = parseField(ret.Index(i), bytes, offset, params)
@@ -508,6 +689,8 @@ var (
 	rawValueType         = reflect.TypeOf(RawValue{})
 	rawContentsType      = reflect.TypeOf(RawContent(nil))
 	bigIntType           = reflect.TypeOf(new(big.Int))
+
+	bigObjectIdentifierType = reflect.TypeOf(BigObjectIdentifier{})
 )
 
 // invalidLength returns true iff offset + length > sliceLength, or if the
@@ -531,61 +714,115 @@ func parseField(v reflect.Value, bytes []byte, initOffset int, params fieldParam
 		return
 	}
 
-	// Deal with raw values.
-	if fieldType == rawValueType {
-		var t tagAndLength
-		t, offset, err = parseTagAndLength(bytes, offset)
-		if err != nil {
-			return
+	t, offset, end, err := parseElement(bytes, offset, params.ber)
+	if err != nil {
+		return
+	}
+	start := initOffset
+	if params.explicit {
+		expectedClass := ClassContextSpecific
+		if params.application {
+			expectedClass = ClassApplication
 		}
-		if invalidLength(offset, t.length, len(bytes)) {
-			err = SyntaxError{"data truncated"}
+		if t.class == expectedClass && t.tag == *params.tag && (t.length == 0 || t.isCompound) {
+			if t.length == 0 {
+				if fieldType != flagType {
+					err = StructuralError{"zero length explicit tag was not an asn1.Flag"}
+					return
+				}
+				v.SetBool(true)
+				offset = end
+				return
+			}
+			// Unwrap the explicit tag.  Parsing continues after the
+			// wrapped element or, if the tag has indefinite length,
+			// after its end-of-contents marker.
+			outerEnd := end
+			indefinite := outerEnd != offset+t.length
+			start = offset
+			t, offset, end, err = parseElement(bytes, offset, params.ber)
+			if err != nil {
+				return
+			}
+			if indefinite {
+				end = outerEnd
+			}
+		} else {
+			// The tags didn't match, it might be an optional element.
+			ok := setDefaultValue(v, params)
+			if ok {
+				offset = initOffset
+			} else {
+				err = StructuralError{"explicitly tagged member didn't match"}
+			}
 			return
 		}
-		result := RawValue{t.class, t.tag, t.isCompound, bytes[offset : offset+t.length], bytes[initOffset : offset+t.length]}
-		offset += t.length
+	}
+	innerBytes := bytes[offset : offset+t.length]
+
+	// Deal with raw values.  Without a tag, a RawValue matches any
+	// element; an implicit tag is checked like that of any other value.
+	if fieldType == rawValueType {
+		if !params.explicit && params.tag != nil {
+			expectedClass := ClassContextSpecific
+			if params.application {
+				expectedClass = ClassApplication
+			}
+			if t.class != expectedClass || t.tag != *params.tag {
+				ok := setDefaultValue(v, params)
+				if ok {
+					offset = initOffset
+				} else {
+					err = StructuralError{"implicitly tagged member didn't match"}
+				}
+				return
+			}
+		}
+		result := RawValue{t.class, t.tag, t.isCompound, innerBytes, bytes[start:end]}
+		offset = end
 		v.Set(reflect.ValueOf(result))
 		return
 	}
 
 	// Deal with the ANY type.
 	if ifaceType := fieldType; ifaceType.Kind() == reflect.Interface && ifaceType.NumMethod() == 0 {
-		var t tagAndLength
-		t, offset, err = parseTagAndLength(bytes, offset)
-		if err != nil {
-			return
-		}
-		if invalidLength(offset, t.length, len(bytes)) {
-			err = SyntaxError{"data truncated"}
-			return
-		}
 		var result interface{}
-		if !t.isCompound && t.class == classUniversal {
-			innerBytes := bytes[offset : offset+t.length]
+		if !t.isCompound && t.class == ClassUniversal {
 			switch t.tag {
-			case tagPrintableString:
+			case TagPrintableString:
 				result, err = parsePrintableString(innerBytes)
-			case tagIA5String:
+			case TagIA5String:
 				result, err = parseIA5String(innerBytes)
-			case tagT61String:
+			case TagT61String:
 				result, err = parseT61String(innerBytes)
-			case tagUTF8String:
+			case TagUTF8String:
 				result, err = parseUTF8String(innerBytes)
-			case tagInteger:
+			case TagBMPString:
+				result, err = parseBMPString(innerBytes)
+			case TagUniversalString:
+				result, err = parseUniversalString(innerBytes)
+			case TagInteger:
 				result, err = parseInt64(innerBytes)
-			case tagBitString:
+			case TagBitString:
 				result, err = parseBitString(innerBytes)
-			case tagOID:
-				result, err = parseObjectIdentifier(innerBytes)
-			case tagUTCTime:
+			case TagOID:
+				var oid []int
+				oid, err = parseObjectIdentifier(innerBytes)
+				if err == nil {
+					result = ObjectIdentifier(oid)
+				} else if _, ok := err.(StructuralError); ok {
+					// A component is too large for an int.
+					result, err = parseBigObjectIdentifier(innerBytes)
+				}
+			case TagUTCTime:
 				result, err = parseUTCTime(innerBytes)
-			case tagOctetString:
+			case TagOctetString:
 				result = innerBytes
 			default:
 				// If we don't know how to handle the type, we just leave Value as nil.
 			}
 		}
-		offset += t.length
+		offset = end
 		if err != nil {
 			return
 		}
@@ -600,49 +837,14 @@ func parseField(v reflect.Value, bytes []byte, initOffset int, params fieldParam
 		return
 	}
 
-	t, offset, err := parseTagAndLength(bytes, offset)
-	if err != nil {
-		return
-	}
-	if params.explicit {
-		expectedClass := classContextSpecific
-		if params.application {
-			expectedClass = classApplication
-		}
-		if t.class == expectedClass && t.tag == *params.tag && (t.length == 0 || t.isCompound) {
-			if t.length > 0 {
-				t, offset, err = parseTagAndLength(bytes, offset)
-				if err != nil {
-					return
-				}
-			} else {
-				if fieldType != flagType {
-					err = StructuralError{"zero length explicit tag was not an asn1.Flag"}
-					return
-				}
-				v.SetBool(true)
-				return
-			}
-		} else {
-			// The tags didn't match, it might be an optional element.
-			ok := setDefaultValue(v, params)
-			if ok {
-				offset = initOffset
-			} else {
-				err = StructuralError{"explicitly tagged member didn't match"}
-			}
-			return
-		}
-	}
-
 	// Special case for strings: all the ASN.1 string types map to the Go
 	// type string. getUniversalType returns the tag for PrintableString
 	// when it sees a string, so if we see a different string type on the
 	// wire, we change the universal type to match.
-	if universalTag == tagPrintableString {
-		if t.class == classUniversal {
+	if universalTag == TagPrintableString {
+		if t.class == ClassUniversal {
 			switch t.tag {
-			case tagIA5String, tagGeneralString, tagT61String, tagUTF8String:
+			case TagIA5String, TagGeneralString, TagT61String, TagUTF8String, TagBMPString, TagUniversalString:
 				universalTag = t.tag
 			}
 		} else if params.stringType != 0 {
@@ -652,24 +854,24 @@ func parseField(v reflect.Value, bytes []byte, initOffset int, params fieldParam
 
 	// Special case for time: UTCTime and GeneralizedTime both map to the
 	// Go type time.Time.
-	if universalTag == tagUTCTime && t.tag == tagGeneralizedTime && t.class == classUniversal {
-		universalTag = tagGeneralizedTime
+	if universalTag == TagUTCTime && t.tag == TagGeneralizedTime && t.class == ClassUniversal {
+		universalTag = TagGeneralizedTime
 	}
 
 	if params.set {
-		universalTag = tagSet
+		universalTag = TagSet
 	}
 
-	expectedClass := classUniversal
+	expectedClass := ClassUniversal
 	expectedTag := universalTag
 
 	if !params.explicit && params.tag != nil {
-		expectedClass = classContextSpecific
+		expectedClass = ClassContextSpecific
 		expectedTag = *params.tag
 	}
 
 	if !params.explicit && params.application && params.tag != nil {
-		expectedClass = classApplication
+		expectedClass = ClassApplication
 		expectedTag = *params.tag
 	}
 
@@ -684,15 +886,17 @@ func parseField(v reflect.Value, bytes []byte, initOffset int, params fieldParam
 		}
 		return
 	}
-	if invalidLength(offset, t.length, len(bytes)) {
-		err = SyntaxError{"data truncated"}
-		return
-	}
-	innerBytes := bytes[offset : offset+t.length]
-	offset += t.length
+	offset = end
 
 	// We deal with the structures defined in this package first.
 	switch fieldType {
+	case bigObjectIdentifierType:
+		oid, err1 := parseBigObjectIdentifier(innerBytes)
+		if err1 == nil {
+			v.Set(reflect.ValueOf(oid))
+		}
+		err = err1
+		return
 	case objectIdentifierType:
 		newSlice, err1 := parseObjectIdentifier(innerBytes)
 		v.Set(reflect.MakeSlice(v.Type(), len(newSlice), len(newSlice)))
@@ -711,7 +915,7 @@ func parseField(v reflect.Value, bytes []byte, initOffset int, params fieldParam
 	case timeType:
 		var time time.Time
 		var err1 error
-		if universalTag == tagUTCTime {
+		if universalTag == TagUTCTime {
 			time, err1 = parseUTCTime(innerBytes)
 		} else {
 			time, err1 = parseGeneralizedTime(innerBytes)
@@ -775,7 +979,9 @@ func parseField(v reflect.Value, bytes []byte, initOffset int, params fieldParam
 			if i == 0 && field.Type == rawContentsType {
 				continue
 			}
-			innerOffset, err = parseField(val.Field(i), innerBytes, innerOffset, parseFieldParameters(field.Tag.Get("asn1")))
+			fieldParams := parseFieldParameters(field.Tag.Get("asn1"))
+			fieldParams.ber = fieldParams.ber || params.ber
+			innerOffset, err = parseField(val.Field(i), innerBytes, innerOffset, fieldParams)
 			if err != nil {
 				return
 			}
@@ -791,7 +997,7 @@ func parseField(v reflect.Value, bytes []byte, initOffset int, params fieldParam
 			reflect.Copy(val, reflect.ValueOf(innerBytes))
 			return
 		}
-		newSlice, err1 := parseSequenceOf(innerBytes, sliceType, sliceType.Elem())
+		newSlice, err1 := parseSequenceOf(innerBytes, sliceType, sliceType.Elem(), params.ber)
 		if err1 == nil {
 			val.Set(newSlice)
 		}
@@ -800,15 +1006,19 @@ func parseField(v reflect.Value, bytes []byte, initOffset int, params fieldParam
 	case reflect.String:
 		var v string
 		switch universalTag {
-		case tagPrintableString:
+		case TagPrintableString:
 			v, err = parsePrintableString(innerBytes)
-		case tagIA5String:
+		case TagIA5String:
 			v, err = parseIA5String(innerBytes)
-		case tagT61String:
+		case TagT61String:
 			v, err = parseT61String(innerBytes)
-		case tagUTF8String:
+		case TagUTF8String:
 			v, err = parseUTF8String(innerBytes)
-		case tagGeneralString:
+		case TagBMPString:
+			v, err = parseBMPString(innerBytes)
+		case TagUniversalString:
+			v, err = parseUniversalString(innerBytes)
+		case TagGeneralString:
 			// GeneralString is specified in ISO-2022/ECMA-35,
 			// A brief review suggests that it includes structures
 			// that allow the encoding to change midstring and
@@ -869,13 +1079,15 @@ func setDefaultValue(v reflect.Value, params fieldParameters) (ok bool) {
 // An ASN.1 OCTET STRING can be written to a []byte.
 //
 // An ASN.1 OBJECT IDENTIFIER can be written to an
-// ObjectIdentifier.
+// ObjectIdentifier, or to a BigObjectIdentifier if its
+// components may not fit in an int.
 //
 // An ASN.1 ENUMERATED can be written to an Enumerated.
 //
 // An ASN.1 UTCTIME or GENERALIZEDTIME can be written to a time.Time.
 //
-// An ASN.1 PrintableString or IA5String can be written to a string.
+// An ASN.1 PrintableString, IA5String, T61String, UTF8String,
+// GeneralString, BMPString or UniversalString can be written to a string.
 //
 // Any of the above ASN.1 values can be written to an interface{}.
 // The value stored in the interface has the corresponding Go type.
@@ -888,9 +1100,19 @@ func setDefaultValue(v reflect.Value, params fieldParameters) (ok bool) {
 // if each of the elements in the sequence can be
 // written to the corresponding element in the struct.
 //
+// Any ASN.1 value can be written to a RawValue.  A RawValue field with an
+// implicit tag matches only an element with that tag, and one with an
+// explicit tag receives the element within the tag, which is how a tagged
+// CHOICE is usually represented.
+//
+// Unmarshal accepts only the definite lengths of DER.  With the ber
+// parameter, constructed values may also use the BER indefinite-length form,
+// in which case the contents of a RawValue omit the end-of-contents marker.
+//
 // The following tags on struct fields have special meaning to Unmarshal:
 //
 //	application	specifies that a APPLICATION tag is used
+//	ber		accepts BER indefinite lengths in the value and those within it
 //	default:x	sets the default value for optional integer fields
 //	explicit	specifies that an additional, explicit tag wraps the implicit one
 //	optional	marks the field as ASN.1 OPTIONAL
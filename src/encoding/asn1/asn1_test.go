@@ -357,8 +357,8 @@ func newBool(b bool) *bool { return &b }
 
 var parseFieldParametersTestData []parseFieldParametersTest = []parseFieldParametersTest{
 	{"", fieldParameters{}},
-	{"ia5", fieldParameters{stringType: tagIA5String}},
-	{"printable", fieldParameters{stringType: tagPrintableString}},
+	{"ia5", fieldParameters{stringType: TagIA5String}},
+	{"printable", fieldParameters{stringType: TagPrintableString}},
 	{"optional", fieldParameters{optional: true}},
 	{"explicit", fieldParameters{explicit: true, tag: new(int)}},
 	{"application", fieldParameters{application: true, tag: new(int)}},
@@ -366,8 +366,9 @@ var parseFieldParametersTestData []parseFieldParametersTest = []parseFieldParame
 	{"default:42", fieldParameters{defaultValue: newInt64(42)}},
 	{"tag:17", fieldParameters{tag: newInt(17)}},
 	{"optional,explicit,default:42,tag:17", fieldParameters{optional: true, explicit: true, defaultValue: newInt64(42), tag: newInt(17)}},
-	{"optional,explicit,default:42,tag:17,rubbish1", fieldParameters{true, true, false, newInt64(42), newInt(17), 0, false, false}},
+	{"optional,explicit,default:42,tag:17,rubbish1", fieldParameters{true, true, false, newInt64(42), newInt(17), 0, false, false, false}},
 	{"set", fieldParameters{set: true}},
+	{"ber", fieldParameters{ber: true}},
 }
 
 func TestParseFieldParameters(t *testing.T) {
@@ -409,6 +410,15 @@ type TestSet struct {
 	Ints []int `asn1:"set"`
 }
 
+type TestChoice struct {
+	A RawValue `asn1:"optional,tag:0"`
+	B RawValue `asn1:"optional,tag:1"`
+}
+
+type TestExplicitChoice struct {
+	A RawValue `asn1:"explicit,tag:0"`
+}
+
 var unmarshalTestData = []struct {
 	in  []byte
 	out interface{}
@@ -430,6 +440,22 @@ var unmarshalTestData = []struct {
 	{[]byte{0x30, 0x0b, 0x13, 0x03, 0x66, 0x6f, 0x6f, 0x02, 0x01, 0x22, 0x02, 0x01, 0x33}, &TestElementsAfterString{"foo", 0x22, 0x33}},
 	{[]byte{0x30, 0x05, 0x02, 0x03, 0x12, 0x34, 0x56}, &TestBigInt{big.NewInt(0x123456)}},
 	{[]byte{0x30, 0x0b, 0x31, 0x09, 0x02, 0x01, 0x01, 0x02, 0x01, 0x02, 0x02, 0x01, 0x03}, &TestSet{Ints: []int{1, 2, 3}}},
+	{[]byte{0x1e, 0x04, 0x00, 'h', 0x03, 0xa3}, newString("hΣ")},
+	{[]byte{0x1c, 0x08, 0x00, 0x00, 0x00, 'h', 0x00, 0x01, 0xf6, 0x00}, newString("h\U0001f600")},
+	{[]byte{0x30, 0x03, 0x81, 0x01, 0x05}, &TestChoice{B: RawValue{2, 1, false, []byte{5}, []byte{0x81, 0x01, 0x05}}}},
+	{[]byte{0x30, 0x05, 0xa0, 0x03, 0x02, 0x01, 0x05}, &TestExplicitChoice{RawValue{0, 2, false, []byte{5}, []byte{0x02, 0x01, 0x05}}}},
+}
+
+// berUnmarshalTestData holds BER indefinite-length encodings,
+// which only the ber parameter lets Unmarshal accept.
+var berUnmarshalTestData = []struct {
+	in  []byte
+	out interface{}
+}{
+	{[]byte{0x30, 0x80, 0x02, 0x01, 0x01, 0x02, 0x01, 0x02, 0x00, 0x00}, &[]int{1, 2}},
+	{[]byte{0x30, 0x80, 0x30, 0x80, 0x02, 0x01, 0x7f, 0x00, 0x00, 0x00, 0x00}, &nestedStruct{intStruct{127}}},
+	{[]byte{0x30, 0x80, 0xa1, 0x80, 0x02, 0x01, 0x01, 0x00, 0x00, 0x02, 0x01, 0x02, 0x00, 0x00}, &TestContextSpecificTags2{1, 2}},
+	{[]byte{0x30, 0x80, 0x04, 0x01, 0x01, 0x00, 0x00}, &RawValue{0, 16, true, []byte{0x04, 0x01, 0x01}, []byte{0x30, 0x80, 0x04, 0x01, 0x01, 0x00, 0x00}}},
 }
 
 func TestUnmarshal(t *testing.T) {
@@ -446,6 +472,85 @@ func TestUnmarshal(t *testing.T) {
 	}
 }
 
+func TestUnmarshalBER(t *testing.T) {
+	for i, test := range berUnmarshalTestData {
+		pv := reflect.New(reflect.TypeOf(test.out).Elem())
+		if _, err := Unmarshal(test.in, pv.Interface()); err == nil {
+			t.Errorf("#%d: Unmarshal(%x) succeeded, want error", i, test.in)
+		}
+		pv = reflect.New(reflect.TypeOf(test.out).Elem())
+		val := pv.Interface()
+		if _, err := UnmarshalWithParams(test.in, val, "ber"); err != nil {
+			t.Errorf("#%d: UnmarshalWithParams(%x, \"ber\") failed: %v", i, test.in, err)
+		}
+		if !reflect.DeepEqual(val, test.out) {
+			t.Errorf("#%d:\nhave %#v\nwant %#v", i, val, test.out)
+		}
+	}
+}
+
+var badUnmarshalTestData = [][]byte{
+	{0x1e, 0x03, 0x00, 'h', 0x00},              // odd BMPString
+	{0x1e, 0x02, 0xd8, 0x00},                   // surrogate in BMPString
+	{0x1c, 0x04, 0x00, 0x11, 0x00, 0x00},       // UniversalString out of range
+	{0x30, 0x03, 0x82, 0x01, 0x05},             // wrong implicit tag on RawValue
+	{0x30, 0x05, 0xa1, 0x03, 0x02, 0x01, 0x05}, // wrong explicit tag on RawValue
+}
+
+func TestUnmarshalBad(t *testing.T) {
+	vals := []interface{}{new(string), new(string), new(string), new(implicitRawValueTest), new(TestExplicitChoice)}
+	for i, in := range badUnmarshalTestData {
+		if _, err := Unmarshal(in, vals[i]); err == nil {
+			t.Errorf("#%d: Unmarshal(%x) succeeded, want error", i, in)
+		}
+	}
+
+	for i, in := range [][]byte{
+		{0x30, 0x80, 0x02, 0x01, 0x01}, // missing end-of-contents
+		{0x04, 0x80, 0x01, 0x00, 0x00}, // indefinite primitive
+	} {
+		if _, err := UnmarshalWithParams(in, new([]byte), "ber"); err == nil {
+			t.Errorf("#%d: UnmarshalWithParams(%x, \"ber\") succeeded, want error", i, in)
+		}
+	}
+}
+
+func TestBigObjectIdentifier(t *testing.T) {
+	// 2.25.18446744073709551616, an identifier derived from a UUID.
+	der := []byte{0x06, 0x0b, 0x69, 0x82, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x00}
+	want := BigObjectIdentifier{big.NewInt(2), big.NewInt(25), new(big.Int).Lsh(big.NewInt(1), 64)}
+
+	var oid BigObjectIdentifier
+	if _, err := Unmarshal(der, &oid); err != nil {
+		t.Fatal(err)
+	}
+	if !oid.Equal(want) {
+		t.Errorf("got %v, want %v", oid, want)
+	}
+	if s := oid.String(); s != "2.25.18446744073709551616" {
+		t.Errorf("String() = %s", s)
+	}
+	if _, ok := oid.ObjectIdentifier(); ok {
+		t.Errorf("ObjectIdentifier() succeeded for %v", oid)
+	}
+	if _, err := Unmarshal(der, new(ObjectIdentifier)); err == nil {
+		t.Errorf("Unmarshal into ObjectIdentifier succeeded")
+	}
+
+	var v interface{}
+	if _, err := Unmarshal(der, &v); err != nil {
+		t.Fatal(err)
+	}
+	if oid, ok := v.(BigObjectIdentifier); !ok || !oid.Equal(want) {
+		t.Errorf("Unmarshal into interface{} got %#v", v)
+	}
+
+	small := BigObjectIdentifier{big.NewInt(1), big.NewInt(2), big.NewInt(840)}
+	if oid, ok := small.ObjectIdentifier(); !ok || !oid.Equal(ObjectIdentifier{1, 2, 840}) {
+		t.Errorf("ObjectIdentifier() = %v, %v", oid, ok)
+	}
+}
+
 type Certificate struct {
 	TBSCertificate     TBSCertificate
 	SignatureAlgorithm AlgorithmIdentifier
@@ -833,8 +938,8 @@ var explicitTaggedTimeTestData = []struct {
 }
 
 func TestExplicitTaggedTime(t *testing.T) {
-	// Test that a time.Time will match either tagUTCTime or
-	// tagGeneralizedTime.
+	// Test that a time.Time will match either TagUTCTime or
+	// TagGeneralizedTime.
 	for i, test := range explicitTaggedTimeTestData {
 		var got explicitTaggedTimeTest
 		_, err := Unmarshal(test.in, &got)
@@ -0,0 +1,185 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package asn1
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+)
+
+// A Builder builds DER-encoded data one element at a time, for structures
+// that are awkward to describe with the struct tags that Marshal
+// understands, such as those of PKCS#7 and Kerberos.  The contents of a
+// constructed element are added by a function that is given a Builder for
+// them.  The zero value is an empty Builder ready to use.
+//
+// After the first error, the Builder ignores further additions and Bytes
+// returns the error.
+type Builder struct {
+	buf []byte
+	err error
+}
+
+// Bytes returns the data built so far, or the first error encountered.
+func (b *Builder) Bytes() ([]byte, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.buf, nil
+}
+
+// SetError makes the Builder fail with err, unless it has already failed.
+// It lets the function building a constructed element report errors of
+// its own.
+func (b *Builder) SetError(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// AddRaw appends der, which must be a sequence of complete encoded
+// elements, such as the FullBytes of a RawValue.
+func (b *Builder) AddRaw(der []byte) {
+	if b.err != nil {
+		return
+	}
+	b.buf = append(b.buf, der...)
+}
+
+// AddValue appends the encoding of val, as by MarshalWithParams.
+func (b *Builder) AddValue(val interface{}, params string) {
+	if b.err != nil {
+		return
+	}
+	der, err := MarshalWithParams(val, params)
+	if err != nil {
+		b.err = err
+		return
+	}
+	b.buf = append(b.buf, der...)
+}
+
+// AddPrimitive appends a primitive element of the given class and tag with
+// the given contents.
+func (b *Builder) AddPrimitive(class, tag int, contents []byte) {
+	b.addElement(class, tag, false, contents)
+}
+
+// AddElement appends a constructed element of the given class and tag
+// whose contents are added by f.
+func (b *Builder) AddElement(class, tag int, f func(*Builder)) {
+	if b.err != nil {
+		return
+	}
+	contents, err := build(f)
+	if err != nil {
+		b.err = err
+		return
+	}
+	b.addElement(class, tag, true, contents)
+}
+
+func (b *Builder) addElement(class, tag int, isCompound bool, contents []byte) {
+	if b.err != nil {
+		return
+	}
+	out := newForkableWriter()
+	marshalTagAndLength(out, tagAndLength{class, tag, len(contents), isCompound})
+	b.buf = append(b.buf, out.Bytes()...)
+	b.buf = append(b.buf, contents...)
+}
+
+// build returns the data that f adds to a new Builder.
+func build(f func(*Builder)) ([]byte, error) {
+	var child Builder
+	f(&child)
+	return child.Bytes()
+}
+
+// AddSequence appends a SEQUENCE whose elements are added by f.
+func (b *Builder) AddSequence(f func(*Builder)) {
+	b.AddElement(ClassUniversal, TagSequence, f)
+}
+
+// AddSet appends a SET whose elements are added by f, in the order f adds
+// them.
+func (b *Builder) AddSet(f func(*Builder)) {
+	b.AddElement(ClassUniversal, TagSet, f)
+}
+
+// AddSetOf appends a SET OF whose elements are added by f.  The elements
+// are sorted into ascending order of their encodings, as DER requires.
+func (b *Builder) AddSetOf(f func(*Builder)) {
+	if b.err != nil {
+		return
+	}
+	contents, err := build(f)
+	if err != nil {
+		b.err = err
+		return
+	}
+	var elems [][]byte
+	for offset := 0; offset < len(contents); {
+		start := offset
+		_, _, offset, err = parseElement(contents, offset, true)
+		if err != nil {
+			b.err = err
+			return
+		}
+		elems = append(elems, contents[start:offset])
+	}
+	sort.Sort(byteSlices(elems))
+	b.addElement(ClassUniversal, TagSet, true, bytes.Join(elems, nil))
+}
+
+// AddExplicit appends the elements added by f wrapped in an explicit,
+// context-specific tag.
+func (b *Builder) AddExplicit(tag int, f func(*Builder)) {
+	b.AddElement(ClassContextSpecific, tag, f)
+}
+
+// AddBoolean appends a BOOLEAN.
+func (b *Builder) AddBoolean(v bool) {
+	var c byte
+	if v {
+		c = 0xff
+	}
+	b.AddPrimitive(ClassUniversal, TagBoolean, []byte{c})
+}
+
+// AddInt64 appends an INTEGER.
+func (b *Builder) AddInt64(v int64) {
+	out := newForkableWriter()
+	marshalInt64(out, v)
+	b.AddPrimitive(ClassUniversal, TagInteger, out.Bytes())
+}
+
+// AddBigInt appends an INTEGER.
+func (b *Builder) AddBigInt(n *big.Int) {
+	out := newForkableWriter()
+	marshalBigInt(out, n)
+	b.AddPrimitive(ClassUniversal, TagInteger, out.Bytes())
+}
+
+// AddOctetString appends an OCTET STRING.
+func (b *Builder) AddOctetString(v []byte) {
+	b.AddPrimitive(ClassUniversal, TagOctetString, v)
+}
+
+// AddNull appends a NULL.
+func (b *Builder) AddNull() {
+	b.AddPrimitive(ClassUniversal, TagNull, nil)
+}
+
+// AddObjectIdentifier appends an OBJECT IDENTIFIER.
+func (b *Builder) AddObjectIdentifier(oid ObjectIdentifier) {
+	out := newForkableWriter()
+	if err := marshalObjectIdentifier(out, oid); err != nil {
+		b.SetError(err)
+		return
+	}
+	b.AddPrimitive(ClassUniversal, TagOID, out.Bytes())
+}
@@ -0,0 +1,151 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package asn1
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+type builderTest struct {
+	Version int
+	Digests []int `asn1:"set"`
+	Content RawValue
+	Certs   RawValue `asn1:"optional,tag:0"`
+	Comment string   `asn1:"explicit,tag:1,utf8"`
+	Flag    bool
+}
+
+func TestBuilder(t *testing.T) {
+	var b Builder
+	b.AddSequence(func(b *Builder) {
+		b.AddInt64(1)
+		b.AddSetOf(func(b *Builder) {
+			b.AddInt64(300)
+			b.AddInt64(2)
+		})
+		b.AddSequence(func(b *Builder) {
+			b.AddObjectIdentifier(ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1})
+			b.AddNull()
+		})
+		b.AddElement(ClassContextSpecific, 0, func(b *Builder) {
+			b.AddOctetString([]byte("cert"))
+		})
+		b.AddExplicit(1, func(b *Builder) {
+			b.AddValue("é", "utf8")
+		})
+		b.AddBoolean(true)
+	})
+	der, err := b.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v builderTest
+	if _, err := Unmarshal(der, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Version != 1 || len(v.Digests) != 2 || v.Digests[0] != 2 || v.Digests[1] != 300 ||
+		v.Comment != "é" || !v.Flag || !v.Certs.IsCompound {
+		t.Fatalf("got %+v", v)
+	}
+	again, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(der, again) {
+		t.Errorf("Builder gave %x, Marshal gave %x", der, again)
+	}
+
+	p := NewParser(der)
+	seq, err := p.ReadSequence()
+	if err != nil || !p.Empty() {
+		t.Fatalf("ReadSequence: %v, rest %x", err, p.Rest())
+	}
+	if n, err := seq.ReadInt64(); err != nil || n != 1 {
+		t.Errorf("ReadInt64 = %d, %v", n, err)
+	}
+	set, err := seq.ReadSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []int64{2, 300} {
+		if n, err := set.ReadBigInt(); err != nil || n.Cmp(big.NewInt(want)) != 0 {
+			t.Errorf("ReadBigInt = %v, %v; want %d", n, err, want)
+		}
+	}
+	var content RawValue
+	if err := seq.ReadValue(&content, ""); err != nil || content.Tag != TagSequence {
+		t.Errorf("ReadValue = %+v, %v", content, err)
+	}
+	if seq.Peek(ClassContextSpecific, 1) || !seq.Peek(ClassContextSpecific, 0) {
+		t.Errorf("Peek does not see [0]")
+	}
+	certs, err := seq.ReadElement(ClassContextSpecific, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err := certs.ReadOctetString(); err != nil || string(s) != "cert" {
+		t.Errorf("ReadOctetString = %q, %v", s, err)
+	}
+	if _, ok, err := seq.ReadOptionalExplicit(2); ok || err != nil {
+		t.Errorf("ReadOptionalExplicit(2) = %v, %v", ok, err)
+	}
+	comment, ok, err := seq.ReadOptionalExplicit(1)
+	if !ok || err != nil {
+		t.Fatalf("ReadOptionalExplicit(1) = %v, %v", ok, err)
+	}
+	if rv, err := comment.ReadRawValue(); err != nil || rv.Tag != TagUTF8String || string(rv.Bytes) != "é" {
+		t.Errorf("ReadRawValue = %+v, %v", rv, err)
+	}
+	if f, err := seq.ReadBoolean(); err != nil || !f {
+		t.Errorf("ReadBoolean = %v, %v", f, err)
+	}
+	if !seq.Empty() {
+		t.Errorf("data left over: %x", seq.Rest())
+	}
+}
+
+func TestBuilderError(t *testing.T) {
+	errTest := errors.New("test")
+	var b Builder
+	b.AddSequence(func(b *Builder) {
+		b.AddInt64(1)
+		b.SetError(errTest)
+	})
+	b.AddInt64(2)
+	if _, err := b.Bytes(); err != errTest {
+		t.Errorf("got error %v, want %v", err, errTest)
+	}
+
+	b = Builder{}
+	b.AddObjectIdentifier(ObjectIdentifier{3})
+	if _, err := b.Bytes(); err == nil {
+		t.Errorf("invalid OBJECT IDENTIFIER was accepted")
+	}
+}
+
+func TestParserBER(t *testing.T) {
+	// A constructed, indefinite-length OCTET STRING in two segments,
+	// within an indefinite-length SEQUENCE.
+	ber, _ := hex.DecodeString("3080" + "2480" + "04026162" + "040163" + "0000" + "020105" + "0000")
+	p := NewParser(ber)
+	seq, err := p.ReadSequence()
+	if err != nil || !p.Empty() {
+		t.Fatalf("ReadSequence: %v, rest %x", err, p.Rest())
+	}
+	if s, err := seq.ReadOctetString(); err != nil || string(s) != "abc" {
+		t.Errorf("ReadOctetString = %q, %v", s, err)
+	}
+	if n, err := seq.ReadInt64(); err != nil || n != 5 {
+		t.Errorf("ReadInt64 = %d, %v", n, err)
+	}
+	if _, err := seq.ReadInt64(); err == nil {
+		t.Errorf("ReadInt64 past the end succeeded")
+	}
+}
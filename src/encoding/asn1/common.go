@@ -16,31 +16,37 @@ import (
 //   the class type: the namespace of the tag
 //   the length of the object, in bytes
 
-// Here are some standard tags and classes
+// Here are some standard tags and classes, as used in RawValue and by
+// Builder and Parser.
 
+// ASN.1 tags represent the type of the following object.
 const (
-	tagBoolean         = 1
-	tagInteger         = 2
-	tagBitString       = 3
-	tagOctetString     = 4
-	tagOID             = 6
-	tagEnum            = 10
-	tagUTF8String      = 12
-	tagSequence        = 16
-	tagSet             = 17
-	tagPrintableString = 19
-	tagT61String       = 20
-	tagIA5String       = 22
-	tagUTCTime         = 23
-	tagGeneralizedTime = 24
-	tagGeneralString   = 27
+	TagBoolean         = 1
+	TagInteger         = 2
+	TagBitString       = 3
+	TagOctetString     = 4
+	TagNull            = 5
+	TagOID             = 6
+	TagEnum            = 10
+	TagUTF8String      = 12
+	TagSequence        = 16
+	TagSet             = 17
+	TagPrintableString = 19
+	TagT61String       = 20
+	TagIA5String       = 22
+	TagUTCTime         = 23
+	TagGeneralizedTime = 24
+	TagGeneralString   = 27
+	TagUniversalString = 28
+	TagBMPString       = 30
 )
 
+// ASN.1 class types represent the namespace of the tag.
 const (
-	classUniversal       = 0
-	classApplication     = 1
-	classContextSpecific = 2
-	classPrivate         = 3
+	ClassUniversal       = 0
+	ClassApplication     = 1
+	ClassContextSpecific = 2
+	ClassPrivate         = 3
 )
 
 type tagAndLength struct {
@@ -65,6 +71,11 @@ type tagAndLength struct {
 // You can layer EXPLICIT and IMPLICIT tags to an arbitrary depth, however we
 // don't support that here. We support a single layer of EXPLICIT or IMPLICIT
 // tagging with tag strings on the fields of a structure.
+//
+// A CHOICE has no tag of its own, so an IMPLICIT tag cannot replace it; X.680
+// treats a tagged CHOICE as EXPLICITly tagged.  A CHOICE is represented here
+// by a RawValue or an interface{}, and an implicit tag on a RawValue replaces
+// the tag of the chosen alternative.
 
 // fieldParameters is the parsed representation of tag string from a structure field.
 type fieldParameters struct {
@@ -76,6 +87,7 @@ type fieldParameters struct {
 	stringType   int    // the string tag to use when marshaling.
 	set          bool   // true iff this should be encoded as a SET
 	omitEmpty    bool   // true iff this should be omitted if empty when marshaling.
+	ber          bool   // true iff BER indefinite lengths are accepted in this element and those within it.
 
 	// Invariants:
 	//   if explicit is set, tag is non-nil.
@@ -95,11 +107,15 @@ func parseFieldParameters(str string) (ret fieldParameters) {
 				ret.tag = new(int)
 			}
 		case part == "ia5":
-			ret.stringType = tagIA5String
+			ret.stringType = TagIA5String
 		case part == "printable":
-			ret.stringType = tagPrintableString
+			ret.stringType = TagPrintableString
 		case part == "utf8":
-			ret.stringType = tagUTF8String
+			ret.stringType = TagUTF8String
+		case part == "bmp":
+			ret.stringType = TagBMPString
+		case part == "universal":
+			ret.stringType = TagUniversalString
 		case strings.HasPrefix(part, "default:"):
 			i, err := strconv.ParseInt(part[8:], 10, 64)
 			if err == nil {
@@ -121,6 +137,8 @@ func parseFieldParameters(str string) (ret fieldParameters) {
 			}
 		case part == "omitempty":
 			ret.omitEmpty = true
+		case part == "ber":
+			ret.ber = true
 		}
 	}
 	return
@@ -130,34 +148,34 @@ func parseFieldParameters(str string) (ret fieldParameters) {
 // and expected compound flag.
 func getUniversalType(t reflect.Type) (tagNumber int, isCompound, ok bool) {
 	switch t {
-	case objectIdentifierType:
-		return tagOID, false, true
+	case objectIdentifierType, bigObjectIdentifierType:
+		return TagOID, false, true
 	case bitStringType:
-		return tagBitString, false, true
+		return TagBitString, false, true
 	case timeType:
-		return tagUTCTime, false, true
+		return TagUTCTime, false, true
 	case enumeratedType:
-		return tagEnum, false, true
+		return TagEnum, false, true
 	case bigIntType:
-		return tagInteger, false, true
+		return TagInteger, false, true
 	}
 	switch t.Kind() {
 	case reflect.Bool:
-		return tagBoolean, false, true
+		return TagBoolean, false, true
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return tagInteger, false, true
+		return TagInteger, false, true
 	case reflect.Struct:
-		return tagSequence, true, true
+		return TagSequence, true, true
 	case reflect.Slice:
 		if t.Elem().Kind() == reflect.Uint8 {
-			return tagOctetString, false, true
+			return TagOctetString, false, true
 		}
 		if strings.HasSuffix(t.Name(), "SET") {
-			return tagSet, true, true
+			return TagSet, true, true
 		}
-		return tagSequence, true, true
+		return TagSequence, true, true
 	case reflect.String:
-		return tagPrintableString, false, true
+		return TagPrintableString, false, true
 	}
 	return 0, false, false
 }
@@ -11,7 +11,10 @@ import (
 	"io"
 	"math/big"
 	"reflect"
+	"sort"
+	"strings"
 	"time"
+	"unicode/utf16"
 	"unicode/utf8"
 )
 
@@ -258,6 +261,43 @@ func marshalObjectIdentifier(out *forkableWriter, oid []int) (err error) {
 	return
 }
 
+func marshalBigObjectIdentifier(out *forkableWriter, oid BigObjectIdentifier) (err error) {
+	if len(oid) < 2 || oid[0].Sign() < 0 || oid[1].Sign() < 0 || oid[0].Cmp(big.NewInt(2)) > 0 ||
+		(oid[0].Cmp(big.NewInt(2)) < 0 && oid[1].Cmp(big.NewInt(40)) >= 0) {
+		return StructuralError{"invalid object identifier"}
+	}
+
+	first := new(big.Int).Mul(oid[0], big.NewInt(40))
+	first.Add(first, oid[1])
+	marshalBigBase128Int(out, first)
+	for _, v := range oid[2:] {
+		if v.Sign() < 0 {
+			return StructuralError{"invalid object identifier"}
+		}
+		marshalBigBase128Int(out, v)
+	}
+	return
+}
+
+// marshalBigBase128Int writes the non-negative n in base 128, most
+// significant group first.
+func marshalBigBase128Int(out *forkableWriter, n *big.Int) {
+	l := (n.BitLen() + 6) / 7
+	if l == 0 {
+		l = 1
+	}
+	for i := l - 1; i >= 0; i-- {
+		var o byte
+		for j := uint(0); j < 7; j++ {
+			o |= byte(n.Bit(i*7+int(j))) << j
+		}
+		if i != 0 {
+			o |= 0x80
+		}
+		out.WriteByte(o)
+	}
+}
+
 func marshalPrintableString(out *forkableWriter, s string) (err error) {
 	b := []byte(s)
 	for _, c := range b {
@@ -287,6 +327,27 @@ func marshalUTF8String(out *forkableWriter, s string) (err error) {
 	return
 }
 
+func marshalBMPString(out *forkableWriter, s string) (err error) {
+	for _, r := range s {
+		if r > 0xffff || utf16.IsSurrogate(r) {
+			return StructuralError{"BMPString contains invalid character"}
+		}
+		out.WriteByte(byte(r >> 8))
+		out.WriteByte(byte(r))
+	}
+	return
+}
+
+func marshalUniversalString(out *forkableWriter, s string) (err error) {
+	for _, r := range s {
+		_, err = out.Write([]byte{byte(r >> 24), byte(r >> 16), byte(r >> 8), byte(r)})
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
 func marshalTwoDigits(out *forkableWriter, v int) (err error) {
 	err = out.WriteByte(byte('0' + (v/10)%10))
 	if err != nil {
@@ -421,6 +482,8 @@ func marshalBody(out *forkableWriter, value reflect.Value, params fieldParameter
 		return marshalBitString(out, value.Interface().(BitString))
 	case objectIdentifierType:
 		return marshalObjectIdentifier(out, value.Interface().(ObjectIdentifier))
+	case bigObjectIdentifierType:
+		return marshalBigObjectIdentifier(out, value.Interface().(BigObjectIdentifier))
 	case bigIntType:
 		return marshalBigInt(out, value.Interface().(*big.Int))
 	}
@@ -479,6 +542,10 @@ func marshalBody(out *forkableWriter, value reflect.Value, params fieldParameter
 			return
 		}
 
+		if params.set || strings.HasSuffix(sliceType.Name(), "SET") {
+			return marshalSetOf(out, v)
+		}
+
 		var fp fieldParameters
 		for i := 0; i < v.Len(); i++ {
 			var pre *forkableWriter
@@ -491,10 +558,14 @@ func marshalBody(out *forkableWriter, value reflect.Value, params fieldParameter
 		return
 	case reflect.String:
 		switch params.stringType {
-		case tagIA5String:
+		case TagIA5String:
 			return marshalIA5String(out, v.String())
-		case tagPrintableString:
+		case TagPrintableString:
 			return marshalPrintableString(out, v.String())
+		case TagBMPString:
+			return marshalBMPString(out, v.String())
+		case TagUniversalString:
+			return marshalUniversalString(out, v.String())
 		default:
 			return marshalUTF8String(out, v.String())
 		}
@@ -503,6 +574,35 @@ func marshalBody(out *forkableWriter, value reflect.Value, params fieldParameter
 	return StructuralError{"unknown Go type"}
 }
 
+// marshalSetOf writes the elements of the slice v in the order DER requires
+// for a SET OF: ascending order of their encodings (X.690, 11.6).
+func marshalSetOf(out *forkableWriter, v reflect.Value) (err error) {
+	elems := make([][]byte, v.Len())
+	for i := range elems {
+		f := newForkableWriter()
+		if err = marshalField(f, v.Index(i), fieldParameters{}); err != nil {
+			return
+		}
+		var b bytes.Buffer
+		f.writeTo(&b)
+		elems[i] = b.Bytes()
+	}
+	sort.Sort(byteSlices(elems))
+	for _, e := range elems {
+		if _, err = out.Write(e); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// byteSlices sorts encodings into ascending lexicographic order.
+type byteSlices [][]byte
+
+func (s byteSlices) Len() int           { return len(s) }
+func (s byteSlices) Less(i, j int) bool { return bytes.Compare(s[i], s[j]) < 0 }
+func (s byteSlices) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
 func marshalField(out *forkableWriter, v reflect.Value, params fieldParameters) (err error) {
 	// If the field is an interface{} then recurse into it.
 	if v.Kind() == reflect.Interface && v.Type().NumMethod() == 0 {
@@ -531,8 +631,32 @@ func marshalField(out *forkableWriter, v reflect.Value, params fieldParameters)
 		}
 	}
 
+	class := ClassUniversal
+	if params.tag != nil {
+		class = ClassContextSpecific
+		if params.application {
+			class = ClassApplication
+		}
+	}
+
 	if v.Type() == rawValueType {
 		rv := v.Interface().(RawValue)
+		if !params.explicit && params.tag != nil && (rv.Class != class || rv.Tag != *params.tag) {
+			// An implicit tag replaces that of the value.
+			if len(rv.FullBytes) != 0 {
+				t, offset, end, err := parseElement(rv.FullBytes, 0, true)
+				if err != nil {
+					return err
+				}
+				rv.IsCompound, rv.Bytes = t.isCompound, rv.FullBytes[offset:offset+t.length]
+				rv.FullBytes = rv.FullBytes[:end]
+			}
+			rv.Class, rv.Tag, rv.FullBytes = class, *params.tag, nil
+		}
+		var explicitTag *forkableWriter
+		if params.explicit {
+			explicitTag, out = out.fork()
+		}
 		if len(rv.FullBytes) != 0 {
 			_, err = out.Write(rv.FullBytes)
 		} else {
@@ -542,6 +666,9 @@ func marshalField(out *forkableWriter, v reflect.Value, params fieldParameters)
 			}
 			_, err = out.Write(rv.Bytes)
 		}
+		if err == nil && params.explicit {
+			err = marshalTagAndLength(explicitTag, tagAndLength{class, *params.tag, out.Len(), true})
+		}
 		return
 	}
 
@@ -550,14 +677,14 @@ func marshalField(out *forkableWriter, v reflect.Value, params fieldParameters)
 		err = StructuralError{fmt.Sprintf("unknown Go type: %v", v.Type())}
 		return
 	}
-	class := classUniversal
+	universalClass := ClassUniversal
 
-	if params.stringType != 0 && tag != tagPrintableString {
+	if params.stringType != 0 && tag != TagPrintableString {
 		return StructuralError{"explicit string type given to non-string member"}
 	}
 
 	switch tag {
-	case tagPrintableString:
+	case TagPrintableString:
 		if params.stringType == 0 {
 			// This is a string without an explicit string type. We'll use
 			// a PrintableString if the character set in the string is
@@ -567,24 +694,24 @@ func marshalField(out *forkableWriter, v reflect.Value, params fieldParameters)
 					if !utf8.ValidString(v.String()) {
 						return errors.New("asn1: string not valid UTF-8")
 					}
-					tag = tagUTF8String
+					tag = TagUTF8String
 					break
 				}
 			}
 		} else {
 			tag = params.stringType
 		}
-	case tagUTCTime:
+	case TagUTCTime:
 		if outsideUTCRange(v.Interface().(time.Time)) {
-			tag = tagGeneralizedTime
+			tag = TagGeneralizedTime
 		}
 	}
 
 	if params.set {
-		if tag != tagSequence {
+		if tag != TagSequence {
 			return StructuralError{"non sequence tagged as set"}
 		}
-		tag = tagSet
+		tag = TagSet
 	}
 
 	tags, body := out.fork()
@@ -604,17 +731,17 @@ func marshalField(out *forkableWriter, v reflect.Value, params fieldParameters)
 	if !params.explicit && params.tag != nil {
 		// implicit tag.
 		tag = *params.tag
-		class = classContextSpecific
+		universalClass = class
 	}
 
-	err = marshalTagAndLength(tags, tagAndLength{class, tag, bodyLen, isCompound})
+	err = marshalTagAndLength(tags, tagAndLength{universalClass, tag, bodyLen, isCompound})
 	if err != nil {
 		return
 	}
 
 	if params.explicit {
 		err = marshalTagAndLength(explicitTag, tagAndLength{
-			class:      classContextSpecific,
+			class:      class,
 			tag:        *params.tag,
 			length:     bodyLen + tags.Len(),
 			isCompound: true,
@@ -629,15 +756,28 @@ func marshalField(out *forkableWriter, v reflect.Value, params fieldParameters)
 // In addition to the struct tags recognised by Unmarshal, the following can be
 // used:
 //
+//	bmp:		causes strings to be marshaled as ASN.1, BMPString strings
 //	ia5:		causes strings to be marshaled as ASN.1, IA5 strings
 //	omitempty:	causes empty slices to be skipped
 //	printable:	causes strings to be marshaled as ASN.1, PrintableString strings.
+//	universal:	causes strings to be marshaled as ASN.1, UniversalString strings
 //	utf8:		causes strings to be marshaled as ASN.1, UTF8 strings
+//
+// The elements of a SET OF are written in ascending order of their
+// encodings, as DER requires.  A RawValue with an implicit tag is written
+// with that tag in place of its own, and one with an explicit tag, such as
+// a tagged CHOICE, is wrapped in the tag.
 func Marshal(val interface{}) ([]byte, error) {
+	return MarshalWithParams(val, "")
+}
+
+// MarshalWithParams allows field parameters to be specified for the
+// top-level element. The form of the params is the same as the field tags.
+func MarshalWithParams(val interface{}, params string) ([]byte, error) {
 	var out bytes.Buffer
 	v := reflect.ValueOf(val)
 	f := newForkableWriter()
-	err := marshalField(f, v, fieldParameters{})
+	err := marshalField(f, v, parseFieldParameters(params))
 	if err != nil {
 		return nil, err
 	}
@@ -64,6 +64,31 @@ type defaultTest struct {
 
 type testSET []int
 
+type setOfTest struct {
+	A []int `asn1:"set"`
+}
+
+type bmpStringTest struct {
+	A string `asn1:"bmp"`
+}
+
+type universalStringTest struct {
+	A string `asn1:"universal"`
+}
+
+type explicitRawValueTest struct {
+	A RawValue `asn1:"explicit,tag:0"`
+}
+
+type implicitRawValueTest struct {
+	A RawValue `asn1:"tag:1"`
+}
+
+type applicationTagTest struct {
+	A int `asn1:"application,explicit,tag:1"`
+	B int `asn1:"application,tag:2"`
+}
+
 var PST = time.FixedZone("PST", -8*60*60)
 
 type marshalTest struct {
@@ -140,6 +165,16 @@ var marshalTests = []marshalTest{
 	{defaultTest{0}, "3003020100"},
 	{defaultTest{1}, "3000"},
 	{defaultTest{2}, "3003020102"},
+	{testSET([]int{3, 1, 2}), "3109020101020102020103"},
+	{setOfTest{[]int{256, 2}}, "30093107020102020201" + "00"},
+	{bmpStringTest{"hΣ"}, "30061e04006803a3"},
+	{universalStringTest{"h"}, "30061c0400000068"},
+	{BigObjectIdentifier{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4)}, "06032a0304"},
+	{BigObjectIdentifier{big.NewInt(2), big.NewInt(25), new(big.Int).Lsh(big.NewInt(1), 64)}, "060b6982808080808080808000"},
+	{explicitRawValueTest{RawValue{Tag: 2, Bytes: []byte{5}}}, "3005a003020105"},
+	{implicitRawValueTest{RawValue{Tag: 4, Bytes: []byte{5}}}, "3003810105"},
+	{implicitRawValueTest{RawValue{FullBytes: []byte{4, 1, 5}}}, "3003810105"},
+	{applicationTagTest{5, 6}, "3008610302010542" + "0106"},
 }
 
 func TestMarshal(t *testing.T) {
@@ -156,6 +191,32 @@ func TestMarshal(t *testing.T) {
 	}
 }
 
+func TestMarshalBad(t *testing.T) {
+	for i, v := range []interface{}{
+		bmpStringTest{"\U0001f600"},
+		BigObjectIdentifier{big.NewInt(3), big.NewInt(1)},
+		BigObjectIdentifier{big.NewInt(1), big.NewInt(40)},
+	} {
+		if _, err := Marshal(v); err == nil {
+			t.Errorf("#%d: Marshal(%v) succeeded, want error", i, v)
+		}
+	}
+}
+
+func TestMarshalWithParams(t *testing.T) {
+	data, err := MarshalWithParams("test", "ia5,explicit,tag:3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a306160474657374"; hex.EncodeToString(data) != want {
+		t.Errorf("got %x, want %s", data, want)
+	}
+	var s string
+	if _, err := UnmarshalWithParams(data, &s, "explicit,tag:3"); err != nil || s != "test" {
+		t.Errorf("UnmarshalWithParams = %q, %v", s, err)
+	}
+}
+
 func TestInvalidUTF8(t *testing.T) {
 	_, err := Marshal(string([]byte{0xff, 0xff}))
 	if err == nil {
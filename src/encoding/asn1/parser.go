@@ -0,0 +1,194 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package asn1
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+)
+
+// A Parser reads BER- or DER-encoded data one element at a time, the
+// counterpart of Builder.  Reading a constructed element returns a Parser
+// for its contents.
+type Parser struct {
+	data []byte
+}
+
+// NewParser returns a Parser that reads the elements of data.
+func NewParser(data []byte) *Parser {
+	return &Parser{data}
+}
+
+// Empty reports whether all the data has been read.
+func (p *Parser) Empty() bool {
+	return len(p.data) == 0
+}
+
+// Rest returns the data that has not been read.
+func (p *Parser) Rest() []byte {
+	return p.data
+}
+
+// Peek reports whether the next element has the given class and tag.
+func (p *Parser) Peek(class, tag int) bool {
+	if len(p.data) == 0 {
+		return false
+	}
+	t, _, err := parseTagAndLengthForm(p.data, 0, true)
+	return err == nil && t.class == class && t.tag == tag
+}
+
+// ReadRawValue reads the next element, whatever its type.
+func (p *Parser) ReadRawValue() (RawValue, error) {
+	t, offset, end, err := parseElement(p.data, 0, true)
+	if err != nil {
+		return RawValue{}, err
+	}
+	rv := RawValue{t.class, t.tag, t.isCompound, p.data[offset : offset+t.length], p.data[:end]}
+	p.data = p.data[end:]
+	return rv, nil
+}
+
+// ReadElement reads the next element, which must be constructed and have
+// the given class and tag, and returns a Parser for its contents.
+func (p *Parser) ReadElement(class, tag int) (*Parser, error) {
+	contents, err := p.read(class, tag, true)
+	if err != nil {
+		return nil, err
+	}
+	return NewParser(contents), nil
+}
+
+// ReadPrimitive reads the next element, which must be primitive and have
+// the given class and tag, and returns its contents.
+func (p *Parser) ReadPrimitive(class, tag int) ([]byte, error) {
+	return p.read(class, tag, false)
+}
+
+func (p *Parser) read(class, tag int, isCompound bool) ([]byte, error) {
+	t, offset, end, err := parseElement(p.data, 0, true)
+	if err != nil {
+		return nil, err
+	}
+	if t.class != class || t.tag != tag || t.isCompound != isCompound {
+		return nil, StructuralError{fmt.Sprintf("tags don't match (%d vs %+v)", tag, t)}
+	}
+	contents := p.data[offset : offset+t.length]
+	p.data = p.data[end:]
+	return contents, nil
+}
+
+// ReadSequence reads a SEQUENCE and returns a Parser for its elements.
+func (p *Parser) ReadSequence() (*Parser, error) {
+	return p.ReadElement(ClassUniversal, TagSequence)
+}
+
+// ReadSet reads a SET or SET OF and returns a Parser for its elements.
+func (p *Parser) ReadSet() (*Parser, error) {
+	return p.ReadElement(ClassUniversal, TagSet)
+}
+
+// ReadOptionalExplicit reads the next element if it is an explicit,
+// context-specific tag with the given number, and returns a Parser for the
+// elements within it.  It reports whether the element was present.
+func (p *Parser) ReadOptionalExplicit(tag int) (*Parser, bool, error) {
+	if !p.Peek(ClassContextSpecific, tag) {
+		return nil, false, nil
+	}
+	q, err := p.ReadElement(ClassContextSpecific, tag)
+	if err != nil {
+		return nil, false, err
+	}
+	return q, true, nil
+}
+
+// ReadValue reads the next element into the value pointed to by val,
+// as by UnmarshalWithParams with the ber parameter.
+func (p *Parser) ReadValue(val interface{}, params string) error {
+	v := reflect.ValueOf(val).Elem()
+	fieldParams := parseFieldParameters(params)
+	fieldParams.ber = true
+	offset, err := parseField(v, p.data, 0, fieldParams)
+	if err != nil {
+		return err
+	}
+	p.data = p.data[offset:]
+	return nil
+}
+
+// ReadBoolean reads a BOOLEAN.
+func (p *Parser) ReadBoolean() (bool, error) {
+	contents, err := p.ReadPrimitive(ClassUniversal, TagBoolean)
+	if err != nil {
+		return false, err
+	}
+	return parseBool(contents)
+}
+
+// ReadInt64 reads an INTEGER that fits in an int64.
+func (p *Parser) ReadInt64() (int64, error) {
+	contents, err := p.ReadPrimitive(ClassUniversal, TagInteger)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt64(contents)
+}
+
+// ReadBigInt reads an INTEGER.
+func (p *Parser) ReadBigInt() (*big.Int, error) {
+	contents, err := p.ReadPrimitive(ClassUniversal, TagInteger)
+	if err != nil {
+		return nil, err
+	}
+	if len(contents) == 0 {
+		return nil, SyntaxError{"empty integer"}
+	}
+	return parseBigInt(contents), nil
+}
+
+// ReadObjectIdentifier reads an OBJECT IDENTIFIER.
+func (p *Parser) ReadObjectIdentifier() (ObjectIdentifier, error) {
+	contents, err := p.ReadPrimitive(ClassUniversal, TagOID)
+	if err != nil {
+		return nil, err
+	}
+	return parseObjectIdentifier(contents)
+}
+
+// ReadOctetString reads an OCTET STRING.  The BER constructed form, in
+// which the string is split into segments, is also accepted; the segments
+// are joined.
+func (p *Parser) ReadOctetString() ([]byte, error) {
+	return p.readOctetString(ClassUniversal, TagOctetString)
+}
+
+// ReadImplicitOctetString is like ReadOctetString but reads an OCTET STRING
+// with an implicit, context-specific tag.
+func (p *Parser) ReadImplicitOctetString(tag int) ([]byte, error) {
+	return p.readOctetString(ClassContextSpecific, tag)
+}
+
+func (p *Parser) readOctetString(class, tag int) ([]byte, error) {
+	if len(p.data) == 0 {
+		return nil, SyntaxError{"data truncated"}
+	}
+	if p.data[0]&0x20 == 0 {
+		return p.ReadPrimitive(class, tag)
+	}
+	q, err := p.ReadElement(class, tag)
+	if err != nil {
+		return nil, err
+	}
+	var s []byte
+	for !q.Empty() {
+		seg, err := q.ReadOctetString()
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, seg...)
+	}
+	return s, nil
+}
@@ -23,16 +23,26 @@ import (
 type Encoding struct {
 	encode    string
 	decodeMap [256]byte
+	padChar   rune
+	strict    bool
 }
 
+const (
+	StdPadding rune = '=' // Standard padding character
+	NoPadding  rune = -1  // No padding
+)
+
 const encodeStd = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567"
 const encodeHex = "0123456789ABCDEFGHIJKLMNOPQRSTUV"
 
 // NewEncoding returns a new Encoding defined by the given alphabet,
-// which must be a 32-byte string.
+// which must be a 32-byte string.  The resulting Encoding uses the
+// default padding character ('='), which may be changed or disabled
+// via WithPadding.
 func NewEncoding(encoder string) *Encoding {
 	e := new(Encoding)
 	e.encode = encoder
+	e.padChar = StdPadding
 	for i := 0; i < len(e.decodeMap); i++ {
 		e.decodeMap[i] = 0xFF
 	}
@@ -42,6 +52,31 @@ func NewEncoding(encoder string) *Encoding {
 	return e
 }
 
+// WithPadding creates a new encoding identical to enc except
+// with a specified padding character, or NoPadding to disable padding.
+// The padding character must not be '\r' or '\n', must not be
+// contained in the encoding's alphabet and must be a rune equal or
+// below '\xff'.
+func (enc Encoding) WithPadding(padding rune) *Encoding {
+	if padding == '\r' || padding == '\n' || padding > 0xff {
+		panic("invalid padding")
+	}
+	if padding != NoPadding && enc.decodeMap[byte(padding)] != 0xFF {
+		panic("padding contained in alphabet")
+	}
+	enc.padChar = padding
+	return &enc
+}
+
+// Strict creates a new encoding identical to enc except with
+// strict decoding enabled.  In this mode, the decoder requires that
+// trailing padding bits are zero, as described in RFC 4648 section 3.5,
+// so that each encoded string has a single canonical form.
+func (enc Encoding) Strict() *Encoding {
+	enc.strict = true
+	return &enc
+}
+
 // StdEncoding is the standard base32 encoding, as defined in
 // RFC 4648.
 var StdEncoding = NewEncoding(encodeStd)
@@ -50,6 +85,14 @@ var StdEncoding = NewEncoding(encodeStd)
 // It is typically used in DNS.
 var HexEncoding = NewEncoding(encodeHex)
 
+// RawStdEncoding is the standard raw, unpadded base32 encoding.
+// This is the same as StdEncoding but omits padding characters.
+var RawStdEncoding = StdEncoding.WithPadding(NoPadding)
+
+// RawHexEncoding is the unpadded ``Extended Hex Alphabet'' encoding.
+// This is the same as HexEncoding but omits padding characters.
+var RawHexEncoding = HexEncoding.WithPadding(NoPadding)
+
 var removeNewlinesMapper = func(r rune) rune {
 	if r == '\r' || r == '\n' {
 		return -1
@@ -64,8 +107,8 @@ var removeNewlinesMapper = func(r rune) rune {
 // Encode encodes src using the encoding enc, writing
 // EncodedLen(len(src)) bytes to dst.
 //
-// The encoding pads the output to a multiple of 8 bytes,
-// so Encode is not appropriate for use on individual blocks
+// If the encoding uses padding, the output is padded to a multiple
+// of 8 bytes.  Either way, Encode is not appropriate for use on individual blocks
 // of a large data stream.  Use NewEncoder() instead.
 func (enc *Encoding) Encode(dst, src []byte) {
 	if len(src) == 0 {
@@ -101,28 +144,28 @@ func (enc *Encoding) Encode(dst, src []byte) {
 			b0 = src[0] >> 3
 		}
 
-		// Encode 5-bit blocks using the base32 alphabet
-		dst[0] = enc.encode[b0]
-		dst[1] = enc.encode[b1]
-		dst[2] = enc.encode[b2]
-		dst[3] = enc.encode[b3]
-		dst[4] = enc.encode[b4]
-		dst[5] = enc.encode[b5]
-		dst[6] = enc.encode[b6]
-		dst[7] = enc.encode[b7]
-
-		// Pad the final quantum
+		// Encode 5-bit blocks using the base32 alphabet.  A final
+		// incomplete quantum is encoded in as many characters as
+		// its bits need and then padded, if the encoding pads.
+		size := 8
+		switch len(src) {
+		case 1:
+			size = 2
+		case 2:
+			size = 4
+		case 3:
+			size = 5
+		case 4:
+			size = 7
+		}
+		b := [8]byte{b0, b1, b2, b3, b4, b5, b6, b7}
+		for i := 0; i < size; i++ {
+			dst[i] = enc.encode[b[i]]
+		}
 		if len(src) < 5 {
-			dst[7] = '='
-			if len(src) < 4 {
-				dst[6] = '='
-				dst[5] = '='
-				if len(src) < 3 {
-					dst[4] = '='
-					if len(src) < 2 {
-						dst[3] = '='
-						dst[2] = '='
-					}
+			if enc.padChar != NoPadding {
+				for i := size; i < 8; i++ {
+					dst[i] = byte(enc.padChar)
 				}
 			}
 			break
@@ -202,8 +245,8 @@ func (e *encoder) Close() error {
 	// If there's anything left in the buffer, flush it out
 	if e.err == nil && e.nbuf > 0 {
 		e.enc.Encode(e.out[0:], e.buf[0:e.nbuf])
+		_, e.err = e.w.Write(e.out[0:e.enc.EncodedLen(e.nbuf)])
 		e.nbuf = 0
-		_, e.err = e.w.Write(e.out[0:8])
 	}
 	return e.err
 }
@@ -217,9 +260,83 @@ func NewEncoder(enc *Encoding, w io.Writer) io.WriteCloser {
 	return &encoder{enc: enc, w: w}
 }
 
+type lineWriter struct {
+	w       io.Writer
+	lineLen int // maximum length of a line
+	col     int // length of the current line
+}
+
+var crlf = []byte("\r\n")
+
+// Write writes p to w, breaking lines after lineLen bytes.
+// The line break is written with the first byte of the next line,
+// so that the last line is never followed by an empty one.
+func (l *lineWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		if l.col == l.lineLen {
+			if _, err = l.w.Write(crlf); err != nil {
+				return n, err
+			}
+			l.col = 0
+		}
+		m := l.lineLen - l.col
+		if m > len(p) {
+			m = len(p)
+		}
+		m, err = l.w.Write(p[:m])
+		n += m
+		l.col += m
+		if err != nil {
+			return n, err
+		}
+		p = p[m:]
+	}
+	return n, nil
+}
+
+type lineEncoder struct {
+	io.WriteCloser // the encoder
+	lw             *lineWriter
+}
+
+// Close flushes any pending output from the encoder
+// and terminates the last line.
+func (e *lineEncoder) Close() error {
+	if err := e.WriteCloser.Close(); err != nil {
+		return err
+	}
+	if e.lw.col > 0 {
+		e.lw.col = 0
+		if _, err := e.lw.w.Write(crlf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewLineEncoder returns a new base32 stream encoder like NewEncoder
+// that breaks the encoded data into lines of lineLen bytes, each
+// terminated by "\r\n".
+// The last line may be shorter.  When finished writing, the caller
+// must Close the returned encoder to flush any partially written
+// blocks and terminate the last line.
+// The decoders returned by NewDecoder skip the line breaks.
+func NewLineEncoder(enc *Encoding, w io.Writer, lineLen int) io.WriteCloser {
+	if lineLen <= 0 {
+		panic("base32: invalid line length")
+	}
+	lw := &lineWriter{w: w, lineLen: lineLen}
+	return &lineEncoder{NewEncoder(enc, lw), lw}
+}
+
 // EncodedLen returns the length in bytes of the base32 encoding
 // of an input buffer of length n.
-func (enc *Encoding) EncodedLen(n int) int { return (n + 4) / 5 * 8 }
+func (enc *Encoding) EncodedLen(n int) int {
+	if enc.padChar == NoPadding {
+		return (n*8 + 4) / 5 // minimum # chars at 5 bits per char
+	}
+	return (n + 4) / 5 * 8 // minimum # 8-char quanta, 5 bytes each
+}
 
 /*
  * Decoder
@@ -239,23 +356,29 @@ func (enc *Encoding) decode(dst, src []byte) (n int, end bool, err error) {
 	olen := len(src)
 	for len(src) > 0 && !end {
 		// Decode quantum using the base32 alphabet
+		qstart := olen - len(src)
 		var dbuf [8]byte
 		dlen := 8
 
 		for j := 0; j < 8; {
 			if len(src) == 0 {
-				return n, false, CorruptInputError(olen - len(src) - j)
+				if enc.padChar != NoPadding || j == 1 || j == 3 || j == 6 {
+					return n, false, CorruptInputError(olen - len(src) - j)
+				}
+				// An unpadded final quantum.
+				dlen, end = j, true
+				break
 			}
 			in := src[0]
 			src = src[1:]
-			if in == '=' && j >= 2 && len(src) < 8 {
+			if rune(in) == enc.padChar && j >= 2 && len(src) < 8 {
 				// We've reached the end and there's padding
 				if len(src)+j < 8-1 {
 					// not enough padding
 					return n, false, CorruptInputError(olen)
 				}
 				for k := 0; k < 8-1-j; k++ {
-					if len(src) > k && src[k] != '=' {
+					if len(src) > k && rune(src[k]) != enc.padChar {
 						// incorrect padding
 						return n, false, CorruptInputError(olen - len(src) + k - 1)
 					}
@@ -296,19 +419,28 @@ func (enc *Encoding) decode(dst, src []byte) (n int, end bool, err error) {
 		case 2:
 			dst[0] = dbuf[0]<<3 | dbuf[1]>>2
 		}
-		dst = dst[5:]
+
+		// In strict mode, the bits of the last character of a
+		// partial quantum left over after its final byte must be zero.
+		var nb int
+		var extra byte
 		switch dlen {
 		case 2:
-			n += 1
+			nb, extra = 1, dbuf[1]&0x03
 		case 4:
-			n += 2
+			nb, extra = 2, dbuf[3]&0x0F
 		case 5:
-			n += 3
+			nb, extra = 3, dbuf[4]&0x01
 		case 7:
-			n += 4
+			nb, extra = 4, dbuf[6]&0x07
 		case 8:
-			n += 5
+			nb = 5
+		}
+		if enc.strict && extra != 0 {
+			return n, end, CorruptInputError(qstart + dlen - 1)
 		}
+		dst = dst[nb:]
+		n += nb
 	}
 	return n, end, nil
 }
@@ -344,10 +476,6 @@ type decoder struct {
 }
 
 func (d *decoder) Read(p []byte) (n int, err error) {
-	if d.err != nil {
-		return 0, d.err
-	}
-
 	// Use leftover decoded output from last read.
 	if len(d.out) > 0 {
 		n = copy(p, d.out)
@@ -355,6 +483,10 @@ func (d *decoder) Read(p []byte) (n int, err error) {
 		return n, nil
 	}
 
+	if d.err != nil {
+		return 0, d.err
+	}
+
 	// Read a chunk.
 	nn := len(p) / 5 * 8
 	if nn < 8 {
@@ -365,6 +497,23 @@ func (d *decoder) Read(p []byte) (n int, err error) {
 	}
 	nn, d.err = io.ReadAtLeast(d.r, d.buf[d.nbuf:nn], 8-d.nbuf)
 	d.nbuf += nn
+	if d.enc.padChar == NoPadding && d.nbuf > 0 && d.nbuf < 8 &&
+		(d.err == io.EOF || d.err == io.ErrUnexpectedEOF) {
+		// Decode the final, unpadded quantum.
+		var nw int
+		nw, d.end, d.err = d.enc.decode(d.outbuf[0:], d.buf[0:d.nbuf])
+		d.nbuf = 0
+		d.out = d.outbuf[0:nw]
+		n = copy(p, d.out)
+		d.out = d.out[n:]
+		if d.err == nil {
+			d.err = io.EOF
+		}
+		if n > 0 || len(d.out) > 0 {
+			return n, nil
+		}
+		return 0, d.err
+	}
 	if d.nbuf < 8 {
 		return 0, d.err
 	}
@@ -423,4 +572,11 @@ func NewDecoder(enc *Encoding, r io.Reader) io.Reader {
 
 // DecodedLen returns the maximum length in bytes of the decoded data
 // corresponding to n bytes of base32-encoded data.
-func (enc *Encoding) DecodedLen(n int) int { return n / 8 * 5 }
+func (enc *Encoding) DecodedLen(n int) int {
+	if enc.padChar == NoPadding {
+		// Unpadded data may end with partial quantum.
+		return n * 5 / 8
+	}
+	// Padded base32 should always be a multiple of 8 characters in length.
+	return n / 8 * 5
+}
@@ -10,6 +10,7 @@ import (
 	"io/ioutil"
 	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 type testpair struct {
@@ -285,6 +286,147 @@ LNEBUWIIDFON2CA3DBMJXXE5LNFY==
 	}
 }
 
+// encodings lists the variants of the standard encoding, with a
+// function that converts a padded string of the standard encoding to
+// the form of each.
+var encodings = []struct {
+	enc  *Encoding
+	conv func(string) string
+}{
+	{StdEncoding, func(s string) string { return s }},
+	{RawStdEncoding, func(s string) string { return strings.TrimRight(s, "=") }},
+	{StdEncoding.WithPadding('.'), func(s string) string { return strings.Replace(s, "=", ".", -1) }},
+	{StdEncoding.Strict(), func(s string) string { return s }},
+}
+
+func TestEncodings(t *testing.T) {
+	for i, e := range encodings {
+		for _, p := range append(pairs, bigtest) {
+			want := e.conv(p.encoded)
+			got := e.enc.EncodeToString([]byte(p.decoded))
+			testEqual(t, "%d: Encode(%q) = %q, want %q", i, p.decoded, got, want)
+			testEqual(t, "%d: EncodedLen(%d) = %d, want %d", i, len(p.decoded), e.enc.EncodedLen(len(p.decoded)), len(want))
+
+			dbuf, err := e.enc.DecodeString(want)
+			testEqual(t, "%d: DecodeString(%q) = error %v, want %v", i, want, err, error(nil))
+			testEqual(t, "%d: DecodeString(%q) = %q, want %q", i, want, string(dbuf), p.decoded)
+
+			bb := &bytes.Buffer{}
+			encoder := NewEncoder(e.enc, bb)
+			encoder.Write([]byte(p.decoded))
+			encoder.Close()
+			testEqual(t, "%d: Encoder(%q) = %q, want %q", i, p.decoded, bb.String(), want)
+
+			for j, r := range readers(want) {
+				dbuf, err = ioutil.ReadAll(NewDecoder(e.enc, r))
+				testEqual(t, "%d: Decoder/%d(%q) = error %v, want %v", i, j, want, err, error(nil))
+				testEqual(t, "%d: Decoder/%d(%q) = %q, want %q", i, j, want, string(dbuf), p.decoded)
+			}
+		}
+	}
+}
+
+// readers returns readers of s that deliver it in various ways.
+func readers(s string) []io.Reader {
+	return []io.Reader{
+		strings.NewReader(s),
+		iotest.OneByteReader(strings.NewReader(s)),
+		iotest.HalfReader(strings.NewReader(s)),
+		iotest.DataErrReader(strings.NewReader(s)),
+	}
+}
+
+func TestWithPaddingPanics(t *testing.T) {
+	for _, pad := range []rune{'\r', '\n', 'A', '7', 0x100} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("WithPadding(%q) did not panic", pad)
+				}
+			}()
+			StdEncoding.WithPadding(pad)
+		}()
+	}
+}
+
+func TestStrict(t *testing.T) {
+	// Each input has nonzero bits after its last encoded byte.
+	tests := []struct {
+		enc    *Encoding
+		input  string
+		offset int
+	}{
+		{StdEncoding, "MZ======", 1},
+		{StdEncoding, "MZXR====", 3},
+		{StdEncoding, "MZXW7===", 4},
+		{StdEncoding, "MZXW6YR=", 6},
+		{RawStdEncoding, "MZ", 1},
+		{RawStdEncoding, "MZXW6YR", 6},
+	}
+	for _, tc := range tests {
+		if _, err := tc.enc.DecodeString(tc.input); err != nil {
+			t.Errorf("DecodeString(%q) = %v, want success without Strict", tc.input, err)
+		}
+		_, err := tc.enc.Strict().DecodeString(tc.input)
+		testEqual(t, "Strict DecodeString(%q) = error %v, want %v", tc.input, err, error(CorruptInputError(tc.offset)))
+		_, err = ioutil.ReadAll(NewDecoder(tc.enc.Strict(), strings.NewReader(tc.input)))
+		testEqual(t, "Strict Decoder(%q) = error %v, want %v", tc.input, err, error(CorruptInputError(tc.offset)))
+	}
+}
+
+func TestRawCorrupt(t *testing.T) {
+	for _, input := range []string{"M", "MZX", "MZXW6Y", "MY======"} {
+		if _, err := RawStdEncoding.DecodeString(input); err == nil {
+			t.Errorf("RawStdEncoding.DecodeString(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func TestLineEncoder(t *testing.T) {
+	raw := make([]byte, 1000)
+	for i := range raw {
+		raw[i] = byte(i * 7)
+	}
+	want := StdEncoding.EncodeToString(raw)
+	for _, lineLen := range []int{1, 7, 8, 72, len(want) - 1, len(want), len(want) + 1} {
+		for _, chunk := range []int{1, 3, 100, len(raw)} {
+			var bb bytes.Buffer
+			w := NewLineEncoder(StdEncoding, &bb, lineLen)
+			for p := raw; len(p) > 0; {
+				n := chunk
+				if n > len(p) {
+					n = len(p)
+				}
+				if _, err := w.Write(p[:n]); err != nil {
+					t.Fatalf("lineLen %d, chunk %d: Write: %v", lineLen, chunk, err)
+				}
+				p = p[n:]
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("lineLen %d, chunk %d: Close: %v", lineLen, chunk, err)
+			}
+			out := bb.String()
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Errorf("lineLen %d, chunk %d: output does not end in CRLF", lineLen, chunk)
+			}
+			lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+			for i, line := range lines {
+				if len(line) > lineLen || i < len(lines)-1 && len(line) != lineLen {
+					t.Errorf("lineLen %d, chunk %d: line %d has length %d", lineLen, chunk, i, len(line))
+					break
+				}
+			}
+			if got := strings.Join(lines, ""); got != want {
+				t.Errorf("lineLen %d, chunk %d: lines join to %q, want %q", lineLen, chunk, got, want)
+			}
+			decoded, err := ioutil.ReadAll(NewDecoder(StdEncoding, &bb))
+			if err != nil || !bytes.Equal(decoded, raw) {
+				t.Errorf("lineLen %d, chunk %d: decoding output = %v, %v", lineLen, chunk, decoded, err)
+			}
+		}
+	}
+}
+
 func BenchmarkEncodeToString(b *testing.B) {
 	data := make([]byte, 8192)
 	b.SetBytes(int64(len(data)))
@@ -24,16 +24,26 @@ import (
 type Encoding struct {
 	encode    string
 	decodeMap [256]byte
+	padChar   rune
+	strict    bool
 }
 
+const (
+	StdPadding rune = '=' // Standard padding character
+	NoPadding  rune = -1  // No padding
+)
+
 const encodeStd = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
 const encodeURL = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
 
 // NewEncoding returns a new Encoding defined by the given alphabet,
-// which must be a 64-byte string.
+// which must be a 64-byte string.  The resulting Encoding uses the
+// default padding character ('='), which may be changed or disabled
+// via WithPadding.
 func NewEncoding(encoder string) *Encoding {
 	e := new(Encoding)
 	e.encode = encoder
+	e.padChar = StdPadding
 	for i := 0; i < len(e.decodeMap); i++ {
 		e.decodeMap[i] = 0xFF
 	}
@@ -43,6 +53,31 @@ func NewEncoding(encoder string) *Encoding {
 	return e
 }
 
+// WithPadding creates a new encoding identical to enc except
+// with a specified padding character, or NoPadding to disable padding.
+// The padding character must not be '\r' or '\n', must not be
+// contained in the encoding's alphabet and must be a rune equal or
+// below '\xff'.
+func (enc Encoding) WithPadding(padding rune) *Encoding {
+	if padding == '\r' || padding == '\n' || padding > 0xff {
+		panic("invalid padding")
+	}
+	if padding != NoPadding && enc.decodeMap[byte(padding)] != 0xFF {
+		panic("padding contained in alphabet")
+	}
+	enc.padChar = padding
+	return &enc
+}
+
+// Strict creates a new encoding identical to enc except with
+// strict decoding enabled.  In this mode, the decoder requires that
+// trailing padding bits are zero, as described in RFC 4648 section 3.5,
+// so that each encoded string has a single canonical form.
+func (enc Encoding) Strict() *Encoding {
+	enc.strict = true
+	return &enc
+}
+
 // StdEncoding is the standard base64 encoding, as defined in
 // RFC 4648.
 var StdEncoding = NewEncoding(encodeStd)
@@ -51,6 +86,16 @@ var StdEncoding = NewEncoding(encodeStd)
 // It is typically used in URLs and file names.
 var URLEncoding = NewEncoding(encodeURL)
 
+// RawStdEncoding is the standard raw, unpadded base64 encoding,
+// as defined in RFC 4648 section 3.2.
+// This is the same as StdEncoding but omits padding characters.
+var RawStdEncoding = StdEncoding.WithPadding(NoPadding)
+
+// RawURLEncoding is the unpadded alternate base64 encoding defined in RFC 4648.
+// It is typically used in URLs and file names.
+// This is the same as URLEncoding but omits padding characters.
+var RawURLEncoding = URLEncoding.WithPadding(NoPadding)
+
 var removeNewlinesMapper = func(r rune) rune {
 	if r == '\r' || r == '\n' {
 		return -1
@@ -65,8 +110,8 @@ var removeNewlinesMapper = func(r rune) rune {
 // Encode encodes src using the encoding enc, writing
 // EncodedLen(len(src)) bytes to dst.
 //
-// The encoding pads the output to a multiple of 4 bytes,
-// so Encode is not appropriate for use on individual blocks
+// If the encoding uses padding, the output is padded to a multiple
+// of 4 bytes.  Either way, Encode is not appropriate for use on individual blocks
 // of a large data stream.  Use NewEncoder() instead.
 func (enc *Encoding) Encode(dst, src []byte) {
 	if len(src) == 0 {
@@ -95,14 +140,19 @@ func (enc *Encoding) Encode(dst, src []byte) {
 		// Encode 6-bit blocks using the base64 alphabet
 		dst[0] = enc.encode[b0]
 		dst[1] = enc.encode[b1]
-		dst[2] = enc.encode[b2]
-		dst[3] = enc.encode[b3]
-
-		// Pad the final quantum
-		if len(src) < 3 {
-			dst[3] = '='
-			if len(src) < 2 {
-				dst[2] = '='
+		if len(src) >= 3 {
+			dst[2] = enc.encode[b2]
+			dst[3] = enc.encode[b3]
+		} else {
+			// Final incomplete quantum
+			if len(src) >= 2 {
+				dst[2] = enc.encode[b2]
+			}
+			if enc.padChar != NoPadding {
+				if len(src) < 2 {
+					dst[2] = byte(enc.padChar)
+				}
+				dst[3] = byte(enc.padChar)
 			}
 			break
 		}
@@ -182,8 +232,8 @@ func (e *encoder) Close() error {
 	// If there's anything left in the buffer, flush it out
 	if e.err == nil && e.nbuf > 0 {
 		e.enc.Encode(e.out[0:], e.buf[0:e.nbuf])
+		_, e.err = e.w.Write(e.out[0:e.enc.EncodedLen(e.nbuf)])
 		e.nbuf = 0
-		_, e.err = e.w.Write(e.out[0:4])
 	}
 	return e.err
 }
@@ -197,9 +247,87 @@ func NewEncoder(enc *Encoding, w io.Writer) io.WriteCloser {
 	return &encoder{enc: enc, w: w}
 }
 
+// MIMELineLength is the maximum length of the lines of
+// base64-encoded data in MIME messages (RFC 2045).
+const MIMELineLength = 76
+
+type lineWriter struct {
+	w       io.Writer
+	lineLen int // maximum length of a line
+	col     int // length of the current line
+}
+
+var crlf = []byte("\r\n")
+
+// Write writes p to w, breaking lines after lineLen bytes.
+// The line break is written with the first byte of the next line,
+// so that the last line is never followed by an empty one.
+func (l *lineWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		if l.col == l.lineLen {
+			if _, err = l.w.Write(crlf); err != nil {
+				return n, err
+			}
+			l.col = 0
+		}
+		m := l.lineLen - l.col
+		if m > len(p) {
+			m = len(p)
+		}
+		m, err = l.w.Write(p[:m])
+		n += m
+		l.col += m
+		if err != nil {
+			return n, err
+		}
+		p = p[m:]
+	}
+	return n, nil
+}
+
+type lineEncoder struct {
+	io.WriteCloser // the encoder
+	lw             *lineWriter
+}
+
+// Close flushes any pending output from the encoder
+// and terminates the last line.
+func (e *lineEncoder) Close() error {
+	if err := e.WriteCloser.Close(); err != nil {
+		return err
+	}
+	if e.lw.col > 0 {
+		e.lw.col = 0
+		if _, err := e.lw.w.Write(crlf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewLineEncoder returns a new base64 stream encoder like NewEncoder
+// that breaks the encoded data into lines of lineLen bytes, each
+// terminated by "\r\n", as required by MIME (see MIMELineLength).
+// The last line may be shorter.  When finished writing, the caller
+// must Close the returned encoder to flush any partially written
+// blocks and terminate the last line.
+// The decoders returned by NewDecoder skip the line breaks.
+func NewLineEncoder(enc *Encoding, w io.Writer, lineLen int) io.WriteCloser {
+	if lineLen <= 0 {
+		panic("base64: invalid line length")
+	}
+	lw := &lineWriter{w: w, lineLen: lineLen}
+	return &lineEncoder{NewEncoder(enc, lw), lw}
+}
+
 // EncodedLen returns the length in bytes of the base64 encoding
 // of an input buffer of length n.
-func (enc *Encoding) EncodedLen(n int) int { return (n + 2) / 3 * 4 }
+func (enc *Encoding) EncodedLen(n int) int {
+	if enc.padChar == NoPadding {
+		return (n*8 + 5) / 6 // minimum # chars at 6 bits per char
+	}
+	return (n + 2) / 3 * 4 // minimum # 4-char quanta, 3 bytes each
+}
 
 /*
  * Decoder
@@ -219,16 +347,22 @@ func (enc *Encoding) decode(dst, src []byte) (n int, end bool, err error) {
 	olen := len(src)
 	for len(src) > 0 && !end {
 		// Decode quantum using the base64 alphabet
+		qstart := olen - len(src)
 		var dbuf [4]byte
 		dlen := 4
 
 		for j := range dbuf {
 			if len(src) == 0 {
-				return n, false, CorruptInputError(olen - len(src) - j)
+				if enc.padChar != NoPadding || j < 2 {
+					return n, false, CorruptInputError(olen - len(src) - j)
+				}
+				// An unpadded final quantum.
+				dlen, end = j, true
+				break
 			}
 			in := src[0]
 			src = src[1:]
-			if in == '=' {
+			if rune(in) == enc.padChar {
 				// We've reached the end and there's padding
 				switch j {
 				case 0, 1:
@@ -240,7 +374,7 @@ func (enc *Encoding) decode(dst, src []byte) (n int, end bool, err error) {
 						// not enough padding
 						return n, false, CorruptInputError(olen)
 					}
-					if src[0] != '=' {
+					if rune(src[0]) != enc.padChar {
 						// incorrect padding
 						return n, false, CorruptInputError(olen - len(src) - 1)
 					}
@@ -271,7 +405,13 @@ func (enc *Encoding) decode(dst, src []byte) (n int, end bool, err error) {
 		case 2:
 			dst[0] = dbuf[0]<<2 | dbuf[1]>>4
 		}
-		dst = dst[3:]
+
+		// In strict mode, the bits of the last character of a
+		// partial quantum left over after its final byte must be zero.
+		if enc.strict && (dlen == 2 && dbuf[1]&0x0F != 0 || dlen == 3 && dbuf[2]&0x03 != 0) {
+			return n, end, CorruptInputError(qstart + dlen - 1)
+		}
+		dst = dst[dlen-1:]
 		n += dlen - 1
 	}
 
@@ -309,10 +449,6 @@ type decoder struct {
 }
 
 func (d *decoder) Read(p []byte) (n int, err error) {
-	if d.err != nil {
-		return 0, d.err
-	}
-
 	// Use leftover decoded output from last read.
 	if len(d.out) > 0 {
 		n = copy(p, d.out)
@@ -320,6 +456,10 @@ func (d *decoder) Read(p []byte) (n int, err error) {
 		return n, nil
 	}
 
+	if d.err != nil {
+		return 0, d.err
+	}
+
 	// Read a chunk.
 	nn := len(p) / 3 * 4
 	if nn < 4 {
@@ -330,6 +470,23 @@ func (d *decoder) Read(p []byte) (n int, err error) {
 	}
 	nn, d.err = io.ReadAtLeast(d.r, d.buf[d.nbuf:nn], 4-d.nbuf)
 	d.nbuf += nn
+	if d.enc.padChar == NoPadding && d.nbuf > 0 && d.nbuf < 4 &&
+		(d.err == io.EOF || d.err == io.ErrUnexpectedEOF) {
+		// Decode the final, unpadded quantum.
+		var nw int
+		nw, d.end, d.err = d.enc.decode(d.outbuf[0:], d.buf[0:d.nbuf])
+		d.nbuf = 0
+		d.out = d.outbuf[0:nw]
+		n = copy(p, d.out)
+		d.out = d.out[n:]
+		if d.err == nil {
+			d.err = io.EOF
+		}
+		if n > 0 || len(d.out) > 0 {
+			return n, nil
+		}
+		return 0, d.err
+	}
 	if d.err != nil || d.nbuf < 4 {
 		return 0, d.err
 	}
@@ -388,4 +545,11 @@ func NewDecoder(enc *Encoding, r io.Reader) io.Reader {
 
 // DecodedLen returns the maximum length in bytes of the decoded data
 // corresponding to n bytes of base64-encoded data.
-func (enc *Encoding) DecodedLen(n int) int { return n / 4 * 3 }
+func (enc *Encoding) DecodedLen(n int) int {
+	if enc.padChar == NoPadding {
+		// Unpadded data may end with partial quantum.
+		return n * 6 / 8
+	}
+	// Padded base64 should always be a multiple of 4 characters in length.
+	return n / 4 * 3
+}
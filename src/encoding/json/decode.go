@@ -37,6 +37,16 @@ import (
 // To unmarshal JSON into a struct, Unmarshal matches incoming object
 // keys to the keys used by Marshal (either the struct field name or its tag),
 // preferring an exact match but also accepting a case-insensitive match.
+// Unmarshal ignores keys that match no field, and if a key occurs more
+// than once, the last value wins.  A Decoder can be configured to be
+// stricter; see its MatchCase, DisallowUnknownFields and
+// DisallowDuplicateKeys methods.
+//
+// To unmarshal a JSON object into a map, Unmarshal first establishes a map to
+// use.  If the map is nil, Unmarshal allocates a new map.  Otherwise Unmarshal
+// reuses the existing map, keeping existing entries.  Unmarshal then stores
+// key-value pairs from the JSON object into the map.  The map's key type must
+// either be a string, an integer, or implement encoding.TextUnmarshaler.
 //
 // To unmarshal JSON into an interface value,
 // Unmarshal stores one of these in the interface value:
@@ -174,6 +184,10 @@ type decodeState struct {
 	nextscan   scanner // for calls to nextValue
 	savedError error
 	useNumber  bool
+
+	disallowUnknownFields bool
+	matchCase             bool
+	disallowDuplicateKeys bool
 }
 
 // errPhase is used for errors that should not happen unless
@@ -470,6 +484,7 @@ func (d *decodeState) array(v reflect.Value) {
 }
 
 var nullLiteral = []byte("null")
+var textUnmarshalerType = reflect.TypeOf(new(encoding.TextUnmarshaler)).Elem()
 
 // object consumes an object from d.data[d.off-1:], decoding into the value v.
 // the first byte ('{') of the object has been read already.
@@ -498,16 +513,26 @@ func (d *decodeState) object(v reflect.Value) {
 		return
 	}
 
-	// Check type of target: struct or map[string]T
+	// Check type of target:
+	//   struct or
+	//   map[T1]T2 where T1 is string, an integer type,
+	//             or an encoding.TextUnmarshaler
 	switch v.Kind() {
 	case reflect.Map:
-		// map must have string kind
+		// Map key must either have string kind, have an integer kind,
+		// or be an encoding.TextUnmarshaler.
 		t := v.Type()
-		if t.Key().Kind() != reflect.String {
-			d.saveError(&UnmarshalTypeError{"object", v.Type()})
-			d.off--
-			d.next() // skip over { } in input
-			return
+		switch t.Key().Kind() {
+		case reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		default:
+			if !reflect.PtrTo(t.Key()).Implements(textUnmarshalerType) {
+				d.saveError(&UnmarshalTypeError{"object", v.Type()})
+				d.off--
+				d.next() // skip over { } in input
+				return
+			}
 		}
 		if v.IsNil() {
 			v.Set(reflect.MakeMap(t))
@@ -522,6 +547,7 @@ func (d *decodeState) object(v reflect.Value) {
 	}
 
 	var mapElem reflect.Value
+	var seen map[string]bool // keys seen, if checking for duplicates
 
 	for {
 		// Read opening " of string key or closing }.
@@ -564,11 +590,15 @@ func (d *decodeState) object(v reflect.Value) {
 					f = ff
 					break
 				}
-				if f == nil && ff.equalFold(ff.nameBytes, key) {
+				if f == nil && !d.matchCase && ff.equalFold(ff.nameBytes, key) {
 					f = ff
 				}
 			}
 			if f != nil {
+				if d.disallowDuplicateKeys {
+					// Different keys may match the same field.
+					seen = d.checkDuplicate(seen, f.name, key)
+				}
 				subv = v
 				destring = f.quoted
 				for _, i := range f.index {
@@ -580,8 +610,13 @@ func (d *decodeState) object(v reflect.Value) {
 					}
 					subv = subv.Field(i)
 				}
+			} else if d.disallowUnknownFields {
+				d.saveError(fmt.Errorf("json: unknown field %q", key))
 			}
 		}
+		if d.disallowDuplicateKeys && v.Kind() != reflect.Map && !subv.IsValid() {
+			seen = d.checkDuplicate(seen, string(key), key)
+		}
 
 		// Read : before value.
 		if op == scanSkipSpace {
@@ -608,8 +643,47 @@ func (d *decodeState) object(v reflect.Value) {
 		// Write value back to map;
 		// if using struct, subv points into struct already.
 		if v.Kind() == reflect.Map {
-			kv := reflect.ValueOf(key).Convert(v.Type().Key())
-			v.SetMapIndex(kv, subv)
+			kt := v.Type().Key()
+			var kv reflect.Value
+			switch {
+			case kt.Kind() == reflect.String:
+				kv = reflect.ValueOf(key).Convert(kt)
+			case reflect.PtrTo(kt).Implements(textUnmarshalerType):
+				kv = reflect.New(kt)
+				if err := kv.Interface().(encoding.TextUnmarshaler).UnmarshalText(key); err != nil {
+					d.saveError(err)
+					kv = reflect.Value{}
+				} else {
+					kv = kv.Elem()
+				}
+			default:
+				s := string(key)
+				switch kt.Kind() {
+				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+					n, err := strconv.ParseInt(s, 10, 64)
+					if err != nil || reflect.Zero(kt).OverflowInt(n) {
+						d.saveError(&UnmarshalTypeError{"number " + s, kt})
+						break
+					}
+					kv = reflect.ValueOf(n).Convert(kt)
+				case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+					n, err := strconv.ParseUint(s, 10, 64)
+					if err != nil || reflect.Zero(kt).OverflowUint(n) {
+						d.saveError(&UnmarshalTypeError{"number " + s, kt})
+						break
+					}
+					kv = reflect.ValueOf(n).Convert(kt)
+				default:
+					panic("json: Unexpected key type") // should never occur
+				}
+			}
+			if kv.IsValid() {
+				if d.disallowDuplicateKeys {
+					// Different keys may decode to the same map key.
+					seen = d.checkDuplicate(seen, mapKeyName(kv, key), key)
+				}
+				v.SetMapIndex(kv, subv)
+			}
 		}
 
 		// Next token must be , or }.
@@ -865,6 +939,40 @@ func (d *decodeState) arrayInterface() []interface{} {
 	return v
 }
 
+// checkDuplicate records that the object being decoded has a key with
+// the given canonical name, and saves an error if it had one already.
+// It returns the set of the names seen, which it allocates if nil.
+func (d *decodeState) checkDuplicate(seen map[string]bool, name string, key []byte) map[string]bool {
+	if seen == nil {
+		seen = make(map[string]bool)
+	}
+	if seen[name] {
+		d.saveError(fmt.Errorf("json: duplicate key %q in object", key))
+	}
+	seen[name] = true
+	return seen
+}
+
+// mapKeyName returns a canonical name for the map key kv, which was
+// decoded from key, so that keys that decode to the same value have the
+// same name.
+func mapKeyName(kv reflect.Value, key []byte) string {
+	switch kv.Kind() {
+	case reflect.String:
+		return kv.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(kv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(kv.Uint(), 10)
+	}
+	if kv.Type().Implements(textMarshalerType) {
+		if b, err := kv.Interface().(encoding.TextMarshaler).MarshalText(); err == nil {
+			return string(b)
+		}
+	}
+	return string(key)
+}
+
 // objectInterface is like object but returns map[string]interface{}.
 func (d *decodeState) objectInterface() map[string]interface{} {
 	m := make(map[string]interface{})
@@ -896,6 +1004,12 @@ func (d *decodeState) objectInterface() map[string]interface{} {
 			d.error(errPhase)
 		}
 
+		if d.disallowDuplicateKeys {
+			if _, dup := m[key]; dup {
+				d.saveError(fmt.Errorf("json: duplicate key %q in object", key))
+			}
+		}
+
 		// Read value.
 		m[key] = d.valueInterface()
 
@@ -411,8 +411,74 @@ var unmarshalTests = []unmarshalTest{
 	{
 		in:  `{"2009-11-10T23:00:00Z": "hello world"}`,
 		ptr: &map[time.Time]string{},
-		err: &UnmarshalTypeError{"object", reflect.TypeOf(map[time.Time]string{})},
+		out: map[time.Time]string{time.Date(2009, 11, 10, 23, 0, 0, 0, time.UTC): "hello world"},
 	},
+
+	// integer and encoding.TextUnmarshaler map keys
+	{
+		in:  `{"-1":"a","0":"b","127":"c"}`,
+		ptr: new(map[int8]string),
+		out: map[int8]string{-1: "a", 0: "b", 127: "c"},
+	},
+	{
+		in:  `{"18446744073709551615":1,"0":2}`,
+		ptr: new(map[uint64]int),
+		out: map[uint64]int{18446744073709551615: 1, 0: 2},
+	},
+	{
+		in:  `{"128":"x"}`,
+		ptr: new(map[int8]string),
+		err: &UnmarshalTypeError{"number 128", reflect.TypeOf(int8(0))},
+	},
+	{
+		in:  `{"-1":"x"}`,
+		ptr: new(map[uint]string),
+		err: &UnmarshalTypeError{"number -1", reflect.TypeOf(uint(0))},
+	},
+	{
+		in:  `{"x":1}`,
+		ptr: new(map[int]int),
+		err: &UnmarshalTypeError{"number x", reflect.TypeOf(0)},
+	},
+	{
+		in:  `{"a":1,"b":2}`,
+		ptr: new(map[textKey]int),
+		out: map[textKey]int{textKey{"a"}: 1, textKey{"b"}: 2},
+	},
+	{
+		in:  `{"a":1}`,
+		ptr: new(map[textKeyString]int),
+		out: map[textKeyString]int{"a": 1},
+	},
+	{
+		in:  `{"a":1}`,
+		ptr: new(map[bool]int),
+		err: &UnmarshalTypeError{"object", reflect.TypeOf(map[bool]int{})},
+	},
+}
+
+// textKey is a map key type implementing encoding.TextMarshaler
+// and encoding.TextUnmarshaler.
+type textKey struct {
+	s string
+}
+
+func (k textKey) MarshalText() ([]byte, error) { return []byte(k.s), nil }
+
+func (k *textKey) UnmarshalText(b []byte) error {
+	k.s = string(b)
+	return nil
+}
+
+// textKeyString is a map key type of string kind whose text methods
+// are ignored in favor of the string itself.
+type textKeyString string
+
+func (k textKeyString) MarshalText() ([]byte, error) { return []byte("wrong"), nil }
+
+func (k *textKeyString) UnmarshalText(b []byte) error {
+	*k = "wrong"
+	return nil
 }
 
 func TestMarshal(t *testing.T) {
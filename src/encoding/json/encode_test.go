@@ -6,6 +6,7 @@ package json
 
 import (
 	"bytes"
+	"errors"
 	"math"
 	"reflect"
 	"testing"
@@ -374,48 +375,51 @@ func TestDuplicatedFieldDisappears(t *testing.T) {
 }
 
 func TestStringBytes(t *testing.T) {
-	// Test that encodeState.stringBytes and encodeState.string use the same encoding.
-	es := &encodeState{}
-	var r []rune
-	for i := '\u0000'; i <= unicode.MaxRune; i++ {
-		r = append(r, i)
-	}
-	s := string(r) + "\xff\xff\xffhello" // some invalid UTF-8 too
-	_, err := es.string(s)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	esBytes := &encodeState{}
-	_, err = esBytes.stringBytes([]byte(s))
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	enc := es.Buffer.String()
-	encBytes := esBytes.Buffer.String()
-	if enc != encBytes {
-		i := 0
-		for i < len(enc) && i < len(encBytes) && enc[i] == encBytes[i] {
-			i++
+	// Test that encodeState.stringBytes and encodeState.string use the same encoding,
+	// with and without HTML escaping.
+	for _, escapeHTML := range []bool{true, false} {
+		es := &encodeState{escapeHTML: escapeHTML}
+		var r []rune
+		for i := '\u0000'; i <= unicode.MaxRune; i++ {
+			r = append(r, i)
 		}
-		enc = enc[i:]
-		encBytes = encBytes[i:]
-		i = 0
-		for i < len(enc) && i < len(encBytes) && enc[len(enc)-i-1] == encBytes[len(encBytes)-i-1] {
-			i++
+		s := string(r) + "\xff\xff\xffhello" // some invalid UTF-8 too
+		_, err := es.string(s)
+		if err != nil {
+			t.Fatal(err)
 		}
-		enc = enc[:len(enc)-i]
-		encBytes = encBytes[:len(encBytes)-i]
 
-		if len(enc) > 20 {
-			enc = enc[:20] + "..."
-		}
-		if len(encBytes) > 20 {
-			encBytes = encBytes[:20] + "..."
+		esBytes := &encodeState{escapeHTML: escapeHTML}
+		_, err = esBytes.stringBytes([]byte(s))
+		if err != nil {
+			t.Fatal(err)
 		}
 
-		t.Errorf("encodings differ at %#q vs %#q", enc, encBytes)
+		enc := es.Buffer.String()
+		encBytes := esBytes.Buffer.String()
+		if enc != encBytes {
+			i := 0
+			for i < len(enc) && i < len(encBytes) && enc[i] == encBytes[i] {
+				i++
+			}
+			enc = enc[i:]
+			encBytes = encBytes[i:]
+			i = 0
+			for i < len(enc) && i < len(encBytes) && enc[len(enc)-i-1] == encBytes[len(encBytes)-i-1] {
+				i++
+			}
+			enc = enc[:len(enc)-i]
+			encBytes = encBytes[:len(encBytes)-i]
+
+			if len(enc) > 20 {
+				enc = enc[:20] + "..."
+			}
+			if len(encBytes) > 20 {
+				encBytes = encBytes[:20] + "..."
+			}
+
+			t.Errorf("escapeHTML=%v: encodings differ at %#q vs %#q", escapeHTML, enc, encBytes)
+		}
 	}
 }
 
@@ -530,3 +534,44 @@ func TestEncodeString(t *testing.T) {
 		}
 	}
 }
+
+func TestMapKeys(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want string
+	}{
+		{map[int]string{10: "b", -2: "a", 3: "c"}, `{"-2":"a","10":"b","3":"c"}`},
+		{map[uint8]bool{255: true, 0: false}, `{"0":false,"255":true}`},
+		{map[uintptr]int{1: 1}, `{"1":1}`},
+		{map[textKey]int{textKey{"z"}: 1, textKey{"<a>"}: 2}, `{"\u003ca\u003e":2,"z":1}`},
+		{map[textKeyString]int{"b": 1, "a": 2}, `{"a":2,"b":1}`},
+	}
+	for _, tt := range tests {
+		b, err := Marshal(tt.in)
+		if err != nil {
+			t.Errorf("Marshal(%v): %v", tt.in, err)
+			continue
+		}
+		if string(b) != tt.want {
+			t.Errorf("Marshal(%v) = %s, want %s", tt.in, b, tt.want)
+		}
+	}
+
+	// Keys of other types are unsupported.
+	if _, err := Marshal(map[bool]int{true: 1}); err == nil {
+		t.Errorf("Marshal(map[bool]int) did not fail")
+	} else if _, ok := err.(*UnsupportedTypeError); !ok {
+		t.Errorf("Marshal(map[bool]int): got error %T, want *UnsupportedTypeError", err)
+	}
+}
+
+type badTextKey int
+
+func (badTextKey) MarshalText() ([]byte, error) { return nil, errors.New("bad key") }
+
+func TestMapKeyMarshalerError(t *testing.T) {
+	_, err := Marshal(map[badTextKey]int{1: 1})
+	if _, ok := err.(*MarshalerError); !ok {
+		t.Errorf("got error %v, want *MarshalerError", err)
+	}
+}
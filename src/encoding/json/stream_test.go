@@ -53,6 +53,77 @@ func TestEncoder(t *testing.T) {
 	}
 }
 
+var streamEncodedIndent = `0.1
+"hello"
+null
+true
+false
+[
+>."a",
+>."b",
+>."c"
+>]
+{
+>."ß": "long s",
+>."K": "Kelvin"
+>}
+3.14
+`
+
+func TestEncoderIndent(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetIndent(">", ".")
+	for _, v := range streamTest {
+		enc.Encode(v)
+	}
+	if have, want := buf.String(), streamEncodedIndent; have != want {
+		t.Error("indented encoding mismatch")
+		diff(t, []byte(have), []byte(want))
+	}
+}
+
+func TestEncoderSetEscapeHTML(t *testing.T) {
+	var c C
+	var ct CText
+	for _, tt := range []struct {
+		name       string
+		v          interface{}
+		wantEscape string
+		want       string
+	}{
+		{"c", c, `"\u003c\u0026\u003e"`, `"<&>"`},
+		{"ct", ct, `"\"\u003c\u0026\u003e\""`, `"\"<&>\""`},
+		{`"<str>"`, "<str>", `"\u003cstr\u003e"`, `"<str>"`},
+		{
+			"tagStruct",
+			struct {
+				Str string `json:",string"`
+			}{"<&>"},
+			`{"Str":"\"\\u003c\\u0026\\u003e\""}`,
+			`{"Str":"\"<&>\""}`,
+		},
+	} {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		if err := enc.Encode(tt.v); err != nil {
+			t.Fatalf("Encode(%s): %s", tt.name, err)
+		}
+		if got := strings.TrimSpace(buf.String()); got != tt.wantEscape {
+			t.Errorf("Encode(%s) = %#q, want %#q", tt.name, got, tt.wantEscape)
+		}
+		buf.Reset()
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(tt.v); err != nil {
+			t.Fatalf("SetEscapeHTML(false) Encode(%s): %s", tt.name, err)
+		}
+		if got := strings.TrimSpace(buf.String()); got != tt.want {
+			t.Errorf("SetEscapeHTML(false) Encode(%s) = %#q, want %#q",
+				tt.name, got, tt.want)
+		}
+	}
+}
+
 func TestDecoder(t *testing.T) {
 	for i := 0; i <= len(streamTest); i++ {
 		// Use stream without newlines as input,
@@ -204,3 +275,89 @@ func BenchmarkEncoderEncode(b *testing.B) {
 		}
 	}
 }
+
+type strictT struct {
+	Name  string
+	Count int `json:"count"`
+	Inner *strictT
+}
+
+// foldKey is a map key type whose UnmarshalText folds case, so that
+// different keys decode to the same value.
+type foldKey struct {
+	s string
+}
+
+func (k foldKey) MarshalText() ([]byte, error) { return []byte(k.s), nil }
+
+func (k *foldKey) UnmarshalText(b []byte) error {
+	k.s = strings.ToLower(string(b))
+	return nil
+}
+
+var strictTests = []struct {
+	in      string
+	unknown bool // DisallowUnknownFields
+	exact   bool // MatchCase
+	dups    bool // DisallowDuplicateKeys
+	v       interface{}
+	out     interface{}
+	err     string
+}{
+	{in: `{"Name":"a","count":1,"Extra":true}`, v: new(strictT), out: &strictT{Name: "a", Count: 1}},
+	{in: `{"Name":"a","count":1,"Extra":true}`, unknown: true, v: new(strictT), out: &strictT{Name: "a", Count: 1}, err: `json: unknown field "Extra"`},
+	{in: `{"Inner":{"extra":1}}`, unknown: true, v: new(strictT), out: &strictT{Inner: &strictT{}}, err: `json: unknown field "extra"`},
+	{in: `{"Extra":true}`, unknown: true, v: new(map[string]interface{}), out: &map[string]interface{}{"Extra": true}},
+	{in: `{"name":"a","COUNT":1}`, v: new(strictT), out: &strictT{Name: "a", Count: 1}},
+	{in: `{"name":"a","COUNT":1}`, exact: true, v: new(strictT), out: &strictT{}},
+	{in: `{"name":"a","Name":"b"}`, exact: true, unknown: true, v: new(strictT), out: &strictT{Name: "b"}, err: `json: unknown field "name"`},
+	{in: `{"Name":"a","Name":"b"}`, v: new(strictT), out: &strictT{Name: "b"}},
+	{in: `{"Name":"a","Name":"b"}`, dups: true, v: new(strictT), out: &strictT{Name: "b"}, err: `json: duplicate key "Name" in object`},
+	{in: `{"Name":"a","name":"b"}`, dups: true, v: new(strictT), out: &strictT{Name: "b"}, err: `json: duplicate key "name" in object`},
+	{in: `{"Name":"a","name":"b"}`, dups: true, exact: true, v: new(strictT), out: &strictT{Name: "a"}},
+	{in: `{"x":1,"y":{"x":2},"z":3}`, dups: true, v: new(map[string]interface{}), out: &map[string]interface{}{"x": 1.0, "y": map[string]interface{}{"x": 2.0}, "z": 3.0}},
+	{in: `{"x":1,"x":2}`, dups: true, v: new(map[string]int), out: &map[string]int{"x": 2}, err: `json: duplicate key "x" in object`},
+	{in: `{"1":1,"01":2}`, v: new(map[int]int), out: &map[int]int{1: 2}},
+	{in: `{"1":1,"01":2}`, dups: true, v: new(map[int]int), out: &map[int]int{1: 2}, err: `json: duplicate key "01" in object`},
+	{in: `{"1":1,"+1":2}`, dups: true, v: new(map[int]int), out: &map[int]int{1: 2}, err: `json: duplicate key "+1" in object`},
+	{in: `{"1":1,"01":2}`, dups: true, v: new(map[uint]int), out: &map[uint]int{1: 2}, err: `json: duplicate key "01" in object`},
+	{in: `{"A":1,"a":2}`, dups: true, v: new(map[foldKey]int), out: &map[foldKey]int{foldKey{"a"}: 2}, err: `json: duplicate key "a" in object`},
+	{in: `[{"x":1},{"x":2,"x":3}]`, dups: true, v: new(interface{}), out: func() interface{} {
+		var v interface{} = []interface{}{map[string]interface{}{"x": 1.0}, map[string]interface{}{"x": 3.0}}
+		return &v
+	}(), err: `json: duplicate key "x" in object`},
+	{in: `{"Extra":1,"Extra":2}`, dups: true, v: new(strictT), out: new(strictT), err: `json: duplicate key "Extra" in object`},
+}
+
+func TestDecoderStrict(t *testing.T) {
+	for i, tt := range strictTests {
+		dec := NewDecoder(strings.NewReader(tt.in))
+		if tt.unknown {
+			dec.DisallowUnknownFields()
+		}
+		if tt.exact {
+			dec.MatchCase()
+		}
+		if tt.dups {
+			dec.DisallowDuplicateKeys()
+		}
+		err := dec.Decode(tt.v)
+		if tt.err == "" && err != nil || tt.err != "" && (err == nil || err.Error() != tt.err) {
+			t.Errorf("#%d: %s: error %v, want %q", i, tt.in, err, tt.err)
+		}
+		if !reflect.DeepEqual(tt.v, tt.out) {
+			t.Errorf("#%d: %s: have %+v, want %+v", i, tt.in, tt.v, tt.out)
+		}
+	}
+}
+
+func TestUnmarshalNotStrict(t *testing.T) {
+	// Unmarshal keeps the lenient behavior of the zero Decoder.
+	var v strictT
+	if err := Unmarshal([]byte(`{"NAME":"a","Name":"b","extra":1}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "b" {
+		t.Errorf("Name = %q, want %q", v.Name, "b")
+	}
+}
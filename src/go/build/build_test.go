@@ -222,3 +222,38 @@ func TestImportCmd(t *testing.T) {
 		t.Fatalf("Import cmd/internal/objfile returned Dir=%q, want %q", filepath.ToSlash(p.Dir), ".../src/cmd/internal/objfile")
 	}
 }
+
+func TestImportVendor(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctxt := Default
+	ctxt.GOPATH = filepath.Join(cwd, "testdata/withvendor")
+	src := filepath.Join(ctxt.GOPATH, "src")
+
+	tests := []struct {
+		srcDir string
+		mode   ImportMode
+		want   string
+	}{
+		{"a/b/sub", 0, "a/b/vendor/c/d"},
+		{"a/b", 0, "a/b/vendor/c/d"},
+		{"a", 0, "a/vendor/c/d"},
+		{"c", 0, "c/d"},
+		{"a/b/sub", IgnoreVendor, "c/d"},
+	}
+	for _, tt := range tests {
+		p, err := ctxt.Import("c/d", filepath.Join(src, tt.srcDir), tt.mode)
+		if err != nil {
+			t.Errorf("Import(%q, %q, %d): %v", "c/d", tt.srcDir, tt.mode, err)
+			continue
+		}
+		if p.ImportPath != tt.want {
+			t.Errorf("Import(%q, %q, %d).ImportPath = %q, want %q", "c/d", tt.srcDir, tt.mode, p.ImportPath, tt.want)
+		}
+		if want := filepath.Join(src, tt.want); p.Dir != want {
+			t.Errorf("Import(%q, %q, %d).Dir = %q, want %q", "c/d", tt.srcDir, tt.mode, p.Dir, want)
+		}
+	}
+}
@@ -53,6 +53,11 @@ var pkgDeps = map[string][]string{
 	"unicode/utf16": {},
 	"unicode/utf8":  {},
 
+	"container/list":    {},
+	"sync/errgroup":     {"L0"},
+	"sync/semaphore":    {"L0", "container/list"},
+	"sync/singleflight": {"L0"},
+
 	"L1": {
 		"L0",
 		"math",
@@ -60,6 +65,9 @@ var pkgDeps = map[string][]string{
 		"math/rand",
 		"sort",
 		"strconv",
+		"sync/errgroup",
+		"sync/semaphore",
+		"sync/singleflight",
 		"unicode/utf16",
 		"unicode/utf8",
 	},
@@ -144,11 +152,12 @@ var pkgDeps = map[string][]string{
 	"log": {"L1", "os", "fmt", "time"},
 
 	// Packages used by testing must be low-level (L2+fmt).
-	"regexp":         {"L2", "regexp/syntax"},
-	"regexp/syntax":  {"L2"},
-	"runtime/debug":  {"L2", "fmt", "io/ioutil", "os", "time"},
-	"runtime/pprof":  {"L2", "fmt", "text/tabwriter"},
-	"text/tabwriter": {"L2"},
+	"regexp":          {"L2", "regexp/syntax"},
+	"regexp/syntax":   {"L2"},
+	"runtime/debug":   {"L2", "fmt", "io/ioutil", "os", "time"},
+	"runtime/metrics": {"L0", "math"},
+	"runtime/pprof":   {"L2", "fmt", "text/tabwriter"},
+	"text/tabwriter":  {"L2"},
 
 	"testing":        {"L2", "flag", "fmt", "os", "runtime/pprof", "time"},
 	"testing/iotest": {"L2", "log"},
@@ -212,6 +221,7 @@ var pkgDeps = map[string][]string{
 	"image/jpeg":          {"L4"},
 	"image/png":           {"L4", "compress/zlib"},
 	"index/suffixarray":   {"L4", "regexp"},
+	"internal/trace":      {"L4", "OS"},
 	"math/big":            {"L4"},
 	"mime":                {"L4", "OS", "syscall"},
 	"net/url":             {"L4"},
@@ -256,23 +266,37 @@ var pkgDeps = map[string][]string{
 	"net/textproto": {"L4", "OS", "net"},
 
 	// Core crypto.
-	"crypto/aes":    {"L3"},
-	"crypto/des":    {"L3"},
-	"crypto/hmac":   {"L3"},
-	"crypto/md5":    {"L3"},
-	"crypto/rc4":    {"L3"},
-	"crypto/sha1":   {"L3"},
-	"crypto/sha256": {"L3"},
-	"crypto/sha512": {"L3"},
+	"crypto/aes":     {"L3"},
+	"crypto/argon2":  {"L3", "crypto/blake2b"},
+	"crypto/blake2b": {"L3"},
+	"crypto/blake2s": {"L3"},
+	"crypto/des":     {"L3"},
+	"crypto/hkdf":    {"L3", "crypto/hmac"},
+	"crypto/hmac":    {"L3"},
+	"crypto/md5":     {"L3"},
+	"crypto/pbkdf2":  {"L3", "crypto/hmac"},
+	"crypto/rc4":     {"L3"},
+	"crypto/scrypt":  {"L3", "crypto/pbkdf2", "crypto/sha256"},
+	"crypto/sha1":    {"L3"},
+	"crypto/sha256":  {"L3"},
+	"crypto/sha3":    {"L3"},
+	"crypto/sha512":  {"L3"},
 
 	"CRYPTO": {
 		"crypto/aes",
+		"crypto/argon2",
+		"crypto/blake2b",
+		"crypto/blake2s",
 		"crypto/des",
+		"crypto/hkdf",
 		"crypto/hmac",
 		"crypto/md5",
+		"crypto/pbkdf2",
 		"crypto/rc4",
+		"crypto/scrypt",
 		"crypto/sha1",
 		"crypto/sha256",
+		"crypto/sha3",
 		"crypto/sha512",
 	},
 
@@ -281,6 +305,9 @@ var pkgDeps = map[string][]string{
 	// math/big, which imports fmt.
 	"crypto/rand": {"L4", "CRYPTO", "OS", "math/big", "syscall", "internal/syscall"},
 
+	// Password hashing, which needs random salts.
+	"crypto/bcrypt": {"L4", "CRYPTO", "crypto/rand"},
+
 	// Mathematical crypto: dependencies on fmt (L4) and math/big.
 	// We could avoid some of the fmt, but math/big imports fmt anyway.
 	"crypto/dsa":      {"L4", "CRYPTO", "math/big"},
@@ -306,9 +333,14 @@ var pkgDeps = map[string][]string{
 	},
 	"crypto/x509": {
 		"L4", "CRYPTO-MATH", "OS", "CGO",
-		"crypto/x509/pkix", "encoding/pem", "encoding/hex", "net", "syscall",
+		"crypto/x509/internal/pbes2", "crypto/x509/pkix", "encoding/pem", "encoding/hex", "net", "syscall",
+	},
+	"crypto/x509/internal/pbes2": {"L4", "CRYPTO-MATH", "crypto/x509/pkix"},
+	"crypto/x509/pkcs12": {
+		"L4", "CRYPTO-MATH", "crypto/tls", "crypto/x509", "crypto/x509/internal/pbes2", "crypto/x509/pkcs7", "crypto/x509/pkix",
 	},
-	"crypto/x509/pkix": {"L4", "CRYPTO-MATH"},
+	"crypto/x509/pkcs7": {"L4", "CRYPTO-MATH", "crypto/x509", "crypto/x509/pkix"},
+	"crypto/x509/pkix":  {"L4", "CRYPTO-MATH"},
 
 	// Simple net+crypto-aware packages.
 	"mime/multipart": {"L4", "OS", "mime", "crypto/rand", "net/textproto"},
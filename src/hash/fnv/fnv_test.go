@@ -6,6 +6,7 @@ package fnv
 
 import (
 	"bytes"
+	"encoding"
 	"encoding/binary"
 	"hash"
 	"testing"
@@ -76,6 +77,55 @@ func testGolden(t *testing.T, hash hash.Hash, gold []golden) {
 	}
 }
 
+func TestGoldenMarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		newHash func() hash.Hash
+		gold    []golden
+	}{
+		{"32", func() hash.Hash { return New32() }, golden32},
+		{"32a", func() hash.Hash { return New32a() }, golden32a},
+		{"64", func() hash.Hash { return New64() }, golden64},
+		{"64a", func() hash.Hash { return New64a() }, golden64a},
+	}
+	for _, tt := range tests {
+		for _, g := range tt.gold {
+			h := tt.newHash()
+			h2 := tt.newHash()
+
+			h.Write([]byte(g.text[:len(g.text)/2]))
+
+			state, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+			if err != nil {
+				t.Errorf("fnv%s: could not marshal: %v", tt.name, err)
+				continue
+			}
+
+			if err := h2.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+				t.Errorf("fnv%s: could not unmarshal: %v", tt.name, err)
+				continue
+			}
+
+			h.Write([]byte(g.text[len(g.text)/2:]))
+			h2.Write([]byte(g.text[len(g.text)/2:]))
+
+			if actual, actual2 := h.Sum(nil), h2.Sum(nil); !bytes.Equal(actual, actual2) {
+				t.Errorf("fnv%s(%q) = 0x%x != marshaled 0x%x", tt.name, g.text, actual, actual2)
+			}
+		}
+	}
+}
+
+func TestMarshalTypeMismatch(t *testing.T) {
+	state, err := New32().(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		t.Fatalf("could not marshal: %v", err)
+	}
+	if err := New32a().(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err == nil {
+		t.Errorf("no error when unmarshaling FNV-1 state into FNV-1a hash")
+	}
+}
+
 func TestIntegrity32(t *testing.T) {
 	testIntegrity(t, New32())
 }
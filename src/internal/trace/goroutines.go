@@ -0,0 +1,147 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trace
+
+// GDesc contains statistics about execution of a single goroutine.
+type GDesc struct {
+	ID           uint64
+	Name         string // function name of the goroutine, if known
+	PC           uint64
+	CreationTime int64
+	StartTime    int64
+	EndTime      int64
+
+	ExecTime      int64
+	SchedWaitTime int64
+	IOTime        int64
+	BlockTime     int64
+	SyscallTime   int64
+	SweepTime     int64
+	TotalTime     int64
+
+	*gdesc // private part
+}
+
+// gdesc is a private part of GDesc that is required only during analysis.
+type gdesc struct {
+	lastStartTime    int64
+	blockNetTime     int64
+	blockSyncTime    int64
+	blockSyscallTime int64
+	blockSweepTime   int64
+	blockSchedTime   int64
+}
+
+// GoroutineStats generates statistics for all goroutines in the trace.
+func GoroutineStats(events []*Event) map[uint64]*GDesc {
+	gs := make(map[uint64]*GDesc)
+	g := func(id uint64) *GDesc {
+		d := gs[id]
+		if d == nil {
+			d = &GDesc{ID: id, gdesc: new(gdesc)}
+			gs[id] = d
+		}
+		return d
+	}
+	var lastTs int64
+	for _, ev := range events {
+		lastTs = ev.Ts
+		if ev.G != 0 && len(ev.Stk) != 0 {
+			d := g(ev.G)
+			if d.Name == "" {
+				// The outermost frame is the goroutine's function.
+				f := ev.Stk[len(ev.Stk)-1]
+				d.Name = f.Fn
+				d.PC = f.PC
+			}
+		}
+		switch ev.Type {
+		case EvGoCreate:
+			d := g(ev.Args[0])
+			d.CreationTime = ev.Ts
+			d.blockSchedTime = ev.Ts
+		case EvGoStart:
+			d := g(ev.G)
+			if d.StartTime == 0 {
+				d.StartTime = ev.Ts
+			}
+			if d.blockSchedTime != 0 {
+				d.SchedWaitTime += ev.Ts - d.blockSchedTime
+				d.blockSchedTime = 0
+			}
+			d.lastStartTime = ev.Ts
+		case EvGoEnd, EvGoStop:
+			d := g(ev.G)
+			d.exec(ev.Ts)
+			d.EndTime = ev.Ts
+			d.TotalTime = ev.Ts - d.CreationTime
+		case EvGoBlockSend, EvGoBlockRecv, EvGoBlockSelect,
+			EvGoBlockSync, EvGoBlockCond:
+			d := g(ev.G)
+			d.exec(ev.Ts)
+			d.blockSyncTime = ev.Ts
+		case EvGoSched, EvGoPreempt:
+			d := g(ev.G)
+			d.exec(ev.Ts)
+			d.blockSchedTime = ev.Ts
+		case EvGoSleep, EvGoBlock:
+			d := g(ev.G)
+			d.exec(ev.Ts)
+		case EvGoBlockNet:
+			d := g(ev.G)
+			d.exec(ev.Ts)
+			d.blockNetTime = ev.Ts
+		case EvGoUnblock:
+			d := g(ev.Args[0])
+			if d.blockNetTime != 0 {
+				d.IOTime += ev.Ts - d.blockNetTime
+				d.blockNetTime = 0
+			}
+			if d.blockSyncTime != 0 {
+				d.BlockTime += ev.Ts - d.blockSyncTime
+				d.blockSyncTime = 0
+			}
+			d.blockSchedTime = ev.Ts
+		case EvGoSysBlock:
+			d := g(ev.G)
+			d.exec(ev.Ts)
+			d.blockSyscallTime = ev.Ts
+		case EvGoSysExit:
+			d := g(ev.G)
+			if d.blockSyscallTime != 0 {
+				d.SyscallTime += ev.Ts - d.blockSyscallTime
+				d.blockSyscallTime = 0
+			}
+			d.blockSchedTime = ev.Ts
+		case EvGCSweepStart:
+			if ev.G != 0 {
+				g(ev.G).blockSweepTime = ev.Ts
+			}
+		case EvGCSweepDone:
+			if d := gs[ev.G]; ev.G != 0 && d != nil && d.blockSweepTime != 0 {
+				d.SweepTime += ev.Ts - d.blockSweepTime
+				d.blockSweepTime = 0
+			}
+		}
+	}
+
+	// Account for goroutines that were still alive at the end of the trace.
+	for _, d := range gs {
+		if d.EndTime == 0 {
+			d.TotalTime = lastTs - d.CreationTime
+		}
+		d.gdesc = nil
+	}
+	delete(gs, 0)
+	return gs
+}
+
+// exec accounts the time the goroutine has been running since its last start.
+func (d *GDesc) exec(ts int64) {
+	if d.lastStartTime != 0 {
+		d.ExecTime += ts - d.lastStartTime
+		d.lastStartTime = 0
+	}
+}
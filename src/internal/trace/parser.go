@@ -0,0 +1,599 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package trace parses the binary execution traces produced by
+// runtime.StartTrace and runtime/pprof.StartTrace.
+package trace
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Event describes one event in the trace.
+type Event struct {
+	Off   int       // offset in input file (for debugging and error reporting)
+	Type  byte      // one of Ev*
+	Ts    int64     // timestamp in nanoseconds
+	P     int       // P on which the event happened (can be one of TimerP, NetpollP, SyscallP)
+	G     uint64    // G on which the event happened
+	StkID uint64    // unique stack ID
+	Stk   []*Frame  // stack trace (can be empty)
+	Args  [2]uint64 // event-type-specific arguments
+	// linked event (can be nil), depends on event type:
+	// for GCStart: the GCDone
+	// for GCScanStart: the GCScanDone
+	// for GCSweepStart: the GCSweepDone
+	// for GCSTWStart: the GCSTWDone
+	// for GoCreate: first GoStart of the created goroutine
+	// for GoStart: the associated GoEnd, GoBlock or other blocking event
+	// for GoSched/GoPreempt: the next GoStart
+	// for GoBlock and other blocking events: the unblock event
+	// for GoUnblock: the associated GoStart
+	// for blocking GoSysCall: the associated GoSysExit
+	// for GoSysExit: the next GoStart
+	Link *Event
+}
+
+// Frame is a frame in stack traces.
+type Frame struct {
+	PC   uint64
+	Fn   string
+	File string
+	Line int
+}
+
+const (
+	// Special P identifiers:
+	FakeP    = 1000000 + iota
+	TimerP   // depicts timer unblocks
+	NetpollP // depicts network unblocks
+	SyscallP // depicts returns from syscalls
+)
+
+// Parse parses, post-processes and verifies the trace.
+// Parsing is lenient: events that cannot be matched up with their
+// counterparts (for example because the trace was started while
+// a goroutine was already blocked) are kept but left unlinked.
+func Parse(r io.Reader) ([]*Event, error) {
+	rawEvents, err := readTrace(r)
+	if err != nil {
+		return nil, err
+	}
+	events, err := parseEvents(rawEvents)
+	if err != nil {
+		return nil, err
+	}
+	postProcessTrace(events)
+	return events, nil
+}
+
+// rawEvent is a helper type used during parsing.
+type rawEvent struct {
+	off  int
+	typ  byte
+	args []uint64
+}
+
+// readTrace does wire-format parsing and verification.
+// It does not care about specific event types and argument meaning.
+func readTrace(r io.Reader) ([]rawEvent, error) {
+	// Read and validate trace header.
+	var buf [16]byte
+	off, err := io.ReadFull(r, buf[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: read %v, err %v", off, err)
+	}
+	if !bytes.Equal(buf[:], []byte("go 1.5 trace\x00\x00\x00\x00")) {
+		return nil, fmt.Errorf("not a trace file")
+	}
+
+	// Read events.
+	var events []rawEvent
+	br := bufio.NewReader(r)
+	for {
+		// Read event type and number of arguments (1 byte).
+		off0 := off
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read trace at offset %d: %v", off, err)
+		}
+		off++
+		typ := b << 2 >> 2
+		narg := b >> 6
+		if typ == EvNone || typ >= EvCount {
+			return nil, fmt.Errorf("unknown event type %v at offset 0x%x", typ, off0)
+		}
+		ev := rawEvent{typ: typ, off: off0}
+		if narg < 3 {
+			for i := 0; i < int(narg)+1; i++ { // first argument is timestamp, or P for batches
+				var v uint64
+				v, off, err = readVal(br, off)
+				if err != nil {
+					return nil, err
+				}
+				ev.args = append(ev.args, v)
+			}
+		} else {
+			// If narg == 3, the first value is length of the event in bytes.
+			var v uint64
+			v, off, err = readVal(br, off)
+			if err != nil {
+				return nil, err
+			}
+			evLen := v
+			off1 := off
+			for evLen > uint64(off-off1) {
+				v, off, err = readVal(br, off)
+				if err != nil {
+					return nil, err
+				}
+				ev.args = append(ev.args, v)
+			}
+			if evLen != uint64(off-off1) {
+				return nil, fmt.Errorf("event has wrong length at offset 0x%x: want %v, got %v", off0, evLen, off-off1)
+			}
+		}
+		events = append(events, ev)
+	}
+	return events, nil
+}
+
+// parseEvents transforms raw events into events.
+// It does analyze and verify per-event-type arguments.
+func parseEvents(rawEvents []rawEvent) ([]*Event, error) {
+	var ticksPerSec, lastTs int64
+	var lastG, timerGoid uint64
+	var lastP int
+	lastGs := make(map[int]uint64) // last goroutine running on P
+	stacks := make(map[uint64][]*Frame)
+	var events []*Event
+	for _, raw := range rawEvents {
+		desc := EventDescriptions[raw.typ]
+		narg := len(desc.Args)
+		if desc.Stack {
+			narg++
+		}
+		switch raw.typ {
+		case EvBatch, EvFrequency, EvTimerGoroutine:
+			// These events have no timestamp, only arguments.
+		case EvStack:
+			narg = 2 // and a variable number of PCs
+		default:
+			narg++ // timestamp
+		}
+		if raw.typ != EvStack && len(raw.args) != narg {
+			return nil, fmt.Errorf("%v has wrong number of arguments at offset 0x%x: want %v, got %v",
+				desc.Name, raw.off, narg, len(raw.args))
+		}
+		switch raw.typ {
+		case EvBatch:
+			lastGs[lastP] = lastG
+			lastP = int(raw.args[0])
+			lastG = lastGs[lastP]
+			lastTs = int64(raw.args[1])
+		case EvFrequency:
+			ticksPerSec = int64(raw.args[0])
+			if ticksPerSec <= 0 {
+				return nil, fmt.Errorf("EvFrequency contains invalid frequency %v at offset 0x%x",
+					ticksPerSec, raw.off)
+			}
+		case EvTimerGoroutine:
+			timerGoid = raw.args[0]
+		case EvStack:
+			if len(raw.args) < 2 {
+				return nil, fmt.Errorf("EvStack has wrong number of arguments at offset 0x%x: want at least 2, got %v",
+					raw.off, len(raw.args))
+			}
+			size := raw.args[1]
+			if size > 1000 {
+				return nil, fmt.Errorf("EvStack has bad number of frames at offset 0x%x: %v",
+					raw.off, size)
+			}
+			if uint64(len(raw.args)) != size+2 {
+				return nil, fmt.Errorf("EvStack has wrong number of arguments at offset 0x%x: want %v, got %v",
+					raw.off, size+2, len(raw.args))
+			}
+			id := raw.args[0]
+			if id != 0 && size > 0 {
+				stk := make([]*Frame, size)
+				for i := 0; i < int(size); i++ {
+					stk[i] = &Frame{PC: raw.args[i+2]}
+				}
+				stacks[id] = stk
+			}
+		default:
+			e := &Event{Off: raw.off, Type: raw.typ, P: lastP, G: lastG}
+			e.Ts = lastTs + int64(raw.args[0])
+			lastTs = e.Ts
+			for i := range desc.Args {
+				e.Args[i] = raw.args[i+1]
+			}
+			if desc.Stack {
+				e.StkID = raw.args[len(desc.Args)+1]
+			}
+			switch raw.typ {
+			case EvGoStart:
+				lastG = e.Args[0]
+				e.G = lastG
+			case EvGCStart, EvGCDone, EvGCScanStart, EvGCScanDone, EvGCSTWStart, EvGCSTWDone:
+				e.G = 0
+			case EvProcStart, EvProcStop:
+				lastG = 0
+				e.G = 0
+			case EvGoEnd, EvGoStop, EvGoSched, EvGoPreempt,
+				EvGoSleep, EvGoBlock, EvGoBlockSend, EvGoBlockRecv,
+				EvGoBlockSelect, EvGoBlockSync, EvGoBlockCond, EvGoBlockNet,
+				EvGoSysBlock:
+				lastG = 0
+			case EvGoSysExit:
+				e.G = e.Args[0]
+			}
+			events = append(events, e)
+		}
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("trace is empty")
+	}
+	if ticksPerSec == 0 {
+		return nil, fmt.Errorf("no EvFrequency event")
+	}
+
+	// Sort by time and translate cpu ticks to real time.
+	sort.Stable(eventList(events))
+	minTs := events[0].Ts
+	for _, ev := range events {
+		// Split the conversion to avoid overflowing int64 on long traces.
+		d := ev.Ts - minTs
+		ev.Ts = d/ticksPerSec*1e9 + d%ticksPerSec*1e9/ticksPerSec
+		// Move timers, network unblocks and syscalls to separate fake Ps.
+		if ev.Type == EvGoUnblock {
+			if timerGoid != 0 && ev.G == timerGoid {
+				ev.P = TimerP
+			} else if ev.G == 0 {
+				ev.P = NetpollP
+			}
+		}
+		if ev.Type == EvGoSysExit {
+			ev.P = SyscallP
+		}
+		if ev.StkID != 0 {
+			ev.Stk = stacks[ev.StkID]
+		}
+	}
+	return events, nil
+}
+
+// postProcessTrace links events that describe the beginning and the end
+// of the same activity (see the comment on Event.Link).
+// Events that happened before tracing started, or whose counterpart
+// was not recorded, are left unlinked rather than reported as errors.
+func postProcessTrace(events []*Event) {
+	type gdesc struct {
+		start   *Event // GoStart of the current running period
+		pending *Event // event waiting for the next GoUnblock or GoStart
+		syscall *Event // last GoSysCall
+	}
+	gs := make(map[uint64]*gdesc)
+	g := func(id uint64) *gdesc {
+		d := gs[id]
+		if d == nil {
+			d = new(gdesc)
+			gs[id] = d
+		}
+		return d
+	}
+	var evGC, evScan, evSweep, evSTW *Event
+
+	for _, ev := range events {
+		switch ev.Type {
+		case EvGCStart:
+			evGC = ev
+		case EvGCDone:
+			if evGC != nil {
+				evGC.Link = ev
+				evGC = nil
+			}
+		case EvGCScanStart:
+			evScan = ev
+		case EvGCScanDone:
+			if evScan != nil {
+				evScan.Link = ev
+				evScan = nil
+			}
+		case EvGCSweepStart:
+			evSweep = ev
+		case EvGCSweepDone:
+			if evSweep != nil {
+				evSweep.Link = ev
+				evSweep = nil
+			}
+		case EvGCSTWStart:
+			evSTW = ev
+		case EvGCSTWDone:
+			if evSTW != nil {
+				evSTW.Link = ev
+				evSTW = nil
+			}
+		case EvGoCreate:
+			g(ev.Args[0]).pending = ev
+		case EvGoWaiting, EvGoInSyscall:
+			g(ev.Args[0])
+		case EvGoStart:
+			d := g(ev.G)
+			if d.pending != nil {
+				d.pending.Link = ev
+				d.pending = nil
+			}
+			d.start = ev
+		case EvGoEnd, EvGoStop:
+			d := g(ev.G)
+			if d.start != nil {
+				d.start.Link = ev
+				d.start = nil
+			}
+			d.pending = nil
+		case EvGoSched, EvGoPreempt,
+			EvGoSleep, EvGoBlock, EvGoBlockSend, EvGoBlockRecv,
+			EvGoBlockSelect, EvGoBlockSync, EvGoBlockCond, EvGoBlockNet:
+			d := g(ev.G)
+			if d.start != nil {
+				d.start.Link = ev
+				d.start = nil
+			}
+			d.pending = ev
+		case EvGoUnblock:
+			d := g(ev.Args[0])
+			if d.pending != nil {
+				d.pending.Link = ev
+			}
+			d.pending = ev
+		case EvGoSysCall:
+			g(ev.G).syscall = ev
+		case EvGoSysBlock:
+			d := g(ev.G)
+			if d.start != nil {
+				d.start.Link = ev
+				d.start = nil
+			}
+		case EvGoSysExit:
+			d := g(ev.G)
+			if d.syscall != nil {
+				d.syscall.Link = ev
+				d.syscall = nil
+			}
+			d.pending = ev
+		}
+	}
+}
+
+// Symbolize attaches func/file/line info to stack traces.
+// It uses 'go tool addr2line' on the binary bin that produced the trace.
+func Symbolize(events []*Event, bin string) error {
+	// First, collect and dedup all pcs.
+	pcs := make(map[uint64]*Frame)
+	for _, ev := range events {
+		for _, f := range ev.Stk {
+			pcs[f.PC] = nil
+		}
+	}
+	if len(pcs) == 0 {
+		return nil
+	}
+
+	// Start addr2line.
+	cmd := exec.Command("go", "tool", "addr2line", bin)
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to pipe addr2line stdin: %v", err)
+	}
+	cmd.Stderr = nil
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to pipe addr2line stdout: %v", err)
+	}
+	err = cmd.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start addr2line: %v", err)
+	}
+	outb := bufio.NewReader(out)
+
+	// Write all pcs to addr2line.
+	// Need to copy pcs to an array, because map iteration order is non-deterministic.
+	var pcArray []uint64
+	for pc := range pcs {
+		pcArray = append(pcArray, pc)
+	}
+	// Addr2line does not flush its output until stdin is closed,
+	// so write all the requests at once.
+	go func() {
+		w := bufio.NewWriter(in)
+		for _, pc := range pcArray {
+			// The stack traces contain return PCs, so look up the
+			// call instruction instead.
+			fmt.Fprintf(w, "0x%x\n", pc-1)
+		}
+		w.Flush()
+		in.Close()
+	}()
+
+	// Read in answers.
+	for _, pc := range pcArray {
+		fn, err := outb.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read from addr2line: %v", err)
+		}
+		file, err := outb.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read from addr2line: %v", err)
+		}
+		f := &Frame{PC: pc}
+		f.Fn = fn[:len(fn)-1]
+		f.File = file[:len(file)-1]
+		if colon := strings.LastIndex(f.File, ":"); colon != -1 {
+			ln, err := strconv.Atoi(f.File[colon+1:])
+			if err == nil {
+				f.File = f.File[:colon]
+				f.Line = ln
+			}
+		}
+		pcs[pc] = f
+	}
+	cmd.Wait()
+
+	// Replace frames in events array.
+	for _, ev := range events {
+		for i, f := range ev.Stk {
+			ev.Stk[i] = pcs[f.PC]
+		}
+	}
+
+	return nil
+}
+
+// readVal reads unsigned base-128 value from r.
+func readVal(r io.ByteReader, off0 int) (v uint64, off int, err error) {
+	off = off0
+	for i := 0; i < 10; i++ {
+		var b byte
+		b, err = r.ReadByte()
+		if err != nil {
+			err = fmt.Errorf("failed to read trace at offset %d: read %v, error %v", off0, off, err)
+			return
+		}
+		off++
+		v |= uint64(b&0x7f) << (uint(i) * 7)
+		if b&0x80 == 0 {
+			return
+		}
+	}
+	err = fmt.Errorf("bad value at offset 0x%x", off0)
+	return
+}
+
+type eventList []*Event
+
+func (l eventList) Len() int {
+	return len(l)
+}
+
+func (l eventList) Less(i, j int) bool {
+	return l[i].Ts < l[j].Ts
+}
+
+func (l eventList) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+}
+
+// Print dumps events to stdout. For debugging.
+func Print(events []*Event) {
+	for _, ev := range events {
+		desc := EventDescriptions[ev.Type]
+		fmt.Printf("%v %v p=%v g=%v off=%v", ev.Ts, desc.Name, ev.P, ev.G, ev.Off)
+		for i, a := range desc.Args {
+			fmt.Printf(" %v=%v", a, ev.Args[i])
+		}
+		fmt.Printf("\n")
+	}
+}
+
+// Event types in the trace.
+// Verbatim copy from src/runtime/trace.go.
+const (
+	EvNone           = 0  // unused
+	EvBatch          = 1  // start of per-P batch of events [pid, timestamp]
+	EvFrequency      = 2  // contains tracer timer frequency [frequency (ticks per second)]
+	EvStack          = 3  // stack [stack id, number of PCs, array of PCs]
+	EvGomaxprocs     = 4  // current value of GOMAXPROCS [timestamp, GOMAXPROCS, stack id]
+	EvProcStart      = 5  // start of P [timestamp, thread id]
+	EvProcStop       = 6  // stop of P [timestamp]
+	EvGCStart        = 7  // GC start [timestamp, stack id]
+	EvGCDone         = 8  // GC done [timestamp]
+	EvGCScanStart    = 9  // GC concurrent scan and mark start [timestamp]
+	EvGCScanDone     = 10 // GC concurrent scan and mark done [timestamp]
+	EvGCSweepStart   = 11 // GC sweep start [timestamp, stack id]
+	EvGCSweepDone    = 12 // GC sweep done [timestamp]
+	EvGoCreate       = 13 // goroutine creation [timestamp, new goroutine id, start PC, stack id]
+	EvGoStart        = 14 // goroutine starts running [timestamp, goroutine id]
+	EvGoEnd          = 15 // goroutine ends [timestamp]
+	EvGoStop         = 16 // goroutine stops (like in select{}) [timestamp, stack]
+	EvGoSched        = 17 // goroutine calls Gosched [timestamp, stack]
+	EvGoPreempt      = 18 // goroutine is preempted [timestamp, stack]
+	EvGoSleep        = 19 // goroutine calls Sleep [timestamp, stack]
+	EvGoBlock        = 20 // goroutine blocks [timestamp, stack]
+	EvGoUnblock      = 21 // goroutine is unblocked [timestamp, goroutine id, stack]
+	EvGoBlockSend    = 22 // goroutine blocks on chan send [timestamp, stack]
+	EvGoBlockRecv    = 23 // goroutine blocks on chan recv [timestamp, stack]
+	EvGoBlockSelect  = 24 // goroutine blocks on select [timestamp, stack]
+	EvGoBlockSync    = 25 // goroutine blocks on Mutex/RWMutex [timestamp, stack]
+	EvGoBlockCond    = 26 // goroutine blocks on Cond [timestamp, stack]
+	EvGoBlockNet     = 27 // goroutine blocks on network [timestamp, stack]
+	EvGoSysCall      = 28 // syscall enter [timestamp, stack]
+	EvGoSysExit      = 29 // syscall exit [timestamp, goroutine id]
+	EvGoSysBlock     = 30 // syscall blocks [timestamp]
+	EvGoWaiting      = 31 // denotes that goroutine is blocked when tracing starts [goroutine id]
+	EvGoInSyscall    = 32 // denotes that goroutine is in syscall when tracing starts [goroutine id]
+	EvHeapAlloc      = 33 // memstats.heap_alloc change [timestamp, heap_alloc]
+	EvNextGC         = 34 // memstats.next_gc change [timestamp, next_gc]
+	EvTimerGoroutine = 35 // denotes timer goroutine [timer goroutine id]
+	EvGCSTWStart     = 36 // stop-the-world start [timestamp]
+	EvGCSTWDone      = 37 // stop-the-world done [timestamp]
+	EvCount          = 38
+)
+
+// EventDescriptions describes the name, stack presence and arguments
+// of each event type.
+var EventDescriptions = [EvCount]struct {
+	Name  string
+	Stack bool
+	Args  []string
+}{
+	EvNone:           {"None", false, []string{}},
+	EvBatch:          {"Batch", false, []string{"p", "ticks"}},
+	EvFrequency:      {"Frequency", false, []string{"freq"}},
+	EvStack:          {"Stack", false, []string{"id", "siz"}},
+	EvGomaxprocs:     {"Gomaxprocs", true, []string{"procs"}},
+	EvProcStart:      {"ProcStart", false, []string{"thread"}},
+	EvProcStop:       {"ProcStop", false, []string{}},
+	EvGCStart:        {"GCStart", true, []string{}},
+	EvGCDone:         {"GCDone", false, []string{}},
+	EvGCScanStart:    {"GCScanStart", false, []string{}},
+	EvGCScanDone:     {"GCScanDone", false, []string{}},
+	EvGCSweepStart:   {"GCSweepStart", true, []string{}},
+	EvGCSweepDone:    {"GCSweepDone", false, []string{}},
+	EvGoCreate:       {"GoCreate", true, []string{"g", "pc"}},
+	EvGoStart:        {"GoStart", false, []string{"g"}},
+	EvGoEnd:          {"GoEnd", false, []string{}},
+	EvGoStop:         {"GoStop", true, []string{}},
+	EvGoSched:        {"GoSched", true, []string{}},
+	EvGoPreempt:      {"GoPreempt", true, []string{}},
+	EvGoSleep:        {"GoSleep", true, []string{}},
+	EvGoBlock:        {"GoBlock", true, []string{}},
+	EvGoUnblock:      {"GoUnblock", true, []string{"g"}},
+	EvGoBlockSend:    {"GoBlockSend", true, []string{}},
+	EvGoBlockRecv:    {"GoBlockRecv", true, []string{}},
+	EvGoBlockSelect:  {"GoBlockSelect", true, []string{}},
+	EvGoBlockSync:    {"GoBlockSync", true, []string{}},
+	EvGoBlockCond:    {"GoBlockCond", true, []string{}},
+	EvGoBlockNet:     {"GoBlockNet", true, []string{}},
+	EvGoSysCall:      {"GoSysCall", true, []string{}},
+	EvGoSysExit:      {"GoSysExit", false, []string{"g"}},
+	EvGoSysBlock:     {"GoSysBlock", false, []string{}},
+	EvGoWaiting:      {"GoWaiting", false, []string{"g"}},
+	EvGoInSyscall:    {"GoInSyscall", false, []string{"g"}},
+	EvHeapAlloc:      {"HeapAlloc", false, []string{"mem"}},
+	EvNextGC:         {"NextGC", false, []string{"mem"}},
+	EvTimerGoroutine: {"TimerGoroutine", false, []string{"g"}},
+	EvGCSTWStart:     {"GCSTWStart", false, []string{}},
+	EvGCSTWDone:      {"GCSTWDone", false, []string{}},
+}
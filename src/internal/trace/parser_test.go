@@ -0,0 +1,121 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trace
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCorruptedInputs(t *testing.T) {
+	// These inputs crashed parser previously.
+	tests := []string{
+		"gZ\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00",
+		"go 1.5 trace\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00",
+		"go 1.5 trace\x00\x00\x00\x00\x00\x010\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00",
+		"go 1.5 trace\x00\x00\x00\x00\x02\x00",
+		"go 1.5 trace\x00\x00\x00\x00\x01\x10\x81",
+		"go 1.5 trace\x00\x00\x00\x00\xc3\x05\x00\x01\x01\x01\x01",
+	}
+	for _, data := range tests {
+		events, err := Parse(strings.NewReader(data))
+		if err == nil || events != nil {
+			t.Fatalf("no error on input: %q", data)
+		}
+	}
+}
+
+// traceWriter builds a trace in the wire format for tests.
+type traceWriter struct {
+	bytes.Buffer
+}
+
+func newTraceWriter() *traceWriter {
+	w := new(traceWriter)
+	w.WriteString("go 1.5 trace\x00\x00\x00\x00")
+	return w
+}
+
+func (w *traceWriter) emit(typ byte, args ...uint64) {
+	nargs := byte(len(args)) - 1
+	if nargs > 3 {
+		nargs = 3
+	}
+	var buf []byte
+	for _, a := range args {
+		for ; a >= 0x80; a >>= 7 {
+			buf = append(buf, 0x80|byte(a))
+		}
+		buf = append(buf, byte(a))
+	}
+	w.WriteByte(typ | nargs<<6)
+	if nargs == 3 {
+		w.WriteByte(byte(len(buf)))
+	}
+	w.Write(buf)
+}
+
+func TestParseLinks(t *testing.T) {
+	w := newTraceWriter()
+	w.emit(EvBatch, 0, 100)
+	w.emit(EvProcStart, 0, 1)
+	w.emit(EvGoStart, 1, 1)
+	w.emit(EvGoCreate, 1, 2, 0x1000, 0)
+	w.emit(EvGoBlockRecv, 1, 0)
+	w.emit(EvGoStart, 1, 2)
+	w.emit(EvGoUnblock, 1, 1, 0)
+	w.emit(EvGoEnd, 1)
+	w.emit(EvGoStart, 1, 1)
+	w.emit(EvGoSysCall, 1, 0)
+	w.emit(EvGoSysBlock, 1)
+	w.emit(EvProcStop, 1)
+	w.emit(EvBatch, ^uint64(0), 120)
+	w.emit(EvGoSysExit, 0, 1)
+	w.emit(EvFrequency, 1e9)
+
+	events, err := Parse(&w.Buffer)
+	if err != nil {
+		t.Fatalf("failed to parse trace: %v", err)
+	}
+	var types []byte
+	for _, ev := range events {
+		types = append(types, ev.Type)
+	}
+	want := []byte{EvProcStart, EvGoStart, EvGoCreate, EvGoBlockRecv, EvGoStart, EvGoUnblock,
+		EvGoEnd, EvGoStart, EvGoSysCall, EvGoSysBlock, EvProcStop, EvGoSysExit}
+	if !bytes.Equal(types, want) {
+		t.Fatalf("got event types %v, want %v", types, want)
+	}
+	if events[11].Ts != 20 || events[11].G != 1 || events[11].P != SyscallP {
+		t.Errorf("bad GoSysExit event: %+v", events[11])
+	}
+	if events[3].G != 1 || events[5].G != 2 {
+		t.Errorf("events are attributed to wrong goroutines: %+v, %+v", events[3], events[5])
+	}
+	links := []struct{ from, to int }{
+		{1, 3},  // GoStart -> GoBlockRecv
+		{2, 4},  // GoCreate -> GoStart
+		{3, 5},  // GoBlockRecv -> GoUnblock
+		{4, 6},  // GoStart -> GoEnd
+		{5, 7},  // GoUnblock -> GoStart
+		{7, 9},  // GoStart -> GoSysBlock
+		{8, 11}, // GoSysCall -> GoSysExit
+	}
+	for _, l := range links {
+		if events[l.from].Link != events[l.to] {
+			t.Errorf("event %v (%v) is not linked to event %v (%v)", l.from,
+				EventDescriptions[events[l.from].Type].Name, l.to, EventDescriptions[events[l.to].Type].Name)
+		}
+	}
+
+	gs := GoroutineStats(events)
+	if g := gs[2]; g == nil || g.ExecTime != 2 || g.EndTime != 6 {
+		t.Errorf("bad stats for goroutine 2: %+v", g)
+	}
+	if g := gs[1]; g == nil || g.BlockTime != 2 || g.SyscallTime != 11 {
+		t.Errorf("bad stats for goroutine 1: %+v", g)
+	}
+}
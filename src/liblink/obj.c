@@ -13,6 +13,89 @@ enum
 	NSYM = 50,
 };
 
+// linkinlcall records the inlining of a call to func
+// at line lineno and returns its index in ctxt->inlcall.
+int32
+linkinlcall(Link *ctxt, int32 lineno, LSym *func)
+{
+	Inlcall *c;
+
+	if(ctxt->ninlcall >= ctxt->minlcall) {
+		ctxt->minlcall = (ctxt->ninlcall+1)*2;
+		ctxt->inlcall = erealloc(ctxt->inlcall, ctxt->minlcall*sizeof ctxt->inlcall[0]);
+	}
+	c = &ctxt->inlcall[ctxt->ninlcall];
+	c->lineno = lineno;
+	c->func = func;
+	return ctxt->ninlcall++;
+}
+
+// linkinlpos returns the virtual line number for line lineno
+// in the body inlined by the call with index call.
+// The positions of one inlined body are recorded together,
+// so only the most recent entries need to be searched for lineno.
+int32
+linkinlpos(Link *ctxt, int32 lineno, int32 call)
+{
+	int32 i;
+	Inlpos *p;
+
+	if(lineno >= LineInl)
+		return lineno;
+	for(i=ctxt->ninlpos-1; i>=0 && ctxt->inlpos[i].call == call; i--)
+		if(ctxt->inlpos[i].lineno == lineno)
+			return LineInl + i;
+	if(ctxt->ninlpos >= ctxt->minlpos) {
+		ctxt->minlpos = (ctxt->ninlpos+1)*2;
+		ctxt->inlpos = erealloc(ctxt->inlpos, ctxt->minlpos*sizeof ctxt->inlpos[0]);
+	}
+	p = &ctxt->inlpos[ctxt->ninlpos];
+	p->lineno = lineno;
+	p->call = call;
+	return LineInl + ctxt->ninlpos++;
+}
+
+// linkimppos returns the line number for line line of file
+// in the body of a function imported from another package.
+// file is the name recorded for the file by that package
+// and is used as is.
+int32
+linkimppos(Link *ctxt, char *file, int32 line)
+{
+	Imppos *p;
+
+	if(ctxt->nimppos >= LineInl - LineImp)
+		return 0;
+	if(ctxt->nimppos >= ctxt->mimppos) {
+		ctxt->mimppos = (ctxt->nimppos+1)*2;
+		ctxt->imppos = erealloc(ctxt->imppos, ctxt->mimppos*sizeof ctxt->imppos[0]);
+	}
+	p = &ctxt->imppos[ctxt->nimppos];
+	p->file = file;
+	p->line = line;
+	return LineImp + ctxt->nimppos++;
+}
+
+// linkrealline returns the real line number for lineno.
+// If lineno is a virtual line number within an inlined body,
+// linkrealline sets *call to the index of the inlining call;
+// otherwise it sets *call to -1.
+int32
+linkrealline(Link *ctxt, int32 lineno, int32 *call)
+{
+	Inlpos *p;
+
+	if(lineno < LineInl) {
+		if(call != nil)
+			*call = -1;
+		return lineno;
+	}
+	p = &ctxt->inlpos[lineno - LineInl];
+	if(call != nil)
+		*call = p->call;
+	return p->lineno;
+}
+
 int
 linklinefmt(Link *ctxt, Fmt *fp)
 {
@@ -23,11 +106,21 @@ linklinefmt(Link *ctxt, Fmt *fp)
 		Hist*	line;	/* start of this #line directive */
 		int32	ldel;	/* delta line number to apply to #line */
 	} a[HISTSZ];
-	int32 lno, d;
+	int32 lno, d, call;
 	int i, n;
 	Hist *h;
+	Imppos *p;
 
-	lno = va_arg(fp->args, int32);
+	// Report code inlined from other functions,
+	// as in diagnostics, at the outermost call.
+	lno = linkrealline(ctxt, va_arg(fp->args, int32), &call);
+	while(call >= 0)
+		lno = linkrealline(ctxt, ctxt->inlcall[call].lineno, &call);
+	if(lno >= LineImp) {
+		p = &ctxt->imppos[lno - LineImp];
+		fmtprint(fp, "%s:%d", p->file, p->line);
+		return 0;
+	}
 
 	n = 0;
 	for(h=ctxt->hist; h!=nil; h=h->link) {
@@ -133,7 +226,13 @@ linkgetline(Link *ctxt, int32 line, LSym **f, int32 *l)
 	Hist *h;
 	char buf[1024], buf1[1024], *file;
 
-	lno = line;
+	lno = linkrealline(ctxt, line, nil);
+	if(lno >= LineImp) {
+		*f = linklookup(ctxt, ctxt->imppos[lno - LineImp].file, HistVersion);
+		*l = ctxt->imppos[lno - LineImp].line;
+		return;
+	}
+
 	n = 0;
 	for(h=ctxt->hist; h!=nil; h=h->link) {
 		if(h->offset < 0)
@@ -590,6 +590,15 @@ addstacksplit(Link *ctxt, LSym *cursym)
 			deltasp -= 2;
 			p->spadj = -2;
 			continue;
+		case AADJSP:
+			// The adjustment allocating the frame has its spadj
+			// set already. Others are written in assembly that must
+			// save the flags before anything changes them.
+			if(p->spadj == 0) {
+				deltasp += p->from.offset;
+				p->spadj = p->from.offset;
+			}
+			continue;
 		case ARET:
 			break;
 		}
@@ -873,7 +882,7 @@ relinv(int a)
 static void
 xfol(Link *ctxt, Prog *p, Prog **last)
 {
-	Prog *q;
+	Prog *q, *r, *e;
 	int i;
 	int a;
 
@@ -933,6 +942,21 @@ loop:
 				goto loop;
 			}
 		} /* */
+		/*
+		 * the jump to p should have p's PC-value data,
+		 * so that it describes the same program state.
+		 * stop at the old end of the layout, which the
+		 * copies follow.
+		 */
+		e = *last;
+		for(r=p; r != nil && r->as == APCDATA; r=r->link) {
+			q = copyp(ctxt, r);
+			q->mark = 1;
+			(*last)->link = q;
+			*last = q;
+			if(r == e)
+				break;
+		}
 		q = ctxt->arch->prg();
 		q->as = AJMP;
 		q->lineno = p->lineno;
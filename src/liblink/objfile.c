@@ -288,6 +288,15 @@ writeobj(Link *ctxt, Biobuf *b)
 		ctxt->arch->addstacksplit(ctxt, s);
 		ctxt->arch->assemble(ctxt, s);
 		linkpcln(ctxt, s);
+		if(s->pcln->nfuncdata > FUNCDATA_InlTree && s->pcln->funcdata[FUNCDATA_InlTree] != nil) {
+			// Emit the inlining tree built by linkpcln.
+			if(data == nil)
+				data = s->pcln->funcdata[FUNCDATA_InlTree];
+			else
+				edata->next = s->pcln->funcdata[FUNCDATA_InlTree];
+			edata = s->pcln->funcdata[FUNCDATA_InlTree];
+			edata->next = nil;
+		}
 	}
 
 	// Emit header.
@@ -6,6 +6,7 @@
 #include <libc.h>
 #include <bio.h>
 #include <link.h>
+#include "../runtime/funcdata.h"
 
 static void
 addvarint(Link *ctxt, Pcdata *d, uint32 val)
@@ -147,6 +148,34 @@ funcpctab(Link *ctxt, Pcdata *dst, LSym *func, char *desc, int32 (*valfunc)(Link
 	ctxt->debugpcln -= dbg;
 }
 
+// pcfileindex returns the number of file f in the file list of pcln,
+// adding f to the list if needed.
+static int32
+pcfileindex(Pcln *pcln, LSym *f)
+{
+	int32 i;
+
+	if(f == pcln->lastfile)
+		return pcln->lastindex;
+
+	for(i=0; i<pcln->nfile; i++) {
+		if(pcln->file[i] == f) {
+			pcln->lastfile = f;
+			pcln->lastindex = i;
+			return i;
+		}
+	}
+
+	if(pcln->nfile >= pcln->mfile) {
+		pcln->mfile = (pcln->nfile+1)*2;
+		pcln->file = erealloc(pcln->file, pcln->mfile*sizeof pcln->file[0]);
+	}
+	pcln->file[pcln->nfile++] = f;
+	pcln->lastfile = f;
+	pcln->lastindex = i;
+	return i;
+}
+
 // pctofileline computes either the file number (arg == 0)
 // or the line number (arg == 1) to use at p.
 // Because p->lineno applies to p, phase == 0 (before p)
@@ -154,7 +183,7 @@ funcpctab(Link *ctxt, Pcdata *dst, LSym *func, char *desc, int32 (*valfunc)(Link
 static int32
 pctofileline(Link *ctxt, LSym *sym, int32 oldval, Prog *p, int32 phase, void *arg)
 {
-	int32 i, l;
+	int32 l;
 	LSym *f;
 	Pcln *pcln;
 
@@ -170,26 +199,95 @@ pctofileline(Link *ctxt, LSym *sym, int32 oldval, Prog *p, int32 phase, void *ar
 	if(arg == nil)
 		return l;
 	pcln = arg;
-	
-	if(f == pcln->lastfile)
-		return pcln->lastindex;
+	return pcfileindex(pcln, f);
+}
 
-	for(i=0; i<pcln->nfile; i++) {
-		if(pcln->file[i] == f) {
-			pcln->lastfile = f;
-			pcln->lastindex = i;
+// inlnode returns the index in the inlining tree of pcln of the node
+// for the inlined call with index call in ctxt->inlcall, adding the node
+// and the nodes of the inlined calls enclosing it to the tree if needed.
+static int32
+inlnode(Link *ctxt, Pcln *pcln, int32 call)
+{
+	int32 i, parent;
+
+	for(i=0; i<pcln->ninl; i++)
+		if(pcln->inlcall[i] == call)
 			return i;
-		}
+
+	linkrealline(ctxt, ctxt->inlcall[call].lineno, &parent);
+	if(parent >= 0)
+		parent = inlnode(ctxt, pcln, parent);
+
+	if(pcln->ninl >= pcln->minl) {
+		pcln->minl = (pcln->ninl+1)*2;
+		pcln->inlcall = erealloc(pcln->inlcall, pcln->minl*sizeof pcln->inlcall[0]);
+		pcln->inlparent = erealloc(pcln->inlparent, pcln->minl*sizeof pcln->inlparent[0]);
 	}
+	pcln->inlcall[pcln->ninl] = call;
+	pcln->inlparent[pcln->ninl] = parent;
+	return pcln->ninl++;
+}
 
-	if(pcln->nfile >= pcln->mfile) {
-		pcln->mfile = (pcln->nfile+1)*2;
-		pcln->file = erealloc(pcln->file, pcln->mfile*sizeof pcln->file[0]);
+// pctoinline computes the index in the inlining tree of the function
+// of the innermost inlined call whose body p is part of, or -1 if p
+// is not part of an inlined body.  The tree, built as a side effect
+// in the Pcln passed as arg, has a node for each such call.
+// Because p->lineno applies to p, phase == 0 (before p)
+// takes care of the update.
+static int32
+pctoinline(Link *ctxt, LSym *sym, int32 oldval, Prog *p, int32 phase, void *arg)
+{
+	int32 call;
+
+	USED(sym);
+
+	if(p->as == ctxt->arch->ATEXT || p->as == ctxt->arch->ANOP || p->as == ctxt->arch->AUSEFIELD || p->lineno == 0 || phase == 1)
+		return oldval;
+	linkrealline(ctxt, p->lineno, &call);
+	if(call < 0)
+		return -1;
+	return inlnode(ctxt, arg, call);
+}
+
+// mkinltree returns a symbol holding the inlining tree of cursym,
+// for use as its FUNCDATA_InlTree.  The symbol holds the number of
+// nodes, n, followed by n nodes of four 32-bit words each:
+//	parent	index of the parent node, or -1
+//	file	index in the file list of the function of the file of the call
+//	line	line number of the call
+//	func	offset in the symbol of the NUL-terminated name of the function called
+// followed by the function names.  The linker moves the tree into
+// the pclntab, where the file and function name are represented as
+// for the function itself.
+static LSym*
+mkinltree(Link *ctxt, LSym *cursym, Pcln *pcln)
+{
+	LSym *s, *f;
+	Inlcall *c;
+	int32 i, l, name;
+	char *p;
+
+	s = linklookup(ctxt, smprint("%s.inltree", cursym->name), cursym->version);
+	s->type = SRODATA;
+	s->dupok = cursym->dupok;
+	adduint32(ctxt, s, pcln->ninl);
+	name = 4 + pcln->ninl*16;
+	for(i=0; i<pcln->ninl; i++) {
+		c = &ctxt->inlcall[pcln->inlcall[i]];
+		linkgetline(ctxt, c->lineno, &f, &l);
+		adduint32(ctxt, s, pcln->inlparent[i]);
+		adduint32(ctxt, s, pcfileindex(pcln, f));
+		adduint32(ctxt, s, l);
+		adduint32(ctxt, s, name);
+		name += strlen(c->func->name)+1;
 	}
-	pcln->file[pcln->nfile++] = f;
-	pcln->lastfile = f;
-	pcln->lastindex = i;
-	return i;
+	for(i=0; i<pcln->ninl; i++) {
+		p = ctxt->inlcall[pcln->inlcall[i]].func->name;
+		symgrow(ctxt, s, s->np+strlen(p)+1);
+		strcpy((char*)s->p+s->np-strlen(p)-1, p);
+	}
+	s->size = s->np;
+	return s;
 }
 
 // pctospadj computes the sp adjustment in effect.
@@ -237,7 +335,7 @@ linkpcln(Link *ctxt, LSym *cursym)
 {
 	Prog *p;
 	Pcln *pcln;
-	int i, npcdata, nfuncdata, n;
+	int i, npcdata, nfuncdata, n, inl;
 	uint32 *havepc, *havefunc;
 
 	ctxt->cursym = cursym;
@@ -247,11 +345,20 @@ linkpcln(Link *ctxt, LSym *cursym)
 
 	npcdata = 0;
 	nfuncdata = 0;
+	inl = 0;
 	for(p = cursym->text; p != nil; p = p->link) {
 		if(p->as == ctxt->arch->APCDATA && p->from.offset >= npcdata)
 			npcdata = p->from.offset+1;
 		if(p->as == ctxt->arch->AFUNCDATA && p->from.offset >= nfuncdata)
 			nfuncdata = p->from.offset+1;
+		if(p->lineno >= LineInl)
+			inl = 1;
+	}
+	if(inl) {
+		if(npcdata <= PCDATA_InlTreeIndex)
+			npcdata = PCDATA_InlTreeIndex+1;
+		if(nfuncdata <= FUNCDATA_InlTree)
+			nfuncdata = FUNCDATA_InlTree+1;
 	}
 
 	pcln->pcdata = emallocz(npcdata*sizeof pcln->pcdata[0]);
@@ -284,6 +391,8 @@ linkpcln(Link *ctxt, LSym *cursym)
 		funcpctab(ctxt, &pcln->pcdata[i], cursym, "pctopcdata", pctopcdata, (void*)(uintptr)i);
 	}
 	free(havepc);
+	if(inl)
+		funcpctab(ctxt, &pcln->pcdata[PCDATA_InlTreeIndex], cursym, "pctoinline", pctoinline, pcln);
 	
 	// funcdata
 	if(nfuncdata > 0) {
@@ -299,6 +408,8 @@ linkpcln(Link *ctxt, LSym *cursym)
 			}
 		}
 	}
+	if(pcln->ninl > 0)
+		pcln->funcdata[FUNCDATA_InlTree] = mkinltree(ctxt, cursym, pcln);
 }
 
 // iteration over encoded pcdata tables.
@@ -4,7 +4,10 @@
 
 package net
 
-import "time"
+import (
+	"sync/singleflight"
+	"time"
+)
 
 // protocols contains minimal mappings between internet protocol
 // names and numbers for platforms that don't have a complete list of
@@ -31,7 +34,7 @@ func LookupIP(host string) (addrs []IP, err error) {
 	return lookupIPMerge(host)
 }
 
-var lookupGroup singleflight
+var lookupGroup singleflight.Group
 
 // lookupIPMerge wraps lookupIP, but makes sure that for any given
 // host, only one lookup is in-flight at a time. The returned memory
@@ -90,7 +93,7 @@ func lookupIPDeadline(host string, deadline time.Time) (addrs []IP, err error) {
 		return nil, errTimeout
 
 	case r := <-ch:
-		return lookupIPReturn(r.v, r.err, r.shared)
+		return lookupIPReturn(r.Val, r.Err, r.Shared)
 	}
 }
 
@@ -1297,7 +1297,7 @@ func (v Value) Set(x Value) {
 	}
 	x = x.assignTo("reflect.Set", v.typ, target)
 	if x.flag&flagIndir != 0 {
-		memmove(v.ptr, x.ptr, v.typ.size)
+		typedmemmove(v.typ, v.ptr, x.ptr)
 	} else {
 		*(*unsafe.Pointer)(v.ptr) = x.ptr
 	}
@@ -1816,22 +1816,24 @@ func Copy(dst, src Value) int {
 		n = sn
 	}
 
-	// Copy via memmove.
-	var da, sa unsafe.Pointer
+	// Copy via typedslicecopy, which applies write barriers
+	// to pointerful elements.
+	var ds, ss sliceHeader
 	if dk == Array {
-		da = dst.ptr
+		ds.Data = dst.ptr
 	} else {
-		da = (*sliceHeader)(dst.ptr).Data
+		ds.Data = (*sliceHeader)(dst.ptr).Data
 	}
 	if src.flag&flagIndir == 0 {
-		sa = unsafe.Pointer(&src.ptr)
+		ss.Data = unsafe.Pointer(&src.ptr)
 	} else if sk == Array {
-		sa = src.ptr
+		ss.Data = src.ptr
 	} else {
-		sa = (*sliceHeader)(src.ptr).Data
+		ss.Data = (*sliceHeader)(src.ptr).Data
 	}
-	memmove(da, sa, uintptr(n)*de.Size())
-	return n
+	ds.Len, ds.Cap = n, n
+	ss.Len, ss.Cap = n, n
+	return typedslicecopy(de.common(), ds, ss)
 }
 
 // A runtimeSelect is a single case passed to rselect.
@@ -2428,6 +2430,15 @@ func ifaceE2I(t *rtype, src interface{}, dst unsafe.Pointer)
 //go:noescape
 func memmove(adst, asrc unsafe.Pointer, n uintptr)
 
+// typedmemmove copies a value of type t to dst from src.
+//go:noescape
+func typedmemmove(t *rtype, dst, src unsafe.Pointer)
+
+// typedslicecopy copies a slice of elemType values from src to dst,
+// returning the number of elements copied.
+//go:noescape
+func typedslicecopy(elemType *rtype, dst, src sliceHeader) int
+
 // Dummy annotation marking that the value x escapes,
 // for use in cases where the reflect code is so clever that
 // the compiler cannot follow.
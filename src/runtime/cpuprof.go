@@ -47,6 +47,12 @@
 // handoff using atomic operations.  The operations are needed, however,
 // in order to let the log closer set the high bit to indicate "EOF" safely
 // in the situation when normally the goroutine "owns" handoff.
+//
+// Samples taken while the goroutine had a profiler label set
+// (see runtime/pprof) are recorded separately per label set.
+// In the log, the stack of such a sample is followed by two
+// extra words: a zero, which cannot be a valid pc, and the
+// label set identifier assigned by runtime/pprof.
 
 package runtime
 
@@ -60,9 +66,10 @@ const (
 )
 
 type cpuprofEntry struct {
-	count uintptr
-	depth uintptr
-	stack [maxCPUProfStack]uintptr
+	count  uintptr
+	depth  uintptr
+	labels uintptr // profiler label set, 0 if none
+	stack  [maxCPUProfStack]uintptr
 }
 
 type cpuProfile struct {
@@ -185,26 +192,27 @@ func SetCPUProfileRate(hz int) {
 	unlock(&cpuprofLock)
 }
 
-func cpuproftick(pc *uintptr, n int32) {
+func cpuproftick(pc *uintptr, n int32, labels uintptr) {
 	if n > maxCPUProfStack {
 		n = maxCPUProfStack
 	}
 	s := (*[maxCPUProfStack]uintptr)(unsafe.Pointer(pc))[:n]
-	cpuprof.add(s)
+	cpuprof.add(s, labels)
 }
 
-// add adds the stack trace to the profile.
+// add adds the stack trace with the given profiler label set to the profile.
 // It is called from signal handlers and other limited environments
 // and cannot allocate memory or acquire locks that might be
 // held at the time of the signal, nor can it use substantial amounts
 // of stack.  It is allowed to call evict.
-func (p *cpuProfile) add(pc []uintptr) {
+func (p *cpuProfile) add(pc []uintptr, labels uintptr) {
 	// Compute hash.
 	h := uintptr(0)
 	for _, x := range pc {
 		h = h<<8 | (h >> (8 * (unsafe.Sizeof(h) - 1)))
 		h += x*31 + x*7 + x*3
 	}
+	h += labels * 41
 	p.count++
 
 	// Add to entry count if already present in table.
@@ -212,7 +220,7 @@ func (p *cpuProfile) add(pc []uintptr) {
 Assoc:
 	for i := range b.entry {
 		e := &b.entry[i]
-		if e.depth != uintptr(len(pc)) {
+		if e.depth != uintptr(len(pc)) || e.labels != labels {
 			continue
 		}
 		for j := range pc {
@@ -242,6 +250,7 @@ Assoc:
 
 	// Reuse the newly evicted entry.
 	e.depth = uintptr(len(pc))
+	e.labels = labels
 	e.count = 1
 	copy(e.stack[:], pc)
 }
@@ -255,6 +264,9 @@ Assoc:
 func (p *cpuProfile) evict(e *cpuprofEntry) bool {
 	d := e.depth
 	nslot := d + 2
+	if e.labels != 0 {
+		nslot += 2
+	}
 	log := &p.log[p.toggle]
 	if p.nlog+nslot > uintptr(len(p.log[0])) {
 		if !p.flushlog() {
@@ -266,10 +278,15 @@ func (p *cpuProfile) evict(e *cpuprofEntry) bool {
 	q := p.nlog
 	log[q] = e.count
 	q++
-	log[q] = d
+	log[q] = nslot - 2
 	q++
 	copy(log[q:], e.stack[:d])
 	q += d
+	if e.labels != 0 {
+		log[q] = 0
+		log[q+1] = e.labels
+		q += 2
+	}
 	p.nlog = q
 	e.count = 0
 	return true
@@ -399,6 +416,21 @@ Flush:
 	return nil
 }
 
+// setProfLabel and getProfLabel are called from runtime/pprof
+// to maintain the profiler label set of the current goroutine.
+// Text, the printable form of the label set, is shown after the
+// status in the goroutine's header in tracebacks.  It must not
+// be modified afterward.
+func setProfLabel(labels uintptr, text *string) {
+	gp := getg()
+	gp.labels = labels
+	gp.labeltext = text
+}
+
+func getProfLabel() uintptr {
+	return getg().labels
+}
+
 func uintptrBytes(p []uintptr) (ret []byte) {
 	pp := (*sliceStruct)(unsafe.Pointer(&p))
 	rp := (*sliceStruct)(unsafe.Pointer(&ret))
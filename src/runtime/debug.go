@@ -22,7 +22,7 @@ func GOMAXPROCS(n int) int {
 		return ret
 	}
 
-	semacquire(&worldsema, false)
+	semacquire(&worldsema, 0)
 	gp := getg()
 	gp.m.gcing = 1
 	systemstack(stoptheworld)
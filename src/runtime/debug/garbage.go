@@ -18,6 +18,22 @@ type GCStats struct {
 	Pause          []time.Duration // pause history, most recent first
 	PauseEnd       []time.Time     // pause end times history, most recent first
 	PauseQuantiles []time.Duration
+	Phases         []GCPhases // phase history, most recent first
+}
+
+// GCPhases breaks a garbage collection down into its phases.
+// The collector stops the world three times in a collection, and the
+// Pause of the collection is the sum of those pauses.  For the phases
+// that run concurrently with the program, GCPhases reports the time
+// the collector spent in them, which is CPU time taken from the
+// program.
+type GCPhases struct {
+	SweepTermination time.Duration // pause to finish sweeping and start the collection
+	Scan             time.Duration // scanning goroutine stacks and globals
+	MarkStart        time.Duration // pause to turn on the write barrier
+	Mark             time.Duration // background marking
+	Assist           time.Duration // marking by goroutines as they allocate
+	MarkTermination  time.Duration // pause to finish marking
 }
 
 // ReadGCStats reads statistics about garbage collection into stats.
@@ -28,29 +44,31 @@ type GCStats struct {
 // summarizing the distribution of pause time. For example, if
 // len(stats.PauseQuantiles) is 5, it will be filled with the minimum,
 // 25%, 50%, 75%, and maximum pause times.
+// stats.Phases is reused if large enough, reallocated otherwise.
 func ReadGCStats(stats *GCStats) {
-	// Create a buffer with space for at least two copies of the
-	// pause history tracked by the runtime. One will be returned
-	// to the caller and the other will be used as transfer buffer
-	// for end times history and as a temporary buffer for
-	// computing quantiles.
+	// Create a buffer with space for the pause history tracked by
+	// the runtime and for the end times and phases of each pause.
+	// The pauses will be returned to the caller; the rest is a
+	// transfer buffer, part of which is reused as a temporary buffer
+	// for computing quantiles.
 	const maxPause = len(((*runtime.MemStats)(nil)).PauseNs)
-	if cap(stats.Pause) < 2*maxPause+3 {
-		stats.Pause = make([]time.Duration, 2*maxPause+3)
+	const nphase = 6 // fields in GCPhases
+	if cap(stats.Pause) < (2+nphase)*maxPause+3 {
+		stats.Pause = make([]time.Duration, (2+nphase)*maxPause+3)
 	}
 
 	// readGCStats fills in the pause and end times histories (up to
-	// maxPause entries) and then three more: Unix ns time of last GC,
-	// number of GC, and total pause time in nanoseconds. Here we
-	// depend on the fact that time.Duration's native unit is
-	// nanoseconds, so the pauses and the total pause time do not need
-	// any conversion.
+	// maxPause entries), then nphase phase times for each pause, and
+	// then three more: Unix ns time of last GC, number of GC, and
+	// total pause time in nanoseconds. Here we depend on the fact
+	// that time.Duration's native unit is nanoseconds, so the pauses,
+	// the phases and the total pause time do not need any conversion.
 	readGCStats(&stats.Pause)
 	n := len(stats.Pause) - 3
 	stats.LastGC = time.Unix(0, int64(stats.Pause[n]))
 	stats.NumGC = int64(stats.Pause[n+1])
 	stats.PauseTotal = stats.Pause[n+2]
-	n /= 2 // buffer holds pauses and end times
+	n /= 2 + nphase // buffer holds pauses, end times and phases
 	stats.Pause = stats.Pause[:n]
 
 	if cap(stats.PauseEnd) < maxPause {
@@ -61,6 +79,21 @@ func ReadGCStats(stats *GCStats) {
 		stats.PauseEnd = append(stats.PauseEnd, time.Unix(0, int64(ns)))
 	}
 
+	if cap(stats.Phases) < maxPause {
+		stats.Phases = make([]GCPhases, 0, maxPause)
+	}
+	stats.Phases = stats.Phases[:0]
+	for p := stats.Pause[n+n : n*(2+nphase)]; len(p) > 0; p = p[nphase:] {
+		stats.Phases = append(stats.Phases, GCPhases{
+			SweepTermination: p[0],
+			Scan:             p[1],
+			MarkStart:        p[2],
+			Mark:             p[3],
+			Assist:           p[4],
+			MarkTermination:  p[5],
+		})
+	}
+
 	if len(stats.PauseQuantiles) > 0 {
 		if n == 0 {
 			for i := range stats.PauseQuantiles {
@@ -100,6 +133,29 @@ func SetGCPercent(percent int) int {
 	return int(old)
 }
 
+// SetMemoryLimit sets a soft limit on the memory held by the runtime:
+// the heap, goroutine stacks and the runtime's own data structures,
+// less the memory returned to the operating system.  In terms of
+// runtime.MemStats, the limit applies to Sys - HeapReleased.
+// As that total approaches the limit, the garbage collector runs
+// more often than the GC percentage alone would call for, and idle
+// memory is returned to the operating system right away.
+// The limit is respected even if garbage collection has been disabled
+// with SetGCPercent(-1), in which case the garbage collector runs only
+// when the limit calls for it.
+//
+// The limit is soft: the runtime exceeds it rather than fail when the
+// live heap does not fit, collecting no more often than each time the
+// heap grows by an eighth.
+//
+// SetMemoryLimit returns the previous setting.  A negative limit does
+// not change the setting, so SetMemoryLimit(-1) reads it.
+// The initial setting is the value of the GOMEMLIMIT environment variable
+// at startup, or math.MaxInt64, meaning no limit, if the variable is not set.
+func SetMemoryLimit(limit int64) int64 {
+	return setMemoryLimit(limit)
+}
+
 // FreeOSMemory forces a garbage collection followed by an
 // attempt to return as much memory to the operating system
 // as possible. (Even if this is not called, the runtime gradually
@@ -83,6 +83,21 @@ func TestReadGCStats(t *testing.T) {
 		}
 		off = (off + len(mstats.PauseEnd) - 1) % len(mstats.PauseEnd)
 	}
+
+	if len(stats.Phases) != n {
+		t.Fatalf("len(stats.Phases) = %d, want %d", len(stats.Phases), n)
+	}
+	for i, ph := range stats.Phases {
+		if pause := ph.SweepTermination + ph.MarkStart + ph.MarkTermination; pause != stats.Pause[i] {
+			t.Errorf("stats.Phases[%d] pauses add up to %d, want stats.Pause[%d] = %d", i, pause, i, stats.Pause[i])
+		}
+	}
+	if n == 0 {
+		return
+	}
+	if ph := stats.Phases[0]; ph.SweepTermination <= 0 || ph.MarkStart <= 0 || ph.MarkTermination <= 0 || ph.Mark <= 0 {
+		t.Errorf("stats.Phases[0] = %+v, want positive pauses and mark time", ph)
+	}
 }
 
 var big = make([]byte, 1<<20)
@@ -113,3 +128,40 @@ func TestSetGCPercent(t *testing.T) {
 		t.Errorf("SetGCPercent(123); SetGCPercent(x) = %d, want 123", new)
 	}
 }
+
+func TestSetMemoryLimit(t *testing.T) {
+	const limit = 64 << 20
+	old := SetMemoryLimit(limit)
+	defer SetMemoryLimit(old)
+	if got := SetMemoryLimit(-1); got != limit {
+		t.Fatalf("SetMemoryLimit(%d); SetMemoryLimit(-1) = %d, want %d", limit, got, limit)
+	}
+	defer SetGCPercent(SetGCPercent(-1))
+
+	var ms1, ms2 runtime.MemStats
+	runtime.ReadMemStats(&ms1)
+	if ms1.MemoryLimit != limit {
+		t.Errorf("MemoryLimit = %d, want %d", ms1.MemoryLimit, limit)
+	}
+	// Allocate garbage well beyond the limit.  With GC off,
+	// only the limit keeps the heap from growing without bound.
+	var sink []byte
+	maxHeap := uint64(0)
+	for i := 0; i < 1024; i++ {
+		sink = make([]byte, 1<<20)
+		if i%64 == 0 {
+			runtime.ReadMemStats(&ms2)
+			if ms2.HeapAlloc > maxHeap {
+				maxHeap = ms2.HeapAlloc
+			}
+		}
+	}
+	_ = sink
+	runtime.ReadMemStats(&ms2)
+	if ms2.NumGC == ms1.NumGC {
+		t.Fatalf("no collection while allocating %d MB of garbage with a %d MB limit", 1024, limit>>20)
+	}
+	if maxHeap > 2*limit {
+		t.Errorf("heap grew to %d MB with a %d MB limit", maxHeap>>20, limit>>20)
+	}
+}
@@ -11,6 +11,7 @@ import (
 // Uses assembly to call corresponding runtime-internal functions.
 func setMaxStack(int) int
 func setGCPercent(int32) int32
+func setMemoryLimit(int64) int64
 func setPanicOnFault(bool) bool
 func setMaxThreads(int) int
 
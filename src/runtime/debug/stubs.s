@@ -20,6 +20,9 @@ TEXT ·setMaxStack(SB),NOSPLIT,$0-0
 TEXT ·setGCPercent(SB),NOSPLIT,$0-0
   JMP runtime·setGCPercent(SB)
 
+TEXT ·setMemoryLimit(SB),NOSPLIT,$0-0
+  JMP runtime·setMemoryLimit(SB)
+
 TEXT ·setPanicOnFault(SB),NOSPLIT,$0-0
   JMP runtime·setPanicOnFault(SB)
 
@@ -129,3 +129,5 @@ func GostringW(w []uint16) (s string) {
 
 var Gostringnocopy = gostringnocopy
 var Maxstring = &maxstring
+
+var ParseByteCount = parseByteCount
@@ -17,20 +17,42 @@ and use may change from release to release.
 The GOGC variable sets the initial garbage collection target percentage.
 A collection is triggered when the ratio of freshly allocated data to live data
 remaining after the previous collection reaches this percentage. The default
-is GOGC=100. Setting GOGC=off disables the garbage collector entirely.
+is GOGC=100. Setting GOGC=off disables the garbage collector entirely,
+unless a memory limit is set.
 The runtime/debug package's SetGCPercent function allows changing this
 percentage at run time. See http://golang.org/pkg/runtime/debug/#SetGCPercent.
 
+The GOMEMLIMIT variable sets a soft limit on the memory the runtime holds,
+in bytes, optionally followed by one of the units B, KiB, MiB, GiB or TiB.
+As the runtime's memory approaches the limit, the garbage collector runs more
+often and idle memory is returned to the operating system sooner.
+The default is GOMEMLIMIT=off, meaning no limit. The runtime/debug package's
+SetMemoryLimit function allows changing the limit at run time.
+See http://golang.org/pkg/runtime/debug/#SetMemoryLimit.
+
 The GODEBUG variable controls debug output from the runtime. GODEBUG value is
 a comma-separated list of name=val pairs. Supported names are:
 
 	allocfreetrace: setting allocfreetrace=1 causes every allocation to be
 	profiled and a stack trace printed on each object's allocation and free.
 
+	asyncpreemptoff: setting asyncpreemptoff=1 disables signal-based
+	asynchronous goroutine preemption. Goroutines are then preempted only
+	at function calls, so a loop without calls can delay a garbage collection
+	or keep other goroutines from running. On linux/amd64 the runtime sends
+	SIGURG to a thread to preempt the goroutine it is running; programs that
+	use os/signal to receive SIGURG may see such signals.
+
 	efence: setting efence=1 causes the allocator to run in a mode
 	where each object is allocated on a unique page and addresses are
 	never recycled.
 
+	gccheckmark: setting gccheckmark=1 enables verification of the
+	concurrent mark: after each collection the garbage collector marks the
+	heap again with the world stopped and crashes the program if it finds
+	a reachable object the concurrent mark missed. This lengthens the
+	final pause of every collection to that of a full mark.
+
 	gctrace: setting gctrace=1 causes the garbage collector to emit a single line to standard
 	error at each collection, summarizing the amount of memory collected and the
 	length of the pause. Setting gctrace=2 emits the same summary but also
@@ -72,6 +94,11 @@ and if possible the program crashes in an operating-specific manner instead of
 exiting. For example, on Unix systems, the program raises SIGABRT to trigger a
 core dump.
 
+The header line of each goroutine's stack trace gives its number and
+status and, if the goroutine has profiler labels set with runtime/pprof,
+its labels, as in
+	goroutine 7 [chan receive, 3 minutes] {handler:"/upload"}:
+
 The GOARCH, GOOS, GOPATH, and GOROOT environment variables complete
 the set of Go environment variables. They influence the building of Go programs
 (see http://golang.org/cmd/go and http://golang.org/pkg/go/build).
@@ -88,33 +115,52 @@ package runtime
 // program counter, file name, and line number within the file of the corresponding
 // call.  The boolean ok is false if it was not possible to recover the information.
 func Caller(skip int) (pc uintptr, file string, line int, ok bool) {
-	// Ask for two PCs: the one we were asked for
-	// and what it called, so that we can see if it
-	// "called" sigpanic.
-	var rpc [2]uintptr
-	if callers(1+skip-1, &rpc[0], 2) < 2 {
-		return
-	}
-	f := findfunc(rpc[1])
-	if f == nil {
-		// TODO(rsc): Probably a bug?
-		// The C version said "have retpc at least"
-		// but actually returned pc=0.
-		ok = true
-		return
+	// Every physical frame holds at least one logical frame, so the
+	// frame asked for is among the skip+1 physical frames above
+	// Caller itself, but it may be in fewer if calls were inlined.
+	var buf [32]uintptr
+	rpc := buf[:]
+	if skip+2 > len(rpc) {
+		rpc = make([]uintptr, skip+2)
 	}
-	pc = rpc[1]
-	xpc := pc
-	g := findfunc(rpc[0])
-	// All architectures turn faults into apparent calls to sigpanic.
-	// If we see a call to sigpanic, we do not back up the PC to find
-	// the line number of the call instruction, because there is no call.
-	if xpc > f.entry && (g == nil || g.entry != funcPC(sigpanic)) {
-		xpc--
+	n := callers(0, &rpc[0], skip+2)
+	// rpc[0] is Caller itself, which is never sigpanic.
+	waspanic := false
+	for _, pc := range rpc[1:n] {
+		f := findfunc(pc)
+		if f == nil {
+			if skip == 0 {
+				// TODO(rsc): Probably a bug?
+				// The C version said "have retpc at least"
+				// but actually returned pc=0.
+				return 0, "", 0, true
+			}
+			skip--
+			waspanic = false
+			continue
+		}
+		xpc := pc
+		// All architectures turn faults into apparent calls to sigpanic.
+		// If we see a call to sigpanic, we do not back up the PC to find
+		// the line number of the call instruction, because there is no call.
+		if xpc > f.entry && !waspanic {
+			xpc--
+		}
+		file, line32 := funcline(f, xpc)
+		tree := funcinltree(f)
+		for ix := funcinlindex(f, tree, xpc, true); ix >= 0; ix = tree[ix].parent {
+			if skip == 0 {
+				return pc, file, int(line32), true
+			}
+			skip--
+			file, line32 = inlinedcallline(&tree[ix])
+		}
+		if skip == 0 {
+			return pc, file, int(line32), true
+		}
+		skip--
+		waspanic = f.entry == funcPC(sigpanic)
 	}
-	file, line32 := funcline(f, xpc)
-	line = int(line32)
-	ok = true
 	return
 }
 
@@ -132,6 +178,11 @@ func Caller(skip int) (pc uintptr, file string, line int, ok bool) {
 // As an exception to this rule, if pc[i-1] corresponds to the function
 // runtime.sigpanic, then pc[i] is the program counter of a faulting
 // instruction and should be used without any subtraction.
+//
+// A single entry pc[i] may stand for several frames if the compiler
+// inlined calls at it. To translate the program counters into function
+// names, file names and line numbers that account for inlined calls
+// and for the adjustments above, use CallersFrames.
 func Callers(skip int, pc []uintptr) int {
 	// runtime.callers uses pc.array==nil as a signal
 	// to print a stack trace.  Pick off 0-length pc here
@@ -11,10 +11,13 @@
 // symtab.go also contains a copy of these constants.
 
 #define PCDATA_StackMapIndex 0
+#define PCDATA_InlTreeIndex 1
+#define PCDATA_RegMap 2
 
 #define FUNCDATA_ArgsPointerMaps 0 /* garbage collector blocks */
 #define FUNCDATA_LocalsPointerMaps 1
 #define FUNCDATA_DeadValueMaps 2
+#define FUNCDATA_InlTree 3
 
 // Pseudo-assembly statements.
 
@@ -229,7 +229,13 @@ func mallocgc(size uintptr, typ *_type, flags uint32) unsafe.Pointer {
 		var ptrmask *uint8
 		if size == ptrSize {
 			// It's one word and it has pointers, it must be a pointer.
-			*xbits |= (bitsPointer << 2) << shift
+			// The other half of the byte belongs to another object,
+			// which the collector may be marking concurrently.
+			if gcphase == _GCoff {
+				*xbits |= (bitsPointer << 2) << shift
+			} else {
+				atomicor8(xbits, (bitsPointer<<2)<<shift)
+			}
 			goto marked
 		}
 		if typ.kind&kindGCProg != 0 {
@@ -341,7 +347,11 @@ marked:
 		}
 	}
 
-	if memstats.heap_alloc >= memstats.next_gc/2 {
+	if gcphase == _GCmark {
+		gcassistalloc(size)
+	}
+
+	if gctriggered() && atomicload(&bggc.running) == 0 {
 		gogc(0)
 	}
 
@@ -442,6 +452,7 @@ func profilealloc(mp *m, x unsafe.Pointer, size uintptr) {
 	mProf_Malloc(x, size)
 }
 
+// force = 0 - start a collection in the background if the heap has reached the trigger
 // force = 1 - do GC regardless of current heap usage
 // force = 2 - go GC and eager sweep
 func gogc(force int32) {
@@ -450,42 +461,102 @@ func gogc(force int32) {
 	// holding locks. To avoid deadlocks during stoptheworld, don't bother
 	// trying to run gc while holding a lock. The next mallocgc without a lock
 	// will do the gc instead.
+	// With GOGC=off and no memory limit only forced collections run,
+	// which also waits out any background cycle still in progress.
 	mp := acquirem()
-	if gp := getg(); gp == mp.g0 || mp.locks > 1 || !memstats.enablegc || panicking != 0 || gcpercent < 0 {
+	if gp := getg(); gp == mp.g0 || mp.locks > 1 || !memstats.enablegc || panicking != 0 || force == 0 && gcpercent < 0 && memoryLimit == maxMemoryLimit {
 		releasem(mp)
 		return
 	}
 	releasem(mp)
 	mp = nil
 
-	semacquire(&worldsema, false)
+	if force == 0 {
+		// The background collector runs the cycle, so that the
+		// goroutine that happened to reach the trigger can go on.
+		startbggc()
+		return
+	}
+	gccycle(force)
+}
 
-	if force == 0 && memstats.heap_alloc < memstats.next_gc {
+// gccycle runs a collection on the current goroutine.  With force == 0
+// it does so only if the heap has reached the trigger.
+//
+// The world is stopped three times: to finish the sweep of the last
+// cycle, to turn on the write barrier, and to finish marking.  Stacks
+// and globals are scanned, and the heap is marked, with the world
+// running, the mark in slices between which the goroutine yields to
+// the mutators, which assist it as they allocate.
+func gccycle(force int32) {
+	semacquire(&worldsema, 0)
+
+	if force == 0 && !gctriggered() {
 		// typically threads which lost the race to grab
 		// worldsema exit here when gc is done.
 		semrelease(&worldsema)
 		return
 	}
 
-	// Ok, we're doing it!  Stop everybody else
-	startTime := nanotime()
-	mp = acquirem()
+	if trace.enabled {
+		traceGCStart()
+	}
+
+	// Sweep what the background sweeper has not got to yet with the
+	// world running, so that sweep termination has little left.
+	for gosweepone() != ^uintptr(0) {
+		sweep.nbgsweep++
+	}
+
+	// Sweep termination.
+	t0 := nanotime()
+	systemstack(stoptheworld)
+	if trace.enabled {
+		traceGCSTWStart()
+	}
+	systemstack(finishsweep_m) // finish sweep before we start concurrent scan.
+	if trace.enabled {
+		traceGCSTWDone()
+	}
+	systemstack(starttheworld)
+	t1 := nanotime()
+
+	// Do a concurrent heap scan before we stop the world.
+	if trace.enabled {
+		traceGCScanStart()
+	}
+	systemstack(gcscan_m)
+	t2 := nanotime()
+
+	// Mark start.
+	systemstack(stoptheworld)
+	paced := force == 0 && memstats.heap_alloc < heaplimitgoal
+	systemstack(func() {
+		gcinstallmarkwb_m(paced)
+	})
+	systemstack(starttheworld)
+	t3 := nanotime()
+
+	work.phases.sweepterm = t1 - t0
+	work.phases.scan = t2 - t1
+	work.phases.markstart = t3 - t2
+
+	gcbgmark()
+	if trace.enabled {
+		traceGCScanDone()
+	}
+
+	// Mark termination.
+	mp := acquirem()
 	mp.gcing = 1
 	releasem(mp)
 
+	startTime := nanotime()
 	systemstack(stoptheworld)
-	systemstack(finishsweep_m) // finish sweep before we start concurrent scan.
-	if true {                  // To turn on concurrent scan and mark set to true...
-		systemstack(starttheworld)
-		// Do a concurrent heap scan before we stop the world.
-		systemstack(gcscan_m)
-		systemstack(stoptheworld)
-		systemstack(gcinstallmarkwb_m)
-		systemstack(starttheworld)
-		systemstack(gcmark_m)
-		systemstack(stoptheworld)
-		systemstack(gcinstalloffwb_m)
+	if trace.enabled {
+		traceGCSTWStart()
 	}
+	systemstack(gcinstalloffwb_m)
 
 	if mp != acquirem() {
 		gothrow("gogc: rescheduled")
@@ -518,12 +589,19 @@ func gogc(force int32) {
 	})
 
 	// all done
+	if trace.enabled {
+		traceGCSTWDone()
+	}
 	mp.gcing = 0
 	semrelease(&worldsema)
 	systemstack(starttheworld)
 	releasem(mp)
 	mp = nil
 
+	if trace.enabled {
+		traceGCDone()
+	}
+
 	// now that gc is done, kick off finalizer thread if needed
 	if !concurrentSweep {
 		// give the queued finalizers, if any, a chance to run
@@ -772,7 +850,7 @@ func runfinq() {
 			fing = gp
 			fingwait = true
 			gp.issystem = true
-			goparkunlock(&finlock, "finalizer wait")
+			goparkunlock(&finlock, "finalizer wait", traceEvGoBlock, 2)
 			gp.issystem = false
 			continue
 		}
@@ -311,6 +311,7 @@ func largeAlloc(size uintptr, flag uint32) *mspan {
 		gothrow("out of memory")
 	}
 	s.limit = uintptr(s.start)<<_PageShift + size
+	xadd64(&objstats.largeallocs, 1)
 	v := unsafe.Pointer(uintptr(s.start) << _PageShift)
 	// setup for mark sweep
 	markspan(v, 0, 0, true)
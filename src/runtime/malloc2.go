@@ -266,6 +266,10 @@ type mstats struct {
 	enablegc       bool
 	debuggc        bool
 
+	// Statistics about the memory limit and scavenging.
+	memory_limit   uint64 // copy of memoryLimit
+	heap_scavenged uint64 // bytes released to the os, even if reused since
+
 	// Statistics about allocation size classes.
 
 	by_size [_NumSizeClasses]struct {
@@ -44,6 +44,42 @@ func TestMemStats(t *testing.T) {
 	if st.HeapIdle+st.HeapInuse != st.HeapSys {
 		t.Fatalf("HeapIdle(%d) + HeapInuse(%d) should be equal to HeapSys(%d), but isn't.", st.HeapIdle, st.HeapInuse, st.HeapSys)
 	}
+
+	if st.HeapScavenged < st.HeapReleased {
+		t.Fatalf("HeapScavenged(%d) should be at least HeapReleased(%d), but isn't.", st.HeapScavenged, st.HeapReleased)
+	}
+}
+
+func TestParseByteCount(t *testing.T) {
+	tests := []struct {
+		s  string
+		n  int64
+		ok bool
+	}{
+		{"0", 0, true},
+		{"1234", 1234, true},
+		{"1234B", 1234, true},
+		{"16KiB", 16 << 10, true},
+		{"512MiB", 512 << 20, true},
+		{"3GiB", 3 << 30, true},
+		{"2TiB", 2 << 40, true},
+		{"9223372036854775807", 1<<63 - 1, true},
+		{"9223372036854775808", 0, false},
+		{"8388608TiB", 0, false},
+		{"", 0, false},
+		{"B", 0, false},
+		{"MiB", 0, false},
+		{"-1", 0, false},
+		{"1.5GiB", 0, false},
+		{"10MB", 0, false},
+		{"10 MiB", 0, false},
+	}
+	for _, tt := range tests {
+		n, ok := ParseByteCount(tt.s)
+		if n != tt.n || ok != tt.ok {
+			t.Errorf("ParseByteCount(%q) = %d, %v; want %d, %v", tt.s, n, ok, tt.n, tt.ok)
+		}
+	}
 }
 
 var mallocSink uintptr
@@ -75,6 +75,9 @@ func mCache_Refill(c *mcache, sizeclass int32) *mspan {
 		println(s.ref, (s.npages<<_PageShift)/s.elemsize)
 		gothrow("empty span")
 	}
+	// Count all the free objects in s as allocated now;
+	// mCentral_UncacheSpan takes back those left over.
+	xadd64(&objstats.smallallocs[sizeclass], int64((s.npages<<_PageShift)/s.elemsize)-int64(s.ref))
 	c.alloc[sizeclass] = s
 	_g_.m.locks--
 	return s
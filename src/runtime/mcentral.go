@@ -110,6 +110,7 @@ func mCentral_UncacheSpan(c *mcentral, s *mspan) {
 	cap := int32((s.npages << _PageShift) / s.elemsize)
 	n := cap - int32(s.ref)
 	if n > 0 {
+		xadd64(&objstats.smallallocs[c.sizeclass], -int64(n))
 		mSpanList_Remove(s)
 		mSpanList_Insert(&c.nonempty, s)
 	}
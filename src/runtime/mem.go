@@ -50,6 +50,10 @@ type MemStats struct {
 	EnableGC     bool
 	DebugGC      bool
 
+	// Memory limit and scavenging statistics.
+	MemoryLimit   uint64 // soft limit on Sys-HeapReleased (see runtime/debug.SetMemoryLimit)
+	HeapScavenged uint64 // bytes released to the OS, including those reused since
+
 	// Per-size allocation statistics.
 	// 61 is NumSizeClasses in the C code.
 	BySize [61]struct {
@@ -79,7 +83,7 @@ func ReadMemStats(m *MemStats) {
 	// because stoptheworld can only be used by
 	// one goroutine at a time, and there might be
 	// a pending garbage collection already calling it.
-	semacquire(&worldsema, false)
+	semacquire(&worldsema, 0)
 	gp := getg()
 	gp.m.gcing = 1
 	systemstack(stoptheworld)
@@ -97,7 +101,7 @@ func ReadMemStats(m *MemStats) {
 
 // Implementation of runtime/debug.WriteHeapDump
 func writeHeapDump(fd uintptr) {
-	semacquire(&worldsema, false)
+	semacquire(&worldsema, 0)
 	gp := getg()
 	gp.m.gcing = 1
 	systemstack(stoptheworld)
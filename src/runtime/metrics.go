@@ -0,0 +1,188 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Implementation of runtime/metrics.
+//
+// Unlike ReadMemStats, reading metrics does not stop the world.
+// Every metric is computed from values that the runtime maintains
+// with atomic updates, so a sample is cheap but different metrics
+// in one Read need not be consistent with each other.
+
+package runtime
+
+import "unsafe"
+
+// objstats counts heap objects allocated and freed, by size class.
+// Unlike the per-mcache counts folded into memstats, which are only
+// complete while the world is stopped, these are updated atomically.
+// A small object counts as allocated as soon as its span is cached
+// in an mcache, so up to a span's worth of objects per size class
+// and P are counted before they are handed out.
+var objstats struct {
+	smallallocs [_NumSizeClasses]uint64
+	smallfrees  [_NumSizeClasses]uint64
+	largeallocs uint64
+	largefrees  uint64
+}
+
+// A timeHistogram is a histogram of durations, updated atomically.
+// Bucket 0 counts durations of zero, and bucket i > 0 counts
+// durations d with 1<<(i-1) <= d < 1<<i nanoseconds.
+// The last bucket also counts all longer durations.
+type timeHistogram struct {
+	counts [timeHistNumBuckets]uint64
+}
+
+const timeHistNumBuckets = 48 // the last bucket starts at about 19.5 hours
+
+// record adds the duration d, in nanoseconds, to h.
+//go:nosplit
+func (h *timeHistogram) record(d int64) {
+	i := 0
+	for ; d > 0 && i < timeHistNumBuckets-1; d >>= 1 {
+		i++
+	}
+	xadd64(&h.counts[i], 1)
+}
+
+// schedLatencySampleRate is how many times a goroutine becomes runnable
+// for each time that its wait to run is timed for schedlatencies.
+const schedLatencySampleRate = 8
+
+var (
+	schedlatencies timeHistogram // sampled times that goroutines waited to run
+	gcpauses       timeHistogram // stop-the-world pauses of the garbage collector
+)
+
+// metricSample mirrors the layout of metrics.Sample.
+type metricSample struct {
+	name  string
+	value metricValue
+}
+
+// metricValue mirrors the layout of metrics.Value.
+type metricValue struct {
+	kind    metricKind
+	scalar  uint64         // uint64 or the bits of a float64
+	pointer unsafe.Pointer // *metricFloat64Histogram
+}
+
+// metricKind mirrors metrics.ValueKind.
+type metricKind int
+
+const (
+	metricKindBad metricKind = iota
+	metricKindUint64
+	metricKindFloat64
+	metricKindFloat64Histogram
+)
+
+// metricFloat64Histogram mirrors the layout of metrics.Float64Histogram.
+type metricFloat64Histogram struct {
+	counts  []uint64
+	buckets []float64
+}
+
+// readMetrics is called from runtime/metrics.Read.
+// The names of the metrics must match the list in runtime/metrics.
+func readMetrics(samples []metricSample) {
+	for i := range samples {
+		s := &samples[i]
+		readMetric(s.name, &s.value)
+	}
+}
+
+// readMetric sets v to the value of the named metric,
+// or marks it bad if there is no such metric.
+func readMetric(name string, v *metricValue) {
+	switch name {
+	case "/gc/cycles/total:gc-cycles":
+		v.setUint64(uint64(atomicload(&memstats.numgc)))
+	case "/gc/heap/goal:bytes":
+		v.setUint64(atomicload64(&memstats.next_gc))
+	case "/gc/heap/allocs:objects":
+		n := atomicload64(&objstats.largeallocs)
+		for i := range objstats.smallallocs {
+			n += atomicload64(&objstats.smallallocs[i])
+		}
+		v.setUint64(n)
+	case "/gc/heap/frees:objects":
+		n := atomicload64(&objstats.largefrees)
+		for i := range objstats.smallfrees {
+			n += atomicload64(&objstats.smallfrees[i])
+		}
+		v.setUint64(n)
+	case "/gc/heap/allocs-by-size:bytes":
+		sizeclassHistogram(v, &objstats.smallallocs, &objstats.largeallocs)
+	case "/gc/heap/frees-by-size:bytes":
+		sizeclassHistogram(v, &objstats.smallfrees, &objstats.largefrees)
+	case "/gc/pauses:seconds":
+		gcpauses.write(v)
+	case "/sched/goroutines:goroutines":
+		v.setUint64(uint64(gcount()))
+	case "/sched/gomaxprocs:threads":
+		v.setUint64(uint64(gomaxprocs))
+	case "/sched/latencies:seconds":
+		schedlatencies.write(v)
+	default:
+		v.kind = metricKindBad
+		v.scalar = 0
+	}
+}
+
+func (v *metricValue) setUint64(x uint64) {
+	v.kind = metricKindUint64
+	v.scalar = x
+}
+
+// float64HistOrInit returns the histogram held by v, reusing
+// the one already there if it has room for n counts.
+func (v *metricValue) float64HistOrInit(n int) *metricFloat64Histogram {
+	var h *metricFloat64Histogram
+	if v.kind == metricKindFloat64Histogram && v.pointer != nil {
+		h = (*metricFloat64Histogram)(v.pointer)
+	} else {
+		h = new(metricFloat64Histogram)
+		v.kind = metricKindFloat64Histogram
+		v.pointer = unsafe.Pointer(h)
+	}
+	if cap(h.counts) < n {
+		h.counts = make([]uint64, n)
+	}
+	h.counts = h.counts[:n]
+	if cap(h.buckets) < n+1 {
+		h.buckets = make([]float64, n+1)
+	}
+	h.buckets = h.buckets[:n+1]
+	return h
+}
+
+// sizeclassHistogram sets v to a histogram of object sizes with a bucket
+// for each size class, holding the counts small, and a last bucket for
+// large objects, holding the count large.  Each bucket starts one byte
+// past the size of the previous class, so that it contains the sizes
+// rounded up to its class.
+func sizeclassHistogram(v *metricValue, small *[_NumSizeClasses]uint64, large *uint64) {
+	h := v.float64HistOrInit(_NumSizeClasses)
+	for i := 1; i < _NumSizeClasses; i++ {
+		h.counts[i-1] = atomicload64(&small[i])
+		h.buckets[i-1] = float64(class_to_size[i-1] + 1)
+	}
+	h.counts[_NumSizeClasses-1] = atomicload64(large)
+	h.buckets[_NumSizeClasses-1] = float64(_MaxSmallSize + 1)
+	h.buckets[_NumSizeClasses] = posinf()
+}
+
+// write sets v to h in seconds.
+func (h *timeHistogram) write(v *metricValue) {
+	m := v.float64HistOrInit(timeHistNumBuckets)
+	m.buckets[0] = 0
+	for i := range h.counts {
+		m.counts[i] = atomicload64(&h.counts[i])
+		if i > 0 {
+			m.buckets[i] = float64(int64(1)<<uint(i-1)) / 1e9
+		}
+	}
+	m.buckets[timeHistNumBuckets] = posinf()
+}
@@ -0,0 +1,49 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics_test
+
+import (
+	"io/ioutil"
+	"regexp"
+	"runtime/metrics"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestDescriptionNameFormat(t *testing.T) {
+	r := regexp.MustCompile("^(?P<name>/[^:]+):(?P<unit>[^:*/]+(?:[*/][^:*/]+)*)$")
+	descriptions := metrics.All()
+	for _, desc := range descriptions {
+		if !r.MatchString(desc.Name) {
+			t.Errorf("metrics %q does not match regexp %s", desc.Name, r)
+		}
+	}
+}
+
+func TestDescriptionsSorted(t *testing.T) {
+	all := metrics.All()
+	if !sort.IsSorted(byName(all)) {
+		t.Errorf("metric descriptions are not sorted by name")
+	}
+}
+
+func TestDocs(t *testing.T) {
+	doc, err := ioutil.ReadFile("doc.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, desc := range metrics.All() {
+		if !strings.Contains(string(doc), "\n\t"+desc.Name+"\n") {
+			t.Errorf("metric %s is not documented in doc.go", desc.Name)
+		}
+	}
+}
+
+type byName []metrics.Description
+
+func (x byName) Len() int           { return len(x) }
+func (x byName) Swap(i, j int)      { x[i], x[j] = x[j], x[i] }
+func (x byName) Less(i, j int) bool { return x[i].Name < x[j].Name }
@@ -0,0 +1,160 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics_test
+
+import (
+	"math"
+	"runtime"
+	"runtime/metrics"
+	"testing"
+	"time"
+)
+
+func prepareAllMetricsSamples() (map[string]metrics.Description, []metrics.Sample) {
+	all := metrics.All()
+	samples := make([]metrics.Sample, len(all))
+	descs := make(map[string]metrics.Description)
+	for i := range all {
+		samples[i].Name = all[i].Name
+		descs[all[i].Name] = all[i]
+	}
+	return descs, samples
+}
+
+var sink []byte
+
+func TestReadMetrics(t *testing.T) {
+	// Generate some garbage and a few collections,
+	// and keep the scheduler busy.
+	done := make(chan bool)
+	for i := 0; i < 4; i++ {
+		go func() {
+			for j := 0; j < 100; j++ {
+				runtime.Gosched()
+			}
+			done <- true
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+	for i := 0; i < 1000; i++ {
+		sink = make([]byte, 100)
+	}
+	sink = make([]byte, 1<<20)
+	runtime.GC()
+
+	descs, samples := prepareAllMetricsSamples()
+	metrics.Read(samples)
+	values := make(map[string]metrics.Sample)
+	for _, s := range samples {
+		values[s.Name] = s
+		desc := descs[s.Name]
+		if s.Value.Kind() != desc.Kind {
+			t.Errorf("metric %s has kind %v, want %v", s.Name, s.Value.Kind(), desc.Kind)
+			continue
+		}
+		if s.Value.Kind() == metrics.KindFloat64Histogram {
+			h := s.Value.Float64Histogram()
+			if len(h.Buckets) != len(h.Counts)+1 {
+				t.Errorf("metric %s has %d buckets for %d counts", s.Name, len(h.Buckets), len(h.Counts))
+				continue
+			}
+			for i := 1; i < len(h.Buckets); i++ {
+				if h.Buckets[i] <= h.Buckets[i-1] {
+					t.Errorf("metric %s has unordered buckets %v", s.Name, h.Buckets)
+					break
+				}
+			}
+			if !math.IsInf(h.Buckets[len(h.Buckets)-1], 1) {
+				t.Errorf("metric %s: last bucket does not end at +Inf", s.Name)
+			}
+		}
+	}
+
+	var mstats runtime.MemStats
+	runtime.ReadMemStats(&mstats)
+	if got := values["/gc/cycles/total:gc-cycles"].Value.Uint64(); got == 0 || got > uint64(mstats.NumGC) {
+		t.Errorf("/gc/cycles/total:gc-cycles = %d, want in [1, %d]", got, mstats.NumGC)
+	}
+	if got := values["/sched/goroutines:goroutines"].Value.Uint64(); got == 0 {
+		t.Errorf("/sched/goroutines:goroutines = 0")
+	}
+	if got, want := values["/sched/gomaxprocs:threads"].Value.Uint64(), uint64(runtime.GOMAXPROCS(-1)); got != want {
+		t.Errorf("/sched/gomaxprocs:threads = %d, want %d", got, want)
+	}
+
+	// The histograms by size must add up to the totals.
+	for _, kind := range []string{"allocs", "frees"} {
+		total := values["/gc/heap/"+kind+":objects"].Value.Uint64()
+		var sum uint64
+		for _, c := range values["/gc/heap/"+kind+"-by-size:bytes"].Value.Float64Histogram().Counts {
+			sum += c
+		}
+		if sum != total || total == 0 {
+			t.Errorf("/gc/heap/%s-by-size:bytes counts add up to %d, /gc/heap/%s:objects is %d", kind, sum, kind, total)
+		}
+	}
+	allocs := values["/gc/heap/allocs-by-size:bytes"].Value.Float64Histogram()
+	if n := len(allocs.Counts); allocs.Counts[n-1] == 0 {
+		t.Errorf("/gc/heap/allocs-by-size:bytes counts no large objects")
+	}
+	if allocs.Buckets[0] != 1 {
+		t.Errorf("/gc/heap/allocs-by-size:bytes first bucket starts at %v, want 1", allocs.Buckets[0])
+	}
+
+	pauses := values["/gc/pauses:seconds"].Value.Float64Histogram()
+	var npauses uint64
+	for _, c := range pauses.Counts {
+		npauses += c
+	}
+	if npauses == 0 {
+		t.Errorf("/gc/pauses:seconds is empty after a GC")
+	}
+}
+
+func TestReadMetricsReuse(t *testing.T) {
+	samples := []metrics.Sample{{Name: "/sched/latencies:seconds"}, {Name: "/no/such:metric"}}
+	metrics.Read(samples)
+	h := samples[0].Value.Float64Histogram()
+	if samples[1].Value.Kind() != metrics.KindBad {
+		t.Errorf("unknown metric has kind %v, want KindBad", samples[1].Value.Kind())
+	}
+	metrics.Read(samples)
+	if samples[0].Value.Float64Histogram() != h {
+		t.Errorf("Read did not reuse the histogram")
+	}
+}
+
+func TestSchedLatencies(t *testing.T) {
+	// Goroutines that keep yielding become runnable many times,
+	// so some of their waits must be sampled.
+	read := func() uint64 {
+		s := []metrics.Sample{{Name: "/sched/latencies:seconds"}}
+		metrics.Read(s)
+		var n uint64
+		for _, c := range s[0].Value.Float64Histogram().Counts {
+			n += c
+		}
+		return n
+	}
+	before := read()
+	deadline := time.Now().Add(10 * time.Second)
+	for read() == before {
+		if time.Now().After(deadline) {
+			t.Fatalf("no scheduling latencies recorded")
+		}
+		for i := 0; i < 100; i++ {
+			runtime.Gosched()
+		}
+	}
+}
+
+func BenchmarkReadMetricsLatency(b *testing.B) {
+	_, samples := prepareAllMetricsSamples()
+	for i := 0; i < b.N; i++ {
+		metrics.Read(samples)
+	}
+}
@@ -110,6 +110,9 @@
 // (this mark is tracked in next_gc variable). This keeps the GC cost in linear
 // proportion to the allocation cost. Adjusting GOGC just changes the linear constant
 // (and also the amount of extra memory used).
+// Since the mark runs concurrently, the cycle starts before the heap reaches next_gc,
+// at a trigger the pacer sets so that marking finishes about when it gets there,
+// see pacerdata below.
 
 package runtime
 
@@ -136,6 +139,81 @@ var oneptr = [...]uint8{bitsPointer}
 // Initialized from $GOGC.  GOGC=off means no GC.
 var gcpercent int32
 
+// Initialized from $GOMEMLIMIT.  maxMemoryLimit means no limit.
+// Protected by mheap.lock for writes.
+var memoryLimit int64 = maxMemoryLimit
+
+const maxMemoryLimit = 1<<63 - 1
+
+// heaplimitgoal is the heap size at which the memory limit triggers
+// a collection even though next_gc has not been reached.  It is
+// recomputed when sweeping finishes and when the limit changes.
+var heaplimitgoal uint64 = ^uint64(0)
+
+const (
+	_GCBgMarkSlice     = 1000 * 1000 // ns the background mark runs before it yields
+	_GCMarkChunk       = 64 << 10    // scan work between checks of the slice and the pacer
+	_GCAssistMin       = 64 << 10    // scan work a goroutine owes before it assists
+	_GCGoalUtilization = 0.25        // fraction of the CPU the mark should use
+	_GCMinTriggerRatio = 0.05
+	_GCMaxTriggerRatio = 0.95
+)
+
+// The pacer decides when a cycle starts and how much allocating
+// goroutines help to mark.
+//
+// The cycle starts when the heap has grown triggerratio of the way
+// from the heap the last cycle marked to the goal, next_gc, so that
+// the mark can finish as the heap reaches the goal.  At the end of
+// each paced mark the ratio moves toward the one that would have
+// finished at the goal with the mark using _GCGoalUtilization of the
+// CPU, given how far the heap grew and how much CPU the mark took.
+//
+// The background mark alone may not keep up with a program that
+// allocates quickly, so while marking each goroutine owes scan work
+// in proportion to what it allocates: assistratio is the scan work
+// still expected over the heap growth left before the goal.  Scan work
+// the background mark does counts as credit that goroutines draw on
+// before they assist.  Scan work is measured in bytes of the objects
+// scanned.
+type pacerdata struct {
+	triggerratio float64 // fraction of the growth to the goal at which a cycle starts
+	triggerscale uint64  // see gctrigger
+
+	paced        bool   // the cycle started at the trigger
+	heapmarked   uint64 // heap marked by the last cycle
+	markstart    int64  // when the concurrent mark started
+	scanexpected int64  // scan work the mark is expected to need
+	lastscanwork int64  // scan work of the last mark
+
+	// Updated atomically during the mark.
+	scanwork   uint64 // scan work done
+	bgcredit   uint64 // an int64: background scan work not yet drawn on by assists
+	bgmarktime uint64 // time spent in the background mark
+	assisttime uint64 // time spent in assists
+
+	assistratio float64 // scan work owed per byte allocated
+}
+
+var pacer pacerdata
+
+// Duration of each phase of a collection: the pauses of the
+// stop-the-world phases and the time spent in the concurrent ones.
+type gcphasetimes struct {
+	sweepterm int64 // pause: finish the sweep, start the cycle
+	scan      int64 // scan stacks and globals
+	markstart int64 // pause: turn on the write barrier
+	mark      int64 // background mark
+	assist    int64 // mutator assists
+	markterm  int64 // pause: finish marking, start the sweep
+}
+
+// Number of fields in gcphasetimes.
+const gcphasecount = 6
+
+// Phase times of recent cycles, indexed like memstats.pause_ns.
+var gcphasehist [len(memstats.pause_ns)]gcphasetimes
+
 // Holding worldsema grants an M the right to try to stop the world.
 // The procedure is:
 //
@@ -201,6 +279,8 @@ type workdata struct {
 
 	// Copy of mheap.allspans for marker or sweeper.
 	spans []*mspan
+
+	phases gcphasetimes // of the cycle in progress
 }
 
 var work workdata
@@ -232,9 +312,13 @@ func have_cgo_allocate() bool {
 // When marking an object if the bool checkmark is true one uses the above
 // encoding, otherwise one uses the bitMarked bit in the lower two bits
 // of the nibble.
+// The checkmark pass re-marks the heap with the world stopped after
+// every cycle to verify the concurrent mark. It makes the mark
+// termination pause as long as a full collection, so it is off
+// unless GODEBUG=gccheckmark=1 or GCcheckmarkenable turn it on.
 var (
 	checkmark         = false
-	gccheckmarkenable = true
+	gccheckmarkenable = false
 )
 
 // Is address b in the known heap. If it doesn't have a valid gcmap
@@ -443,7 +527,9 @@ func greyobject(obj uintptr, mbits *markbits, wbuf *workbuf) *workbuf {
 		// but the object it shares the byte with is already marked,
 		// then all the possible concurrent updates are trying to set the same bit,
 		// so we can use a non-atomic update.
-		if mbits.xbits&(bitMask|bitMask<<gcBits) != bitBoundary|bitBoundary<<gcBits || work.nproc == 1 {
+		// Outside mark termination the mutators and other markers
+		// run concurrently even if work.nproc == 1.
+		if mbits.xbits&(bitMask|bitMask<<gcBits) != bitBoundary|bitBoundary<<gcBits || work.nproc == 1 && gcphase == _GCmarktermination {
 			*mbits.bitp = mbits.xbits | bitMarked<<mbits.shift
 		} else {
 			atomicor8(mbits.bitp, bitMarked<<mbits.shift)
@@ -851,9 +937,19 @@ func stackmapdata(stkmap *stackmap, n int32) bitvector {
 }
 
 // Scan a stack frame: local variables and function arguments/results.
-func scanframe(frame *stkframe, unused unsafe.Pointer) bool {
+func scanframe(frame *stkframe, v unsafe.Pointer) bool {
 
 	f := frame.fn
+	if f.entry == asyncPreemptPC {
+		// The frame holds the registers of the interrupted
+		// function above it.
+		scanasyncregs(frame)
+		if v != nil {
+			*(*bool)(v) = true
+		}
+		return true
+	}
+
 	targetpc := frame.continpc
 	if targetpc == 0 {
 		// Frame is dead.
@@ -862,7 +958,11 @@ func scanframe(frame *stkframe, unused unsafe.Pointer) bool {
 	if _DebugGC > 1 {
 		print("scanframe ", gofuncname(f), "\n")
 	}
-	if targetpc != f.entry {
+	if v != nil && *(*bool)(v) {
+		// The frame was interrupted at targetpc, which is not
+		// a return address, and has stack maps for it.
+		*(*bool)(v) = false
+	} else if targetpc != f.entry {
 		targetpc--
 	}
 	pcdata := pcdatavalue(f, _PCDATA_StackMapIndex, targetpc)
@@ -950,7 +1050,10 @@ func scanstack(gp *g) {
 		gothrow("can't scan gchelper stack")
 	}
 
-	gentraceback(^uintptr(0), ^uintptr(0), 0, gp, 0, nil, 0x7fffffff, scanframe, nil, 0)
+	// Set by scanframe at an asyncPreempt frame, so that it looks up
+	// the stack maps of the interrupted frame above at the pc itself.
+	var interrupted bool
+	gentraceback(^uintptr(0), ^uintptr(0), 0, gp, 0, nil, 0x7fffffff, scanframe, noescape(unsafe.Pointer(&interrupted)), 0)
 	tracebackdefers(gp, scanframe, nil)
 }
 
@@ -1317,6 +1420,7 @@ func mSpan_Sweep(s *mspan, preserve bool) bool {
 			}
 			c.local_nlargefree++
 			c.local_largefree += size
+			xadd64(&objstats.largefrees, 1)
 			xadd64(&memstats.next_gc, -int64(size)*int64(gcpercent+100)/100)
 			res = true
 		} else {
@@ -1353,6 +1457,7 @@ func mSpan_Sweep(s *mspan, preserve bool) bool {
 	}
 	if nfree > 0 {
 		c.local_nsmallfree[cl] += uintptr(nfree)
+		xadd64(&objstats.smallfrees[cl], int64(nfree))
 		c.local_cachealloc -= intptr(uintptr(nfree) * size)
 		xadd64(&memstats.next_gc, -int64(nfree)*int64(size)*int64(gcpercent+100)/100)
 		res = mCentral_FreeSpan(&mheap_.central[cl].mcentral, s, int32(nfree), head, end, preserve)
@@ -1376,6 +1481,18 @@ type sweepdata struct {
 
 var sweep sweepdata
 
+// State of the background collector, which runs the cycles that
+// allocation starts.
+// Protected by gclock, except that running is read atomically.
+type bggcdata struct {
+	g       *g
+	parked  bool
+	started bool
+	running uint32 // a cycle has been asked for and not yet finished
+}
+
+var bggc bggcdata
+
 // sweeps one span
 // returns number of pages returned to heap, or ^uintptr(0) if there is nothing to sweep
 func sweepone() uintptr {
@@ -1388,7 +1505,11 @@ func sweepone() uintptr {
 	for {
 		idx := xadd(&sweep.spanidx, 1) - 1
 		if idx >= uint32(len(work.spans)) {
-			mheap_.sweepdone = 1
+			if mheap_.sweepdone == 0 && cas(&mheap_.sweepdone, 0, 1) {
+				// The heap now holds the live data plus
+				// what was allocated during the sweep.
+				setheaplimitgoal()
+			}
 			_g_.m.locks--
 			return ^uintptr(0)
 		}
@@ -1483,6 +1604,7 @@ func updatememstats(stats *gcstats) {
 		}
 	}
 
+	memstats.memory_limit = uint64(memoryLimit)
 	memstats.mcache_inuse = uint64(mheap_.cachealloc.inuse)
 	memstats.mspan_inuse = uint64(mheap_.spanalloc.inuse)
 	memstats.sys = memstats.heap_sys + memstats.stacks_sys + memstats.mspan_sys +
@@ -1553,6 +1675,10 @@ func gcinit() {
 
 	work.markfor = parforalloc(_MaxGcproc)
 	gcpercent = readgogc()
+	memoryLimit = readgomemlimit()
+	gccheckmarkenable = debug.gccheckmark > 0
+	pacer.triggerratio = 7.0 / 8
+	pacersettrigger()
 	gcdatamask = unrollglobgcprog((*byte)(unsafe.Pointer(&gcdata)), uintptr(unsafe.Pointer(&edata))-uintptr(unsafe.Pointer(&data)))
 	gcbssmask = unrollglobgcprog((*byte)(unsafe.Pointer(&gcbss)), uintptr(unsafe.Pointer(&ebss))-uintptr(unsafe.Pointer(&bss)))
 }
@@ -1810,21 +1936,264 @@ func gcscan_m() {
 	// Let the g that called us continue to run.
 }
 
-// Mark all objects that are known about.
-func gcmark_m() {
-	scanblock(0, 0, nil)
-}
-
 // For now this must be bracketed with a stoptheworld and a starttheworld to ensure
 // all go routines see the new barrier.
-func gcinstallmarkwb_m() {
+// paced says whether the cycle started at the pacer's trigger.
+func gcinstallmarkwb_m(paced bool) {
 	gcphase = _GCmark
+	pacerstart(paced)
 }
 
 // For now this must be bracketed with a stoptheworld and a starttheworld to ensure
 // all go routines see the new barrier.
 func gcinstalloffwb_m() {
 	gcphase = _GCoff
+	pacerend()
+}
+
+// gcbgmark marks the heap concurrently on the goroutine running the
+// cycle, in slices of _GCBgMarkSlice between which it yields to the
+// mutators, until it finds no more queued work.  What the write
+// barrier queues after that is left to mark termination.
+func gcbgmark() {
+	for {
+		start := nanotime()
+		var done bool
+		systemstack(func() {
+			done = gcbgmarkslice_m(start)
+		})
+		xadd64(&pacer.bgmarktime, nanotime()-start)
+		if done {
+			return
+		}
+		Gosched()
+	}
+}
+
+// gcbgmarkslice_m marks until the slice that began at start is over,
+// and reports whether it ran out of queued work first.
+func gcbgmarkslice_m(start int64) bool {
+	for {
+		n := gcdrainn(_GCMarkChunk)
+		xadd64(&pacer.bgcredit, n)
+		pacerrevise()
+		if n < _GCMarkChunk {
+			return true
+		}
+		if nanotime()-start >= _GCBgMarkSlice || sched.gcwaiting != 0 {
+			return false
+		}
+	}
+}
+
+// gcdrainn scans queued objects until it has done n bytes of scan work
+// or found no more queued work, and returns the scan work it did.
+// Unlike scanblock(0, 0, nil) it never waits for other markers, so the
+// background mark and the assists can share the queues while the
+// world runs.
+func gcdrainn(n int64) int64 {
+	var done int64
+	wbuf := getpartialorempty()
+	for done < n {
+		if wbuf.nobj == 0 {
+			putempty(wbuf)
+			wbuf = (*workbuf)(lfstackpop(&work.full))
+			if wbuf == nil {
+				wbuf = (*workbuf)(lfstackpop(&work.partial))
+			}
+			if wbuf == nil {
+				break
+			}
+		}
+		wbuf.nobj--
+		b := wbuf.obj[wbuf.nobj]
+		wbuf = scanobject(b, mheap_.arena_used-b, nil, wbuf)
+		done += int64(h_spans[(b-mheap_.arena_start)>>_PageShift].elemsize)
+	}
+	if wbuf != nil {
+		putpartial(wbuf)
+	}
+	xadd64(&pacer.scanwork, done)
+	return done
+}
+
+// gcassistalloc charges the current goroutine for allocating size
+// bytes during the concurrent mark.  Once it owes _GCAssistMin of
+// scan work it pays, first out of the background mark's credit and
+// then by marking.
+func gcassistalloc(size uintptr) {
+	mp := acquirem()
+	gp := mp.curg
+	if gp != getg() || mp.locks > 1 || mp.gcing != 0 {
+		// Not a user goroutine, or one that holds locks.
+		releasem(mp)
+		return
+	}
+	releasem(mp)
+
+	gp.gcalloc += size
+	debt := int64(float64(gp.gcalloc)*pacer.assistratio) - gp.gcscanwork
+	if debt < _GCAssistMin {
+		return
+	}
+	for {
+		credit := int64(atomicload64(&pacer.bgcredit))
+		if credit <= 0 {
+			break
+		}
+		take := debt
+		if take > credit {
+			take = credit
+		}
+		if cas64(&pacer.bgcredit, uint64(credit), uint64(credit-take)) {
+			gp.gcscanwork += take
+			debt -= take
+			break
+		}
+	}
+	if debt <= 0 {
+		return
+	}
+
+	start := nanotime()
+	var done int64
+	empty := false
+	systemstack(func() {
+		// Drain in chunks so that a stop the world does not wait
+		// on a large assist.  The mark may have ended since the
+		// caller looked.
+		for done < debt && gcphase == _GCmark && !gp.preempt && sched.gcwaiting == 0 {
+			want := debt - done
+			if want > _GCMarkChunk {
+				want = _GCMarkChunk
+			}
+			n := gcdrainn(want)
+			done += n
+			if n < want {
+				empty = true
+				break
+			}
+		}
+	})
+	if empty {
+		// There was no more queued work.  Forgive the rest of
+		// the debt rather than look again at every allocation.
+		done = debt
+	}
+	gp.gcscanwork += done
+	xadd64(&pacer.assisttime, nanotime()-start)
+}
+
+// gctrigger returns the heap size at which the next cycle starts:
+// triggerratio of the way from the heap the last cycle marked to
+// next_gc, which is that heap grown by GOGC.
+func gctrigger() uint64 {
+	goal := memstats.next_gc
+	if goal == ^uint64(0) {
+		return goal
+	}
+	return (goal >> 16) * pacer.triggerscale
+}
+
+// gctriggered reports whether the heap has grown enough for a cycle
+// to start, because of GOGC or of the memory limit.
+func gctriggered() bool {
+	return memstats.heap_alloc >= gctrigger() || memstats.heap_alloc >= heaplimitgoal
+}
+
+// pacersettrigger recomputes triggerscale after triggerratio or
+// gcpercent changes.  Since next_gc is the marked heap grown by GOGC,
+// the trigger is next_gc scaled by triggerscale/(1<<16).
+func pacersettrigger() {
+	scale := 1.0
+	if gcpercent > 0 {
+		growth := float64(gcpercent) / 100
+		scale = (1 + pacer.triggerratio*growth) / (1 + growth)
+	}
+	pacer.triggerscale = uint64(scale * (1 << 16))
+}
+
+// pacerstart sets up the pacer for the mark.  The world is stopped.
+func pacerstart(paced bool) {
+	pacer.paced = paced
+	if gcpercent >= 0 && memstats.next_gc != ^uint64(0) {
+		// Sweeping has finished, so next_gc is down to the
+		// heap the last cycle marked grown by GOGC.
+		pacer.heapmarked = memstats.next_gc * 100 / (uint64(gcpercent) + 100)
+	} else {
+		pacer.heapmarked = memstats.heap_alloc
+	}
+	pacer.scanexpected = pacer.lastscanwork
+	if pacer.scanexpected == 0 {
+		pacer.scanexpected = int64(pacer.heapmarked)
+	}
+	pacer.scanwork = 0
+	pacer.bgcredit = 0
+	pacer.bgmarktime = 0
+	pacer.assisttime = 0
+	pacer.markstart = nanotime()
+	for i := uintptr(0); i < allglen; i++ {
+		gp := allgs[i]
+		gp.gcalloc = 0
+		gp.gcscanwork = 0
+	}
+	pacerrevise()
+}
+
+// pacerrevise recomputes assistratio from the scan work left and
+// the heap growth left before the goal.
+func pacerrevise() {
+	goal := memstats.next_gc
+	if heaplimitgoal < goal {
+		goal = heaplimitgoal
+	}
+	if goal == ^uint64(0) {
+		pacer.assistratio = 0
+		return
+	}
+	remaining := pacer.scanexpected - int64(atomicload64(&pacer.scanwork))
+	if remaining < _GCMarkChunk {
+		// The estimate was short; assume a little is left.
+		remaining = _GCMarkChunk
+	}
+	distance := int64(goal) - int64(memstats.heap_alloc)
+	if distance <= 0 {
+		// Past the goal already: assist as much as there is work.
+		distance = 1
+	}
+	pacer.assistratio = float64(remaining) / float64(distance)
+}
+
+// pacerend records the times of the mark and, if the cycle started at
+// the trigger, moves triggerratio toward the one that would have met
+// the goal.  The world is stopped.
+func pacerend() {
+	work.phases.mark = int64(pacer.bgmarktime)
+	work.phases.assist = int64(pacer.assisttime)
+	pacer.lastscanwork = int64(pacer.scanwork)
+
+	elapsed := nanotime() - pacer.markstart
+	if !pacer.paced || gcpercent <= 0 || pacer.heapmarked == 0 || elapsed <= 0 {
+		return
+	}
+	goalgrowth := float64(gcpercent) / 100
+	actualgrowth := float64(memstats.heap_alloc)/float64(pacer.heapmarked) - 1
+	triggergrowth := pacer.triggerratio * goalgrowth
+	utilization := float64(pacer.bgmarktime+pacer.assisttime) / (float64(elapsed) * float64(gomaxprocs))
+
+	// Had the mark used the goal utilization, the heap would have
+	// grown past the trigger in proportion to how much less CPU
+	// that is.  Move the trigger by half the distance by which
+	// that growth misses the goal.
+	miss := goalgrowth - triggergrowth - utilization/_GCGoalUtilization*(actualgrowth-triggergrowth)
+	ratio := (triggergrowth + miss/2) / goalgrowth
+	if ratio < _GCMinTriggerRatio {
+		ratio = _GCMinTriggerRatio
+	} else if ratio > _GCMaxTriggerRatio {
+		ratio = _GCMaxTriggerRatio
+	}
+	pacer.triggerratio = ratio
+	pacersettrigger()
 }
 
 func gc(start_time int64, eagersweep bool) {
@@ -1916,20 +2285,59 @@ func gc(start_time int64, eagersweep bool) {
 
 	cachestats()
 	// next_gc calculation is tricky with concurrent sweep since we don't know size of live heap
-	// estimate what was live heap size after previous GC (for printing only)
-	heap0 := memstats.next_gc * 100 / (uint64(gcpercent) + 100)
+	// estimate what was live heap size after previous GC (for printing only).
+	// There is no estimate if the previous GC ran with GOGC=off, because
+	// of the memory limit, and next_gc was left at its maximum.
+	var heap0 uint64
+	if gcpercent >= 0 && memstats.next_gc != ^uint64(0) {
+		heap0 = memstats.next_gc * 100 / (uint64(gcpercent) + 100)
+	}
 	// conservatively set next_gc to high value assuming that everything is live
 	// concurrent/lazy sweep will reduce this number while discovering new garbage
-	memstats.next_gc = memstats.heap_alloc + memstats.heap_alloc*uint64(gcpercent)/100
+	if gcpercent < 0 {
+		// Collecting only because of the memory limit.
+		memstats.next_gc = ^uint64(0)
+	} else {
+		memstats.next_gc = memstats.heap_alloc + memstats.heap_alloc*uint64(gcpercent)/100
+	}
+	// Until sweeping finishes heap_alloc overstates the live heap,
+	// so the memory limit cannot trigger another collection yet.
+	heaplimitgoal = ^uint64(0)
+	if trace.enabled {
+		traceNextGC()
+	}
 
 	t4 := nanotime()
-	atomicstore64(&memstats.last_gc, uint64(unixnanotime())) // must be Unix time to make sense to user
-	memstats.pause_ns[memstats.numgc%uint32(len(memstats.pause_ns))] = uint64(t4 - t0)
-	memstats.pause_end[memstats.numgc%uint32(len(memstats.pause_end))] = uint64(t4)
-	memstats.pause_total_ns += uint64(t4 - t0)
-	memstats.numgc++
-	if memstats.debuggc {
-		print("pause ", t4-t0, "\n")
+	if checkmark {
+		// The checkmark pass runs within the mark termination of
+		// the cycle just recorded, from the same start time, so
+		// extend that pause instead of recording another cycle.
+		i := (memstats.numgc - 1) % uint32(len(memstats.pause_ns))
+		extra := t4 - t0 - gcphasehist[i].markterm
+		gcphasehist[i].markterm = t4 - t0
+		memstats.pause_ns[i] += uint64(extra)
+		memstats.pause_end[i] = uint64(t4)
+		memstats.pause_total_ns += uint64(extra)
+	} else {
+		// The pause of a cycle is that of its three stop-the-world
+		// phases.  Only mark termination runs again for gctrace > 1.
+		work.phases.markterm = t4 - t0
+		pause := work.phases.sweepterm + work.phases.markstart + work.phases.markterm
+		atomicstore64(&memstats.last_gc, uint64(unixnanotime())) // must be Unix time to make sense to user
+		memstats.pause_ns[memstats.numgc%uint32(len(memstats.pause_ns))] = uint64(pause)
+		memstats.pause_end[memstats.numgc%uint32(len(memstats.pause_end))] = uint64(t4)
+		memstats.pause_total_ns += uint64(pause)
+		gcphasehist[memstats.numgc%uint32(len(gcphasehist))] = work.phases
+		if work.phases.sweepterm != 0 {
+			gcpauses.record(work.phases.sweepterm)
+			gcpauses.record(work.phases.markstart)
+		}
+		gcpauses.record(work.phases.markterm)
+		work.phases = gcphasetimes{}
+		memstats.numgc++
+		if memstats.debuggc {
+			print("pause ", pause, "\n")
+		}
 	}
 
 	if debug.gctrace > 0 {
@@ -1991,14 +2399,20 @@ func gc(start_time int64, eagersweep bool) {
 			sweep.started = true
 		} else if sweep.parked {
 			sweep.parked = false
-			ready(sweep.g)
+			ready(sweep.g, 0)
 		}
 		unlock(&gclock)
 	} else {
 		// Sweep all spans eagerly.
+		if trace.enabled {
+			traceGCSweepStart()
+		}
 		for sweepone() != ^uintptr(0) {
 			sweep.npausesweep++
 		}
+		if trace.enabled {
+			traceGCSweepDone()
+		}
 		// Do an additional mProf_GC, because all 'free' events are now real as well.
 		mProf_GC()
 	}
@@ -2034,11 +2448,12 @@ func readGCStats(pauses *[]uint64) {
 func readGCStats_m(pauses *[]uint64) {
 	p := *pauses
 	// Calling code in runtime/debug should make the slice large enough.
-	if cap(p) < len(memstats.pause_ns)+3 {
+	if cap(p) < len(memstats.pause_ns)*(2+gcphasecount)+3 {
 		gothrow("runtime: short slice passed to readGCStats")
 	}
 
-	// Pass back: pauses, pause ends, last gc (absolute time), number of gc, total pause ns.
+	// Pass back: pauses, pause ends, phase times (gcphasecount for
+	// each pause), last gc (absolute time), number of gc, total pause ns.
 	lock(&mheap_.lock)
 
 	n := memstats.numgc
@@ -2051,17 +2466,26 @@ func readGCStats_m(pauses *[]uint64) {
 	// from there to go back farther in time. We deliver the times
 	// most recent first (in p[0]).
 	p = p[:cap(p)]
+	q := p[n+n:]
 	for i := uint32(0); i < n; i++ {
 		j := (memstats.numgc - 1 - i) % uint32(len(memstats.pause_ns))
 		p[i] = memstats.pause_ns[j]
 		p[n+i] = memstats.pause_end[j]
-	}
-
-	p[n+n] = memstats.last_gc
-	p[n+n+1] = uint64(memstats.numgc)
-	p[n+n+2] = memstats.pause_total_ns
+		ph := &gcphasehist[j]
+		q[0] = uint64(ph.sweepterm)
+		q[1] = uint64(ph.scan)
+		q[2] = uint64(ph.markstart)
+		q[3] = uint64(ph.mark)
+		q[4] = uint64(ph.assist)
+		q[5] = uint64(ph.markterm)
+		q = q[gcphasecount:]
+	}
+
+	q[0] = memstats.last_gc
+	q[1] = uint64(memstats.numgc)
+	q[2] = memstats.pause_total_ns
 	unlock(&mheap_.lock)
-	*pauses = p[:n+n+3]
+	*pauses = p[:n*(2+gcphasecount)+3]
 }
 
 func setGCPercent(in int32) (out int32) {
@@ -2071,10 +2495,69 @@ func setGCPercent(in int32) (out int32) {
 		in = -1
 	}
 	gcpercent = in
+	pacersettrigger()
+	unlock(&mheap_.lock)
+	return out
+}
+
+func setMemoryLimit(in int64) (out int64) {
+	lock(&mheap_.lock)
+	out = memoryLimit
+	if in >= 0 {
+		memoryLimit = in
+	}
 	unlock(&mheap_.lock)
+	if in >= 0 {
+		setheaplimitgoal()
+	}
 	return out
 }
 
+// mappedmem returns the memory the runtime has obtained from the system
+// and not released back to it, which is what the memory limit bounds.
+// It reads memstats without locking, so the result is approximate.
+func mappedmem() uint64 {
+	return memstats.heap_sys - memstats.heap_released + memstats.stacks_sys +
+		memstats.mspan_sys + memstats.mcache_sys + memstats.buckhash_sys +
+		memstats.gc_sys + memstats.other_sys
+}
+
+// overmemorylimit reports whether the runtime maps more memory than the
+// memory limit allows while holding idle heap memory it could release.
+func overmemorylimit() bool {
+	limit := memoryLimit
+	return limit != maxMemoryLimit && mappedmem() > uint64(limit) &&
+		memstats.heap_idle > memstats.heap_released
+}
+
+// setheaplimitgoal recomputes heaplimitgoal from the memory limit.
+// The goal is the limit less the memory the runtime holds outside the
+// heap and the fragmentation of the heap's in-use spans, assuming the
+// scavenger returns idle spans to the system.  The goal never drops
+// below an eighth above the current heap, so that a limit the live heap
+// does not fit in leads to frequent collections rather than to
+// continuous ones.
+func setheaplimitgoal() {
+	limit := memoryLimit
+	if limit == maxMemoryLimit {
+		heaplimitgoal = ^uint64(0)
+		return
+	}
+	heap := memstats.heap_alloc
+	overhead := mappedmem() - (memstats.heap_sys - memstats.heap_released)
+	if memstats.heap_inuse > heap {
+		overhead += memstats.heap_inuse - heap
+	}
+	goal := uint64(0)
+	if uint64(limit) > overhead {
+		goal = uint64(limit) - overhead
+	}
+	if min := heap + heap/8; goal < min {
+		goal = min
+	}
+	heaplimitgoal = goal
+}
+
 func gchelperstart() {
 	_g_ := getg()
 
@@ -93,7 +93,40 @@ func bgsweep() {
 			continue
 		}
 		sweep.parked = true
-		goparkunlock(&gclock, "GC sweep wait")
+		goparkunlock(&gclock, "GC sweep wait", traceEvGoBlock, 2)
+	}
+}
+
+// startbggc asks the background collector to run a cycle,
+// starting it the first time.
+func startbggc() {
+	lock(&gclock)
+	if bggc.running != 0 {
+		unlock(&gclock)
+		return
+	}
+	atomicstore(&bggc.running, 1)
+	start := !bggc.started
+	bggc.started = true
+	if bggc.parked {
+		bggc.parked = false
+		ready(bggc.g, 0)
+	}
+	unlock(&gclock)
+	if start {
+		go bggchelper()
+	}
+}
+
+func bggchelper() {
+	bggc.g = getg()
+	getg().issystem = true
+	for {
+		gccycle(0)
+		lock(&gclock)
+		atomicstore(&bggc.running, 0)
+		bggc.parked = true
+		goparkunlock(&gclock, "GC (idle)", traceEvGoBlock, 2)
 	}
 }
 
@@ -200,6 +233,35 @@ func writebarrierfat(typ *_type, dst, src unsafe.Pointer) {
 	})
 }
 
+// typedmemmove copies a value of type typ to dst from src,
+// with write barriers for the pointers in it.
+// The runtime uses it where it copies values it does not
+// otherwise know the layout of: channel and map elements.
+//go:nosplit
+func typedmemmove(typ *_type, dst, src unsafe.Pointer) {
+	if typ.kind&kindNoPointers != 0 {
+		memmove(dst, src, typ.size)
+		return
+	}
+	writebarrierfat(typ, dst, src)
+}
+
+func reflect_typedmemmove(typ *_type, dst, src unsafe.Pointer) {
+	typedmemmove(typ, dst, src)
+}
+
+func reflect_typedslicecopy(elemType *_type, dst, src slice) int {
+	if elemType.kind&kindNoPointers != 0 {
+		n := dst.len
+		if n > src.len {
+			n = src.len
+		}
+		memmove(unsafe.Pointer(dst.array), unsafe.Pointer(src.array), uintptr(n)*elemType.size)
+		return int(n)
+	}
+	return writebarriercopy(elemType, dst, src)
+}
+
 //go:nosplit
 func writebarriercopy(typ *_type, dst, src slice) int {
 	n := dst.len
@@ -217,6 +217,9 @@ func mHeap_Alloc_m(h *mheap, npage uintptr, sizeclass int32, large bool) *mspan
 			}
 		}
 	}
+	if trace.enabled {
+		traceHeapAlloc()
+	}
 	unlock(&h.lock)
 	return s
 }
@@ -370,6 +373,12 @@ func mHeap_Grow(h *mheap, npage uintptr) bool {
 		ask = _HeapAllocChunk
 	}
 
+	if memoryLimit != maxMemoryLimit && mappedmem()+uint64(ask) > uint64(memoryLimit) {
+		// Growing the heap would exceed the memory limit.
+		// Return idle spans to the system first, however recently used.
+		mHeap_ScavengeLocked(h, uint64(nanotime()), 0)
+	}
+
 	v := mHeap_SysAlloc(h, ask)
 	if v == nil {
 		if ask > npage<<_PageShift {
@@ -440,6 +449,9 @@ func mHeap_Free(h *mheap, s *mspan, acct int32) {
 			memstats.heap_objects--
 		}
 		mHeap_FreeSpanLocked(h, s, true, true)
+		if trace.enabled {
+			traceHeapAlloc()
+		}
 		unlock(&h.lock)
 	})
 }
@@ -533,6 +545,7 @@ func scavengelist(list *mspan, now, limit uint64) uintptr {
 		if (now-uint64(s.unusedsince)) > limit && s.npreleased != s.npages {
 			released := (s.npages - s.npreleased) << _PageShift
 			memstats.heap_released += uint64(released)
+			memstats.heap_scavenged += uint64(released)
 			sumreleased += released
 			s.npreleased = s.npages
 			sysUnused((unsafe.Pointer)(s.start<<_PageShift), s.npages<<_PageShift)
@@ -541,14 +554,22 @@ func scavengelist(list *mspan, now, limit uint64) uintptr {
 	return sumreleased
 }
 
-func mHeap_Scavenge(k int32, now, limit uint64) {
-	h := &mheap_
-	lock(&h.lock)
+// mHeap_ScavengeLocked releases the free spans unused for longer than
+// limit to the system and returns the number of bytes released.
+// h must be locked.
+func mHeap_ScavengeLocked(h *mheap, now, limit uint64) uintptr {
 	var sumreleased uintptr
 	for i := 0; i < len(h.free); i++ {
 		sumreleased += scavengelist(&h.free[i], now, limit)
 	}
 	sumreleased += scavengelist(&h.freelarge, now, limit)
+	return sumreleased
+}
+
+func mHeap_Scavenge(k int32, now, limit uint64) {
+	h := &mheap_
+	lock(&h.lock)
+	sumreleased := mHeap_ScavengeLocked(h, now, limit)
 	unlock(&h.lock)
 
 	if debug.gctrace > 0 {
@@ -21,6 +21,7 @@ const (
 	// profile types
 	memProfile bucketType = 1 + iota
 	blockProfile
+	mutexProfile
 
 	// size of bucket hash table
 	buckHashSize = 179999
@@ -42,7 +43,7 @@ type bucketType int
 type bucket struct {
 	next    *bucket
 	allnext *bucket
-	typ     bucketType // memBucket or blockBucket
+	typ     bucketType // memBucket, blockBucket or mutexBucket
 	hash    uintptr
 	size    uintptr
 	nstk    uintptr
@@ -81,8 +82,8 @@ type memRecord struct {
 	recent_free_bytes  uintptr
 }
 
-// A blockRecord is the bucket data for a bucket of type blockProfile,
-// part of the blocking profile.
+// A blockRecord is the bucket data for a bucket of type blockProfile
+// or mutexProfile, part of the blocking or mutex contention profile.
 type blockRecord struct {
 	count  int64
 	cycles int64
@@ -91,6 +92,7 @@ type blockRecord struct {
 var (
 	mbuckets  *bucket // memory profile buckets
 	bbuckets  *bucket // blocking profile buckets
+	xbuckets  *bucket // mutex profile buckets
 	buckhash  *[179999]*bucket
 	bucketmem uintptr
 )
@@ -103,7 +105,7 @@ func newBucket(typ bucketType, nstk int) *bucket {
 		gothrow("invalid profile bucket type")
 	case memProfile:
 		size += unsafe.Sizeof(memRecord{})
-	case blockProfile:
+	case blockProfile, mutexProfile:
 		size += unsafe.Sizeof(blockRecord{})
 	}
 
@@ -129,9 +131,10 @@ func (b *bucket) mp() *memRecord {
 	return (*memRecord)(data)
 }
 
-// bp returns the blockRecord associated with the blockProfile bucket b.
+// bp returns the blockRecord associated with the blockProfile or
+// mutexProfile bucket b.
 func (b *bucket) bp() *blockRecord {
-	if b.typ != blockProfile {
+	if b.typ != blockProfile && b.typ != mutexProfile {
 		gothrow("bad use of bucket.bp")
 	}
 	data := add(unsafe.Pointer(b), unsafe.Sizeof(*b)+b.nstk*unsafe.Sizeof(uintptr(0)))
@@ -180,10 +183,14 @@ func stkbucket(typ bucketType, size uintptr, stk []uintptr, alloc bool) *bucket
 	b.size = size
 	b.next = buckhash[i]
 	buckhash[i] = b
-	if typ == memProfile {
+	switch typ {
+	case memProfile:
 		b.allnext = mbuckets
 		mbuckets = b
-	} else {
+	case mutexProfile:
+		b.allnext = xbuckets
+		xbuckets = b
+	default:
 		b.allnext = bbuckets
 		bbuckets = b
 	}
@@ -296,6 +303,13 @@ func blockevent(cycles int64, skip int) {
 	if rate <= 0 || (rate > cycles && int64(fastrand1())%rate > cycles) {
 		return
 	}
+	saveblockevent(cycles, skip+1, blockProfile)
+}
+
+// saveblockevent records an event of the given duration in the
+// blocking or mutex profile, attributed to the stack of the current
+// goroutine with skip frames omitted.
+func saveblockevent(cycles int64, skip int, which bucketType) {
 	gp := getg()
 	var nstk int
 	var stk [maxStack]uintptr
@@ -305,12 +319,42 @@ func blockevent(cycles int64, skip int) {
 		nstk = gcallers(gp.m.curg, skip, &stk[0], len(stk))
 	}
 	lock(&proflock)
-	b := stkbucket(blockProfile, 0, stk[:nstk], true)
+	b := stkbucket(which, 0, stk[:nstk], true)
 	b.bp().count++
 	b.bp().cycles += cycles
 	unlock(&proflock)
 }
 
+var mutexprofilerate uint64 // fraction sampled
+
+// SetMutexProfileFraction controls the fraction of mutex contention events
+// that are reported in the mutex profile.  On average 1/rate events are
+// reported.  The previous rate is returned.
+//
+// To turn off profiling entirely, pass rate 0.
+// To just read the current rate, pass rate -1.
+// (For n>1 the details of sampling may change.)
+func SetMutexProfileFraction(rate int) int {
+	if rate < 0 {
+		return int(atomicload64(&mutexprofilerate))
+	}
+	old := atomicload64(&mutexprofilerate)
+	atomicstore64(&mutexprofilerate, uint64(rate))
+	return int(old)
+}
+
+// mutexevent records that a goroutine releasing a contended mutex
+// kept waiters blocked for the given number of cycles.
+func mutexevent(cycles int64, skip int) {
+	if cycles < 0 {
+		cycles = 0
+	}
+	rate := int64(atomicload64(&mutexprofilerate))
+	if rate > 0 && int64(fastrand1())%rate == 0 {
+		saveblockevent(cycles, skip+1, mutexProfile)
+	}
+}
+
 // Go interface to profile data.
 
 // A StackRecord describes a single execution stack.
@@ -486,6 +530,35 @@ func BlockProfile(p []BlockProfileRecord) (n int, ok bool) {
 	return
 }
 
+// MutexProfile returns n, the number of records in the current mutex profile.
+// If len(p) >= n, MutexProfile copies the profile into p and returns n, true.
+// Otherwise, MutexProfile does not change p, and returns n, false.
+//
+// Most clients should use the runtime/pprof package
+// instead of calling MutexProfile directly.
+func MutexProfile(p []BlockProfileRecord) (n int, ok bool) {
+	lock(&proflock)
+	for b := xbuckets; b != nil; b = b.allnext {
+		n++
+	}
+	if n <= len(p) {
+		ok = true
+		for b := xbuckets; b != nil; b = b.allnext {
+			bp := b.bp()
+			r := &p[0]
+			r.Count = int64(bp.count)
+			r.Cycles = int64(bp.cycles)
+			i := copy(r.Stack0[:], b.stk())
+			for ; i < len(r.Stack0); i++ {
+				r.Stack0[i] = 0
+			}
+			p = p[1:]
+		}
+	}
+	unlock(&proflock)
+	return
+}
+
 // ThreadCreateProfile returns n, the number of records in the thread creation profile.
 // If len(p) >= n, ThreadCreateProfile copies the profile into p and returns n, true.
 // If len(p) < n, ThreadCreateProfile does not change p and returns n, false.
@@ -521,7 +594,7 @@ func GoroutineProfile(p []StackRecord) (n int, ok bool) {
 	n = NumGoroutine()
 	if n <= len(p) {
 		gp := getg()
-		semacquire(&worldsema, false)
+		semacquire(&worldsema, 0)
 		gp.m.gcing = 1
 		systemstack(stoptheworld)
 
@@ -563,11 +636,17 @@ func saveg(pc, sp uintptr, gp *g, r *StackRecord) {
 // and returns the number of bytes written to buf.
 // If all is true, Stack formats stack traces of all other goroutines
 // into buf after the trace for the current goroutine.
+// The header line of the trace of a goroutine running with profiler
+// labels (see runtime/pprof) shows the labels after its status.
 func Stack(buf []byte, all bool) int {
+	if all {
+		// Acquire worldsema before acquirem: semacquire may
+		// park while a background collection holds it.
+		semacquire(&worldsema, 0)
+	}
 	mp := acquirem()
 	gp := mp.curg
 	if all {
-		semacquire(&worldsema, false)
 		mp.gcing = 1
 		releasem(mp)
 		systemstack(stoptheworld)
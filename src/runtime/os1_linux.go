@@ -189,6 +189,10 @@ func minit() {
 	_g_ := getg()
 	signalstack((*byte)(unsafe.Pointer(_g_.m.gsignal.stack.lo)), 32*1024)
 	rtsigprocmask(_SIG_SETMASK, &sigset_none, nil, int32(unsafe.Sizeof(sigset_none)))
+
+	// Threads started by clone record their id there,
+	// but the main thread and threads from cgo do not.
+	_g_.m.procid = uint64(gettid())
 }
 
 // Called from dropm to undo the effect of an minit.
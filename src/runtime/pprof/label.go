@@ -0,0 +1,242 @@
+// Copyright 2015 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pprof
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"unsafe"
+)
+
+// A LabelSet is an immutable set of key-value string pairs, called
+// profiler labels.  CPU profile samples taken while a goroutine runs
+// with a label set are tagged with its labels, so that the profile can
+// be sliced by them, for example with the -tagfocus and -tagignore
+// flags of 'go tool pprof'.
+//
+// Every distinct label set used in a process is retained for the
+// lifetime of the process, so labels should have few distinct values,
+// such as a tenant or an endpoint name rather than a request ID.
+type LabelSet struct {
+	list []label // sorted by key, keys are unique
+}
+
+type label struct {
+	key, value string
+}
+
+type byKey []label
+
+func (x byKey) Len() int           { return len(x) }
+func (x byKey) Swap(i, j int)      { x[i], x[j] = x[j], x[i] }
+func (x byKey) Less(i, j int) bool { return x[i].key < x[j].key }
+
+// Labels takes an even number of strings representing key-value pairs
+// and makes a LabelSet containing them.
+// A label overwrites a prior label with the same key.
+func Labels(args ...string) LabelSet {
+	if len(args)%2 != 0 {
+		panic("pprof: uneven number of arguments to Labels")
+	}
+	var s LabelSet
+	for i := 0; i < len(args); i += 2 {
+		s = s.with(args[i], args[i+1])
+	}
+	return s
+}
+
+// with returns a copy of s with the label key set to value.
+func (s LabelSet) with(key, value string) LabelSet {
+	list := make([]label, 0, len(s.list)+1)
+	for _, l := range s.list {
+		if l.key != key {
+			list = append(list, l)
+		}
+	}
+	list = append(list, label{key, value})
+	sort.Sort(byKey(list))
+	return LabelSet{list}
+}
+
+// Value returns the value of the label with the given key and whether
+// the label is present in s.
+func (s LabelSet) Value(key string) (string, bool) {
+	for _, l := range s.list {
+		if l.key == key {
+			return l.value, true
+		}
+	}
+	return "", false
+}
+
+// String returns the labels of s in the form {key:"value", ...}.
+func (s LabelSet) String() string {
+	buf := []byte{'{'}
+	for i, l := range s.list {
+		if i > 0 {
+			buf = append(buf, ", "...)
+		}
+		buf = append(buf, l.key...)
+		buf = append(buf, ':')
+		buf = strconv.AppendQuote(buf, l.value)
+	}
+	return string(append(buf, '}'))
+}
+
+// key returns an encoding of s that is unique to its labels.
+func (s LabelSet) key() string {
+	var buf []byte
+	for _, l := range s.list {
+		buf = strconv.AppendQuote(buf, l.key)
+		buf = strconv.AppendQuote(buf, l.value)
+	}
+	return string(buf)
+}
+
+// labelSets interns the label sets used in the process.
+// The runtime records the label set of a goroutine as its index in
+// sets plus one, so that the signal handler taking CPU profile samples
+// needs neither to allocate nor to follow pointers.  The empty label
+// set is recorded as 0.
+var labelSets struct {
+	mu    sync.Mutex
+	ids   map[string]uintptr
+	sets  []LabelSet
+	texts []*string // String of sets, shown in goroutine stack dumps
+}
+
+// labelSetID returns the identifier of s, interning it if needed.
+func labelSetID(s LabelSet) uintptr {
+	if len(s.list) == 0 {
+		return 0
+	}
+	k := s.key()
+	labelSets.mu.Lock()
+	defer labelSets.mu.Unlock()
+	if id, ok := labelSets.ids[k]; ok {
+		return id
+	}
+	if labelSets.ids == nil {
+		labelSets.ids = make(map[string]uintptr)
+	}
+	labelSets.sets = append(labelSets.sets, s)
+	text := s.String()
+	labelSets.texts = append(labelSets.texts, &text)
+	id := uintptr(len(labelSets.sets))
+	labelSets.ids[k] = id
+	return id
+}
+
+// labelSetByID returns the label set with identifier id.
+func labelSetByID(id uintptr) LabelSet {
+	if id == 0 {
+		return LabelSet{}
+	}
+	labelSets.mu.Lock()
+	defer labelSets.mu.Unlock()
+	return labelSets.sets[id-1]
+}
+
+// setLabelSet sets the profiler label set of the current goroutine
+// to the one with identifier id.
+func setLabelSet(id uintptr) {
+	var text *string
+	if id != 0 {
+		labelSets.mu.Lock()
+		text = labelSets.texts[id-1]
+		labelSets.mu.Unlock()
+	}
+	runtime_setProfLabel(id, text)
+}
+
+// SetGoroutineLabels sets the profiler labels of the current goroutine
+// to labels.  Goroutines started by the current goroutine afterwards
+// inherit them.  The labels appear in the header of the goroutine's
+// stack trace in stack dumps, such as those printed by runtime.Stack,
+// by the goroutine profile with debug=2, and on SIGQUIT.
+//
+// Most clients should use Do instead, which restores the previous
+// labels when done.
+func SetGoroutineLabels(labels LabelSet) {
+	setLabelSet(labelSetID(labels))
+}
+
+// GoroutineLabels returns the profiler labels of the current goroutine.
+func GoroutineLabels() LabelSet {
+	return labelSetByID(runtime_getProfLabel())
+}
+
+// Do calls f with the labels of the current goroutine augmented
+// with labels, which override existing labels with the same keys.
+// Goroutines started by f inherit the augmented labels.
+// The labels of the current goroutine are restored when f returns.
+func Do(labels LabelSet, f func()) {
+	old := runtime_getProfLabel()
+	defer setLabelSet(old)
+	s := labelSetByID(old)
+	for _, l := range labels.list {
+		s = s.with(l.key, l.value)
+	}
+	setLabelSet(labelSetID(s))
+	f()
+}
+
+// markLabelSets records in used the label sets referenced by the
+// samples in data, a chunk of CPU profile data as returned by
+// runtime.CPUProfile.  The stack of a labeled sample ends with a zero
+// word followed by the label set identifier.
+func markLabelSets(used map[uintptr]bool, data []byte) {
+	n := len(data) / int(unsafe.Sizeof(uintptr(0)))
+	if n == 0 {
+		return
+	}
+	words := (*[1 << 28]uintptr)(unsafe.Pointer(&data[0]))[:n:n]
+	for len(words) >= 2 {
+		depth := int(words[1])
+		if depth > len(words)-2 {
+			break
+		}
+		stk := words[2 : 2+depth]
+		if depth >= 2 && stk[depth-2] == 0 {
+			used[stk[depth-1]] = true
+		}
+		words = words[2+depth:]
+	}
+}
+
+// writeLabelSets writes the label sets in used to w, as the trailer
+// of a CPU profile.  It writes nothing if used is empty, leaving the
+// profiles of programs that do not use labels unchanged.
+//
+// The trailer starts with a "--- labels:" line followed by a line
+//	<id> "<key>" "<value>"
+// for each label, where <id> is the identifier that follows the
+// zero word at the end of the stacks of labeled samples.
+func writeLabelSets(w io.Writer, used map[uintptr]bool) error {
+	if len(used) == 0 {
+		return nil
+	}
+	labelSets.mu.Lock()
+	defer labelSets.mu.Unlock()
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "--- labels:\n")
+	for i, s := range labelSets.sets {
+		id := uintptr(i + 1)
+		if !used[id] {
+			continue
+		}
+		for _, l := range s.list {
+			fmt.Fprintf(b, "%d %q %q\n", id, l.key, l.value)
+		}
+	}
+	return b.Flush()
+}
+
+func runtime_setProfLabel(labels uintptr, text *string)
+func runtime_getProfLabel() uintptr
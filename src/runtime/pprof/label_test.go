@@ -0,0 +1,142 @@
+// Copyright 2015 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !nacl
+
+package pprof_test
+
+import (
+	"bytes"
+	"runtime"
+	. "runtime/pprof"
+	"strings"
+	"testing"
+)
+
+func TestLabels(t *testing.T) {
+	s := Labels("b", "2", "a", "1", "b", "3")
+	if got, want := s.String(), `{a:"1", b:"3"}`; got != want {
+		t.Errorf("Labels: got %v, want %v", got, want)
+	}
+	if v, ok := s.Value("a"); !ok || v != "1" {
+		t.Errorf(`Value("a") = %q, %v; want "1", true`, v, ok)
+	}
+	if v, ok := s.Value("c"); ok {
+		t.Errorf(`Value("c") = %q, %v; want "", false`, v, ok)
+	}
+}
+
+func TestGoroutineLabels(t *testing.T) {
+	if got := GoroutineLabels().String(); got != "{}" {
+		t.Fatalf("initial labels: got %v, want {}", got)
+	}
+	Do(Labels("a", "1", "b", "2"), func() {
+		Do(Labels("b", "3"), func() {
+			if got, want := GoroutineLabels().String(), `{a:"1", b:"3"}`; got != want {
+				t.Errorf("nested Do: got %v, want %v", got, want)
+			}
+			c := make(chan string)
+			go func() {
+				c <- GoroutineLabels().String()
+			}()
+			if got, want := <-c, `{a:"1", b:"3"}`; got != want {
+				t.Errorf("new goroutine: got %v, want %v", got, want)
+			}
+		})
+		if got, want := GoroutineLabels().String(), `{a:"1", b:"2"}`; got != want {
+			t.Errorf("after nested Do: got %v, want %v", got, want)
+		}
+	})
+	if got := GoroutineLabels().String(); got != "{}" {
+		t.Errorf("after Do: got %v, want {}", got)
+	}
+
+	SetGoroutineLabels(Labels("c", "4"))
+	if got, want := GoroutineLabels().String(), `{c:"4"}`; got != want {
+		t.Errorf("SetGoroutineLabels: got %v, want %v", got, want)
+	}
+	SetGoroutineLabels(Labels())
+	if got := GoroutineLabels().String(); got != "{}" {
+		t.Errorf("SetGoroutineLabels(Labels()): got %v, want {}", got)
+	}
+}
+
+func TestGoroutineProfileLabels(t *testing.T) {
+	c := make(chan bool)
+	done := make(chan bool)
+	Do(Labels("handler", "/upload"), func() {
+		go func() {
+			c <- true
+			<-c
+			done <- true
+		}()
+	})
+	<-c
+	defer func() {
+		c <- true
+		<-done
+	}()
+
+	var buf bytes.Buffer
+	if err := Lookup("goroutine").WriteTo(&buf, 2); err != nil {
+		t.Fatal(err)
+	}
+	dump := buf.String()
+	if !strings.Contains(dump, "] {handler:\"/upload\"}:\n") {
+		t.Errorf("goroutine dump does not show labels:\n%s", dump)
+	}
+	if !strings.Contains(dump, "[running]:\n") {
+		t.Errorf("goroutine dump shows labels of unlabeled goroutine:\n%s", dump)
+	}
+}
+
+func TestCPUProfileLabel(t *testing.T) {
+	if runtime.GOOS == "plan9" {
+		t.Skip("skipping: CPU profiling is not implemented on plan9")
+	}
+	var prof bytes.Buffer
+	if err := StartCPUProfile(&prof); err != nil {
+		t.Fatal(err)
+	}
+	Do(Labels("key", "value"), func() {
+		cpuHogger(cpuHog1)
+	})
+	StopCPUProfile()
+
+	data := prof.Bytes()
+	i := bytes.Index(data, []byte("--- labels:\n"))
+	if i < 0 {
+		if badOS[runtime.GOOS] {
+			t.Skipf("ignoring failure on %s; see golang.org/issue/6047", runtime.GOOS)
+		}
+		t.Fatalf("profile has no labels section")
+	}
+	trailer := string(data[i:])
+	var id string
+	for _, l := range strings.Split(trailer, "\n")[1:] {
+		if strings.HasSuffix(l, ` "key" "value"`) {
+			id = strings.Fields(l)[0]
+		}
+	}
+	if id == "" {
+		t.Fatalf("labels section does not contain key=value:\n%s", trailer)
+	}
+
+	var labeled uintptr
+	parseProfile(t, data[:i], func(count uintptr, stk []uintptr) {
+		n := len(stk)
+		if n < 2 || stk[n-2] != 0 {
+			return
+		}
+		for _, pc := range stk[:n-2] {
+			if f := runtime.FuncForPC(pc); f != nil && strings.Contains(f.Name(), "cpuHog1") {
+				labeled += count
+				break
+			}
+		}
+	})
+	if labeled == 0 {
+		t.Errorf("no labeled samples of cpuHog1 in profile")
+	}
+}
@@ -450,3 +450,43 @@ func blockCond() {
 	c.Wait()
 	mu.Unlock()
 }
+
+func TestMutexProfile(t *testing.T) {
+	old := runtime.SetMutexProfileFraction(1)
+	defer runtime.SetMutexProfileFraction(old)
+	if old != 0 {
+		t.Fatalf("need MutexProfileRate 0, got %d", old)
+	}
+
+	blockMutex()
+
+	var w bytes.Buffer
+	Lookup("mutex").WriteTo(&w, 1)
+	prof := w.String()
+
+	if !strings.HasPrefix(prof, "--- mutex:\ncycles/second=") {
+		t.Errorf("Bad profile header:\n%v", prof)
+	}
+	prof = strings.Trim(prof, "\n")
+	lines := strings.Split(prof, "\n")
+	if len(lines) != 6 {
+		t.Errorf("expected 6 lines, got %d %q\n%s", len(lines), prof, prof)
+	}
+	if len(lines) < 6 {
+		return
+	}
+	// checking that the line is like "35258904 1 @ 0x48288d 0x47cd28 0x458931"
+	r2 := `^\d+ 1 @(?: 0x[[:xdigit:]]+)+`
+	if ok, err := regexp.MatchString(r2, lines[3]); err != nil || !ok {
+		t.Errorf("%q didn't match %q", lines[3], r2)
+	}
+	// The contention is charged to the goroutine that unlocked the mutex.
+	r3 := `^#.*sync\.\(\*Mutex\)\.Unlock.*$`
+	if ok, err := regexp.MatchString(r3, lines[4]); err != nil || !ok {
+		t.Errorf("%q didn't match %q", lines[4], r3)
+	}
+	r4 := `^#.*runtime/pprof/pprof_test\.go:[0-9]+$`
+	if ok, err := regexp.MatchString(r4, lines[5]); err != nil || !ok {
+		t.Errorf("%q didn't match %q", lines[5], r4)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Asynchronous preemption.
+//
+// A goroutine normally stops only at the stack check in a function
+// prologue, once preemptone has set its stackguard0 to stackPreempt,
+// so a loop without calls can delay a garbage collection or keep
+// other goroutines off its P indefinitely.  Where preemptMSupported,
+// preemptone also sends the thread running the goroutine a signal.
+// If the signal finds the goroutine at an asynchronous safe point,
+// the handler makes it look as though the goroutine had called
+// asyncPreempt, which saves all the registers and calls asyncPreempt2
+// to stop like a goroutine preempted at a stack check.
+//
+// The compiler marks the instructions where that is safe in the
+// PCDATA_RegMap table, whose value at each one is a bit mask of the
+// general registers that may hold pointers, indexed by their hardware
+// encoding, and gives each of them a stack map of its own.  The garbage
+// collector scans the registers saved by asyncPreempt using the register
+// map, and the interrupted frame using the stack maps for the
+// interrupted instruction.  The table is -1 at unsafe points:
+//
+//	- all of the runtime package and all nosplit functions,
+//	  which rely on not being preempted except at calls,
+//	- the prologue and epilogue of every function, and calls and
+//	  returns themselves,
+//	- the instructions from the first write of a call's arguments
+//	  to the call and from the call to the last read of its results,
+//	  whose values no stack map describes,
+//	- assembly functions, which have no such table at all.
+//
+// The registers saved by asyncPreempt are not adjusted when a stack
+// is copied, so shrinkstack leaves the stack of a goroutine stopped
+// this way alone.
+
+package runtime
+
+// wantAsyncPreempt reports whether an asynchronous preemption of gp
+// is both requested and allowed, much as newstack decides whether
+// to honor a preemption request.
+func wantAsyncPreempt(gp *g) bool {
+	if !gp.preempt && !gp.preemptscan {
+		return false
+	}
+	mp := gp.m
+	if mp == nil || mp.curg != gp || mp.p == nil {
+		return false
+	}
+	if mp.locks != 0 || mp.mallocing != 0 || mp.gcing != 0 || mp.p.status != _Prunning {
+		return false
+	}
+	return readgstatus(gp) == _Grunning
+}
+
+// isAsyncSafePoint reports whether gp, stopped by a signal with
+// the given pc and sp, can be preempted there.
+func isAsyncSafePoint(gp *g, pc, sp uintptr) bool {
+	if debug.asyncpreemptoff != 0 {
+		return false
+	}
+
+	// The signal must have interrupted gp on its own stack,
+	// not on the g0 or signal stack of its m, and there must be
+	// room for asyncPreempt.  It and what it calls are nosplit,
+	// so the linker checks that they fit in _StackLimit bytes.
+	if sp < gp.stack.lo || sp >= gp.stack.hi || sp-gp.stack.lo < _StackLimit {
+		return false
+	}
+
+	f := findfunc(pc)
+	if f == nil {
+		// Not Go code.
+		return false
+	}
+	return pcdatavalue1(f, _PCDATA_RegMap, pc, false) >= 0
+}
+
+// asyncPreempt2 stops the goroutine that asyncPreempt was injected
+// into until it is rescheduled.
+//go:nosplit
+func asyncPreempt2() {
+	gp := getg()
+	gp.asyncsafe = true
+	mcall(asyncPreempt_m)
+	gp.asyncsafe = false
+}
+
+// asyncPreempt_m is asyncPreempt2 continued on g0.
+// It does what newstack does for a preemption request.
+func asyncPreempt_m(gp *g) {
+	if gp.preemptscan {
+		casgstatus(gp, _Grunning, _Gwaiting)
+		for !castogscanstatus(gp, _Gwaiting, _Gscanwaiting) {
+			// Likely to be racing with the GC as it sees a _Gwaiting and does the stack scan.
+			// If so this stack will be scanned twice which does not change correctness.
+		}
+		gcphasework(gp)
+		casfrom_Gscanstatus(gp, _Gscanwaiting, _Gwaiting)
+		casgstatus(gp, _Gwaiting, _Grunning)
+		gp.stackguard0 = gp.stack.lo + _StackGuard
+		gp.preempt = false
+		gp.preemptscan = false // Tells the GC premption was successful.
+		gogo(&gp.sched)        // never return
+	}
+	if !gp.preempt {
+		// The request was withdrawn or already honored.
+		gogo(&gp.sched) // never return
+	}
+
+	// Act like goroutine called runtime.Gosched.
+	gopreempt_m(gp) // never return
+}
+
+// asyncPreemptRegs is the number of general registers asyncPreempt
+// saves, in the order of their hardware encoding, at the bottom
+// of its frame.
+const asyncPreemptRegs = 16
+
+// scanasyncregs scans the registers saved in frame, the frame of
+// asyncPreempt, that the register map of the interrupted instruction
+// says may hold pointers.
+func scanasyncregs(frame *stkframe) {
+	pc := frame.lr
+	f := findfunc(pc)
+	if f == nil {
+		gothrow("scanasyncregs: unknown pc")
+	}
+	regs := pcdatavalue(f, _PCDATA_RegMap, pc)
+	if regs < 0 {
+		print("runtime: asynchronous preemption at unsafe point ", hex(pc), " in ", gofuncname(f), "\n")
+		gothrow("scanasyncregs: bad register map")
+	}
+	var ptrmask [asyncPreemptRegs * bitsPerPointer / 8]uint8
+	for i := uintptr(0); i < asyncPreemptRegs; i++ {
+		if regs&(1<<i) != 0 {
+			ptrmask[i/4] |= _BitsPointer << (i % 4 * bitsPerPointer)
+		}
+	}
+	scanblock(frame.sp, asyncPreemptRegs*ptrSize, &ptrmask[0])
+}
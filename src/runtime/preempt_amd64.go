@@ -0,0 +1,9 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+// asyncPreempt saves all the registers and calls asyncPreempt2.
+// Signal handlers inject calls to it at asynchronous safe points.
+func asyncPreempt()
@@ -0,0 +1,84 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+#include "textflag.h"
+
+// asyncPreempt is called, as though by the interrupted code,
+// when a signal handler injects a call at an asynchronous safe point.
+// It saves all the registers and the flags in its frame, calls
+// asyncPreempt2 to stop the goroutine, and restores everything when
+// it runs again.  The general registers are saved at the bottom of
+// the frame in the order of their hardware encoding, with a hole for
+// SP, so that the garbage collector can scan them using the register
+// map of the interrupted instruction (see scanasyncregs).
+TEXT runtime·asyncPreempt(SB),NOSPLIT,$0-0
+	// Save the flags before the stack adjustment clobbers them.
+	PUSHFQ
+	ADJSP	$384
+	MOVQ	AX, 0(SP)
+	MOVQ	CX, 8(SP)
+	MOVQ	DX, 16(SP)
+	MOVQ	BX, 24(SP)
+	MOVQ	BP, 40(SP)
+	MOVQ	SI, 48(SP)
+	MOVQ	DI, 56(SP)
+	MOVQ	R8, 64(SP)
+	MOVQ	R9, 72(SP)
+	MOVQ	R10, 80(SP)
+	MOVQ	R11, 88(SP)
+	MOVQ	R12, 96(SP)
+	MOVQ	R13, 104(SP)
+	MOVQ	R14, 112(SP)
+	MOVQ	R15, 120(SP)
+	MOVOU	X0, 128(SP)
+	MOVOU	X1, 144(SP)
+	MOVOU	X2, 160(SP)
+	MOVOU	X3, 176(SP)
+	MOVOU	X4, 192(SP)
+	MOVOU	X5, 208(SP)
+	MOVOU	X6, 224(SP)
+	MOVOU	X7, 240(SP)
+	MOVOU	X8, 256(SP)
+	MOVOU	X9, 272(SP)
+	MOVOU	X10, 288(SP)
+	MOVOU	X11, 304(SP)
+	MOVOU	X12, 320(SP)
+	MOVOU	X13, 336(SP)
+	MOVOU	X14, 352(SP)
+	MOVOU	X15, 368(SP)
+	CALL	runtime·asyncPreempt2(SB)
+	MOVOU	368(SP), X15
+	MOVOU	352(SP), X14
+	MOVOU	336(SP), X13
+	MOVOU	320(SP), X12
+	MOVOU	304(SP), X11
+	MOVOU	288(SP), X10
+	MOVOU	272(SP), X9
+	MOVOU	256(SP), X8
+	MOVOU	240(SP), X7
+	MOVOU	224(SP), X6
+	MOVOU	208(SP), X5
+	MOVOU	192(SP), X4
+	MOVOU	176(SP), X3
+	MOVOU	160(SP), X2
+	MOVOU	144(SP), X1
+	MOVOU	128(SP), X0
+	MOVQ	120(SP), R15
+	MOVQ	112(SP), R14
+	MOVQ	104(SP), R13
+	MOVQ	96(SP), R12
+	MOVQ	88(SP), R11
+	MOVQ	80(SP), R10
+	MOVQ	72(SP), R9
+	MOVQ	64(SP), R8
+	MOVQ	56(SP), DI
+	MOVQ	48(SP), SI
+	MOVQ	40(SP), BP
+	MOVQ	24(SP), BX
+	MOVQ	16(SP), DX
+	MOVQ	8(SP), CX
+	MOVQ	0(SP), AX
+	ADJSP	$-384
+	POPFQ
+	RET
@@ -0,0 +1,28 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+// preemptMSupported reports whether preemptM is implemented.
+const preemptMSupported = true
+
+// sigPreempt is the signal used for asynchronous preemption.
+// SIGURG is rarely used by programs, and when it is they must
+// already cope with spurious signals, since any process can send it.
+const sigPreempt = _SIGURG
+
+func getpid() uint32
+func tgkill(tgid, tid, sig uint32)
+
+// preemptM asks the thread of mp to preempt the goroutine it is
+// running, by sending it sigPreempt.  A signal already pending
+// for the thread is enough, so preemptM does not send another.
+func preemptM(mp *m) {
+	if mp.procid == 0 {
+		return
+	}
+	if cas(&mp.signalpending, 0, 1) {
+		tgkill(getpid(), uint32(mp.procid), sigPreempt)
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !amd64
+
+package runtime
+
+// asyncPreempt is only implemented in assembly on amd64.
+// Elsewhere it exists so that tracebacks can recognize it,
+// but no signal handler injects calls to it.
+func asyncPreempt() {
+	gothrow("asyncPreempt")
+}
@@ -0,0 +1,17 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !linux !amd64
+
+package runtime
+
+// Asynchronous preemption is only implemented on linux/amd64.
+const (
+	preemptMSupported = false
+	sigPreempt        = 0
+)
+
+func preemptM(mp *m) {
+	gothrow("preemptM not implemented")
+}
@@ -192,7 +192,11 @@ func mcommoninit(mp *m) {
 }
 
 // Mark gp ready to run.
-func ready(gp *g) {
+func ready(gp *g, traceskip int) {
+	if trace.enabled {
+		traceGoUnpark(gp, traceskip)
+	}
+
 	status := readgstatus(gp)
 
 	// Mark runnable.
@@ -388,6 +392,21 @@ func casgstatus(gp *g, oldval, newval uint32) {
 		// 	})
 		// }
 	}
+
+	// Sample the time the goroutine waits to run for the scheduling
+	// latency metric.  A stack copy leaves a runnable goroutine runnable.
+	switch {
+	case newval == _Grunnable && oldval != _Gcopystack:
+		gp.trackingseq++
+		if gp.trackingseq%schedLatencySampleRate == 0 {
+			gp.runnabletime = nanotime()
+		}
+	case oldval == _Grunnable && newval == _Grunning:
+		if gp.runnabletime != 0 {
+			schedlatencies.record(nanotime() - gp.runnabletime)
+			gp.runnabletime = 0
+		}
+	}
 }
 
 // casgstatus(gp, oldstatus, Gcopystack), assuming oldstatus is Gwaiting or Grunnable.
@@ -496,7 +515,7 @@ func restartg(gp *g) {
 			gothrow("processing Gscanenqueue on wrong m")
 		}
 		dropg()
-		ready(gp)
+		ready(gp, 0)
 	}
 }
 
@@ -601,6 +620,11 @@ func stoptheworld() {
 		p := allp[i]
 		s := p.status
 		if s == _Psyscall && cas(&p.status, s, _Pgcstop) {
+			if trace.enabled {
+				traceGoSysBlock(p)
+				traceProcStop(p)
+			}
+			p.syscalltick++
 			sched.stopwait--
 		}
 	}
@@ -1232,6 +1256,10 @@ func execute(gp *g) {
 	_g_.m.curg = gp
 	gp.m = _g_.m
 
+	if trace.enabled {
+		traceGoStart()
+	}
+
 	// Check whether the profiler needs to be turned on or off.
 	hz := sched.profilehz
 	if _g_.m.profilehz != hz {
@@ -1253,7 +1281,7 @@ top:
 	}
 	if fingwait && fingwake {
 		if gp := wakefing(); gp != nil {
-			ready(gp)
+			ready(gp, 0)
 		}
 	}
 
@@ -1359,6 +1387,9 @@ stop:
 				acquirep(_p_)
 				injectglist(gp.schedlink)
 				casgstatus(gp, _Gwaiting, _Grunnable)
+				if trace.enabled {
+					traceGoUnpark(gp, 0)
+				}
 				return gp
 			}
 			injectglist(gp)
@@ -1395,6 +1426,11 @@ func injectglist(glist *g) {
 	if glist == nil {
 		return
 	}
+	if trace.enabled {
+		for gp := glist; gp != nil; gp = gp.schedlink {
+			traceGoUnpark(gp, 0)
+		}
+	}
 	lock(&sched.lock)
 	var n int
 	for n = 0; glist != nil; n++ {
@@ -1430,13 +1466,21 @@ top:
 	}
 
 	var gp *g
+	if trace.enabled || trace.shutdown {
+		gp = traceReader()
+		if gp != nil {
+			casgstatus(gp, _Gwaiting, _Grunnable)
+			traceGoUnpark(gp, 0)
+			resetspinning()
+		}
+	}
 	// Check the global runnable queue once in a while to ensure fairness.
 	// Otherwise two goroutines can completely occupy the local runqueue
 	// by constantly respawning each other.
 	tick := _g_.m.p.schedtick
 	// This is a fancy way to say tick%61==0,
 	// it uses 2 MUL instructions instead of a single DIV and so is faster on modern processors.
-	if uint64(tick)-((uint64(tick)*0x4325c53f)>>36)*61 == 0 && sched.runqsize > 0 {
+	if gp == nil && uint64(tick)-((uint64(tick)*0x4325c53f)>>36)*61 == 0 && sched.runqsize > 0 {
 		lock(&sched.lock)
 		gp = globrunqget(_g_.m.p, 1)
 		unlock(&sched.lock)
@@ -1507,6 +1551,10 @@ func parkunlock(lock *mutex, reason string) {
 func park_m(gp *g) {
 	_g_ := getg()
 
+	if trace.enabled {
+		traceGoPark(_g_.m.waittraceev, _g_.m.waittraceskip, gp)
+	}
+
 	casgstatus(gp, _Grunning, _Gwaiting)
 	dropg()
 
@@ -1516,6 +1564,9 @@ func park_m(gp *g) {
 		_g_.m.waitunlockf = nil
 		_g_.m.waitlock = nil
 		if !ok {
+			if trace.enabled {
+				traceGoUnpark(gp, 2)
+			}
 			casgstatus(gp, _Gwaiting, _Grunnable)
 			execute(gp) // Schedule it back, never returns.
 		}
@@ -1523,8 +1574,7 @@ func park_m(gp *g) {
 	schedule()
 }
 
-// Gosched continuation on g0.
-func gosched_m(gp *g) {
+func goschedImpl(gp *g) {
 	status := readgstatus(gp)
 	if status&^_Gscan != _Grunning {
 		dumpgstatus(gp)
@@ -1539,6 +1589,22 @@ func gosched_m(gp *g) {
 	schedule()
 }
 
+// Gosched continuation on g0.
+func gosched_m(gp *g) {
+	if trace.enabled {
+		traceGoSched()
+	}
+	goschedImpl(gp)
+}
+
+// gopreempt_m is like gosched_m, but records the switch as a preemption.
+func gopreempt_m(gp *g) {
+	if trace.enabled {
+		traceGoPreempt()
+	}
+	goschedImpl(gp)
+}
+
 // Finishes execution of the current goroutine.
 // Must be NOSPLIT because it is called from Go. (TODO - probably not anymore)
 //go:nosplit
@@ -1553,6 +1619,10 @@ func goexit1() {
 func goexit0(gp *g) {
 	_g_ := getg()
 
+	if trace.enabled {
+		traceGoEnd()
+	}
+
 	casgstatus(gp, _Grunning, _Gdead)
 	gp.m = nil
 	gp.lockedm = nil
@@ -1563,6 +1633,8 @@ func goexit0(gp *g) {
 	gp.writebuf = nil
 	gp.waitreason = ""
 	gp.param = nil
+	gp.labels = 0
+	gp.labeltext = nil
 
 	dropg()
 
@@ -1637,11 +1709,20 @@ func reentersyscall(pc, sp uintptr) {
 		})
 	}
 
+	if trace.enabled {
+		systemstack(traceGoSysCall)
+		// systemstack itself clobbers g.sched.{pc,sp} and we might
+		// need them later when the G is genuinely blocked in a
+		// syscall.
+		save(pc, sp)
+	}
+
 	if atomicload(&sched.sysmonwait) != 0 { // TODO: fast atomic
 		systemstack(entersyscall_sysmon)
 		save(pc, sp)
 	}
 
+	_g_.m.syscalltick = _g_.m.p.syscalltick
 	_g_.m.mcache = nil
 	_g_.m.p.m = nil
 	atomicstore(&_g_.m.p.status, _Psyscall)
@@ -1675,8 +1756,15 @@ func entersyscall_sysmon() {
 func entersyscall_gcwait() {
 	_g_ := getg()
 
+	_p_ := _g_.m.p
+
 	lock(&sched.lock)
-	if sched.stopwait > 0 && cas(&_g_.m.p.status, _Psyscall, _Pgcstop) {
+	if sched.stopwait > 0 && cas(&_p_.status, _Psyscall, _Pgcstop) {
+		if trace.enabled {
+			traceGoSysBlock(_p_)
+			traceProcStop(_p_)
+		}
+		_p_.syscalltick++
 		if sched.stopwait--; sched.stopwait == 0 {
 			notewakeup(&sched.stopnote)
 		}
@@ -1692,6 +1780,8 @@ func entersyscallblock(dummy int32) {
 	_g_.m.locks++ // see comment in entersyscall
 	_g_.throwsplit = true
 	_g_.stackguard0 = stackPreempt // see comment in entersyscall
+	_g_.m.syscalltick = _g_.m.p.syscalltick
+	_g_.m.p.syscalltick++
 
 	// Leave SP around for GC and traceback.
 	pc := getcallerpc(unsafe.Pointer(&dummy))
@@ -1725,6 +1815,10 @@ func entersyscallblock(dummy int32) {
 }
 
 func entersyscallblock_handoff() {
+	if trace.enabled {
+		traceGoSysCall()
+		traceGoSysBlock(getg().m.p)
+	}
 	handoffp(releasep())
 }
 
@@ -1742,10 +1836,16 @@ func exitsyscall(dummy int32) {
 	}
 
 	_g_.waitsince = 0
+	oldp := _g_.m.p
 	if exitsyscallfast() {
 		if _g_.m.mcache == nil {
 			gothrow("lost mcache")
 		}
+		if trace.enabled {
+			if oldp != _g_.m.p || _g_.m.syscalltick != _g_.m.p.syscalltick {
+				systemstack(traceGoStart)
+			}
+		}
 		// There's a cpu for us, so we can run.
 		_g_.m.p.syscalltick++
 		// We need to cas the status and scan before resuming...
@@ -1768,6 +1868,15 @@ func exitsyscall(dummy int32) {
 
 	_g_.m.locks--
 
+	if trace.enabled {
+		// Wait till traceGoSysBlock event is emitted.
+		// This ensures consistency of the trace (the goroutine is started after it is blocked).
+		for oldp != nil && oldp.syscalltick == _g_.m.syscalltick {
+			osyield()
+		}
+		systemstack(traceGoSysExit)
+	}
+
 	// Call the scheduler.
 	mcall(exitsyscall0)
 
@@ -1802,16 +1911,41 @@ func exitsyscallfast() bool {
 		// There's a cpu for us, so we can run.
 		_g_.m.mcache = _g_.m.p.mcache
 		_g_.m.p.m = _g_.m
+		if _g_.m.syscalltick != _g_.m.p.syscalltick {
+			if trace.enabled {
+				// The p was retaken and then enter into syscall again (since _g_.m.syscalltick has changed).
+				// traceGoSysBlock for this syscall was already emitted,
+				// but here we effectively retake the p from the new syscall running on the same p.
+				systemstack(func() {
+					// Denote blocking of the new syscall.
+					traceGoSysBlock(_g_.m.p)
+					// Denote completion of the current syscall.
+					traceGoSysExit()
+				})
+			}
+			_g_.m.p.syscalltick++
+		}
 		return true
 	}
 
 	// Try to get any other idle P.
+	oldp := _g_.m.p
 	_g_.m.mcache = nil
 	_g_.m.p = nil
 	if sched.pidle != nil {
 		var ok bool
 		systemstack(func() {
 			ok = exitsyscallfast_pidle()
+			if ok && trace.enabled {
+				if oldp != nil {
+					// Wait till traceGoSysBlock event is emitted.
+					// This ensures consistency of the trace (the goroutine is started after it is blocked).
+					for oldp.syscalltick == _g_.m.syscalltick {
+						osyield()
+					}
+				}
+				traceGoSysExit()
+			}
 		})
 		if ok {
 			return true
@@ -1993,6 +2127,11 @@ func newproc1(fn *funcval, argp *uint8, narg int32, nret int32, callerpc uintptr
 	newg.sched.g = newg
 	gostartcallfn(&newg.sched, fn)
 	newg.gopc = callerpc
+	newg.startpc = fn.fn
+	if _g_.m.curg != nil {
+		newg.labels = _g_.m.curg.labels
+		newg.labeltext = _g_.m.curg.labeltext
+	}
 	casgstatus(newg, _Gdead, _Grunnable)
 
 	if _p_.goidcache == _p_.goidcacheend {
@@ -2008,6 +2147,9 @@ func newproc1(fn *funcval, argp *uint8, narg int32, nret int32, callerpc uintptr
 	if raceenabled {
 		newg.racectx = racegostart(callerpc)
 	}
+	if trace.enabled {
+		traceGoCreate(newg, newg.startpc)
+	}
 	runqput(_p_, newg)
 
 	if atomicload(&sched.npidle) != 0 && atomicload(&sched.nmspinning) == 0 && unsafe.Pointer(fn.fn) != unsafe.Pointer(funcPC(main)) { // TODO: fast atomic
@@ -2339,7 +2481,11 @@ func sigprof(pc *uint8, sp *uint8, lr *uint8, gp *g, mp *m) {
 			osyield()
 		}
 		if prof.hz != 0 {
-			cpuproftick(&stk[0], n)
+			var labels uintptr
+			if mp.curg != nil {
+				labels = mp.curg.labels
+			}
+			cpuproftick(&stk[0], n, labels)
 		}
 		atomicstore(&prof.lock, 0)
 	}
@@ -2388,6 +2534,9 @@ func procresize(new int32) {
 	if old < 0 || old > _MaxGomaxprocs || new <= 0 || new > _MaxGomaxprocs {
 		gothrow("procresize: invalid arg")
 	}
+	if trace.enabled {
+		traceGomaxprocs(new)
+	}
 
 	// initialize new P's
 	for i := int32(0); i < new; i++ {
@@ -2450,9 +2599,19 @@ func procresize(new int32) {
 		runqput(allp[i%new], gp)
 	}
 
+	_g_ := getg()
+	if _g_.m.p != nil && trace.enabled {
+		// The current P is about to be released; allp[0] is acquired below.
+		traceGoSched()
+		traceProcStop(_g_.m.p)
+	}
+
 	// free unused P's
 	for i := new; i < old; i++ {
 		p := allp[i]
+		if trace.enabled {
+			traceProcFree(p)
+		}
 		freemcache(p.mcache)
 		p.mcache = nil
 		gfpurge(p)
@@ -2460,7 +2619,6 @@ func procresize(new int32) {
 		// can't free P itself because it can be referenced by an M in syscall
 	}
 
-	_g_ := getg()
 	if _g_.m.p != nil {
 		_g_.m.p.m = nil
 	}
@@ -2470,6 +2628,9 @@ func procresize(new int32) {
 	p.m = nil
 	p.status = _Pidle
 	acquirep(p)
+	if trace.enabled {
+		traceGoStart()
+	}
 	for i := new - 1; i > 0; i-- {
 		p := allp[i]
 		p.status = _Pidle
@@ -2498,6 +2659,10 @@ func acquirep(_p_ *p) {
 	_g_.m.p = _p_
 	_p_.m = _g_.m
 	_p_.status = _Prunning
+
+	if trace.enabled {
+		traceProcStart()
+	}
 }
 
 // Disassociate p and the current m.
@@ -2512,6 +2677,9 @@ func releasep() *p {
 		print("releasep: m=", _g_.m, " m->p=", _g_.m.p, " p->m=", _p_.m, " m->mcache=", _g_.m.mcache, " p->mcache=", _p_.mcache, " p->status=", _p_.status, "\n")
 		gothrow("releasep: invalid p state")
 	}
+	if trace.enabled {
+		traceProcStop(_g_.m.p)
+	}
 	_g_.m.p = nil
 	_g_.m.mcache = nil
 	_p_.m = nil
@@ -2682,11 +2850,15 @@ func sysmon() {
 			injectglist(forcegc.g)
 			unlock(&forcegc.lock)
 		}
-		// scavenge heap once in a while
+		// scavenge heap once in a while,
+		// and right away when over the memory limit
 		if lastscavenge+scavengelimit/2 < now {
 			mHeap_Scavenge(int32(nscavenge), uint64(now), uint64(scavengelimit))
 			lastscavenge = now
 			nscavenge++
+		} else if overmemorylimit() {
+			mHeap_Scavenge(int32(nscavenge), uint64(now), 0)
+			nscavenge++
 		}
 		if debug.schedtrace > 0 && lasttrace+int64(debug.schedtrace*1000000) <= now {
 			lasttrace = now
@@ -2731,7 +2903,12 @@ func retake(now int64) uint32 {
 			// increment nmidle and report deadlock.
 			incidlelocked(-1)
 			if cas(&_p_.status, s, _Pidle) {
+				if trace.enabled {
+					traceGoSysBlock(_p_)
+					traceProcStop(_p_)
+				}
 				n++
+				_p_.syscalltick++
 				handoffp(_p_)
 			}
 			incidlelocked(1)
@@ -2798,6 +2975,12 @@ func preemptone(_p_ *p) bool {
 	// Setting gp->stackguard0 to StackPreempt folds
 	// preemption into the normal stack overflow check.
 	gp.stackguard0 = stackPreempt
+
+	// A goroutine that makes no calls never checks stackguard0,
+	// so also ask the thread to stop it asynchronously.
+	if preemptMSupported && debug.asyncpreemptoff == 0 {
+		preemptM(mp)
+	}
 	return true
 }
 
@@ -248,6 +248,85 @@ func TestPreemptionGC(t *testing.T) {
 	atomic.StoreUint32(&stop, 1)
 }
 
+func TestAsyncPreempt(t *testing.T) {
+	// Test that goroutines are preempted in loops without calls.
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" {
+		t.Skip("no asynchronous preemption on " + runtime.GOOS + "/" + runtime.GOARCH)
+	}
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+	var stop uint32
+	done := make(chan bool)
+	go func() {
+		for atomic.LoadUint32(&stop) == 0 {
+			// The loop calls only assembly, which
+			// has no stack check to be preempted at.
+		}
+		done <- true
+	}()
+	// Gosched runs the goroutine, which must be preempted
+	// for Gosched to return and for GC to stop the world.
+	runtime.Gosched()
+	runtime.GC()
+	atomic.StoreUint32(&stop, 1)
+	<-done
+}
+
+type asyncNode struct {
+	next *asyncNode
+	val  int
+}
+
+var asyncSink []*asyncNode
+
+func newAsyncList() *asyncNode {
+	var n *asyncNode
+	for i := 0; i < 10; i++ {
+		n = &asyncNode{n, i}
+	}
+	return n
+}
+
+// asyncWalk sums the values in a list until told to stop.
+// Only a register holds the list while it loops, so only the
+// register map of the instruction it is preempted at keeps
+// the list alive.
+func asyncWalk(stop *uint32) (sum int) {
+	n := newAsyncList()
+	for *stop == 0 {
+		for m := n; m != nil; m = m.next {
+			sum += m.val
+		}
+	}
+	return
+}
+
+func TestAsyncPreemptGC(t *testing.T) {
+	// Test that the garbage collector scans the registers
+	// of a goroutine preempted in a loop without calls.
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" {
+		t.Skip("no asynchronous preemption on " + runtime.GOOS + "/" + runtime.GOARCH)
+	}
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+	var stop uint32
+	done := make(chan int)
+	go func() {
+		done <- asyncWalk(&stop)
+	}()
+	for i := 0; i < 5; i++ {
+		runtime.Gosched()
+		runtime.GC()
+		// Reuse the list's memory if it was freed.
+		for j := 0; j < 1000; j++ {
+			asyncSink = append(asyncSink, &asyncNode{nil, 1})
+		}
+	}
+	asyncSink = nil
+	atomic.StoreUint32(&stop, 1)
+	if sum := <-done; sum%45 != 0 {
+		t.Fatalf("sum of list = %d, not a multiple of 45; list freed while in use", sum)
+	}
+}
+
 func TestGCFairness(t *testing.T) {
 	output := executeTest(t, testGCFairnessSource, nil)
 	want := "OK\n"
@@ -306,8 +306,10 @@ type dbgVar struct {
 
 var dbgvars = []dbgVar{
 	{"allocfreetrace", &debug.allocfreetrace},
+	{"asyncpreemptoff", &debug.asyncpreemptoff},
 	{"invalidptr", &invalidptr},
 	{"efence", &debug.efence},
+	{"gccheckmark", &debug.gccheckmark},
 	{"gctrace", &debug.gctrace},
 	{"gcdead", &debug.gcdead},
 	{"scheddetail", &debug.scheddetail},
@@ -418,3 +420,50 @@ func readgogc() int32 {
 	}
 	return int32(goatoi(p))
 }
+
+func readgomemlimit() int64 {
+	p := gogetenv("GOMEMLIMIT")
+	if p == "" || p == "off" {
+		return maxMemoryLimit
+	}
+	n, ok := parseByteCount(p)
+	if !ok {
+		print("GOMEMLIMIT=", p, "\n")
+		gothrow("malformed GOMEMLIMIT")
+	}
+	return n
+}
+
+// parseByteCount parses a non-negative number of bytes with an
+// optional unit suffix, B, KiB, MiB, GiB or TiB.
+func parseByteCount(s string) (int64, bool) {
+	shift := uint(0)
+	for i, u := range [...]string{"KiB", "MiB", "GiB", "TiB"} {
+		if len(s) > len(u) && s[len(s)-len(u):] == u {
+			shift = 10 * uint(i+1)
+			s = s[:len(s)-len(u)]
+			break
+		}
+	}
+	if shift == 0 && len(s) > 1 && s[len(s)-1] == 'B' {
+		s = s[:len(s)-1]
+	}
+	if s == "" {
+		return 0, false
+	}
+	var n int64
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		if n > (maxMemoryLimit-int64(c-'0'))/10 {
+			return 0, false
+		}
+		n = n*10 + int64(c-'0')
+	}
+	if n > maxMemoryLimit>>shift {
+		return 0, false
+	}
+	return n << shift, true
+}
@@ -112,6 +112,7 @@ type sudog struct {
 	prev        *sudog
 	elem        unsafe.Pointer // data element
 	releasetime int64
+	acquiretime int64
 	nrelease    int32  // -1 for acquire
 	waitlink    *sudog // g.waiting list
 }
@@ -180,6 +181,7 @@ type g struct {
 	preemptscan  bool // preempted g does scan for gc
 	gcworkdone   bool // debug: cleared at begining of gc work phase cycle, set by gcphasework, tested at end of cycle
 	throwsplit   bool // must not split stack
+	asyncsafe    bool // g is stopped at an asynchronous safe point
 	raceignore   int8 // ignore race detection events
 	m            *m   // for debuggers, but offset not hard-coded
 	lockedm      *m
@@ -189,8 +191,15 @@ type g struct {
 	sigcode1     uintptr
 	sigpc        uintptr
 	gopc         uintptr // pc of go statement that created this goroutine
+	startpc      uintptr // pc of goroutine function
+	labels       uintptr // profiler label set, see runtime/pprof; inherited by new goroutines
+	labeltext    *string // printable form of labels for goroutine headers, nil if none
 	racectx      uintptr
-	waiting      *sudog // sudog structures this g is waiting on (that have a valid elem ptr)
+	waiting      *sudog  // sudog structures this g is waiting on (that have a valid elem ptr)
+	runnabletime int64   // time g became runnable, if sampled for the scheduling latency metric; else 0
+	trackingseq  uint8   // number of times g became runnable, to sample the scheduling latency
+	gcalloc      uintptr // bytes allocated during the concurrent mark, see gcassistalloc
+	gcscanwork   int64   // scan work done in assists during the concurrent mark
 	end          [0]byte
 }
 
@@ -225,9 +234,10 @@ type m struct {
 	dying         int32
 	profilehz     int32
 	helpgc        int32
-	spinning      bool // m is out of work and is actively looking for work
-	blocked       bool // m is blocked on a note
-	inwb          bool // m is executing a write barrier
+	spinning      bool   // m is out of work and is actively looking for work
+	blocked       bool   // m is blocked on a note
+	inwb          bool   // m is executing a write barrier
+	signalpending uint32 // a preemption signal is pending for m
 	printlock     int8
 	fastrand      uint32
 	ncgocall      uint64 // number of cgo calls in total
@@ -253,6 +263,9 @@ type m struct {
 	traceback     uint8
 	waitunlockf   unsafe.Pointer // todo go func(*g, unsafe.pointer) bool
 	waitlock      unsafe.Pointer
+	waittraceev   byte
+	waittraceskip int
+	syscalltick   uint32
 	//#ifdef GOOS_windows
 	thread uintptr // thread handle
 	// these are here because they are too large to be on the stack
@@ -288,6 +301,7 @@ type p struct {
 	m           *m     // back-link to associated m (nil if idle)
 	mcache      *mcache
 	deferpool   [5]*_defer // pool of available defer structs of different sizes (see panic.c)
+	tracebuf    *traceBuf
 
 	// Cache of goroutine ids, amortizes accesses to runtime·sched.goidgen.
 	goidcache    uint64
@@ -440,13 +454,15 @@ type cgomal struct {
 
 // Holds variables parsed from GODEBUG env var.
 type debugvars struct {
-	allocfreetrace int32
-	efence         int32
-	gctrace        int32
-	gcdead         int32
-	scheddetail    int32
-	schedtrace     int32
-	scavenge       int32
+	allocfreetrace  int32
+	asyncpreemptoff int32
+	efence          int32
+	gccheckmark     int32
+	gctrace         int32
+	gcdead          int32
+	scheddetail     int32
+	schedtrace      int32
+	scavenge        int32
 }
 
 // Indicates to write barrier and sychronization task to preform.
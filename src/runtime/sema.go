@@ -40,15 +40,27 @@ var semtable [semTabSize]struct {
 
 // Called from sync/net packages.
 func asyncsemacquire(addr *uint32) {
-	semacquire(addr, true)
+	semacquire(addr, semaBlockProfile)
+}
+
+// Called from sync.Mutex and sync.RWMutex.
+func asyncsemacquiremutex(addr *uint32) {
+	semacquire(addr, semaBlockProfile|semaMutexProfile)
 }
 
 func asyncsemrelease(addr *uint32) {
 	semrelease(addr)
 }
 
+type semaProfileFlags int
+
+const (
+	semaBlockProfile semaProfileFlags = 1 << iota
+	semaMutexProfile
+)
+
 // Called from runtime.
-func semacquire(addr *uint32, profile bool) {
+func semacquire(addr *uint32, profile semaProfileFlags) {
 	gp := getg()
 	if gp != gp.m.curg {
 		gothrow("semacquire not on the G stack")
@@ -69,10 +81,17 @@ func semacquire(addr *uint32, profile bool) {
 	root := semroot(addr)
 	t0 := int64(0)
 	s.releasetime = 0
-	if profile && blockprofilerate > 0 {
+	s.acquiretime = 0
+	if profile&semaBlockProfile != 0 && blockprofilerate > 0 {
 		t0 = cputicks()
 		s.releasetime = -1
 	}
+	if profile&semaMutexProfile != 0 && mutexprofilerate > 0 {
+		if t0 == 0 {
+			t0 = cputicks()
+		}
+		s.acquiretime = t0
+	}
 	for {
 		lock(&root.lock)
 		// Add ourselves to nwait to disable "easy case" in semrelease.
@@ -86,7 +105,7 @@ func semacquire(addr *uint32, profile bool) {
 		// Any semrelease after the cansemacquire knows we're waiting
 		// (we set nwait above), so go to sleep.
 		root.queue(addr, s)
-		goparkunlock(&root.lock, "semacquire")
+		goparkunlock(&root.lock, "semacquire", traceEvGoBlockSync, 4)
 		if cansemacquire(addr) {
 			break
 		}
@@ -124,8 +143,23 @@ func semrelease(addr *uint32) {
 			break
 		}
 	}
+	var t0 int64
+	if s != nil && s.acquiretime != 0 {
+		// The remaining waiters on addr start waiting for the
+		// next release now, so that each interval of contention
+		// is charged to exactly one releasing goroutine.
+		t0 = cputicks()
+		for x := root.head; x != nil; x = x.next {
+			if x.elem == unsafe.Pointer(addr) {
+				x.acquiretime = t0
+			}
+		}
+	}
 	unlock(&root.lock)
 	if s != nil {
+		if t0 != 0 {
+			mutexevent(t0-s.acquiretime, 3)
+		}
 		if s.releasetime != 0 {
 			s.releasetime = cputicks()
 		}
@@ -222,7 +256,7 @@ func syncsemacquire(s *syncSema) {
 			s.tail.next = w
 		}
 		s.tail = w
-		goparkunlock(&s.lock, "semacquire")
+		goparkunlock(&s.lock, "semacquire", traceEvGoBlockCond, 3)
 		if t0 != 0 {
 			blockevent(int64(w.releasetime)-t0, 2)
 		}
@@ -260,7 +294,7 @@ func syncsemrelease(s *syncSema, n uint32) {
 			s.tail.next = w
 		}
 		s.tail = w
-		goparkunlock(&s.lock, "semarelease")
+		goparkunlock(&s.lock, "semarelease", traceEvGoBlockCond, 3)
 		releaseSudog(w)
 	} else {
 		unlock(&s.lock)
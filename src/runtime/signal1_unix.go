@@ -62,6 +62,11 @@ func sigdisable(sig uint32) {
 		return
 	}
 
+	// The runtime needs its handler for preemption signals.
+	if preemptMSupported && sig == sigPreempt {
+		return
+	}
+
 	t := &sigtable[sig]
 	if t.flags&_SigNotify != 0 && t.flags&_SigHandling != 0 {
 		t.flags &^= _SigHandling
@@ -42,6 +42,13 @@ func sighandler(sig uint32, info *siginfo, ctxt unsafe.Pointer, gp *g) {
 		return
 	}
 
+	if preemptMSupported && sig == sigPreempt {
+		// Might be a preemption signal. It may also have been
+		// sent by someone else, or coalesced with such a signal,
+		// so it is passed on to os/signal below all the same.
+		doSigPreempt(gp, c)
+	}
+
 	if GOOS == "darwin" {
 		// x86-64 has 48-bit virtual addresses. The top 16 bits must echo bit 47.
 		// The hardware delivers a different kind of fault for a malformed address
@@ -161,3 +168,23 @@ func sighandler(sig uint32, info *siginfo, ctxt unsafe.Pointer, gp *g) {
 
 	exit(2)
 }
+
+// doSigPreempt handles a preemption signal that interrupted gp.
+// If gp is at an asynchronous safe point, doSigPreempt makes it
+// look as though gp had called asyncPreempt.
+func doSigPreempt(gp *g, c *sigctxt) {
+	if wantAsyncPreempt(gp) && isAsyncSafePoint(gp, uintptr(c.rip()), uintptr(c.rsp())) {
+		sp := c.rsp()
+		if regSize > ptrSize {
+			sp -= ptrSize
+			*(*uintptr)(unsafe.Pointer(uintptr(sp))) = 0
+		}
+		sp -= ptrSize
+		*(*uintptr)(unsafe.Pointer(uintptr(sp))) = uintptr(c.rip())
+		c.set_rsp(sp)
+		c.set_rip(uint64(funcPC(asyncPreempt)))
+	}
+
+	// Let preemptM send another signal.
+	atomicstore(&getg().m.signalpending, 0)
+}
@@ -707,7 +707,7 @@ func newstack() {
 
 		// Act like goroutine called runtime.Gosched.
 		casgstatus(gp, _Gwaiting, _Grunning)
-		gosched_m(gp) // never return
+		gopreempt_m(gp) // never return
 	}
 
 	// Allocate a bigger segment and move the stack.
@@ -779,6 +779,11 @@ func shrinkstack(gp *g) {
 	if gp.syscallsp != 0 {
 		return
 	}
+	// Nor if the goroutine was preempted asynchronously.
+	// The registers it saved may point into the stack and are not adjusted.
+	if gp.asyncsafe {
+		return
+	}
 	if goos_windows != 0 && gp.m != nil && gp.m.libcallsp != 0 {
 		return
 	}
@@ -23,12 +23,123 @@ func (f *Func) raw() *_func {
 // funcdata.h
 const (
 	_PCDATA_StackMapIndex       = 0
+	_PCDATA_InlTreeIndex        = 1
+	_PCDATA_RegMap              = 2
 	_FUNCDATA_ArgsPointerMaps   = 0
 	_FUNCDATA_LocalsPointerMaps = 1
 	_FUNCDATA_DeadValueMaps     = 2
+	_FUNCDATA_InlTree           = 3
 	_ArgsSizeUnknown            = -0x80000000
 )
 
+// Frames may be used to get function/file/line information for a
+// slice of PC values returned by Callers.
+type Frames struct {
+	callers []uintptr
+
+	// frames holds the frames of the PC last expanded
+	// that Next has yet to return, innermost first.
+	frames []Frame
+
+	// waspanic reports whether the last PC expanded was in sigpanic
+	// or asyncPreempt, in which case the next PC is that of a faulting
+	// or interrupted instruction rather than a return address.
+	waspanic bool
+}
+
+// Frame is the information returned by Frames for each call frame.
+type Frame struct {
+	// PC is the program counter for the location in this frame.
+	// For a frame that calls another frame, this will be the
+	// program counter of a call instruction. Because of pipelining,
+	// this is likely to be the call instruction's return address.
+	PC uintptr
+
+	// Func is the Func value of this call frame. This may be nil
+	// for non-Go code or for a call that the compiler inlined.
+	Func *Func
+
+	// Function is the package path-qualified function name of
+	// this call frame. If non-empty, this string uniquely
+	// identifies a single function in the program.
+	Function string
+
+	// File and Line are the file name and line number of the
+	// location in this frame. For non-leaf frames, this will be
+	// the location of a call.
+	File string
+	Line int
+
+	// Entry point of the function, or zero if not known.
+	// For a call that the compiler inlined, it is the entry point
+	// of the function the call was inlined into, since the
+	// inlined function has no entry point of its own there.
+	Entry uintptr
+}
+
+// CallersFrames takes a slice of PCs returned by Callers and
+// prepares to return function/file/line information.
+// Unlike a single PC looked up with FuncForPC, each PC may stand
+// for several frames: one for each call that the compiler inlined
+// at the PC, followed by one for the function containing the PC.
+// Do not change the slice until you are done with the Frames.
+func CallersFrames(callers []uintptr) *Frames {
+	return &Frames{callers: callers}
+}
+
+// Next returns frame information for the next caller.
+// If more is false, there are no more callers (the Frame value is valid).
+func (ci *Frames) Next() (frame Frame, more bool) {
+	for len(ci.frames) == 0 {
+		if len(ci.callers) == 0 {
+			return Frame{}, false
+		}
+		pc := ci.callers[0]
+		ci.callers = ci.callers[1:]
+		f := findfunc(pc)
+		if f == nil {
+			ci.frames = append(ci.frames, Frame{PC: pc})
+			ci.waspanic = false
+			break
+		}
+		tracepc := pc
+		if pc > f.entry && !ci.waspanic {
+			tracepc--
+		}
+		ci.frames = expandframes(ci.frames, f, pc, tracepc)
+		ci.waspanic = f.entry == funcPC(sigpanic) || f.entry == funcPC(asyncPreempt)
+	}
+	frame = ci.frames[0]
+	ci.frames = ci.frames[1:]
+	return frame, len(ci.frames) > 0 || len(ci.callers) > 0
+}
+
+// expandframes appends to frames the frames at tracepc in f,
+// the innermost inlined call first, and returns the extended slice.
+// pc is the PC to report in the frames.
+func expandframes(frames []Frame, f *_func, pc, tracepc uintptr) []Frame {
+	file, line := funcline1(f, tracepc, false)
+	tree := funcinltree(f)
+	for ix := funcinlindex(f, tree, tracepc, false); ix >= 0; ix = tree[ix].parent {
+		frames = append(frames, Frame{
+			PC:       pc,
+			Function: inlinedcallname(&tree[ix]),
+			File:     file,
+			Line:     int(line),
+			Entry:    f.entry,
+		})
+		file, line = inlinedcallline(&tree[ix])
+	}
+	return append(frames, Frame{
+		PC:       pc,
+		Func:     (*Func)(unsafe.Pointer(f)),
+		Function: gofuncname(f),
+		File:     file,
+		Line:     int(line),
+		Entry:    f.entry,
+	})
+}
+
 var (
 	pclntable []byte
 	ftab      []functab
@@ -100,6 +211,9 @@ func symtabinit() {
 
 // FuncForPC returns a *Func describing the function that contains the
 // given program counter address, or else nil.
+// If pc is within code that the compiler inlined, the Func describes
+// the function the code was inlined into; use CallersFrames to
+// recover the inlined calls.
 func FuncForPC(pc uintptr) *Func {
 	return (*Func)(unsafe.Pointer(findfunc(pc)))
 }
@@ -231,11 +345,15 @@ func funcspdelta(f *_func, targetpc uintptr) int32 {
 }
 
 func pcdatavalue(f *_func, table int32, targetpc uintptr) int32 {
+	return pcdatavalue1(f, table, targetpc, true)
+}
+
+func pcdatavalue1(f *_func, table int32, targetpc uintptr, strict bool) int32 {
 	if table < 0 || table >= f.npcdata {
 		return -1
 	}
 	off := *(*int32)(add(unsafe.Pointer(&f.nfuncdata), unsafe.Sizeof(f.nfuncdata)+uintptr(table)*4))
-	return pcvalue(f, off, targetpc, true)
+	return pcvalue(f, off, targetpc, strict)
 }
 
 func funcdata(f *_func, i int32) unsafe.Pointer {
@@ -252,6 +370,60 @@ func funcdata(f *_func, i int32) unsafe.Pointer {
 	return *(*unsafe.Pointer)(add(p, uintptr(i)*ptrSize))
 }
 
+// An inlinedCall is a node of the inlining tree of a function, which
+// records the calls that the compiler inlined into the function.
+// The linker writes the tree into the pclntab, preceded by the number
+// of nodes; the FUNCDATA_InlTree word of the function holds its offset.
+// The PCDATA_InlTreeIndex table gives, for each PC, the index of the
+// innermost inlined call whose body the PC is in, or -1.
+type inlinedCall struct {
+	parent int32 // index of the node of the enclosing inlined call, or -1
+	file   int32 // file number of the call
+	line   int32 // line number of the call
+	name   int32 // offset in pclntab of the name of the function called
+}
+
+// funcinltree returns the inlining tree of f,
+// or nil if the compiler inlined no calls into f.
+func funcinltree(f *_func) []inlinedCall {
+	off := uintptr(funcdata(f, _FUNCDATA_InlTree))
+	if off == 0 {
+		return nil
+	}
+	var tree []inlinedCall
+	sp := (*sliceStruct)(unsafe.Pointer(&tree))
+	sp.array = unsafe.Pointer(&pclntable[off+4])
+	sp.len = int(*(*int32)(unsafe.Pointer(&pclntable[off])))
+	sp.cap = sp.len
+	return tree
+}
+
+// funcinlindex returns the index in tree, the inlining tree of f,
+// of the innermost inlined call at targetpc, or -1 if there is none.
+func funcinlindex(f *_func, tree []inlinedCall, targetpc uintptr, strict bool) int32 {
+	if tree == nil {
+		return -1
+	}
+	ix := pcdatavalue1(f, _PCDATA_InlTreeIndex, targetpc, strict)
+	if ix >= int32(len(tree)) {
+		return -1
+	}
+	return ix
+}
+
+// inlinedcallname returns the name of the function called by c.
+func inlinedcallname(c *inlinedCall) string {
+	return gostringnocopy(&pclntable[c.name])
+}
+
+// inlinedcallline returns the file and line number of the call c.
+func inlinedcallline(c *inlinedCall) (file string, line int32) {
+	if c.file < 0 || int(c.file) >= len(filetab) {
+		return "?", 0
+	}
+	return gostringnocopy(&pclntable[filetab[c.file]]), c.line
+}
+
 // step advances to the next pc, value pair in the encoded table.
 func step(p []byte, pc *uintptr, val *int32, first bool) (newp []byte, ok bool) {
 	p, uvdelta := readvarint(p)
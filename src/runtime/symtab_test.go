@@ -45,3 +45,48 @@ func testCallerBar(t *testing.T) {
 		}
 	}
 }
+
+// testInlinedIndex is small enough for the compiler to inline.
+func testInlinedIndex(b []byte, i int) byte {
+	return b[i]
+}
+
+// testInlinedCaller returns the program counters of the goroutine
+// as it panics in the call to testInlinedIndex inlined in it.
+func testInlinedCaller(b []byte, i int) (pcs []uintptr) {
+	defer func() {
+		recover()
+		pcs = make([]uintptr, 20)
+		pcs = pcs[:runtime.Callers(1, pcs)]
+	}()
+	testInlinedIndex(b, i)
+	return nil
+}
+
+func TestCallersFrames(t *testing.T) {
+	frames := runtime.CallersFrames(testInlinedCaller(nil, 1))
+	var names []string
+	for {
+		frame, more := frames.Next()
+		names = append(names, frame.Function)
+		if strings.HasSuffix(frame.Function, ".testInlinedIndex") {
+			if frame.Func != nil {
+				t.Errorf("inlined frame %s has Func %s", frame.Function, frame.Func.Name())
+			}
+			if !strings.HasSuffix(frame.File, "symtab_test.go") {
+				t.Errorf("inlined frame %s has file %s", frame.Function, frame.File)
+			}
+			caller, _ := frames.Next()
+			if !strings.HasSuffix(caller.Function, ".testInlinedCaller") ||
+				caller.Func == nil || caller.Func.Name() != caller.Function ||
+				caller.Entry != frame.Entry || caller.Line <= frame.Line {
+				t.Errorf("caller of inlined frame %+v is %+v", frame, caller)
+			}
+			return
+		}
+		if !more {
+			break
+		}
+	}
+	t.Errorf("testInlinedIndex not found in frames %v", names)
+}
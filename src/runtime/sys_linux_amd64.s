@@ -82,6 +82,26 @@ TEXT runtime·usleep(SB),NOSPLIT,$16
 	SYSCALL
 	RET
 
+TEXT runtime·gettid(SB),NOSPLIT,$0-4
+	MOVL	$186, AX	// syscall - gettid
+	SYSCALL
+	MOVL	AX, ret+0(FP)
+	RET
+
+TEXT runtime·getpid(SB),NOSPLIT,$0-4
+	MOVL	$39, AX	// syscall - getpid
+	SYSCALL
+	MOVL	AX, ret+0(FP)
+	RET
+
+TEXT runtime·tgkill(SB),NOSPLIT,$0-12
+	MOVL	tgid+0(FP), DI
+	MOVL	tid+4(FP), SI
+	MOVL	sig+8(FP), DX
+	MOVL	$234, AX	// syscall - tgkill
+	SYSCALL
+	RET
+
 TEXT runtime·raise(SB),NOSPLIT,$0
 	MOVL	$186, AX	// syscall - gettid
 	SYSCALL
@@ -46,9 +46,30 @@ TEXT sync·runtime_Semacquire(SB),NOSPLIT,$0-0
 TEXT sync·runtime_Semrelease(SB),NOSPLIT,$0-0
 	JMP	runtime·asyncsemrelease(SB)
 
+TEXT sync·runtime_SemacquireMutex(SB),NOSPLIT,$0-0
+	JMP	runtime·asyncsemacquiremutex(SB)
+
 TEXT sync·runtime_registerPoolCleanup(SB),NOSPLIT,$0-0
 	JMP	runtime·registerPoolCleanup(SB)
 
+TEXT sync∕atomic·StorePointer(SB),NOSPLIT,$0-0
+	JMP	runtime·sync_atomic_StorePointer(SB)
+
+TEXT sync∕atomic·SwapPointer(SB),NOSPLIT,$0-0
+	JMP	runtime·sync_atomic_SwapPointer(SB)
+
+TEXT sync∕atomic·CompareAndSwapPointer(SB),NOSPLIT,$0-0
+	JMP	runtime·sync_atomic_CompareAndSwapPointer(SB)
+
+TEXT runtime·sync_atomic_StoreUintptr(SB),NOSPLIT,$0-0
+	JMP	sync∕atomic·StoreUintptr(SB)
+
+TEXT runtime·sync_atomic_SwapUintptr(SB),NOSPLIT,$0-0
+	JMP	sync∕atomic·SwapUintptr(SB)
+
+TEXT runtime·sync_atomic_CompareAndSwapUintptr(SB),NOSPLIT,$0-0
+	JMP	sync∕atomic·CompareAndSwapUintptr(SB)
+
 TEXT net·runtime_Semacquire(SB),NOSPLIT,$0-0
 	JMP	runtime·asyncsemacquire(SB)
 
@@ -58,6 +79,15 @@ TEXT net·runtime_Semrelease(SB),NOSPLIT,$0-0
 TEXT runtime∕pprof·runtime_cyclesPerSecond(SB),NOSPLIT,$0-0
 	JMP	runtime·tickspersecond(SB)
 
+TEXT runtime∕pprof·runtime_setProfLabel(SB),NOSPLIT,$0-0
+	JMP	runtime·setProfLabel(SB)
+
+TEXT runtime∕pprof·runtime_getProfLabel(SB),NOSPLIT,$0-0
+	JMP	runtime·getProfLabel(SB)
+
+TEXT runtime∕metrics·runtime_readMetrics(SB),NOSPLIT,$0-0
+	JMP	runtime·readMetrics(SB)
+
 TEXT bytes·Compare(SB),NOSPLIT,$0-0
 	JMP	runtime·cmpbytes(SB)
 
@@ -82,6 +112,12 @@ TEXT reflect·chanrecv(SB), NOSPLIT, $0-0
 TEXT reflect·memmove(SB), NOSPLIT, $0-0
 	JMP	runtime·memmove(SB)
 
+TEXT reflect·typedmemmove(SB), NOSPLIT, $0-0
+	JMP	runtime·reflect_typedmemmove(SB)
+
+TEXT reflect·typedslicecopy(SB), NOSPLIT, $0-0
+	JMP	runtime·reflect_typedslicecopy(SB)
+
 TEXT runtime∕debug·freeOSMemory(SB), NOSPLIT, $0-0
 	JMP	runtime·freeOSMemory(SB)
 
@@ -32,6 +32,7 @@ const usesLR = GOARCH != "amd64" && GOARCH != "amd64p32" && GOARCH != "386"
 
 var (
 	// initialized in tracebackinit
+	asyncPreemptPC       uintptr
 	deferprocPC          uintptr
 	goexitPC             uintptr
 	jmpdeferPC           uintptr
@@ -51,6 +52,7 @@ func tracebackinit() {
 	// Instead of initializing the variables above in the declarations,
 	// schedinit calls this function so that the variables are
 	// initialized and available earlier in the startup sequence.
+	asyncPreemptPC = funcPC(asyncPreempt)
 	deferprocPC = funcPC(deferproc)
 	goexitPC = funcPC(goexit)
 	jmpdeferPC = funcPC(jmpdefer)
@@ -144,6 +146,7 @@ func gentraceback(pc0 uintptr, sp0 uintptr, lr0 uintptr, gp *g, skip int, pcbuf
 		frame.lr = lr0
 	}
 	waspanic := false
+	waspreempt := false
 	wasnewproc := false
 	printing := pcbuf == nil && callback == nil
 	_defer := gp._defer
@@ -321,9 +324,19 @@ func gentraceback(pc0 uintptr, sp0 uintptr, lr0 uintptr, gp *g, skip int, pcbuf
 				//		/home/rsc/go/src/runtime/x.go:23 +0xf
 				//
 				tracepc := frame.pc // back up to CALL instruction for funcline.
-				if (n > 0 || flags&_TraceTrap == 0) && frame.pc > f.entry && !waspanic {
+				if (n > 0 || flags&_TraceTrap == 0) && frame.pc > f.entry && !waspanic && !waspreempt {
 					tracepc--
 				}
+				file, line := funcline(f, tracepc)
+				// Print the calls inlined at tracepc first, innermost
+				// first. Their arguments are not known.
+				tree := funcinltree(f)
+				for ix := funcinlindex(f, tree, tracepc, true); ix >= 0; ix = tree[ix].parent {
+					print(inlinedcallname(&tree[ix]), "(...)\n")
+					print("\t", file, ":", line, "\n")
+					file, line = inlinedcallline(&tree[ix])
+					nprint++
+				}
 				print(gofuncname(f), "(")
 				argp := (*[100]uintptr)(unsafe.Pointer(frame.argp))
 				for i := uintptr(0); i < frame.arglen/ptrSize; i++ {
@@ -337,7 +350,6 @@ func gentraceback(pc0 uintptr, sp0 uintptr, lr0 uintptr, gp *g, skip int, pcbuf
 					print(hex(argp[i]))
 				}
 				print(")\n")
-				file, line := funcline(f, tracepc)
 				print("\t", file, ":", line)
 				if frame.pc > f.entry {
 					print(" +", hex(frame.pc-f.entry))
@@ -353,6 +365,7 @@ func gentraceback(pc0 uintptr, sp0 uintptr, lr0 uintptr, gp *g, skip int, pcbuf
 
 	skipped:
 		waspanic = f.entry == sigpanicPC
+		waspreempt = f.entry == asyncPreemptPC
 		wasnewproc = f.entry == newprocPC || f.entry == deferprocPC
 
 		// Do not unwind past the bottom of the stack.
@@ -615,7 +628,11 @@ func goroutineheader(gp *g) {
 	if gp.lockedm != nil {
 		print(", locked to thread")
 	}
-	print("]:\n")
+	print("]")
+	if gp.labeltext != nil {
+		print(" ", *gp.labeltext)
+	}
+	print(":\n")
 }
 
 func tracebackothers(me *g) {
@@ -0,0 +1,165 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package atomic_test
+
+import (
+	. "sync/atomic"
+	"testing"
+	"unsafe"
+)
+
+func TestBool(t *testing.T) {
+	var x Bool
+	if x.Load() {
+		t.Fatal("zero Bool is true")
+	}
+	x.Store(true)
+	if !x.Load() {
+		t.Fatal("Store(true) did not store true")
+	}
+	if old := x.Swap(false); !old || x.Load() {
+		t.Fatalf("Swap(false) = %v, left %v; want true, false", old, x.Load())
+	}
+	if x.CompareAndSwap(true, true) {
+		t.Fatal("CompareAndSwap(true, true) swapped false")
+	}
+	if !x.CompareAndSwap(false, true) || !x.Load() {
+		t.Fatal("CompareAndSwap(false, true) did not swap")
+	}
+}
+
+func TestPointerType(t *testing.T) {
+	var x Pointer
+	a, b := new(int), new(int)
+	if x.Load() != nil {
+		t.Fatal("zero Pointer is not nil")
+	}
+	x.Store(unsafe.Pointer(a))
+	if old := x.Swap(unsafe.Pointer(b)); old != unsafe.Pointer(a) {
+		t.Fatalf("Swap returned %p; want %p", old, a)
+	}
+	if x.CompareAndSwap(unsafe.Pointer(a), nil) {
+		t.Fatal("CompareAndSwap swapped with wrong old value")
+	}
+	if !x.CompareAndSwap(unsafe.Pointer(b), nil) || x.Load() != nil {
+		t.Fatal("CompareAndSwap did not swap")
+	}
+}
+
+func TestInt32Type(t *testing.T) {
+	var x Int32
+	x.Store(-1)
+	if v := x.Add(3); v != 2 {
+		t.Fatalf("Add(3) = %d; want 2", v)
+	}
+	if old := x.Swap(7); old != 2 || x.Load() != 7 {
+		t.Fatalf("Swap(7) = %d, left %d; want 2, 7", old, x.Load())
+	}
+	if x.CompareAndSwap(2, 3) || !x.CompareAndSwap(7, 3) || x.Load() != 3 {
+		t.Fatal("CompareAndSwap misbehaved")
+	}
+}
+
+func TestInt64Type(t *testing.T) {
+	var x Int64
+	x.Store(-1 << 40)
+	if v := x.Add(1 << 40); v != 0 {
+		t.Fatalf("Add = %d; want 0", v)
+	}
+	if old := x.Swap(1 << 50); old != 0 || x.Load() != 1<<50 {
+		t.Fatalf("Swap = %d, left %d; want 0, 1<<50", old, x.Load())
+	}
+	if x.CompareAndSwap(0, 1) || !x.CompareAndSwap(1<<50, 1) || x.Load() != 1 {
+		t.Fatal("CompareAndSwap misbehaved")
+	}
+}
+
+func TestUint32Type(t *testing.T) {
+	var x Uint32
+	x.Store(1)
+	if v := x.Add(^uint32(0)); v != 0 {
+		t.Fatalf("Add(-1) = %d; want 0", v)
+	}
+	if old := x.Swap(7); old != 0 || x.Load() != 7 {
+		t.Fatalf("Swap(7) = %d, left %d; want 0, 7", old, x.Load())
+	}
+	if x.CompareAndSwap(0, 3) || !x.CompareAndSwap(7, 3) || x.Load() != 3 {
+		t.Fatal("CompareAndSwap misbehaved")
+	}
+}
+
+func TestUint64Type(t *testing.T) {
+	var x Uint64
+	x.Store(1 << 40)
+	if v := x.Add(1 << 40); v != 1<<41 {
+		t.Fatalf("Add = %d; want 1<<41", v)
+	}
+	if old := x.Swap(7); old != 1<<41 || x.Load() != 7 {
+		t.Fatalf("Swap(7) = %d, left %d; want 1<<41, 7", old, x.Load())
+	}
+	if x.CompareAndSwap(0, 3) || !x.CompareAndSwap(7, 3) || x.Load() != 3 {
+		t.Fatal("CompareAndSwap misbehaved")
+	}
+}
+
+func TestUintptrType(t *testing.T) {
+	var x Uintptr
+	x.Store(1)
+	if v := x.Add(2); v != 3 {
+		t.Fatalf("Add(2) = %d; want 3", v)
+	}
+	if old := x.Swap(7); old != 3 || x.Load() != 7 {
+		t.Fatalf("Swap(7) = %d, left %d; want 3, 7", old, x.Load())
+	}
+	if x.CompareAndSwap(0, 3) || !x.CompareAndSwap(7, 3) || x.Load() != 3 {
+		t.Fatal("CompareAndSwap misbehaved")
+	}
+}
+
+type misaligned64 struct {
+	b  byte
+	i  Int64
+	c  int32
+	u  Uint64
+	ia [3]struct {
+		b byte
+		i Int64
+	}
+}
+
+var global64 misaligned64
+
+func TestAlign64(t *testing.T) {
+	if a := unsafe.Alignof(global64.i); a != 8 {
+		t.Errorf("Alignof(Int64) = %d; want 8", a)
+	}
+	if a := unsafe.Alignof(global64.u); a != 8 {
+		t.Errorf("Alignof(Uint64) = %d; want 8", a)
+	}
+	var local misaligned64
+	heap := new(misaligned64)
+	for _, s := range []*misaligned64{&global64, &local, heap} {
+		addrs := []uintptr{
+			uintptr(unsafe.Pointer(&s.i)),
+			uintptr(unsafe.Pointer(&s.u)),
+			uintptr(unsafe.Pointer(&s.ia[1].i)),
+			uintptr(unsafe.Pointer(&s.ia[2].i)),
+		}
+		for _, a := range addrs {
+			if a%8 != 0 {
+				t.Errorf("%#x is not 64-bit aligned", a)
+			}
+		}
+		// These panic if the words are not aligned.
+		s.i.Add(1)
+		s.u.Add(1)
+		s.ia[1].i.Add(1)
+	}
+	var x Int64
+	x.Add(1)
+	if a := uintptr(unsafe.Pointer(&x)); a%8 != 0 {
+		t.Errorf("local Int64 at %#x is not 64-bit aligned", a)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package errgroup provides synchronization and error propagation
+// for groups of goroutines working on subtasks of a common task.
+package errgroup
+
+import "sync"
+
+// A Group is a collection of goroutines working on subtasks that are
+// part of the same overall task.
+//
+// The zero Group is ready to use.  A Group must not be copied after
+// first use.
+type Group struct {
+	wg sync.WaitGroup
+
+	mu   sync.Mutex
+	err  error
+	done chan struct{} // lazily initialized, closed by cancel
+}
+
+// Go calls the given function in a new goroutine.
+//
+// The first call to return a non-nil error cancels the group: the
+// error is kept for Wait to return and the channel returned by Done
+// is closed.
+func (g *Group) Go(f func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := f(); err != nil {
+			g.cancel(err)
+		}
+	}()
+}
+
+// Wait blocks until all function calls from the Go method have
+// returned, then returns the first non-nil error (if any) from them.
+// Wait cancels the group before it returns.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(nil)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.err
+}
+
+// Done returns a channel that is closed when the group is canceled,
+// either because a function started by Go returned an error or
+// because Wait returned.  Functions started by Go should stop their
+// work early when it is closed.
+func (g *Group) Done() <-chan struct{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.done == nil {
+		g.done = make(chan struct{})
+	}
+	return g.done
+}
+
+// cancel records err if it is the first error and closes the done
+// channel if it is not already closed.
+func (g *Group) cancel(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.err == nil {
+		g.err = err
+	}
+	if g.done == nil {
+		g.done = make(chan struct{})
+	}
+	select {
+	case <-g.done:
+	default:
+		close(g.done)
+	}
+}
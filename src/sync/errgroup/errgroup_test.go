@@ -0,0 +1,115 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errgroup_test
+
+import (
+	"errors"
+	"fmt"
+	"sync/errgroup"
+	"testing"
+)
+
+func TestZeroGroup(t *testing.T) {
+	err1 := errors.New("errgroup_test: 1")
+	err2 := errors.New("errgroup_test: 2")
+
+	cases := []struct {
+		errs []error
+	}{
+		{errs: []error{}},
+		{errs: []error{nil}},
+		{errs: []error{err1}},
+		{errs: []error{err1, nil}},
+		{errs: []error{err1, nil, err2}},
+	}
+
+	for _, tc := range cases {
+		var g errgroup.Group
+
+		var firstErr error
+		for i, err := range tc.errs {
+			err := err
+			g.Go(func() error { return err })
+
+			if firstErr == nil && err != nil {
+				firstErr = err
+			}
+
+			if gErr := g.Wait(); gErr != firstErr {
+				t.Errorf("after g.Go(func() error { return err }) for err in %v\n"+
+					"g.Wait() = %v; want %v", tc.errs[:i+1], err, firstErr)
+			}
+		}
+	}
+}
+
+func TestCancel(t *testing.T) {
+	var g errgroup.Group
+	errDoom := errors.New("group_test: doomed")
+	g.Go(func() error {
+		return errDoom
+	})
+	for i := 0; i < 3; i++ {
+		g.Go(func() error {
+			// Each function must see the cancellation
+			// for Wait to return.
+			<-g.Done()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != errDoom {
+		t.Errorf("Wait() = %v; want %v", err, errDoom)
+	}
+	select {
+	case <-g.Done():
+	default:
+		t.Errorf("Done channel not closed after Wait")
+	}
+}
+
+func TestWaitCancels(t *testing.T) {
+	var g errgroup.Group
+	done := g.Done()
+	g.Go(func() error { return nil })
+	if err := g.Wait(); err != nil {
+		t.Errorf("Wait() = %v; want nil", err)
+	}
+	select {
+	case <-done:
+	default:
+		t.Errorf("Done channel not closed after Wait")
+	}
+}
+
+// Compute the sum of squares in parallel, stopping at the first failure.
+func ExampleGroup() {
+	var g errgroup.Group
+	results := make([]int, 5)
+	for i := range results {
+		i := i
+		g.Go(func() error {
+			select {
+			case <-g.Done():
+				return nil
+			default:
+			}
+			if i < 0 {
+				return fmt.Errorf("negative input %d", i)
+			}
+			results[i] = i * i
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		fmt.Println(err)
+		return
+	}
+	sum := 0
+	for _, r := range results {
+		sum += r
+	}
+	fmt.Println(sum)
+	// Output: 30
+}
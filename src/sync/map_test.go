@@ -0,0 +1,169 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sync_test
+
+import (
+	"math/rand"
+	"runtime"
+	. "sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMapMatchesGoMap(t *testing.T) {
+	var m Map
+	ref := make(map[interface{}]interface{})
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		k := r.Intn(64)
+		v := r.Int()
+		switch r.Intn(5) {
+		case 0:
+			got, ok := m.Load(k)
+			want, wantOK := ref[k]
+			if got != want || ok != wantOK {
+				t.Fatalf("Load(%d) = %v, %v; want %v, %v", k, got, ok, want, wantOK)
+			}
+		case 1:
+			m.Store(k, v)
+			ref[k] = v
+		case 2:
+			got, loaded := m.LoadOrStore(k, v)
+			want, wantLoaded := ref[k]
+			if !wantLoaded {
+				want = v
+				ref[k] = v
+			}
+			if got != want || loaded != wantLoaded {
+				t.Fatalf("LoadOrStore(%d, %d) = %v, %v; want %v, %v", k, v, got, loaded, want, wantLoaded)
+			}
+		case 3:
+			m.Delete(k)
+			delete(ref, k)
+		case 4:
+			seen := make(map[interface{}]bool)
+			m.Range(func(k, v interface{}) bool {
+				if seen[k] {
+					t.Fatalf("Range visited key %v twice", k)
+				}
+				seen[k] = true
+				if want, ok := ref[k]; !ok || v != want {
+					t.Fatalf("Range: %v: %v; want %v, %v", k, v, want, ok)
+				}
+				return true
+			})
+			if len(seen) != len(ref) {
+				t.Fatalf("Range visited %d keys; want %d", len(seen), len(ref))
+			}
+		}
+	}
+}
+
+func TestMapRangeStop(t *testing.T) {
+	var m Map
+	for i := 0; i < 10; i++ {
+		m.Store(i, i)
+	}
+	n := 0
+	m.Range(func(k, v interface{}) bool {
+		n++
+		return n < 3
+	})
+	if n != 3 {
+		t.Errorf("Range called f %d times after it returned false; want 3", n)
+	}
+}
+
+func TestMapConcurrent(t *testing.T) {
+	const N = 1000
+	P := runtime.GOMAXPROCS(0)
+	if P < 4 {
+		P = 4
+	}
+	var m Map
+	var loaded int32
+	done := make(chan bool)
+	for p := 0; p < P; p++ {
+		go func(p int) {
+			for i := 0; i < N; i++ {
+				// Every goroutine stores its own keys and
+				// races to store the shared ones.
+				m.Store([2]int{p, i}, i)
+				if _, ok := m.LoadOrStore(i, p); ok {
+					atomic.AddInt32(&loaded, 1)
+				}
+				if v, ok := m.Load([2]int{p, i}); !ok || v != i {
+					t.Errorf("Load(%v) = %v, %v; want %v, true", [2]int{p, i}, v, ok, i)
+				}
+				if i%2 == 0 {
+					m.Delete([2]int{p, i})
+				}
+			}
+			done <- true
+		}(p)
+	}
+	for p := 0; p < P; p++ {
+		<-done
+	}
+	if want := int32((P - 1) * N); loaded != want {
+		t.Errorf("LoadOrStore loaded %d times; want %d", loaded, want)
+	}
+	n := 0
+	m.Range(func(k, v interface{}) bool {
+		n++
+		return true
+	})
+	if want := P*N/2 + N; n != want {
+		t.Errorf("Range visited %d keys; want %d", n, want)
+	}
+}
+
+func BenchmarkMapLoadMostlyHits(b *testing.B) {
+	const hits, misses = 1023, 1
+	var m Map
+	for i := 0; i < hits; i++ {
+		m.LoadOrStore(i, i)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			m.Load(i % (hits + misses))
+			i++
+		}
+	})
+}
+
+func BenchmarkMapStoreDisjoint(b *testing.B) {
+	var m Map
+	var id int32
+	b.RunParallel(func(pb *testing.PB) {
+		base := int(atomic.AddInt32(&id, 1)) << 16
+		i := 0
+		for pb.Next() {
+			m.Store(base+i%1024, i)
+			i++
+		}
+	})
+}
+
+func BenchmarkRWMutexMapLoadMostlyHits(b *testing.B) {
+	const hits, misses = 1023, 1
+	var mu RWMutex
+	m := make(map[interface{}]interface{})
+	for i := 0; i < hits; i++ {
+		m[i] = i
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			mu.RLock()
+			_ = m[i%(hits+misses)]
+			mu.RUnlock()
+			i++
+		}
+	})
+}
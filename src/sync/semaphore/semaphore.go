@@ -0,0 +1,146 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package semaphore provides a weighted semaphore implementation.
+package semaphore
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+)
+
+// ErrCanceled is returned by Acquire when the cancel channel
+// is closed before the semaphore could be acquired.
+var ErrCanceled = errors.New("semaphore: acquire canceled")
+
+type waiter struct {
+	n     int64
+	ready chan struct{} // Closed when semaphore acquired.
+}
+
+// Weighted provides a way to bound concurrent access to a resource.
+// The callers can request access with a given weight.
+//
+// Waiters are served in the order they called Acquire: a waiter that
+// cannot be satisfied holds up those behind it, even if they ask for
+// less, so that large requests are not starved by small ones.
+type Weighted struct {
+	size    int64
+	cur     int64
+	mu      sync.Mutex
+	waiters list.List
+}
+
+// NewWeighted creates a new weighted semaphore with the given
+// maximum combined weight for concurrent access.
+func NewWeighted(n int64) *Weighted {
+	return &Weighted{size: n}
+}
+
+// Acquire acquires the semaphore with a weight of n, blocking until
+// resources are available or cancel is closed.  On success, it returns
+// nil.  On failure, it returns ErrCanceled and leaves the semaphore
+// unchanged.  A nil cancel channel makes Acquire block until the
+// resources are available.
+//
+// If cancel is already closed, Acquire may still succeed without
+// blocking.
+func (s *Weighted) Acquire(cancel <-chan struct{}, n int64) error {
+	s.mu.Lock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mu.Unlock()
+		return nil
+	}
+
+	if n > s.size {
+		// Don't make other Acquire calls block on one that's doomed to fail.
+		s.mu.Unlock()
+		<-cancel
+		return ErrCanceled
+	}
+
+	ready := make(chan struct{})
+	w := waiter{n: n, ready: ready}
+	elem := s.waiters.PushBack(w)
+	s.mu.Unlock()
+
+	select {
+	case <-cancel:
+		s.mu.Lock()
+		select {
+		case <-ready:
+			// Acquired the semaphore after we were canceled.  Rather
+			// than trying to fix up the queue, just pretend we didn't
+			// notice the cancellation.
+			s.mu.Unlock()
+			return nil
+		default:
+			isFront := s.waiters.Front() == elem
+			s.waiters.Remove(elem)
+			// If we're at the front and there're extra tokens left,
+			// notify other waiters.
+			if isFront && s.size > s.cur {
+				s.notifyWaiters()
+			}
+		}
+		s.mu.Unlock()
+		return ErrCanceled
+
+	case <-ready:
+		return nil
+	}
+}
+
+// TryAcquire acquires the semaphore with a weight of n without blocking.
+// On success, returns true.  On failure, returns false and leaves the
+// semaphore unchanged.
+func (s *Weighted) TryAcquire(n int64) bool {
+	s.mu.Lock()
+	success := s.size-s.cur >= n && s.waiters.Len() == 0
+	if success {
+		s.cur += n
+	}
+	s.mu.Unlock()
+	return success
+}
+
+// Release releases the semaphore with a weight of n.
+func (s *Weighted) Release(n int64) {
+	s.mu.Lock()
+	s.cur -= n
+	if s.cur < 0 {
+		s.mu.Unlock()
+		panic("semaphore: released more than held")
+	}
+	s.notifyWaiters()
+	s.mu.Unlock()
+}
+
+// notifyWaiters grants the semaphore to waiters at the front of the
+// queue for as long as there are enough resources for the next one.
+// It is called with s.mu held.
+func (s *Weighted) notifyWaiters() {
+	for {
+		next := s.waiters.Front()
+		if next == nil {
+			break // No more waiters blocked.
+		}
+
+		w := next.Value.(waiter)
+		if s.size-s.cur < w.n {
+			// Not enough tokens for the next waiter.  We could keep
+			// going (to try to find a waiter with a smaller request),
+			// but under load that could cause starvation for large
+			// requests; instead, we leave all remaining waiters
+			// blocked.
+			break
+		}
+
+		s.cur += w.n
+		s.waiters.Remove(next)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package semaphore_test
+
+import (
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"sync/semaphore"
+	"testing"
+	"time"
+)
+
+const maxSleep = 1 * time.Millisecond
+
+func HammerWeighted(sem *semaphore.Weighted, n int64, loops int) {
+	for i := 0; i < loops; i++ {
+		sem.Acquire(nil, n)
+		time.Sleep(time.Duration(rand.Int63n(int64(maxSleep/time.Nanosecond))) * time.Nanosecond)
+		sem.Release(n)
+	}
+}
+
+func TestWeighted(t *testing.T) {
+	n := runtime.GOMAXPROCS(0)
+	loops := 10000 / n
+	if testing.Short() {
+		loops = 1000 / n
+	}
+	sem := semaphore.NewWeighted(int64(n))
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		i := i
+		go func() {
+			defer wg.Done()
+			HammerWeighted(sem, int64(i), loops)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestWeightedPanic(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("release of an unacquired weighted semaphore did not panic")
+		}
+	}()
+	w := semaphore.NewWeighted(1)
+	w.Release(1)
+}
+
+func TestWeightedTryAcquire(t *testing.T) {
+	sem := semaphore.NewWeighted(2)
+	tries := []bool{}
+	sem.Acquire(nil, 1)
+	tries = append(tries, sem.TryAcquire(1))
+	tries = append(tries, sem.TryAcquire(1))
+
+	sem.Release(2)
+
+	tries = append(tries, sem.TryAcquire(1))
+	sem.Acquire(nil, 1)
+	tries = append(tries, sem.TryAcquire(1))
+
+	want := []bool{true, false, true, false}
+	for i := range tries {
+		if tries[i] != want[i] {
+			t.Errorf("tries[%d]: got %t, want %t", i, tries[i], want[i])
+		}
+	}
+}
+
+func TestWeightedAcquire(t *testing.T) {
+	sem := semaphore.NewWeighted(2)
+	cancel := make(chan struct{})
+	tryAcquire := func(n int64) bool {
+		c := make(chan struct{})
+		timer := time.AfterFunc(10*time.Millisecond, func() { close(c) })
+		defer timer.Stop()
+		return sem.Acquire(c, n) == nil
+	}
+
+	tries := []bool{}
+	sem.Acquire(cancel, 1)
+	tries = append(tries, tryAcquire(1))
+	tries = append(tries, tryAcquire(1))
+
+	sem.Release(2)
+
+	tries = append(tries, tryAcquire(1))
+	sem.Acquire(cancel, 1)
+	tries = append(tries, tryAcquire(1))
+
+	want := []bool{true, false, true, false}
+	for i := range tries {
+		if tries[i] != want[i] {
+			t.Errorf("tries[%d]: got %t, want %t", i, tries[i], want[i])
+		}
+	}
+}
+
+func TestWeightedDoesntBlockIfTooBig(t *testing.T) {
+	const n = 2
+	sem := semaphore.NewWeighted(n)
+	{
+		cancel := make(chan struct{})
+		defer close(cancel)
+		go sem.Acquire(cancel, n+1)
+	}
+
+	g := make(chan bool)
+	go func() {
+		sem.Acquire(nil, n)
+		sem.Release(n)
+		g <- true
+	}()
+	select {
+	case <-g:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Acquire of n blocked behind an impossible Acquire of n+1")
+	}
+}
+
+// TestLargeAcquireDoesntStarve times out if a large call to Acquire
+// starves because of repeated small acquisitions that never leave
+// room for it.
+func TestLargeAcquireDoesntStarve(t *testing.T) {
+	n := int64(runtime.GOMAXPROCS(0))
+	sem := semaphore.NewWeighted(n)
+	var stop int32
+
+	var wg sync.WaitGroup
+	wg.Add(int(n))
+	for i := n; i > 0; i-- {
+		sem.Acquire(nil, 1)
+		go func() {
+			defer func() {
+				sem.Release(1)
+				wg.Done()
+			}()
+			for atomic.LoadInt32(&stop) == 0 {
+				time.Sleep(1 * time.Millisecond)
+				sem.Release(1)
+				sem.Acquire(nil, 1)
+			}
+		}()
+	}
+
+	sem.Acquire(nil, n)
+	atomic.StoreInt32(&stop, 1)
+	sem.Release(n)
+	wg.Wait()
+}
+
+// TestAllocCancelDoesntStarve checks that a canceled large Acquire
+// does not keep smaller waiters behind it blocked.
+func TestAllocCancelDoesntStarve(t *testing.T) {
+	sem := semaphore.NewWeighted(10)
+
+	// Block off a portion of the semaphore so that Acquire(_, 10) can
+	// eventually succeed.
+	sem.Acquire(nil, 1)
+
+	// In the background, Acquire(_, 10).
+	cancel := make(chan struct{})
+	go func() {
+		sem.Acquire(cancel, 10)
+	}()
+
+	// Wait until the Acquire(_, 10) call blocks.
+	for sem.TryAcquire(1) {
+		sem.Release(1)
+		runtime.Gosched()
+	}
+
+	// Now try to grab a read lock, and simultaneously unblock the
+	// Acquire(_, 10) call.  Both Acquire calls should unblock and
+	// return, in either order.
+	go close(cancel)
+
+	if err := sem.Acquire(nil, 1); err != nil {
+		t.Fatalf("Acquire(nil, 1) failed unexpectedly: %v", err)
+	}
+}
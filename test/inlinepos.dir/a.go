@@ -0,0 +1,13 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package a
+
+// Index is small enough to be inlined into its callers.
+func Index(b []byte, i int) byte {
+	return b[i] // IndexLine
+}
+
+// IndexLine is the line of the index expression in Index.
+const IndexLine = 9
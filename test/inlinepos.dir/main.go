@@ -0,0 +1,50 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"runtime"
+	"strings"
+
+	"./a"
+)
+
+// callers returns the program counters of the goroutine
+// as it panics in the call to a.Index inlined in it.
+func callers(b []byte, i int) (pcs []uintptr) {
+	defer func() {
+		recover()
+		pcs = make([]uintptr, 20)
+		pcs = pcs[:runtime.Callers(1, pcs)]
+	}()
+	a.Index(b, i)
+	return nil
+}
+
+func main() {
+	frames := runtime.CallersFrames(callers(nil, 1))
+	for {
+		frame, more := frames.Next()
+		if strings.HasSuffix(frame.Function, "a.Index") {
+			if frame.Func != nil {
+				panic("a.Index not inlined")
+			}
+			if !strings.HasSuffix(frame.File, "a.go") || frame.Line != a.IndexLine {
+				println("inlined frame", frame.Function, "at", frame.File, frame.Line)
+				panic("fail")
+			}
+			caller, _ := frames.Next()
+			if !strings.HasSuffix(caller.Function, "main.callers") || !strings.HasSuffix(caller.File, "main.go") {
+				println("caller of inlined frame is", caller.Function, "at", caller.File, caller.Line)
+				panic("fail")
+			}
+			return
+		}
+		if !more {
+			break
+		}
+	}
+	panic("a.Index not found in frames")
+}
@@ -207,6 +207,11 @@ var pt0b = &T{X: 0}
 var pt1 = &T{X: 1, Y: 2}
 var pt1a = &T{3, 4}
 
+var ptxy = &tx.Y
+var pt1x = &t1.X
+var pa1e = &a1[2]
+var paxe = &ax[9]
+
 // The checks similar to
 // var copy_bx = bx
 // are commented out.  The  compiler no longer statically initializes them.
@@ -0,0 +1,60 @@
+// run
+
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test that the compiler's static initialization of
+// the addresses of fields and elements of global variables
+// produces the right addresses.
+
+package main
+
+type T struct {
+	a, b int8
+	c    [4]int32
+	d    struct{ e, f int64 }
+}
+
+var x T
+var y [8]T
+var z = T{a: 1, b: 2, c: [4]int32{3, 4, 5, 6}}
+
+var (
+	pxb  = &x.b
+	pxc2 = &x.c[2]
+	pxdf = &x.d.f
+	py5  = &y[5]
+	py3c = &y[3].c
+	py7d = &y[7].d.e
+	pzc3 = &z.c[3]
+)
+
+var ptrs = []*int8{&x.a, &y[1].b, &z.b}
+
+func main() {
+	if pxb != &x.b {
+		panic("&x.b")
+	}
+	if pxc2 != &x.c[2] {
+		panic("&x.c[2]")
+	}
+	if pxdf != &x.d.f {
+		panic("&x.d.f")
+	}
+	if py5 != &y[5] {
+		panic("&y[5]")
+	}
+	if py3c != &y[3].c {
+		panic("&y[3].c")
+	}
+	if py7d != &y[7].d.e {
+		panic("&y[7].d.e")
+	}
+	if pzc3 != &z.c[3] || *pzc3 != 6 {
+		panic("&z.c[3]")
+	}
+	if ptrs[0] != &x.a || ptrs[1] != &y[1].b || ptrs[2] != &z.b || *ptrs[2] != 2 {
+		panic("ptrs")
+	}
+}